@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"sync"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+
+	"gorm.io/gorm"
+)
+
+// BlockOccupancyCache keeps an in-memory bitmap of the occupied cells of each
+// block, so the suggestion engine can test candidate cells without issuing
+// one query per slot/row/tier.
+type BlockOccupancyCache interface {
+	IsFree(db *gorm.DB, block *model.Block, row, tier int, slotNumbers []int) (bool, error)
+
+	Occupy(blockID, slot, row, tier int, size string)
+	Release(blockID, slot, row, tier int, size string)
+	Invalidate(blockID int)
+}
+
+type BlockOccupancyCacheImpl struct {
+	ContainerPositionRepository repository.ContainerPositionRepository
+	TTL                         time.Duration
+
+	mu        sync.RWMutex
+	snapshots map[int]*blockSnapshot
+	// generations counts the changes of each block, a snapshot loaded while
+	// the block changed may have missed the change and is not stored.
+	generations map[int]uint64
+}
+
+// snapshotLoadAttempts bounds the reloads of a block that keeps changing
+// while it is loaded, the last load is then used without being cached.
+const snapshotLoadAttempts = 3
+
+// NewBlockOccupancyCache creates an empty cache. Snapshots are loaded lazily
+// from the database and reloaded once they are older than ttl, which keeps
+// instances that share a database from drifting apart for too long. A ttl of
+// zero keeps snapshots until they are invalidated.
+func NewBlockOccupancyCache(containerRepo repository.ContainerPositionRepository, ttl time.Duration) BlockOccupancyCache {
+	return &BlockOccupancyCacheImpl{
+		ContainerPositionRepository: containerRepo,
+		TTL:                         ttl,
+		snapshots:                   make(map[int]*blockSnapshot),
+		generations:                 make(map[int]uint64),
+	}
+}
+
+// blockSnapshot is a bitmap with one bit per cell, ordered tier, row, slot.
+type blockSnapshot struct {
+	slots    int
+	rows     int
+	tiers    int
+	bits     []uint64
+	loadedAt time.Time
+}
+
+func newBlockSnapshot(slots, rows, tiers int) *blockSnapshot {
+	cells := slots * rows * tiers
+	return &blockSnapshot{
+		slots:    slots,
+		rows:     rows,
+		tiers:    tiers,
+		bits:     make([]uint64, (cells+63)/64),
+		loadedAt: time.Now(),
+	}
+}
+
+func (b *blockSnapshot) index(slot, row, tier int) (int, bool) {
+	if slot < 1 || row < 1 || tier < 1 || slot > b.slots || row > b.rows || tier > b.tiers {
+		return 0, false
+	}
+	return ((tier-1)*b.rows+(row-1))*b.slots + (slot - 1), true
+}
+
+func (b *blockSnapshot) get(slot, row, tier int) bool {
+	i, ok := b.index(slot, row, tier)
+	if !ok {
+		return true
+	}
+	return b.bits[i/64]&(1<<(i%64)) != 0
+}
+
+func (b *blockSnapshot) set(slot, row, tier int, occupied bool) {
+	i, ok := b.index(slot, row, tier)
+	if !ok {
+		return
+	}
+	if occupied {
+		b.bits[i/64] |= 1 << (i % 64)
+	} else {
+		b.bits[i/64] &^= 1 << (i % 64)
+	}
+}
+
+// mark sets every cell a container of the given size covers. A 40ft
+// container is stored on its first slot but also fills the next one.
+func (b *blockSnapshot) mark(slot, row, tier int, size string, occupied bool) {
+	b.set(slot, row, tier, occupied)
+	if size == "40ft" {
+		b.set(slot+1, row, tier, occupied)
+	}
+}
+
+func (c *BlockOccupancyCacheImpl) IsFree(db *gorm.DB, block *model.Block, row, tier int, slotNumbers []int) (bool, error) {
+	snapshot, err := c.snapshot(db, block)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, slot := range slotNumbers {
+		if snapshot.get(slot, row, tier) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (c *BlockOccupancyCacheImpl) Occupy(blockID, slot, row, tier int, size string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[blockID]++
+	if snapshot, ok := c.snapshots[blockID]; ok {
+		snapshot.mark(slot, row, tier, size, true)
+	}
+}
+
+func (c *BlockOccupancyCacheImpl) Release(blockID, slot, row, tier int, size string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[blockID]++
+	if snapshot, ok := c.snapshots[blockID]; ok {
+		snapshot.mark(slot, row, tier, size, false)
+	}
+}
+
+// Invalidate drops the snapshot of a block so the next lookup reloads it from
+// the database. Callers use it whenever they are unsure the cache still
+// matches what was committed.
+func (c *BlockOccupancyCacheImpl) Invalidate(blockID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[blockID]++
+	delete(c.snapshots, blockID)
+}
+
+// snapshot returns the cached bitmap of the block, loading it from the
+// database when it is missing, expired or built for other block dimensions.
+// A load is only stored when no Occupy, Release or Invalidate of the block
+// ran meanwhile, otherwise the block is loaded again.
+func (c *BlockOccupancyCacheImpl) snapshot(db *gorm.DB, block *model.Block) (*blockSnapshot, error) {
+	c.mu.RLock()
+	snapshot, ok := c.snapshots[block.ID]
+	generation := c.generations[block.ID]
+	c.mu.RUnlock()
+
+	if ok && c.isFresh(snapshot, block) {
+		return snapshot, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		loaded, err := c.load(db, block)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		if c.generations[block.ID] == generation {
+			c.snapshots[block.ID] = loaded
+			c.mu.Unlock()
+			return loaded, nil
+		}
+		generation = c.generations[block.ID]
+		c.mu.Unlock()
+
+		if attempt == snapshotLoadAttempts {
+			return loaded, nil
+		}
+	}
+}
+
+func (c *BlockOccupancyCacheImpl) load(db *gorm.DB, block *model.Block) (*blockSnapshot, error) {
+	var positions []model.ContainerPosition
+	if err := c.ContainerPositionRepository.FindByBlockID(db, &positions, block.ID); err != nil {
+		return nil, err
+	}
+
+	snapshot := newBlockSnapshot(block.Slots, block.Rows, block.Tiers)
+	for _, position := range positions {
+		snapshot.mark(position.SlotNumber, position.RowNumber, position.TierNumber, position.ContainerSize, true)
+	}
+	return snapshot, nil
+}
+
+func (c *BlockOccupancyCacheImpl) isFresh(snapshot *blockSnapshot, block *model.Block) bool {
+	if snapshot.slots != block.Slots || snapshot.rows != block.Rows || snapshot.tiers != block.Tiers {
+		return false
+	}
+	return c.TTL <= 0 || time.Since(snapshot.loadedAt) < c.TTL
+}
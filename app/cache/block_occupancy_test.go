@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"slices"
+	"testing"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+
+	"gorm.io/gorm"
+)
+
+// memoryPositions serves the positions of one block from memory. onLoad, when
+// set, runs in the middle of every FindByBlockID after the rows were read.
+type memoryPositions struct {
+	repository.ContainerPositionRepository
+
+	positions []model.ContainerPosition
+	loads     int
+	onLoad    func()
+}
+
+func (r *memoryPositions) FindByBlockID(db *gorm.DB, positions *[]model.ContainerPosition, blockID int) error {
+	r.loads++
+	*positions = append((*positions)[:0], r.positions...)
+	if r.onLoad != nil {
+		r.onLoad()
+	}
+	return nil
+}
+
+// CheckPositionAvailability counts like the query: each row once when its
+// slot, or the slot after it for a 40ft, is one of slotNumbers.
+func (r *memoryPositions) CheckPositionAvailability(db *gorm.DB, blockID, row, tier int, slotNumbers []int) (int64, error) {
+	var count int64
+	for _, position := range r.positions {
+		if position.BlockID != blockID || position.RowNumber != row || position.TierNumber != tier {
+			continue
+		}
+		if slices.Contains(slotNumbers, position.SlotNumber) ||
+			(position.ContainerSize == "40ft" && slices.Contains(slotNumbers, position.SlotNumber+1)) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestIsFreeMarksBothSlotsOf40ft(t *testing.T) {
+	block := &model.Block{ID: 1, Slots: 4, Rows: 2, Tiers: 2}
+	positions := &memoryPositions{positions: []model.ContainerPosition{
+		{BlockID: 1, SlotNumber: 2, RowNumber: 1, TierNumber: 1, ContainerSize: "40ft"},
+	}}
+	cache := NewBlockOccupancyCache(positions, 0)
+
+	tests := []struct {
+		name  string
+		slots []int
+		row   int
+		want  bool
+	}{
+		{"first slot of the 40ft", []int{2}, 1, false},
+		{"second slot of the 40ft", []int{3}, 1, false},
+		{"free slot next to it", []int{1}, 1, true},
+		{"other row", []int{2, 3}, 2, true},
+		{"slot outside the block", []int{5}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cache.IsFree(nil, block, tt.row, 1, tt.slots)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("IsFree(%v, row %d) = %v, want %v", tt.slots, tt.row, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotKeepsChangeMadeDuringLoad(t *testing.T) {
+	block := &model.Block{ID: 1, Slots: 4, Rows: 1, Tiers: 1}
+	positions := &memoryPositions{}
+	cache := NewBlockOccupancyCache(positions, 0)
+
+	// A placement commits and calls Occupy after the rows were read but
+	// before the loaded snapshot is stored.
+	positions.onLoad = func() {
+		positions.onLoad = nil
+		positions.positions = append(positions.positions, model.ContainerPosition{
+			BlockID: 1, SlotNumber: 1, RowNumber: 1, TierNumber: 1, ContainerSize: "20ft",
+		})
+		cache.Occupy(1, 1, 1, 1, "20ft")
+	}
+
+	free, err := cache.IsFree(nil, block, 1, 1, []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free {
+		t.Fatal("cell occupied during the load is reported free")
+	}
+	if positions.loads != 2 {
+		t.Errorf("loads = %d, want 2", positions.loads)
+	}
+
+	// The reloaded snapshot is cached.
+	if _, err := cache.IsFree(nil, block, 1, 1, []int{2}); err != nil {
+		t.Fatal(err)
+	}
+	if positions.loads != 2 {
+		t.Errorf("loads after a cached lookup = %d, want 2", positions.loads)
+	}
+}
+
+func TestReleaseFreesCachedCell(t *testing.T) {
+	block := &model.Block{ID: 1, Slots: 2, Rows: 1, Tiers: 1}
+	positions := &memoryPositions{positions: []model.ContainerPosition{
+		{BlockID: 1, SlotNumber: 1, RowNumber: 1, TierNumber: 1, ContainerSize: "40ft"},
+	}}
+	cache := NewBlockOccupancyCache(positions, 0)
+
+	if free, _ := cache.IsFree(nil, block, 1, 1, []int{2}); free {
+		t.Fatal("second slot of a 40ft is reported free")
+	}
+	cache.Release(1, 1, 1, 1, "40ft")
+	if free, _ := cache.IsFree(nil, block, 1, 1, []int{1, 2}); !free {
+		t.Fatal("released cells are reported occupied")
+	}
+}
+
+// benchmarkBlock is a half full 20 slot, 6 row, 5 tier block.
+func benchmarkBlock() (*model.Block, *memoryPositions) {
+	block := &model.Block{ID: 1, Slots: 20, Rows: 6, Tiers: 5}
+	positions := &memoryPositions{}
+	for tier := 1; tier <= 2; tier++ {
+		for row := 1; row <= block.Rows; row++ {
+			for slot := 1; slot <= block.Slots; slot++ {
+				positions.positions = append(positions.positions, model.ContainerPosition{
+					BlockID: 1, SlotNumber: slot, RowNumber: row, TierNumber: tier, ContainerSize: "20ft",
+				})
+			}
+		}
+	}
+	return block, positions
+}
+
+// suggestScan tests every cell of the block the way the suggestion engine
+// does and returns the number of free cells. The uncached path issues one
+// availability query per cell, the benchmark serves it from memory so the
+// numbers leave out the database round trip it costs in production.
+func suggestScan(block *model.Block, isFree func(row, tier int, slots []int) bool) int {
+	free := 0
+	for tier := 1; tier <= block.Tiers; tier++ {
+		for row := 1; row <= block.Rows; row++ {
+			for slot := 1; slot <= block.Slots; slot++ {
+				if isFree(row, tier, []int{slot}) {
+					free++
+				}
+			}
+		}
+	}
+	return free
+}
+
+func BenchmarkSuggestCached(b *testing.B) {
+	block, positions := benchmarkBlock()
+	cache := NewBlockOccupancyCache(positions, 0)
+
+	for b.Loop() {
+		suggestScan(block, func(row, tier int, slots []int) bool {
+			free, _ := cache.IsFree(nil, block, row, tier, slots)
+			return free
+		})
+	}
+}
+
+func BenchmarkSuggestUncached(b *testing.B) {
+	block, positions := benchmarkBlock()
+
+	for b.Loop() {
+		suggestScan(block, func(row, tier int, slots []int) bool {
+			count, _ := positions.CheckPositionAvailability(nil, block.ID, row, tier, slots)
+			return count == 0
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/helper/token"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditReadOnlyRoutes are POST routes that only compute an answer and are
+// not logged.
+var auditReadOnlyRoutes = map[string]bool{
+	"/api/suggestion":            true,
+	"/api/suggestion/batch":      true,
+	"/api/housekeeping/plan":     true,
+	"/api/yard-plans/:id/impact": true,
+}
+
+type AuditLogController interface {
+	// Record is the middleware that writes the audit log of every mutating
+	// request.
+	Record(ctx *gin.Context)
+	FindLogs(ctx *gin.Context)
+}
+
+type AuditLogControllerImpl struct {
+	AuditLogService service.AuditLogService
+}
+
+func NewAuditLogController(auditLogService service.AuditLogService) AuditLogController {
+	return &AuditLogControllerImpl{
+		AuditLogService: auditLogService,
+	}
+}
+
+func (c *AuditLogControllerImpl) Record(ctx *gin.Context) {
+	switch ctx.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		ctx.Next()
+		return
+	}
+	if auditReadOnlyRoutes[ctx.FullPath()] {
+		ctx.Next()
+		return
+	}
+
+	// The body is captured while the handler reads it, large uploads are not
+	// buffered twice.
+	body := &capturedBody{ReadCloser: ctx.Request.Body}
+	ctx.Request.Body = body
+
+	auditCtx, trail := auditlog.NewContext(ctx.Request.Context())
+	ctx.Request = ctx.Request.WithContext(auditCtx)
+
+	ctx.Next()
+
+	endpoint := ctx.FullPath()
+	if endpoint == "" {
+		endpoint = ctx.Request.URL.Path
+	}
+
+	request := &web.AuditLogRequest{
+		Actor:       requestActor(ctx),
+		Method:      ctx.Request.Method,
+		Endpoint:    endpoint,
+		Path:        ctx.Request.URL.RequestURI(),
+		ClientIP:    ctx.ClientIP(),
+		StatusCode:  ctx.Writer.Status(),
+		ContentType: ctx.ContentType(),
+		Body:        body.data,
+		BodySize:    max(body.size, ctx.Request.ContentLength),
+		Changes:     trail.Changes(),
+	}
+
+	if customErr := c.AuditLogService.Record(ctx.Request.Context(), request); customErr != nil {
+		log.Printf("audit: %s %s: %s", request.Method, request.Path, customErr.Message)
+	}
+}
+
+func (c *AuditLogControllerImpl) FindLogs(ctx *gin.Context) {
+	query := new(web.AuditLogQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	logResponses, customErr := c.AuditLogService.FindLogs(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Audit logs successfully retrieved.",
+		Data:    logResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// requestActor returns the authenticated user of the request. Most routes do
+// not require a token, the actor is then taken from one when the client
+// sends it.
+func requestActor(ctx *gin.Context) string {
+	if actor := ctx.GetString("authId"); actor != "" {
+		return actor
+	}
+	return bearerActor(ctx.GetHeader("Authorization"))
+}
+
+// bearerActor returns the user of a valid bearer token, empty when the
+// header has none.
+func bearerActor(header string) string {
+	bearerToken, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return ""
+	}
+
+	payload, err := token.ValidateJwtToken(bearerToken)
+	if err != nil {
+		return ""
+	}
+	return payload.AuthId
+}
+
+// capturedBody keeps the first service.AuditPayloadLimit bytes of a request
+// body as it is read and counts the rest.
+type capturedBody struct {
+	io.ReadCloser
+	data []byte
+	size int64
+}
+
+func (b *capturedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := service.AuditPayloadLimit - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(n, room)]...)
+	}
+	b.size += int64(n)
+	return n, err
+}
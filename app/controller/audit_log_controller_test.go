@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"yard-planning/app/service"
+	"yard-planning/helper/token"
+)
+
+func TestBearerActor(t *testing.T) {
+	token.TOKEN_Key = "audit-test-key"
+	valid, err := token.GenerateJwtToken("operator-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token.TOKEN_Key = "another-key"
+	foreign, err := token.GenerateJwtToken("intruder")
+	token.TOKEN_Key = "audit-test-key"
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"valid token", "Bearer " + valid, "operator-7"},
+		{"no header", "", ""},
+		{"not a bearer token", "Basic " + valid, ""},
+		{"token signed with another key", "Bearer " + foreign, ""},
+		{"malformed token", "Bearer not-a-jwt", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bearerActor(tt.header); got != tt.want {
+				t.Errorf("bearerActor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapturedBody(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"small", 100},
+		{"at the limit", service.AuditPayloadLimit},
+		{"over the limit", service.AuditPayloadLimit + 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := strings.Repeat("x", tt.size)
+			body := &capturedBody{ReadCloser: io.NopCloser(strings.NewReader(data))}
+
+			read, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(read) != data {
+				t.Errorf("handler read %d bytes, want %d", len(read), tt.size)
+			}
+			if body.size != int64(tt.size) {
+				t.Errorf("size = %d, want %d", body.size, tt.size)
+			}
+			if want := data[:min(tt.size, service.AuditPayloadLimit)]; !bytes.Equal(body.data, []byte(want)) {
+				t.Errorf("captured %d bytes, want %d", len(body.data), len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BillingController interface {
+	CreateTariff(ctx *gin.Context)
+	UpdateTariff(ctx *gin.Context)
+	DeleteTariff(ctx *gin.Context)
+	FindTariffs(ctx *gin.Context)
+	PreviewInvoice(ctx *gin.Context)
+}
+
+type BillingControllerImpl struct {
+	BillingService service.BillingService
+}
+
+func NewBillingController(billingService service.BillingService) BillingController {
+	return &BillingControllerImpl{
+		BillingService: billingService,
+	}
+}
+
+func (c *BillingControllerImpl) CreateTariff(ctx *gin.Context) {
+	request := new(web.TariffRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	tariffResponse, customErr := c.BillingService.CreateTariff(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Tariff successfully created.",
+		Data:    tariffResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *BillingControllerImpl) UpdateTariff(ctx *gin.Context) {
+	tariffID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.TariffRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	tariffResponse, customErr := c.BillingService.UpdateTariff(ctx.Request.Context(), tariffID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Tariff successfully updated.",
+		Data:    tariffResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BillingControllerImpl) DeleteTariff(ctx *gin.Context) {
+	tariffID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if customErr := c.BillingService.DeleteTariff(ctx.Request.Context(), tariffID); customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Tariff successfully deleted.",
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BillingControllerImpl) FindTariffs(ctx *gin.Context) {
+	tariffResponses, customErr := c.BillingService.FindTariffs(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Tariffs successfully retrieved.",
+		Data:    tariffResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BillingControllerImpl) PreviewInvoice(ctx *gin.Context) {
+	query := new(web.InvoicePreviewQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	invoiceResponse, customErr := c.BillingService.PreviewInvoice(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Invoice preview successfully generated.",
+		Data:    invoiceResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
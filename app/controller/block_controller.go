@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BlockController interface {
+	ViewBlock(ctx *gin.Context)
+}
+
+type BlockControllerImpl struct {
+	BlockViewService service.BlockViewService
+}
+
+func NewBlockController(blockViewService service.BlockViewService) BlockController {
+	return &BlockControllerImpl{
+		BlockViewService: blockViewService,
+	}
+}
+
+func (c *BlockControllerImpl) ViewBlock(ctx *gin.Context) {
+	blockID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	query := new(web.BlockViewQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	view, customErr := c.BlockViewService.ViewBlock(ctx.Request.Context(), blockID, query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	switch query.Format {
+	case "ascii":
+		ctx.String(http.StatusOK, service.RenderBlockViewASCII(view))
+	case "svg":
+		ctx.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(service.RenderBlockViewSVG(view)))
+	default:
+		webResponse := response.WebResponse{
+			Status:  true,
+			Message: "Block view successfully retrieved.",
+			Data:    view,
+		}
+
+		ctx.JSON(http.StatusOK, webResponse)
+	}
+}
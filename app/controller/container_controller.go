@@ -13,15 +13,19 @@ type ContainerController interface {
 	SuggestPosition(ctx *gin.Context)
 	PlaceContainer(ctx *gin.Context)
 	PickupContainer(ctx *gin.Context)
+	SuggestPositions(ctx *gin.Context)
+	PlaceContainers(ctx *gin.Context)
 }
 
 type ContainerControllerImpl struct {
-	ContainerService service.ContainerService
+	ContainerService       service.ContainerService
+	WorkInstructionService service.WorkInstructionService
 }
 
-func NewContainerController(containerService service.ContainerService) ContainerController {
+func NewContainerController(containerService service.ContainerService, workInstructionService service.WorkInstructionService) ContainerController {
 	return &ContainerControllerImpl{
-		ContainerService: containerService,
+		ContainerService:       containerService,
+		WorkInstructionService: workInstructionService,
 	}
 }
 
@@ -67,7 +71,7 @@ func (c *ContainerControllerImpl) PlaceContainer(ctx *gin.Context) {
 		return
 	}
 
-	_, customErr := c.ContainerService.PlaceContainer(ctx.Request.Context(), request)
+	instructionResponse, customErr := c.WorkInstructionService.QueuePlacement(ctx.Request.Context(), request)
 
 	if customErr != nil {
 		ctx.JSON(customErr.StatusCode, customErr)
@@ -75,10 +79,11 @@ func (c *ContainerControllerImpl) PlaceContainer(ctx *gin.Context) {
 	}
 	webResponse := response.WebResponse{
 		Status:  true,
-		Message: "Success",
+		Message: "Placement queued, waiting for equipment confirmation.",
+		Data:    instructionResponse,
 	}
 
-	ctx.JSON(http.StatusCreated, webResponse)
+	ctx.JSON(http.StatusAccepted, webResponse)
 }
 
 func (c *ContainerControllerImpl) PickupContainer(ctx *gin.Context) {
@@ -90,7 +95,7 @@ func (c *ContainerControllerImpl) PickupContainer(ctx *gin.Context) {
 		return
 	}
 
-	_, customErr := c.ContainerService.PickupContainer(ctx.Request.Context(), request)
+	instructionResponse, customErr := c.WorkInstructionService.QueuePickup(ctx.Request.Context(), request)
 	if customErr != nil {
 		ctx.JSON(customErr.StatusCode, customErr)
 		return
@@ -98,8 +103,57 @@ func (c *ContainerControllerImpl) PickupContainer(ctx *gin.Context) {
 
 	webResponse := response.WebResponse{
 		Status:  true,
-		Message: "Success",
+		Message: "Pickup queued, waiting for equipment confirmation.",
+		Data:    instructionResponse,
+	}
+
+	ctx.JSON(http.StatusAccepted, webResponse)
+}
+
+func (c *ContainerControllerImpl) SuggestPositions(ctx *gin.Context) {
+	request := new(web.BatchContainerRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	batchResponse, customErr := c.ContainerService.SuggestPositions(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Suggested positions successfully retrieved.",
+		Data:    batchResponse,
 	}
 
 	ctx.JSON(http.StatusOK, webResponse)
 }
+
+func (c *ContainerControllerImpl) PlaceContainers(ctx *gin.Context) {
+	request := new(web.BatchPlacementRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	batchResponse, customErr := c.WorkInstructionService.QueuePlacements(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Placements queued, waiting for equipment confirmation.",
+		Data:    batchResponse,
+	}
+
+	ctx.JSON(http.StatusAccepted, webResponse)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DispatchController interface {
+	DispatchYard(ctx *gin.Context)
+	SimulateDispatch(ctx *gin.Context)
+}
+
+type DispatchControllerImpl struct {
+	DispatchService service.DispatchService
+}
+
+func NewDispatchController(dispatchService service.DispatchService) DispatchController {
+	return &DispatchControllerImpl{
+		DispatchService: dispatchService,
+	}
+}
+
+func (c *DispatchControllerImpl) DispatchYard(ctx *gin.Context) {
+	query := new(web.DispatchQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponse, customErr := c.DispatchService.DispatchYard(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Equipment queues successfully recomputed.",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *DispatchControllerImpl) SimulateDispatch(ctx *gin.Context) {
+	query := new(web.DispatchQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponse, customErr := c.DispatchService.SimulateDispatch(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dispatch plan successfully simulated.",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DwellController interface {
+	CreateThreshold(ctx *gin.Context)
+	UpdateThreshold(ctx *gin.Context)
+	DeleteThreshold(ctx *gin.Context)
+	FindThresholds(ctx *gin.Context)
+	LongStayReport(ctx *gin.Context)
+	EnforceThresholds(ctx *gin.Context)
+	FindAlerts(ctx *gin.Context)
+}
+
+type DwellControllerImpl struct {
+	DwellService service.DwellService
+}
+
+func NewDwellController(dwellService service.DwellService) DwellController {
+	return &DwellControllerImpl{
+		DwellService: dwellService,
+	}
+}
+
+func (c *DwellControllerImpl) CreateThreshold(ctx *gin.Context) {
+	request := new(web.DwellThresholdRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	thresholdResponse, customErr := c.DwellService.CreateThreshold(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dwell threshold successfully created.",
+		Data:    thresholdResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *DwellControllerImpl) UpdateThreshold(ctx *gin.Context) {
+	thresholdID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.DwellThresholdRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	thresholdResponse, customErr := c.DwellService.UpdateThreshold(ctx.Request.Context(), thresholdID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dwell threshold successfully updated.",
+		Data:    thresholdResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *DwellControllerImpl) DeleteThreshold(ctx *gin.Context) {
+	thresholdID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if customErr := c.DwellService.DeleteThreshold(ctx.Request.Context(), thresholdID); customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dwell threshold successfully deleted.",
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *DwellControllerImpl) FindThresholds(ctx *gin.Context) {
+	thresholdResponses, customErr := c.DwellService.FindThresholds(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dwell thresholds successfully retrieved.",
+		Data:    thresholdResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *DwellControllerImpl) LongStayReport(ctx *gin.Context) {
+	query := new(web.LongStayQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	reportResponse, customErr := c.DwellService.LongStayReport(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Long-stay report successfully generated.",
+		Data:    reportResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *DwellControllerImpl) EnforceThresholds(ctx *gin.Context) {
+	enforcementResponse, customErr := c.DwellService.EnforceThresholds(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dwell thresholds successfully enforced.",
+		Data:    enforcementResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *DwellControllerImpl) FindAlerts(ctx *gin.Context) {
+	query := new(web.DwellAlertQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	alertResponses, customErr := c.DwellService.FindAlerts(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dwell alerts successfully retrieved.",
+		Data:    alertResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
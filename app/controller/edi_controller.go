@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EDIController interface {
+	CreatePartner(ctx *gin.Context)
+	UpdatePartner(ctx *gin.Context)
+	DeletePartner(ctx *gin.Context)
+	FindPartners(ctx *gin.Context)
+	ExportCODECO(ctx *gin.Context)
+	ExportCOARRI(ctx *gin.Context)
+	ImportCOARRI(ctx *gin.Context)
+	ImportBAPLIE(ctx *gin.Context)
+}
+
+type EDIControllerImpl struct {
+	EDIService service.EDIService
+}
+
+func NewEDIController(ediService service.EDIService) EDIController {
+	return &EDIControllerImpl{
+		EDIService: ediService,
+	}
+}
+
+func (c *EDIControllerImpl) CreatePartner(ctx *gin.Context) {
+	request := new(web.EDIPartnerRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	partnerResponse, customErr := c.EDIService.CreatePartner(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "EDI partner successfully created.",
+		Data:    partnerResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *EDIControllerImpl) UpdatePartner(ctx *gin.Context) {
+	partnerID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.EDIPartnerRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	partnerResponse, customErr := c.EDIService.UpdatePartner(ctx.Request.Context(), partnerID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "EDI partner successfully updated.",
+		Data:    partnerResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *EDIControllerImpl) DeletePartner(ctx *gin.Context) {
+	partnerID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if customErr := c.EDIService.DeletePartner(ctx.Request.Context(), partnerID); customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "EDI partner successfully deleted.",
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *EDIControllerImpl) FindPartners(ctx *gin.Context) {
+	partnerResponses, customErr := c.EDIService.FindPartners(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "EDI partners successfully retrieved.",
+		Data:    partnerResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *EDIControllerImpl) ExportCODECO(ctx *gin.Context) {
+	query := new(web.CODECOQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	document, customErr := c.EDIService.ExportCODECO(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="`+document.FileName+`"`)
+	ctx.Data(http.StatusOK, "application/edifact", []byte(document.Content))
+}
+
+func (c *EDIControllerImpl) ExportCOARRI(ctx *gin.Context) {
+	query := new(web.COARRIQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	document, customErr := c.EDIService.ExportCOARRI(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="`+document.FileName+`"`)
+	ctx.Data(http.StatusOK, "application/edifact", []byte(document.Content))
+}
+
+// ImportCOARRI takes the raw interchange as the request body.
+func (c *EDIControllerImpl) ImportCOARRI(ctx *gin.Context) {
+	query := new(web.COARRIImportQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	data, err := ctx.GetRawData()
+	if err != nil || len(data) == 0 {
+		customErr := response.BadRequestError("Request body must contain the COARRI interchange.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	importResponse, customErr := c.EDIService.ImportCOARRI(ctx.Request.Context(), query, string(data))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "COARRI discharge list successfully imported.",
+		Data:    importResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// ImportBAPLIE takes the raw interchange as the request body.
+func (c *EDIControllerImpl) ImportBAPLIE(ctx *gin.Context) {
+	query := new(web.BAPLIEImportQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	data, err := ctx.GetRawData()
+	if err != nil || len(data) == 0 {
+		customErr := response.BadRequestError("Request body must contain the BAPLIE interchange.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	importResponse, customErr := c.EDIService.ImportBAPLIE(ctx.Request.Context(), query, string(data))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	message := "BAPLIE stowage plan successfully imported."
+	if query.ForecastOnly {
+		message = "BAPLIE capacity forecast successfully computed."
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: message,
+		Data:    importResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
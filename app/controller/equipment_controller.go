@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EquipmentController interface {
+	CreateEquipment(ctx *gin.Context)
+	UpdateEquipment(ctx *gin.Context)
+	FindEquipment(ctx *gin.Context)
+}
+
+type EquipmentControllerImpl struct {
+	EquipmentService service.EquipmentService
+}
+
+func NewEquipmentController(equipmentService service.EquipmentService) EquipmentController {
+	return &EquipmentControllerImpl{
+		EquipmentService: equipmentService,
+	}
+}
+
+func (c *EquipmentControllerImpl) CreateEquipment(ctx *gin.Context) {
+	request := new(web.EquipmentRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	equipmentResponse, customErr := c.EquipmentService.CreateEquipment(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Equipment successfully created.",
+		Data:    equipmentResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *EquipmentControllerImpl) UpdateEquipment(ctx *gin.Context) {
+	equipmentID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.EquipmentRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	equipmentResponse, customErr := c.EquipmentService.UpdateEquipment(ctx.Request.Context(), equipmentID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Equipment successfully updated.",
+		Data:    equipmentResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *EquipmentControllerImpl) FindEquipment(ctx *gin.Context) {
+	query := new(web.EquipmentQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	equipmentResponses, customErr := c.EquipmentService.FindEquipment(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Equipment successfully retrieved.",
+		Data:    equipmentResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
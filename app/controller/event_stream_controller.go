@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventStreamController interface {
+	Stream(ctx *gin.Context)
+}
+
+type EventStreamControllerImpl struct {
+	EventStreamService service.EventStreamService
+}
+
+func NewEventStreamController(eventStreamService service.EventStreamService) EventStreamController {
+	return &EventStreamControllerImpl{
+		EventStreamService: eventStreamService,
+	}
+}
+
+// Stream serves the live yard events as Server-Sent Events. EventSource
+// clients resend the ID of the last event as Last-Event-ID when they
+// reconnect and get the events they missed first.
+func (c *EventStreamControllerImpl) Stream(ctx *gin.Context) {
+	query := new(web.EventStreamQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	if header := ctx.GetHeader("Last-Event-ID"); query.LastEventID == 0 && header != "" {
+		lastEventID, err := strconv.Atoi(header)
+		if err != nil || lastEventID < 0 {
+			customErr := response.BadRequestError("Invalid Last-Event-ID header.")
+			ctx.JSON(customErr.StatusCode, customErr)
+			return
+		}
+		query.LastEventID = lastEventID
+	}
+
+	subscription, customErr := c.EventStreamService.Subscribe(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	io.WriteString(ctx.Writer, "retry: 3000\n\n")
+	ctx.Writer.Flush()
+
+	err := c.EventStreamService.Stream(ctx.Request.Context(), subscription, func(event *web.StreamEvent) error {
+		if err := writeStreamEvent(ctx.Writer, event); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+		return nil
+	})
+	if err != nil && !errors.Is(err, service.ErrStreamBehind) {
+		log.Printf("event stream: %v", err)
+	}
+}
+
+// writeStreamEvent writes one event in the text/event-stream format, a nil
+// event as a comment that keeps idle connections open.
+func writeStreamEvent(w io.Writer, event *web.StreamEvent) error {
+	if event == nil {
+		_, err := io.WriteString(w, ": keep-alive\n\n")
+		return err
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
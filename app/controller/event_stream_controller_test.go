@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+	"yard-planning/app/web"
+)
+
+func TestWriteStreamEvent(t *testing.T) {
+	blockID := 5
+	tests := []struct {
+		name  string
+		event *web.StreamEvent
+		want  string
+	}{
+		{"keep-alive", nil, ": keep-alive\n\n"},
+		{"event", &web.StreamEvent{
+			ID:          42,
+			Type:        "ContainerPlaced",
+			AggregateID: "MSKU0000001",
+			BlockID:     &blockID,
+			OccurredAt:  time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC),
+			Data:        json.RawMessage(`{"to_slot":3}`),
+		}, "id: 42\nevent: ContainerPlaced\n" +
+			`data: {"id":42,"type":"ContainerPlaced","aggregate_id":"MSKU0000001","block_id":5,"occurred_at":"2026-10-19T10:00:00Z","data":{"to_slot":3}}` +
+			"\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w bytes.Buffer
+			if err := writeStreamEvent(&w, tt.event); err != nil {
+				t.Fatal(err)
+			}
+			if w.String() != tt.want {
+				t.Errorf("wrote %q, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
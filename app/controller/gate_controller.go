@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GateController interface {
+	GateIn(ctx *gin.Context)
+	ConfirmPlacement(ctx *gin.Context)
+	CancelGateIn(ctx *gin.Context)
+	GateOut(ctx *gin.Context)
+	FindTransaction(ctx *gin.Context)
+}
+
+type GateControllerImpl struct {
+	GateService service.GateService
+}
+
+func NewGateController(gateService service.GateService) GateController {
+	return &GateControllerImpl{
+		GateService: gateService,
+	}
+}
+
+func (c *GateControllerImpl) GateIn(ctx *gin.Context) {
+	request := new(web.GateInRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	transactionResponse, customErr := c.GateService.GateIn(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Gate-in recorded, placement pending.",
+		Data:    transactionResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *GateControllerImpl) ConfirmPlacement(ctx *gin.Context) {
+	transactionID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.ConfirmGatePlacementRequest)
+
+	// The body is optional, the planned cell is used without one.
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(request); err != nil {
+			customErr := response.BadRequestError("Invalid request body.")
+			ctx.JSON(customErr.StatusCode, customErr)
+			return
+		}
+	}
+
+	transactionResponse, customErr := c.GateService.ConfirmPlacement(ctx.Request.Context(), transactionID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Gate-in placement confirmed.",
+		Data:    transactionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *GateControllerImpl) CancelGateIn(ctx *gin.Context) {
+	transactionID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	transactionResponse, customErr := c.GateService.CancelGateIn(ctx.Request.Context(), transactionID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Gate-in cancelled.",
+		Data:    transactionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *GateControllerImpl) GateOut(ctx *gin.Context) {
+	request := new(web.GateOutRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	transactionResponse, customErr := c.GateService.GateOut(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Gate-out completed.",
+		Data:    transactionResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *GateControllerImpl) FindTransaction(ctx *gin.Context) {
+	transactionID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	transactionResponse, customErr := c.GateService.FindTransaction(ctx.Request.Context(), transactionID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Gate transaction successfully retrieved.",
+		Data:    transactionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
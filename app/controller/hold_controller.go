@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldController interface {
+	SetHold(ctx *gin.Context)
+	ReleaseHold(ctx *gin.Context)
+	FindHolds(ctx *gin.Context)
+}
+
+type HoldControllerImpl struct {
+	HoldService service.HoldService
+}
+
+func NewHoldController(holdService service.HoldService) HoldController {
+	return &HoldControllerImpl{
+		HoldService: holdService,
+	}
+}
+
+func (c *HoldControllerImpl) SetHold(ctx *gin.Context) {
+	request := new(web.HoldRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	holdResponse, customErr := c.HoldService.SetHold(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Hold successfully set.",
+		Data:    holdResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *HoldControllerImpl) ReleaseHold(ctx *gin.Context) {
+	holdID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	holdResponse, customErr := c.HoldService.ReleaseHold(ctx.Request.Context(), holdID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Hold successfully released.",
+		Data:    holdResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *HoldControllerImpl) FindHolds(ctx *gin.Context) {
+	query := new(web.HoldQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	holdResponses, customErr := c.HoldService.FindHolds(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Holds successfully retrieved.",
+		Data:    holdResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
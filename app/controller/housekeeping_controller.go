@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HousekeepingController interface {
+	PlanHousekeeping(ctx *gin.Context)
+	ExecuteHousekeeping(ctx *gin.Context)
+}
+
+type HousekeepingControllerImpl struct {
+	HousekeepingService service.HousekeepingService
+}
+
+func NewHousekeepingController(housekeepingService service.HousekeepingService) HousekeepingController {
+	return &HousekeepingControllerImpl{
+		HousekeepingService: housekeepingService,
+	}
+}
+
+func (c *HousekeepingControllerImpl) PlanHousekeeping(ctx *gin.Context) {
+	request := new(web.HousekeepingRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponse, customErr := c.HousekeepingService.PlanHousekeeping(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Housekeeping plan successfully computed (dry run).",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *HousekeepingControllerImpl) ExecuteHousekeeping(ctx *gin.Context) {
+	request := new(web.HousekeepingRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponse, customErr := c.HousekeepingService.ExecuteHousekeeping(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Success",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
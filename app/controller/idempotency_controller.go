@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentMemoryLimit is the largest body held in memory while its hash is
+// taken, larger bodies such as inventory uploads are spooled to a temporary
+// file.
+const idempotentMemoryLimit = 1 << 20
+
+type IdempotencyController interface {
+	// Guard is the middleware of the routes that honour an Idempotency-Key
+	// header. Requests without the header pass through.
+	Guard(ctx *gin.Context)
+}
+
+type IdempotencyControllerImpl struct {
+	IdempotencyService service.IdempotencyService
+}
+
+func NewIdempotencyController(idempotencyService service.IdempotencyService) IdempotencyController {
+	return &IdempotencyControllerImpl{
+		IdempotencyService: idempotencyService,
+	}
+}
+
+func (c *IdempotencyControllerImpl) Guard(ctx *gin.Context) {
+	key := ctx.GetHeader("Idempotency-Key")
+	if key == "" {
+		ctx.Next()
+		return
+	}
+
+	body, bodyHash, err := spoolBody(ctx.Request.Body)
+	if err != nil {
+		customErr := response.BadRequestError("Failed to read request body.")
+		ctx.AbortWithStatusJSON(customErr.StatusCode, customErr)
+		return
+	}
+	defer body.Close()
+	ctx.Request.Body = body
+
+	request := &web.IdempotencyRequest{
+		Actor:    requestActor(ctx),
+		Key:      key,
+		Method:   ctx.Request.Method,
+		Path:     ctx.Request.URL.RequestURI(),
+		BodyHash: bodyHash,
+	}
+
+	keyID, stored, customErr := c.IdempotencyService.Begin(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.AbortWithStatusJSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	if stored != nil {
+		ctx.Header("Idempotent-Replayed", "true")
+		ctx.Data(stored.StatusCode, stored.ContentType, stored.Body)
+		ctx.Abort()
+		return
+	}
+
+	recorder := &recordedResponse{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+
+	// A handler that panics does not leave the key in progress until the
+	// lock timeout, it is freed like after a server error.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if customErr := c.IdempotencyService.Complete(ctx.Request.Context(), keyID, &web.StoredResponse{StatusCode: http.StatusInternalServerError}); customErr != nil {
+				log.Printf("idempotency: %s %s: %s", request.Method, request.Path, customErr.Message)
+			}
+			panic(recovered)
+		}
+	}()
+
+	ctx.Next()
+
+	stored = &web.StoredResponse{
+		StatusCode:  recorder.Status(),
+		ContentType: recorder.Header().Get("Content-Type"),
+		Body:        recorder.body.Bytes(),
+	}
+
+	if customErr := c.IdempotencyService.Complete(ctx.Request.Context(), keyID, stored); customErr != nil {
+		log.Printf("idempotency: %s %s: %s", request.Method, request.Path, customErr.Message)
+	}
+}
+
+// spoolBody reads body once to hash it and returns a copy to hand to the
+// handler, in memory up to idempotentMemoryLimit and in a temporary file
+// beyond. Closing the copy removes the file.
+func spoolBody(body io.Reader) (io.ReadCloser, string, error) {
+	hash := sha256.New()
+	var buffer bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(&buffer, hash), io.LimitReader(body, idempotentMemoryLimit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if n <= idempotentMemoryLimit {
+		return io.NopCloser(&buffer), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	file, err := os.CreateTemp("", "idempotent-body-*")
+	if err != nil {
+		return nil, "", err
+	}
+	spooled := &spooledBody{File: file}
+
+	// The buffered start is already hashed
+	if _, err := buffer.WriteTo(file); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := io.Copy(io.MultiWriter(file, hash), body); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	return spooled, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// spooledBody is a request body kept in a temporary file.
+type spooledBody struct {
+	*os.File
+}
+
+func (b *spooledBody) Close() error {
+	err := b.File.Close()
+	if removeErr := os.Remove(b.File.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// recordedResponse keeps a copy of the response body written through it.
+type recordedResponse struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordedResponse) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordedResponse) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSpoolBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		spooled bool
+	}{
+		{"empty", 0, false},
+		{"small", 512, false},
+		{"at the memory limit", idempotentMemoryLimit, false},
+		{"past the memory limit", idempotentMemoryLimit + 1, true},
+		{"large upload", 3*idempotentMemoryLimit + 17, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte("0123456789abcdef"), tt.size/16+1)[:tt.size]
+			sum := sha256.Sum256(data)
+
+			body, bodyHash, err := spoolBody(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bodyHash != hex.EncodeToString(sum[:]) {
+				t.Errorf("hash = %s, want %s", bodyHash, hex.EncodeToString(sum[:]))
+			}
+
+			file, spooled := body.(*spooledBody)
+			if spooled != tt.spooled {
+				t.Errorf("spooled to a file = %v, want %v", spooled, tt.spooled)
+			}
+
+			read, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(read, data) {
+				t.Errorf("handler reads %d bytes, want the %d sent", len(read), len(data))
+			}
+			if err := body.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if spooled {
+				if _, err := os.Stat(file.Name()); !os.IsNotExist(err) {
+					t.Errorf("temporary file %s left after Close: %v", file.Name(), err)
+				}
+			}
+		})
+	}
+}
+
+// recordingIdempotency claims every key and keeps the completed responses.
+type recordingIdempotency struct {
+	service.IdempotencyService
+	completed []*web.StoredResponse
+}
+
+func (r *recordingIdempotency) Begin(ctx context.Context, request *web.IdempotencyRequest) (int, *web.StoredResponse, *response.CustomError) {
+	return 1, nil, nil
+}
+
+func (r *recordingIdempotency) Complete(ctx context.Context, keyID int, stored *web.StoredResponse) *response.CustomError {
+	r.completed = append(r.completed, stored)
+	return nil
+}
+
+func TestGuardCompletes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+	}{
+		{"handler answers", func(ctx *gin.Context) { ctx.JSON(http.StatusAccepted, gin.H{"status": true}) }, http.StatusAccepted},
+		{"handler fails", func(ctx *gin.Context) { ctx.JSON(http.StatusConflict, gin.H{"status": false}) }, http.StatusConflict},
+		{"handler panics", func(ctx *gin.Context) { panic("placement failed") }, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idempotency := &recordingIdempotency{}
+			router := gin.New()
+			router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, err any) {
+				ctx.AbortWithStatus(http.StatusInternalServerError)
+			}))
+			router.POST("/api/placement", NewIdempotencyController(idempotency).Guard, tt.handler)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/placement", strings.NewReader(`{"container_number":"MSKU0000001"}`))
+			request.Header.Set("Idempotency-Key", "7d4f3c1e-9a2b-4c5d-8e6f-0a1b2c3d4e5f")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if len(idempotency.completed) != 1 {
+				t.Fatalf("key completed %d times, want once", len(idempotency.completed))
+			}
+			if got := idempotency.completed[0].StatusCode; got != tt.wantStatus {
+				t.Errorf("key completed with %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
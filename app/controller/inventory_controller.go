@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/helper/spreadsheet"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryController interface {
+	SearchContainers(ctx *gin.Context)
+	FindContainer(ctx *gin.Context)
+	ImportContainers(ctx *gin.Context)
+	ExportContainers(ctx *gin.Context)
+}
+
+type InventoryControllerImpl struct {
+	InventoryService service.InventoryService
+}
+
+func NewInventoryController(inventoryService service.InventoryService) InventoryController {
+	return &InventoryControllerImpl{
+		InventoryService: inventoryService,
+	}
+}
+
+func (c *InventoryControllerImpl) SearchContainers(ctx *gin.Context) {
+	query := new(web.ContainerSearchQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	listResponse, customErr := c.InventoryService.SearchContainers(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Containers successfully retrieved.",
+		Data:    listResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *InventoryControllerImpl) FindContainer(ctx *gin.Context) {
+	detailResponse, customErr := c.InventoryService.FindContainer(ctx.Request.Context(), ctx.Param("number"))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	setETag(ctx, detailResponse.Revision)
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Container successfully retrieved.",
+		Data:    detailResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// ImportContainers takes the raw CSV or XLSX file as the request body, it is
+// read as it arrives.
+func (c *InventoryControllerImpl) ImportContainers(ctx *gin.Context) {
+	query := new(web.InventoryImportQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	importResponse, customErr := c.InventoryService.ImportContainers(ctx.Request.Context(), query, ctx.Request.Body)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	message := "Containers successfully imported."
+	switch {
+	case query.DryRun:
+		message = "Import file checked, nothing was stored."
+	case importResponse.Invalid > 0:
+		message = "Import rejected, no container was stored. Fix the listed rows and try again."
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: message,
+		Data:    importResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *InventoryControllerImpl) ExportContainers(ctx *gin.Context) {
+	query := new(web.InventoryExportQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	export, customErr := c.InventoryService.ExportContainers(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	writeTableExport(ctx, query.Format, export)
+}
+
+// writeTableExport streams an export as a file download. Once the first
+// bytes are sent the status can not change anymore, a later failure is
+// logged and leaves the file truncated.
+func writeTableExport(ctx *gin.Context, format string, export *web.TableExport) {
+	ctx.Header("Content-Disposition", `attachment; filename="`+export.FileName+`"`)
+	ctx.Header("Content-Type", spreadsheet.ContentType(format))
+	ctx.Status(http.StatusOK)
+
+	writer, err := spreadsheet.NewWriter(format, ctx.Writer, export.SheetName)
+	if err == nil {
+		if err = writer.Write(export.Header); err == nil {
+			err = export.Rows(writer.Write)
+		}
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		log.Printf("export: %s: %v", export.FileName, err)
+	}
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/model"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PreAdviceController interface {
+	CreatePreAdvice(ctx *gin.Context)
+	CreatePreAdvices(ctx *gin.Context)
+	FindPreAdvices(ctx *gin.Context)
+	FindPreAdvice(ctx *gin.Context)
+	CancelPreAdvice(ctx *gin.Context)
+}
+
+type PreAdviceControllerImpl struct {
+	PreAdviceService service.PreAdviceService
+}
+
+func NewPreAdviceController(preAdviceService service.PreAdviceService) PreAdviceController {
+	return &PreAdviceControllerImpl{
+		PreAdviceService: preAdviceService,
+	}
+}
+
+func (c *PreAdviceControllerImpl) CreatePreAdvice(ctx *gin.Context) {
+	request := new(web.PreAdviceRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	result, customErr := c.PreAdviceService.CreatePreAdvice(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Pre-advice successfully created.",
+		Data:    result,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *PreAdviceControllerImpl) CreatePreAdvices(ctx *gin.Context) {
+	request := new(web.BatchPreAdviceRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	batchResponse, customErr := c.PreAdviceService.CreatePreAdvices(ctx.Request.Context(), model.PreAdviceSourceManual, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Pre-advice batch processed.",
+		Data:    batchResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PreAdviceControllerImpl) FindPreAdvices(ctx *gin.Context) {
+	query := new(web.PreAdviceQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	preAdviceResponses, customErr := c.PreAdviceService.FindPendingPreAdvices(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Pending pre-advices successfully retrieved.",
+		Data:    preAdviceResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PreAdviceControllerImpl) FindPreAdvice(ctx *gin.Context) {
+	preAdviceID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	preAdviceResponse, customErr := c.PreAdviceService.FindPreAdvice(ctx.Request.Context(), preAdviceID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Pre-advice successfully retrieved.",
+		Data:    preAdviceResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PreAdviceControllerImpl) CancelPreAdvice(ctx *gin.Context) {
+	preAdviceID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	preAdviceResponse, customErr := c.PreAdviceService.CancelPreAdvice(ctx.Request.Context(), preAdviceID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Pre-advice cancelled.",
+		Data:    preAdviceResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReleaseOrderController interface {
+	CreateReleaseOrder(ctx *gin.Context)
+	FindReleaseOrder(ctx *gin.Context)
+	CancelReleaseOrder(ctx *gin.Context)
+}
+
+type ReleaseOrderControllerImpl struct {
+	ReleaseOrderService service.ReleaseOrderService
+}
+
+func NewReleaseOrderController(releaseOrderService service.ReleaseOrderService) ReleaseOrderController {
+	return &ReleaseOrderControllerImpl{
+		ReleaseOrderService: releaseOrderService,
+	}
+}
+
+func (c *ReleaseOrderControllerImpl) CreateReleaseOrder(ctx *gin.Context) {
+	request := new(web.ReleaseOrderRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	releaseResponse, customErr := c.ReleaseOrderService.CreateReleaseOrder(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Release order successfully created.",
+		Data:    releaseResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *ReleaseOrderControllerImpl) FindReleaseOrder(ctx *gin.Context) {
+	releaseOrderID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	releaseResponse, customErr := c.ReleaseOrderService.FindReleaseOrder(ctx.Request.Context(), releaseOrderID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Release order successfully retrieved.",
+		Data:    releaseResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *ReleaseOrderControllerImpl) CancelReleaseOrder(ctx *gin.Context) {
+	releaseOrderID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	releaseResponse, customErr := c.ReleaseOrderService.CancelReleaseOrder(ctx.Request.Context(), releaseOrderID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Release order cancelled.",
+		Data:    releaseResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
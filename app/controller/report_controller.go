@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController interface {
+	CapacityReport(ctx *gin.Context)
+	TakeCapacitySnapshot(ctx *gin.Context)
+	CapacityHistory(ctx *gin.Context)
+}
+
+type ReportControllerImpl struct {
+	CapacityReportService service.CapacityReportService
+}
+
+func NewReportController(capacityReportService service.CapacityReportService) ReportController {
+	return &ReportControllerImpl{
+		CapacityReportService: capacityReportService,
+	}
+}
+
+func (c *ReportControllerImpl) CapacityReport(ctx *gin.Context) {
+	query := new(web.CapacityReportQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	reportResponse, customErr := c.CapacityReportService.CapacityReport(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Capacity report successfully generated.",
+		Data:    reportResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *ReportControllerImpl) TakeCapacitySnapshot(ctx *gin.Context) {
+	snapshotResult, customErr := c.CapacityReportService.TakeSnapshot(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Capacity snapshot successfully taken.",
+		Data:    snapshotResult,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *ReportControllerImpl) CapacityHistory(ctx *gin.Context) {
+	query := new(web.CapacityHistoryQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	history, customErr := c.CapacityReportService.SnapshotHistory(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Capacity history successfully retrieved.",
+		Data:    history,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
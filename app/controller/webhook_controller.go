@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController interface {
+	FindWebhooks(ctx *gin.Context)
+	CreateWebhook(ctx *gin.Context)
+	UpdateWebhook(ctx *gin.Context)
+	DeleteWebhook(ctx *gin.Context)
+	Dispatch(ctx *gin.Context)
+	FindDeadLetters(ctx *gin.Context)
+	RetryDelivery(ctx *gin.Context)
+}
+
+type WebhookControllerImpl struct {
+	WebhookService service.WebhookService
+}
+
+func NewWebhookController(webhookService service.WebhookService) WebhookController {
+	return &WebhookControllerImpl{
+		WebhookService: webhookService,
+	}
+}
+
+func (c *WebhookControllerImpl) FindWebhooks(ctx *gin.Context) {
+	webhookResponses, customErr := c.WebhookService.FindWebhooks(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Webhooks successfully retrieved.",
+		Data:    webhookResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) CreateWebhook(ctx *gin.Context) {
+	request := new(web.WebhookRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webhookResponse, customErr := c.WebhookService.CreateWebhook(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Webhook successfully registered.",
+		Data:    webhookResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *WebhookControllerImpl) UpdateWebhook(ctx *gin.Context) {
+	webhookID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.WebhookRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webhookResponse, customErr := c.WebhookService.UpdateWebhook(ctx.Request.Context(), webhookID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Webhook successfully updated.",
+		Data:    webhookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) DeleteWebhook(ctx *gin.Context) {
+	webhookID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if customErr := c.WebhookService.DeleteWebhook(ctx.Request.Context(), webhookID); customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Webhook successfully deleted.",
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) Dispatch(ctx *gin.Context) {
+	dispatchResponse, customErr := c.WebhookService.Dispatch(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Webhook deliveries dispatched.",
+		Data:    dispatchResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) FindDeadLetters(ctx *gin.Context) {
+	query := new(web.WebhookDeliveryQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	deliveryResponses, customErr := c.WebhookService.FindDeadLetters(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Dead letters successfully retrieved.",
+		Data:    deliveryResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) RetryDelivery(ctx *gin.Context) {
+	deliveryID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	deliveryResponse, customErr := c.WebhookService.RetryDelivery(ctx.Request.Context(), deliveryID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Webhook delivery queued for retry.",
+		Data:    deliveryResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
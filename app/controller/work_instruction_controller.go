@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkInstructionController interface {
+	QueueMove(ctx *gin.Context)
+	FindInstructions(ctx *gin.Context)
+	FindInstruction(ctx *gin.Context)
+	EquipmentQueue(ctx *gin.Context)
+	ConfirmInstruction(ctx *gin.Context)
+	RejectInstruction(ctx *gin.Context)
+}
+
+type WorkInstructionControllerImpl struct {
+	WorkInstructionService service.WorkInstructionService
+}
+
+func NewWorkInstructionController(workInstructionService service.WorkInstructionService) WorkInstructionController {
+	return &WorkInstructionControllerImpl{
+		WorkInstructionService: workInstructionService,
+	}
+}
+
+func (c *WorkInstructionControllerImpl) QueueMove(ctx *gin.Context) {
+	request := new(web.MoveRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	var ok bool
+	if request.Revision, ok = bindIfMatch(ctx); !ok {
+		return
+	}
+
+	instructionResponse, customErr := c.WorkInstructionService.QueueMove(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Move queued, waiting for equipment confirmation.",
+		Data:    instructionResponse,
+	}
+
+	ctx.JSON(http.StatusAccepted, webResponse)
+}
+
+func (c *WorkInstructionControllerImpl) FindInstructions(ctx *gin.Context) {
+	query := new(web.WorkInstructionQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	instructionResponses, customErr := c.WorkInstructionService.FindInstructions(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Work instructions successfully retrieved.",
+		Data:    instructionResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WorkInstructionControllerImpl) FindInstruction(ctx *gin.Context) {
+	instructionID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	instructionResponse, customErr := c.WorkInstructionService.FindInstruction(ctx.Request.Context(), instructionID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Work instruction successfully retrieved.",
+		Data:    instructionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WorkInstructionControllerImpl) EquipmentQueue(ctx *gin.Context) {
+	equipmentID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	instructionResponses, customErr := c.WorkInstructionService.EquipmentQueue(ctx.Request.Context(), equipmentID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Equipment queue successfully retrieved.",
+		Data:    instructionResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WorkInstructionControllerImpl) ConfirmInstruction(ctx *gin.Context) {
+	instructionID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.ConfirmWorkInstructionRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	instructionResponse, customErr := c.WorkInstructionService.ConfirmInstruction(ctx.Request.Context(), instructionID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Work instruction confirmed.",
+		Data:    instructionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WorkInstructionControllerImpl) RejectInstruction(ctx *gin.Context) {
+	instructionID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.RejectWorkInstructionRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	instructionResponse, customErr := c.WorkInstructionService.RejectInstruction(ctx.Request.Context(), instructionID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Work instruction rejected.",
+		Data:    instructionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
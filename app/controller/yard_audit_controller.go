@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"net/http"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type YardAuditController interface {
+	CreateAudit(ctx *gin.Context)
+	FindAudits(ctx *gin.Context)
+	FindAudit(ctx *gin.Context)
+	RecordScans(ctx *gin.Context)
+	Reconcile(ctx *gin.Context)
+	ApplyCorrections(ctx *gin.Context)
+}
+
+type YardAuditControllerImpl struct {
+	YardAuditService service.YardAuditService
+}
+
+func NewYardAuditController(yardAuditService service.YardAuditService) YardAuditController {
+	return &YardAuditControllerImpl{
+		YardAuditService: yardAuditService,
+	}
+}
+
+func (c *YardAuditControllerImpl) CreateAudit(ctx *gin.Context) {
+	request := new(web.YardAuditRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	auditResponse, customErr := c.YardAuditService.CreateAudit(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard audit started.",
+		Data:    auditResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *YardAuditControllerImpl) FindAudits(ctx *gin.Context) {
+	query := new(web.YardAuditQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	auditResponses, customErr := c.YardAuditService.FindAudits(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard audits successfully retrieved.",
+		Data:    auditResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardAuditControllerImpl) FindAudit(ctx *gin.Context) {
+	auditID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	auditResponse, customErr := c.YardAuditService.FindAudit(ctx.Request.Context(), auditID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard audit successfully retrieved.",
+		Data:    auditResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardAuditControllerImpl) RecordScans(ctx *gin.Context) {
+	auditID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.YardAuditScanRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	auditResponse, customErr := c.YardAuditService.RecordScans(ctx.Request.Context(), auditID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Scans recorded.",
+		Data:    auditResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardAuditControllerImpl) Reconcile(ctx *gin.Context) {
+	auditID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	reconciliation, customErr := c.YardAuditService.Reconcile(ctx.Request.Context(), auditID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard audit reconciled.",
+		Data:    reconciliation,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardAuditControllerImpl) ApplyCorrections(ctx *gin.Context) {
+	auditID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.YardAuditApplyRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	applyResponse, customErr := c.YardAuditService.ApplyCorrections(ctx.Request.Context(), auditID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Audit corrections applied, yard audit closed.",
+		Data:    applyResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
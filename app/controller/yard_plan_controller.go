@@ -0,0 +1,301 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"yard-planning/app/service"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type YardPlanController interface {
+	CreatePlan(ctx *gin.Context)
+	CreateVersion(ctx *gin.Context)
+	PublishPlan(ctx *gin.Context)
+	AnalyzeImpact(ctx *gin.Context)
+	UpdatePlan(ctx *gin.Context)
+	FindPlanByID(ctx *gin.Context)
+	FindPlans(ctx *gin.Context)
+	ExportPlans(ctx *gin.Context)
+}
+
+type YardPlanControllerImpl struct {
+	YardPlanService service.YardPlanService
+}
+
+func NewYardPlanController(yardPlanService service.YardPlanService) YardPlanController {
+	return &YardPlanControllerImpl{
+		YardPlanService: yardPlanService,
+	}
+}
+
+func (c *YardPlanControllerImpl) CreatePlan(ctx *gin.Context) {
+	request := new(web.YardPlanRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponse, customErr := c.YardPlanService.CreatePlan(ctx.Request.Context(), request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Draft yard plan successfully created.",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *YardPlanControllerImpl) CreateVersion(ctx *gin.Context) {
+	planID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.YardPlanRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponse, customErr := c.YardPlanService.CreateVersion(ctx.Request.Context(), planID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Draft yard plan version successfully created.",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *YardPlanControllerImpl) PublishPlan(ctx *gin.Context) {
+	planID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.PublishYardPlanRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	if request.Revision, ok = bindIfMatch(ctx); !ok {
+		return
+	}
+
+	planResponse, customErr := c.YardPlanService.PublishPlan(ctx.Request.Context(), planID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	setETag(ctx, planResponse.Revision)
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard plan successfully published.",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardPlanControllerImpl) AnalyzeImpact(ctx *gin.Context) {
+	planID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.YardPlanRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	impactResponse, customErr := c.YardPlanService.AnalyzeImpact(ctx.Request.Context(), planID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard plan impact successfully analyzed.",
+		Data:    impactResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardPlanControllerImpl) UpdatePlan(ctx *gin.Context) {
+	planID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	request := new(web.UpdateYardPlanRequest)
+
+	if err := ctx.ShouldBindJSON(request); err != nil {
+		customErr := response.BadRequestError("Invalid request body or missing required fields.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	// A dry run only reports the impact, only a saved change needs If-Match.
+	if request.Confirm {
+		if request.Revision, ok = bindIfMatch(ctx); !ok {
+			return
+		}
+	}
+
+	impactResponse, customErr := c.YardPlanService.UpdatePlan(ctx.Request.Context(), planID, request)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	setETag(ctx, impactResponse.Revision)
+	message := "Yard plan not saved, resend with confirm=true to apply the change."
+	if impactResponse.Saved {
+		message = "Yard plan successfully updated."
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: message,
+		Data:    impactResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardPlanControllerImpl) FindPlanByID(ctx *gin.Context) {
+	planID, ok := bindIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	planResponse, customErr := c.YardPlanService.FindPlanByID(ctx.Request.Context(), planID)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	setETag(ctx, planResponse.Revision)
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard plan successfully retrieved.",
+		Data:    planResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *YardPlanControllerImpl) FindPlans(ctx *gin.Context) {
+	query := new(web.YardPlanQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	planResponses, customErr := c.YardPlanService.FindPlans(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Status:  true,
+		Message: "Yard plans successfully retrieved.",
+		Data:    planResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+// ExportPlans sends the yard plans of a yard as a CSV or XLSX download.
+func (c *YardPlanControllerImpl) ExportPlans(ctx *gin.Context) {
+	query := new(web.YardPlanExportQuery)
+
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		customErr := response.BadRequestError("Invalid query parameters.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	export, customErr := c.YardPlanService.ExportPlans(ctx.Request.Context(), query)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	writeTableExport(ctx, query.Format, export)
+}
+
+// bindIDParam reads a numeric path parameter, writing a bad request response
+// when it is missing or not a positive number.
+func bindIDParam(ctx *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param(name))
+	if err != nil || id < 1 {
+		customErr := response.BadRequestError("Invalid " + name + " parameter.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return 0, false
+	}
+	return id, true
+}
+
+// setETag sends the revision of the resource as its ETag.
+func setETag(ctx *gin.Context, revision int) {
+	ctx.Header("ETag", strconv.Quote(strconv.Itoa(revision)))
+}
+
+// bindIfMatch reads the revision from the If-Match header, writing a bad
+// request response when it is missing or not an ETag sent by setETag. A weak
+// ETag, as proxies that compress the response send it back, is read like the
+// strong one. "*" matches any revision and returns zero.
+func bindIfMatch(ctx *gin.Context) (int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" {
+		customErr := response.BadRequestError("If-Match header is required.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	value := strings.TrimPrefix(header, "W/")
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision < 1 {
+		customErr := response.BadRequestError("If-Match header must be the ETag of the resource or *.")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return 0, false
+	}
+	return revision, true
+}
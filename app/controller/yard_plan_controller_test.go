@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		header       string
+		wantRevision int
+		wantOK       bool
+	}{
+		{"strong ETag", `"4"`, 4, true},
+		{"weak ETag", `W/"4"`, 4, true},
+		{"unquoted", "4", 4, true},
+		{"surrounding space", ` "12" `, 12, true},
+		{"any revision", "*", 0, true},
+		{"missing", "", 0, false},
+		{"zero", `"0"`, 0, false},
+		{"negative", `"-1"`, 0, false},
+		{"not a number", `"abc"`, 0, false},
+		{"lowercase weak prefix", `w/"4"`, 0, false},
+		{"list of ETags", `"3", "4"`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/yard-plans/3/publish", nil)
+			if tt.header != "" {
+				ctx.Request.Header.Set("If-Match", tt.header)
+			}
+
+			revision, ok := bindIfMatch(ctx)
+			if ok != tt.wantOK || revision != tt.wantRevision {
+				t.Errorf("bindIfMatch = %d, %v, want %d, %v", revision, ok, tt.wantRevision, tt.wantOK)
+			}
+			if !ok && recorder.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+)
+
+// Entity types of audit log changes.
+const (
+	AuditEntityUser             = "user"
+	AuditEntityContainer        = "container"
+	AuditEntityYardPlan         = "yard_plan"
+	AuditEntityCapacitySnapshot = "capacity_snapshot"
+	AuditEntityDwellThreshold   = "dwell_threshold"
+	AuditEntityDwellAlert       = "dwell_alert"
+	AuditEntityTariff           = "tariff"
+	AuditEntityGateTransaction  = "gate_transaction"
+	AuditEntityHold             = "hold"
+	AuditEntityPreAdvice        = "pre_advice"
+	AuditEntityReleaseOrder     = "release_order"
+	AuditEntityWorkInstruction  = "work_instruction"
+	AuditEntityEquipment        = "equipment"
+	AuditEntityEDIPartner       = "edi_partner"
+	AuditEntityYardAudit        = "yard_audit"
+	AuditEntityYardAuditScan    = "yard_audit_scan"
+	AuditEntityYardAuditFinding = "yard_audit_finding"
+	AuditEntityWebhook          = "webhook"
+	AuditEntityWebhookDelivery  = "webhook_delivery"
+)
+
+// AuditLog is one mutating API request. Rows are only ever inserted.
+type AuditLog struct {
+	ID int `gorm:"primaryKey" json:"id"`
+	// authId of the request, empty for anonymous requests
+	Actor    string `gorm:"type:varchar(100)" json:"actor"`
+	Method   string `gorm:"type:varchar(10);not null" json:"method"`
+	Endpoint string `gorm:"type:varchar(255);not null" json:"endpoint"` // route pattern, /api/tariffs/:id
+	Path     string `gorm:"type:text;not null" json:"path"`
+
+	// JSON bodies with secrets masked, a description for other bodies
+	RequestPayload string `gorm:"type:text" json:"request_payload"`
+	ClientIP       string `gorm:"type:varchar(45)" json:"client_ip"`
+	StatusCode     int    `gorm:"not null" json:"status_code"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+
+	Changes []AuditLogChange `gorm:"foreignKey:AuditLogID" json:"changes"`
+}
+
+// AuditLogChange is the state of one entity before and after the request,
+// as JSON. Before is nil for created entities, After for deleted ones.
+type AuditLogChange struct {
+	ID         int     `gorm:"primaryKey" json:"id"`
+	AuditLogID int     `gorm:"not null" json:"audit_log_id"`
+	EntityType string  `gorm:"type:varchar(50);not null" json:"entity_type"`
+	EntityID   string  `gorm:"type:varchar(100);not null" json:"entity_id"`
+	Before     *string `gorm:"type:text" json:"before,omitempty"`
+	After      *string `gorm:"type:text" json:"after,omitempty"`
+}
@@ -13,6 +13,10 @@ type Block struct {
 	Rows  int `gorm:"not null" json:"rows"`  // Lebar
 	Tiers int `gorm:"not null" json:"tiers"` // Tinggi
 
+	// Yard layout coordinates of slot 1 in meters, slots run along X
+	PosX int `gorm:"not null;default:0" json:"pos_x"`
+	PosY int `gorm:"not null;default:0" json:"pos_y"`
+
 	Yard Yard `gorm:"foreignKey:YardID;references:ID" json:"yard,omitempty"`
 
 	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	CapacityLevelYard  = "YARD"
+	CapacityLevelBlock = "BLOCK"
+	CapacityLevelPlan  = "PLAN"
+)
+
+// CapacitySnapshot stores the capacity figures of one yard, block or yard
+// plan for a given day, used for trend charts.
+type CapacitySnapshot struct {
+	ID           int       `gorm:"primaryKey" json:"id"`
+	SnapshotDate time.Time `gorm:"type:date;not null" json:"snapshot_date"`
+	Level        string    `gorm:"type:varchar(10);not null" json:"level"` // 'YARD', 'BLOCK', 'PLAN'
+
+	YardID     int  `gorm:"not null" json:"yard_id"`
+	BlockID    *int `gorm:"null" json:"block_id,omitempty"`
+	YardPlanID *int `gorm:"null" json:"yard_plan_id,omitempty"`
+
+	TotalTEU    int `gorm:"not null" json:"total_teu"`
+	OccupiedTEU int `gorm:"not null" json:"occupied_teu"`
+	ReservedTEU int `gorm:"not null" json:"reserved_teu"`
+	FreeTEU     int `gorm:"not null" json:"free_teu"`
+	OverflowTEU int `gorm:"not null" json:"overflow_teu"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+}
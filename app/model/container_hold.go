@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	HoldTypeCustoms  = "CUSTOMS"
+	HoldTypeLine     = "LINE"
+	HoldTypeTerminal = "TERMINAL"
+	HoldTypeDamage   = "DAMAGE"
+)
+
+// ContainerHold blocks a container from leaving the yard until it is
+// released. A hold is active while ReleasedAt is empty.
+type ContainerHold struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	ContainerNumber string `gorm:"type:varchar(20);not null;index" json:"container_number"`
+	HoldType        string `gorm:"type:varchar(20);not null" json:"hold_type"` // 'CUSTOMS', 'LINE', 'TERMINAL', 'DAMAGE'
+	Reason          string `gorm:"type:varchar(255)" json:"reason,omitempty"`
+
+	CreatedAt  time.Time  `gorm:"type:timestamp with time zone" json:"created_at"`
+	ReleasedAt *time.Time `gorm:"type:timestamp with time zone" json:"released_at,omitempty"`
+}
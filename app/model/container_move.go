@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	MoveTypePlacement = "PLACEMENT"
+	MoveTypePickup    = "PICKUP"
+	MoveTypeMove      = "MOVE"
+)
+
+// MoveReasonImport marks the placements written by an inventory import. They
+// record containers already in the yard, not a gate-in or a discharge.
+const MoveReasonImport = "IMPORT"
+
+// ContainerMove is one entry of the container movement history. Placements
+// only have a destination, pickups only a source.
+type ContainerMove struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	ContainerNumber string `gorm:"type:varchar(20);not null;index" json:"container_number"`
+	MoveType        string `gorm:"type:varchar(20);not null" json:"move_type"` // 'PLACEMENT', 'PICKUP', 'MOVE'
+
+	FromBlockID *int `gorm:"null" json:"from_block_id,omitempty"`
+	FromSlot    *int `gorm:"null" json:"from_slot,omitempty"`
+	FromRow     *int `gorm:"null" json:"from_row,omitempty"`
+	FromTier    *int `gorm:"null" json:"from_tier,omitempty"`
+
+	ToBlockID *int `gorm:"null" json:"to_block_id,omitempty"`
+	ToSlot    *int `gorm:"null" json:"to_slot,omitempty"`
+	ToRow     *int `gorm:"null" json:"to_row,omitempty"`
+	ToTier    *int `gorm:"null" json:"to_tier,omitempty"`
+
+	ContainerSize   string `gorm:"type:varchar(5);not null" json:"container_size"`
+	ContainerHeight string `gorm:"type:varchar(5);not null" json:"container_height"`
+	ContainerType   string `gorm:"type:varchar(50);not null" json:"container_type"`
+
+	Vessel       string `gorm:"type:varchar(100)" json:"vessel,omitempty"`
+	Voyage       string `gorm:"type:varchar(50)" json:"voyage,omitempty"`
+	ShippingLine string `gorm:"type:varchar(50)" json:"shipping_line,omitempty"`
+	Reason       string `gorm:"type:varchar(255)" json:"reason,omitempty"`
+
+	// Original arrival of a container recorded by an inventory import, the
+	// placement itself is created when the import runs.
+	ArrivedAt *time.Time `gorm:"type:timestamp with time zone;null" json:"arrived_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+}
+
+// ContainerGateEvent is a placement or pickup joined with its yard and the
+// completed gate transaction of the truck that brought or took the container.
+// The gate fields are empty for moves that did not pass the gate.
+type ContainerGateEvent struct {
+	ContainerMove `gorm:"embedded"`
+
+	YardName         string `json:"yard_name"`
+	TruckPlate       string `json:"truck_plate"`
+	SealNumbers      string `json:"seal_numbers"`
+	BookingReference string `json:"booking_reference"`
+}
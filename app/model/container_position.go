@@ -4,6 +4,11 @@ import (
 	"time"
 )
 
+const (
+	ContainerStatusStorage  = "STORAGE"
+	ContainerStatusLongStay = "LONG_STAY"
+)
+
 type ContainerPosition struct {
 	ID              int    `gorm:"primaryKey" json:"id"`
 	ContainerNumber string `gorm:"type:varchar(20);unique;not null" json:"container_number"`
@@ -22,8 +27,27 @@ type ContainerPosition struct {
 
 	YardPlanID *int `gorm:"null" json:"yard_plan_id,omitempty"`
 
+	Vessel       string `gorm:"type:varchar(100)" json:"vessel,omitempty"`
+	Voyage       string `gorm:"type:varchar(50)" json:"voyage,omitempty"`
+	ShippingLine string `gorm:"type:varchar(50)" json:"shipping_line,omitempty"`
+
+	// Revision is bumped by every update, which only applies while the row is
+	// still at the revision it was read with. It is the ETag of the container,
+	// only a move checks it against If-Match.
+	Revision int `gorm:"not null;default:1" json:"revision"`
+
 	Block Block `gorm:"foreignKey:BlockID;references:ID" json:"block,omitempty"`
 
 	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
 	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
 }
+
+// ContainerPositionDetail is a container position joined with the names of
+// its block and yard.
+type ContainerPositionDetail struct {
+	ContainerPosition `gorm:"embedded"`
+
+	BlockName string `json:"block_name"`
+	YardID    int    `json:"yard_id"`
+	YardName  string `json:"yard_name"`
+}
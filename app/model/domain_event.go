@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+)
+
+// Domain event types published to webhooks and event streams.
+const (
+	EventContainerPlaced    = "ContainerPlaced"
+	EventContainerPickedUp  = "ContainerPickedUp"
+	EventContainerMoved     = "ContainerMoved"
+	EventReservationChanged = "ReservationChanged"
+	EventPlanChanged        = "PlanChanged"
+	EventHoldSet            = "HoldSet"
+	EventDwellExceeded      = "DwellExceeded"
+)
+
+// EventTypes lists every domain event type.
+var EventTypes = []string{
+	EventContainerPlaced, EventContainerPickedUp, EventContainerMoved, EventReservationChanged, EventPlanChanged, EventHoldSet, EventDwellExceeded,
+}
+
+// OutboxEvent is a domain event written in the transaction of the change it
+// describes. The dispatcher turns it into one delivery per subscribed
+// webhook and sets DispatchedAt. Payload is the JSON of the event data.
+//
+// StreamID orders the events for live streams. It is assigned once the
+// event is committed, so a stream client that has seen a stream ID has seen
+// every event before it, which IDs cannot guarantee.
+type OutboxEvent struct {
+	ID          int    `gorm:"primaryKey" json:"id"`
+	StreamID    *int   `gorm:"uniqueIndex" json:"stream_id,omitempty"`
+	EventType   string `gorm:"type:varchar(50);not null" json:"event_type"`
+	AggregateID string `gorm:"type:varchar(50);not null" json:"aggregate_id"` // container number or plan ID
+	Payload     string `gorm:"type:text;not null" json:"payload"`
+
+	// Location for stream filters, the yard is derived from BlockID. Moves
+	// between blocks also set ToBlockID.
+	YardID    *int `gorm:"null" json:"yard_id,omitempty"`
+	BlockID   *int `gorm:"null" json:"block_id,omitempty"`
+	ToBlockID *int `gorm:"null" json:"to_block_id,omitempty"`
+
+	CreatedAt    time.Time  `gorm:"type:timestamp with time zone" json:"created_at"`
+	DispatchedAt *time.Time `gorm:"type:timestamp with time zone" json:"dispatched_at,omitempty"`
+}
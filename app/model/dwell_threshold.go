@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	DwellActionReport       = "REPORT"
+	DwellActionNotify       = "NOTIFY"
+	DwellActionMarkLongStay = "MARK_LONG_STAY"
+)
+
+// DwellThreshold is the number of free days a container may stay in the yard.
+// YardID and ContainerType narrow the rule; when both are empty it applies to
+// every container. The most specific matching threshold wins.
+type DwellThreshold struct {
+	ID            int    `gorm:"primaryKey" json:"id"`
+	YardID        *int   `gorm:"null" json:"yard_id,omitempty"`
+	ContainerType string `gorm:"type:varchar(50)" json:"container_type,omitempty"`
+	FreeDays      int    `gorm:"not null" json:"free_days"`
+	Action        string `gorm:"type:varchar(20);not null" json:"action"` // 'REPORT', 'NOTIFY', 'MARK_LONG_STAY'
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// Matches reports whether the threshold applies to a container of the given
+// type stored in the given yard.
+func (t *DwellThreshold) Matches(yardID int, containerType string) bool {
+	if t.YardID != nil && *t.YardID != yardID {
+		return false
+	}
+	if t.ContainerType != "" && t.ContainerType != containerType {
+		return false
+	}
+	return true
+}
+
+// Specificity ranks matching thresholds: a yard-specific rule outranks a
+// type-specific one, and a rule with both outranks either.
+func (t *DwellThreshold) Specificity() int {
+	rank := 0
+	if t.YardID != nil {
+		rank += 2
+	}
+	if t.ContainerType != "" {
+		rank++
+	}
+	return rank
+}
+
+// DwellAlert records that a container passed its free time. One alert is
+// raised per container visit.
+type DwellAlert struct {
+	ID              int       `gorm:"primaryKey" json:"id"`
+	ContainerNumber string    `gorm:"type:varchar(20);not null" json:"container_number"`
+	ArrivalDate     time.Time `gorm:"type:timestamp with time zone" json:"arrival_date"`
+	YardID          int       `gorm:"not null" json:"yard_id"`
+	DwellDays       int       `gorm:"not null" json:"dwell_days"`
+	FreeDays        int       `gorm:"not null" json:"free_days"`
+	Action          string    `gorm:"type:varchar(20);not null" json:"action"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// EDIPartner holds the interchange IDs used in EDI messages exchanged with a
+// shipping line. SenderID identifies the terminal, RecipientID the line.
+type EDIPartner struct {
+	ID           int    `gorm:"primaryKey" json:"id"`
+	ShippingLine string `gorm:"type:varchar(50);not null;unique" json:"shipping_line"`
+	SenderID     string `gorm:"type:varchar(35);not null" json:"sender_id"`
+	RecipientID  string `gorm:"type:varchar(35);not null" json:"recipient_id"`
+	// IANA time zone the report periods and message dates are given in
+	Timezone string `gorm:"type:varchar(64);not null;default:Asia/Jakarta" json:"timezone"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// TimeLocation is the time zone agreed with the partner. An unknown zone
+// falls back to UTC.
+func (p *EDIPartner) TimeLocation() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
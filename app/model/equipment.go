@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	EquipmentTypeRTG          = "RTG"
+	EquipmentTypeReachStacker = "REACH_STACKER"
+)
+
+// Equipment is a yard machine that executes work instructions. It is
+// assigned to the blocks it serves in BlockIDs.
+type Equipment struct {
+	ID            int    `gorm:"primaryKey" json:"id"`
+	Code          string `gorm:"type:varchar(20);not null;unique" json:"code"`
+	EquipmentType string `gorm:"type:varchar(20);not null" json:"equipment_type"` // 'RTG', 'REACH_STACKER'
+	YardID        int    `gorm:"not null" json:"yard_id"`
+	Active        bool   `gorm:"not null" json:"active"`
+
+	BlockIDs []int `gorm:"-" json:"block_ids"`
+
+	// Where the equipment finished its last job, used by the dispatcher
+	CurrentBlockID *int `gorm:"null" json:"current_block_id,omitempty"`
+	CurrentSlot    *int `gorm:"null" json:"current_slot,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	GateDirectionIn  = "IN"
+	GateDirectionOut = "OUT"
+
+	GateStatusPendingPlacement = "PENDING_PLACEMENT"
+	GateStatusCompleted        = "COMPLETED"
+	GateStatusCancelled        = "CANCELLED"
+	GateStatusRejected         = "REJECTED"
+)
+
+// GateTransaction records a truck passing the gate with a container. A
+// gate-in stays PENDING_PLACEMENT, holding its planned cell, until the
+// placement is confirmed. The position is the planned or actual cell for a
+// gate-in and the cell the container was picked from for a gate-out.
+type GateTransaction struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	Direction       string `gorm:"type:varchar(5);not null" json:"direction"` // 'IN', 'OUT'
+	Status          string `gorm:"type:varchar(20);not null" json:"status"`   // 'PENDING_PLACEMENT', 'COMPLETED', 'CANCELLED', 'REJECTED'
+	ContainerNumber string `gorm:"type:varchar(20);not null" json:"container_number"`
+	YardID          int    `gorm:"not null" json:"yard_id"`
+
+	ContainerSize   string `gorm:"type:varchar(5)" json:"container_size"`
+	ContainerHeight string `gorm:"type:varchar(5)" json:"container_height"`
+	ContainerType   string `gorm:"type:varchar(50)" json:"container_type"`
+	Vessel          string `gorm:"type:varchar(100)" json:"vessel,omitempty"`
+	Voyage          string `gorm:"type:varchar(50)" json:"voyage,omitempty"`
+	ShippingLine    string `gorm:"type:varchar(50)" json:"shipping_line,omitempty"`
+
+	TruckPlate    string `gorm:"type:varchar(20);not null" json:"truck_plate"`
+	DriverName    string `gorm:"type:varchar(100);not null" json:"driver_name"`
+	DriverLicense string `gorm:"type:varchar(50)" json:"driver_license,omitempty"`
+	SealNumbers   string `gorm:"type:varchar(255)" json:"seal_numbers,omitempty"` // comma separated
+	DamageRemarks string `gorm:"type:text" json:"damage_remarks,omitempty"`
+
+	BookingReference string `gorm:"type:varchar(50)" json:"booking_reference,omitempty"`
+	ReleaseReference string `gorm:"type:varchar(50)" json:"release_reference,omitempty"`
+
+	BlockID    *int `gorm:"null" json:"block_id,omitempty"`
+	SlotNumber *int `gorm:"null" json:"slot_number,omitempty"`
+	RowNumber  *int `gorm:"null" json:"row_number,omitempty"`
+	TierNumber *int `gorm:"null" json:"tier_number,omitempty"`
+
+	RejectReason string `gorm:"type:varchar(255)" json:"reject_reason,omitempty"`
+
+	CreatedAt   time.Time  `gorm:"type:timestamp with time zone" json:"created_at"`
+	CompletedAt *time.Time `gorm:"type:timestamp with time zone" json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// SetPosition stores the cell of the transaction.
+func (t *GateTransaction) SetPosition(blockID, slot, row, tier int) {
+	t.BlockID, t.SlotNumber, t.RowNumber, t.TierNumber = &blockID, &slot, &row, &tier
+}
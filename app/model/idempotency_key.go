@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	IdempotencyStatusInProgress = "IN_PROGRESS"
+	IdempotencyStatusCompleted  = "COMPLETED"
+)
+
+// IdempotencyKey is the Idempotency-Key of a mutating request and, once the
+// request finished, the response replayed to retries. Keys are unique per
+// actor until they expire.
+type IdempotencyKey struct {
+	ID     int    `gorm:"primaryKey" json:"id"`
+	Actor  string `gorm:"type:varchar(100);not null" json:"actor"`
+	Key    string `gorm:"column:idempotency_key;type:varchar(255);not null" json:"key"`
+	Method string `gorm:"type:varchar(10);not null" json:"method"`
+	Path   string `gorm:"type:text;not null" json:"path"`
+	// hex SHA-256 of method, path and body, retries must match it
+	RequestHash string `gorm:"type:varchar(64);not null" json:"request_hash"`
+	Status      string `gorm:"type:varchar(20);not null" json:"status"` // 'IN_PROGRESS', 'COMPLETED'
+
+	StatusCode   int    `json:"status_code,omitempty"`
+	ContentType  string `gorm:"type:varchar(255)" json:"content_type,omitempty"`
+	ResponseBody string `gorm:"type:text" json:"response_body,omitempty"`
+
+	// LockedAt is when the request in progress started, a lock older than
+	// the lock timeout is taken over by the next retry.
+	LockedAt  time.Time `gorm:"type:timestamp with time zone" json:"locked_at"`
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	ExpiresAt time.Time `gorm:"type:timestamp with time zone" json:"expires_at"`
+}
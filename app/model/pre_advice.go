@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	PreAdviceStatusPending   = "PENDING"
+	PreAdviceStatusArrived   = "ARRIVED"
+	PreAdviceStatusCancelled = "CANCELLED"
+	PreAdviceStatusExpired   = "EXPIRED"
+
+	PreAdviceSourceManual = "MANUAL"
+	PreAdviceSourceCOARRI = "COARRI"
+	PreAdviceSourceBAPLIE = "BAPLIE"
+)
+
+// PreAdvice announces a container expected in the yard. While PENDING it may
+// hold a provisional cell, which suggestions treat as taken. Mismatches lists
+// the announced fields the container did not match on arrival.
+type PreAdvice struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	ContainerNumber string `gorm:"type:varchar(20);not null" json:"container_number"`
+	YardID          int    `gorm:"not null" json:"yard_id"`
+	Status          string `gorm:"type:varchar(20);not null" json:"status"` // 'PENDING', 'ARRIVED', 'CANCELLED', 'EXPIRED'
+	Source          string `gorm:"type:varchar(20);not null" json:"source"`
+
+	ContainerSize   string `gorm:"type:varchar(5);not null" json:"container_size"`
+	ContainerHeight string `gorm:"type:varchar(5);not null" json:"container_height"`
+	ContainerType   string `gorm:"type:varchar(50);not null" json:"container_type"`
+	Vessel          string `gorm:"type:varchar(100)" json:"vessel,omitempty"`
+	Voyage          string `gorm:"type:varchar(50)" json:"voyage,omitempty"`
+	ShippingLine    string `gorm:"type:varchar(50)" json:"shipping_line,omitempty"`
+
+	// Cargo details announced by the stowage plan
+	GrossWeight     int    `gorm:"column:gross_weight_kg;not null;default:0" json:"gross_weight_kg,omitempty"`
+	PortOfDischarge string `gorm:"type:varchar(5)" json:"port_of_discharge,omitempty"`
+	DangerousGoods  string `gorm:"type:varchar(255)" json:"dangerous_goods,omitempty"` // comma separated IMDG class/UN number pairs
+
+	ExpectedFrom time.Time `gorm:"type:timestamp with time zone" json:"expected_from"`
+	ExpectedTo   time.Time `gorm:"type:timestamp with time zone" json:"expected_to"`
+
+	BlockID    *int `gorm:"null" json:"block_id,omitempty"`
+	SlotNumber *int `gorm:"null" json:"slot_number,omitempty"`
+	RowNumber  *int `gorm:"null" json:"row_number,omitempty"`
+	TierNumber *int `gorm:"null" json:"tier_number,omitempty"`
+
+	ArrivedAt  *time.Time `gorm:"type:timestamp with time zone" json:"arrived_at,omitempty"`
+	Mismatches string     `gorm:"type:varchar(255)" json:"mismatches,omitempty"` // comma separated field names
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// SetPosition stores the provisional cell of the pre-advice.
+func (p *PreAdvice) SetPosition(blockID, slot, row, tier int) {
+	p.BlockID, p.SlotNumber, p.RowNumber, p.TierNumber = &blockID, &slot, &row, &tier
+}
+
+// ClearPosition releases the provisional cell.
+func (p *PreAdvice) ClearPosition() {
+	p.BlockID, p.SlotNumber, p.RowNumber, p.TierNumber = nil, nil, nil, nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ReleaseStatusActive    = "ACTIVE"
+	ReleaseStatusCompleted = "COMPLETED"
+	ReleaseStatusCancelled = "CANCELLED"
+)
+
+// ReleaseOrder authorizes a trucking company to pick up the listed
+// containers until ValidUntil. Each container can be picked up once, the
+// order is COMPLETED when all of them are gone. Only the hash of the PIN is
+// stored. Too many wrong PINs in a row lock the order until PinLockedUntil.
+type ReleaseOrder struct {
+	ID              int       `gorm:"primaryKey" json:"id"`
+	ReleaseNumber   string    `gorm:"type:varchar(50);not null;unique" json:"release_number"`
+	PinHash         string    `gorm:"type:varchar(255);not null" json:"-"`
+	TruckingCompany string    `gorm:"type:varchar(100);not null" json:"trucking_company"`
+	ValidUntil      time.Time `gorm:"type:timestamp with time zone" json:"valid_until"`
+	Status          string    `gorm:"type:varchar(20);not null" json:"status"` // 'ACTIVE', 'COMPLETED', 'CANCELLED'
+
+	FailedPinAttempts int        `gorm:"not null;default:0" json:"-"`
+	PinLockedUntil    *time.Time `gorm:"type:timestamp with time zone" json:"pin_locked_until,omitempty"`
+
+	Containers []ReleaseOrderContainer `gorm:"-" json:"containers"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+type ReleaseOrderContainer struct {
+	ID              int        `gorm:"primaryKey" json:"id"`
+	ReleaseOrderID  int        `gorm:"not null" json:"release_order_id"`
+	ContainerNumber string     `gorm:"type:varchar(20);not null" json:"container_number"`
+	PickedUpAt      *time.Time `gorm:"type:timestamp with time zone" json:"picked_up_at,omitempty"`
+}
+
+// Container returns the entry of the given container, nil when the order
+// does not cover it.
+func (r *ReleaseOrder) Container(containerNumber string) *ReleaseOrderContainer {
+	for i := range r.Containers {
+		if r.Containers[i].ContainerNumber == containerNumber {
+			return &r.Containers[i]
+		}
+	}
+	return nil
+}
+
+// AllowsCompany compares trucking company names ignoring case and
+// surrounding spaces.
+func (r *ReleaseOrder) AllowsCompany(company string) bool {
+	return strings.EqualFold(strings.TrimSpace(r.TruckingCompany), strings.TrimSpace(company))
+}
+
+// PinLocked reports whether PIN checks are suspended at now.
+func (r *ReleaseOrder) PinLocked(now time.Time) bool {
+	return r.PinLockedUntil != nil && now.Before(*r.PinLockedUntil)
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+)
+
+// Tariff holds the storage rates of one yard, size and type combination.
+// Empty YardID, ContainerSize or ContainerType match any value; the most
+// specific matching tariff is used. Amounts are in the smallest currency unit.
+type Tariff struct {
+	ID            int    `gorm:"primaryKey" json:"id"`
+	Name          string `gorm:"type:varchar(100);not null" json:"name"`
+	YardID        *int   `gorm:"null" json:"yard_id,omitempty"`
+	ContainerSize string `gorm:"type:varchar(5)" json:"container_size,omitempty"`
+	ContainerType string `gorm:"type:varchar(50)" json:"container_type,omitempty"`
+
+	FreeDays int `gorm:"not null" json:"free_days"`
+	// Charged for every day a reefer is in the yard, free days included
+	ReeferDailyRate int64 `gorm:"not null" json:"reefer_daily_rate"`
+
+	Tiers []TariffTier `gorm:"-" json:"tiers"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// TariffTier is the daily rate charged from FromDay of a visit (the arrival
+// day is day 1) until the day before the next tier starts.
+type TariffTier struct {
+	ID        int   `gorm:"primaryKey" json:"id"`
+	TariffID  int   `gorm:"not null" json:"tariff_id"`
+	FromDay   int   `gorm:"not null" json:"from_day"`
+	DailyRate int64 `gorm:"not null" json:"daily_rate"`
+}
+
+// Matches reports whether the tariff applies to a container of the given size
+// and type stored in the given yard.
+func (t *Tariff) Matches(yardID int, containerSize, containerType string) bool {
+	if t.YardID != nil && *t.YardID != yardID {
+		return false
+	}
+	if t.ContainerSize != "" && t.ContainerSize != containerSize {
+		return false
+	}
+	if t.ContainerType != "" && t.ContainerType != containerType {
+		return false
+	}
+	return true
+}
+
+// Specificity ranks matching tariffs: yard outranks size, which outranks type.
+func (t *Tariff) Specificity() int {
+	rank := 0
+	if t.YardID != nil {
+		rank += 4
+	}
+	if t.ContainerSize != "" {
+		rank += 2
+	}
+	if t.ContainerType != "" {
+		rank++
+	}
+	return rank
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	DeliveryStatusPending   = "PENDING"
+	DeliveryStatusDelivered = "DELIVERED"
+	DeliveryStatusDead      = "DEAD"
+)
+
+// Webhook is an URL domain events are posted to, signed with Secret.
+// EventTypes is a comma separated list, empty subscribes to every event.
+type Webhook struct {
+	ID         int    `gorm:"primaryKey" json:"id"`
+	URL        string `gorm:"type:varchar(500);not null" json:"url"`
+	Secret     string `gorm:"type:varchar(255);not null" json:"-"`
+	EventTypes string `gorm:"type:varchar(255)" json:"event_types"`
+	Active     bool   `gorm:"not null;default:true" json:"active"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants events of the type.
+func (w *Webhook) Subscribes(eventType string) bool {
+	if w.EventTypes == "" {
+		return true
+	}
+	for _, subscribed := range strings.Split(w.EventTypes, ",") {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event to send to one webhook. Failed attempts are
+// retried at NextAttemptAt until the delivery is DEAD, the dead-letter list.
+type WebhookDelivery struct {
+	ID        int    `gorm:"primaryKey" json:"id"`
+	WebhookID int    `gorm:"not null" json:"webhook_id"`
+	EventID   int    `gorm:"not null" json:"event_id"`
+	Status    string `gorm:"type:varchar(20);not null" json:"status"` // 'PENDING', 'DELIVERED', 'DEAD'
+	Attempts  int    `gorm:"not null;default:0" json:"attempts"`
+
+	NextAttemptAt  time.Time  `gorm:"type:timestamp with time zone" json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `gorm:"type:timestamp with time zone" json:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// WebhookDeliveryDetail is a delivery joined with its webhook URL and event
+// type.
+type WebhookDeliveryDetail struct {
+	WebhookDelivery `gorm:"embedded"`
+
+	URL       string `json:"url"`
+	EventType string `json:"event_type"`
+}
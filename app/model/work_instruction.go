@@ -0,0 +1,113 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	WorkTypePlacement = "PLACEMENT"
+	WorkTypePickup    = "PICKUP"
+	WorkTypeMove      = "MOVE"
+
+	WorkStatusPending   = "PENDING"
+	WorkStatusConfirmed = "CONFIRMED"
+	WorkStatusRejected  = "REJECTED"
+)
+
+// Dispatch priorities, a truck is waiting at the block for placements and
+// pickups while yard moves are not time bound.
+const (
+	WorkPriorityMove      = 1
+	WorkPriorityPlacement = 2
+	WorkPriorityPickup    = 3
+)
+
+// WorkInstruction is a job for the equipment working BlockID. Placements only
+// have a destination, pickups only a source. The container position changes
+// when the operator confirms the job, a PENDING placement or move holds its
+// destination cell until then.
+type WorkInstruction struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	WorkType        string `gorm:"type:varchar(20);not null" json:"work_type"` // 'PLACEMENT', 'PICKUP', 'MOVE'
+	Status          string `gorm:"type:varchar(20);not null" json:"status"`    // 'PENDING', 'CONFIRMED', 'REJECTED'
+	ContainerNumber string `gorm:"type:varchar(20);not null" json:"container_number"`
+	YardID          int    `gorm:"not null" json:"yard_id"`
+	BlockID         int    `gorm:"not null" json:"block_id"`
+	EquipmentID     *int   `gorm:"null" json:"equipment_id,omitempty"`
+	Priority        int    `gorm:"not null" json:"priority"`
+	// Position in the queue of the equipment, set by the dispatcher
+	Sequence *int `gorm:"null" json:"sequence,omitempty"`
+	// Instruction that has to be confirmed or rejected first, the moves of a
+	// housekeeping plan are chained in plan order
+	PredecessorID *int `gorm:"null" json:"predecessor_id,omitempty"`
+
+	FromBlockID *int `gorm:"null" json:"from_block_id,omitempty"`
+	FromSlot    *int `gorm:"null" json:"from_slot,omitempty"`
+	FromRow     *int `gorm:"null" json:"from_row,omitempty"`
+	FromTier    *int `gorm:"null" json:"from_tier,omitempty"`
+	// Revision of the container a move was queued against, the move is only
+	// executed while the container is still at it
+	ContainerRevision *int `gorm:"null" json:"container_revision,omitempty"`
+
+	ToBlockID *int `gorm:"null" json:"to_block_id,omitempty"`
+	ToSlot    *int `gorm:"null" json:"to_slot,omitempty"`
+	ToRow     *int `gorm:"null" json:"to_row,omitempty"`
+	ToTier    *int `gorm:"null" json:"to_tier,omitempty"`
+
+	ContainerSize   string `gorm:"type:varchar(5);not null" json:"container_size"`
+	ContainerHeight string `gorm:"type:varchar(5);not null" json:"container_height"`
+	ContainerType   string `gorm:"type:varchar(50);not null" json:"container_type"`
+	Vessel          string `gorm:"type:varchar(100)" json:"vessel,omitempty"`
+	Voyage          string `gorm:"type:varchar(50)" json:"voyage,omitempty"`
+	ShippingLine    string `gorm:"type:varchar(50)" json:"shipping_line,omitempty"`
+
+	// The release order a pickup was authorized with
+	ReleaseOrderID *int   `gorm:"null" json:"release_order_id,omitempty"`
+	Reason         string `gorm:"type:varchar(255)" json:"reason,omitempty"`
+
+	Operator     string     `gorm:"type:varchar(100)" json:"operator,omitempty"`
+	RejectReason string     `gorm:"type:varchar(255)" json:"reject_reason,omitempty"`
+	CompletedAt  *time.Time `gorm:"type:timestamp with time zone" json:"completed_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// WorkPriority returns the default dispatch priority of a work type.
+func WorkPriority(workType string) int {
+	switch workType {
+	case WorkTypePickup:
+		return WorkPriorityPickup
+	case WorkTypePlacement:
+		return WorkPriorityPlacement
+	}
+	return WorkPriorityMove
+}
+
+// Start returns the cell the equipment picks the container up at, the truck
+// lane next to the destination for placements.
+func (w *WorkInstruction) Start() (blockID, slot int) {
+	if w.FromBlockID != nil {
+		return *w.FromBlockID, *w.FromSlot
+	}
+	return *w.ToBlockID, *w.ToSlot
+}
+
+// End returns the cell the equipment puts the container down at, the truck
+// lane next to the source for pickups.
+func (w *WorkInstruction) End() (blockID, slot int) {
+	if w.ToBlockID != nil {
+		return *w.ToBlockID, *w.ToSlot
+	}
+	return *w.FromBlockID, *w.FromSlot
+}
+
+// SetFrom stores the cell the container is taken from.
+func (w *WorkInstruction) SetFrom(blockID, slot, row, tier int) {
+	w.FromBlockID, w.FromSlot, w.FromRow, w.FromTier = &blockID, &slot, &row, &tier
+}
+
+// SetTo stores the cell the container is put down in.
+func (w *WorkInstruction) SetTo(blockID, slot, row, tier int) {
+	w.ToBlockID, w.ToSlot, w.ToRow, w.ToTier = &blockID, &slot, &row, &tier
+}
@@ -8,9 +8,21 @@ type Yard struct {
 	ID       int    `gorm:"primaryKey" json:"id"`
 	Name     string `gorm:"type:varchar(100);unique;not null" json:"name"`
 	Location string `gorm:"type:varchar(255)" json:"location"`
+	// IANA time zone of the yard, its calendar days are counted in it
+	Timezone string `gorm:"type:varchar(64);not null;default:Asia/Jakarta" json:"timezone"`
 
 	Blocks []Block `gorm:"foreignKey:YardID" json:"blocks,omitempty"`
 
 	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
 	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
 }
+
+// TimeLocation is the time zone the yard works in. An unknown zone falls
+// back to UTC.
+func (y *Yard) TimeLocation() *time.Location {
+	loc, err := time.LoadLocation(y.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
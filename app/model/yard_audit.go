@@ -0,0 +1,101 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	AuditStatusOpen   = "OPEN"
+	AuditStatusClosed = "CLOSED"
+
+	FindingTypeMissing    = "MISSING"
+	FindingTypeUnexpected = "UNEXPECTED"
+	FindingTypeMisplaced  = "MISPLACED"
+
+	// Suggested corrections: take a missing container off the inventory, add
+	// an unexpected one where it was found, move a misplaced one there.
+	FindingActionRemove = "REMOVE"
+	FindingActionAdd    = "ADD"
+	FindingActionMove   = "MOVE"
+
+	FindingStatusPending  = "PENDING"
+	FindingStatusApplied  = "APPLIED"
+	FindingStatusRejected = "REJECTED"
+)
+
+// YardAudit is a physical check of a yard, or of one block when BlockID is
+// set. Checkers scan what they find while the audit is OPEN, reconciling
+// compares the scans with the recorded positions. The audit is CLOSED when
+// its corrections are decided.
+type YardAudit struct {
+	ID      int    `gorm:"primaryKey" json:"id"`
+	YardID  int    `gorm:"not null" json:"yard_id"`
+	BlockID *int   `gorm:"null" json:"block_id,omitempty"`
+	Status  string `gorm:"type:varchar(20);not null" json:"status"` // 'OPEN', 'CLOSED'
+
+	CreatedBy    string     `gorm:"type:varchar(100);not null" json:"created_by"`
+	ClosedBy     string     `gorm:"type:varchar(100)" json:"closed_by,omitempty"`
+	ReconciledAt *time.Time `gorm:"type:timestamp with time zone" json:"reconciled_at,omitempty"`
+	ClosedAt     *time.Time `gorm:"type:timestamp with time zone" json:"closed_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
+}
+
+// YardAuditScan is what a checker found in a cell. An empty ContainerNumber
+// means the cell was seen empty. The container specification is optional,
+// it is needed to add a container the inventory does not know.
+type YardAuditScan struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	AuditID         int    `gorm:"not null" json:"audit_id"`
+	BlockID         int    `gorm:"not null" json:"block_id"`
+	SlotNumber      int    `gorm:"not null" json:"slot_number"`
+	RowNumber       int    `gorm:"not null" json:"row_number"`
+	TierNumber      int    `gorm:"not null" json:"tier_number"`
+	ContainerNumber string `gorm:"type:varchar(20)" json:"container_number,omitempty"`
+
+	ContainerSize   string `gorm:"type:varchar(5)" json:"container_size,omitempty"`
+	ContainerHeight string `gorm:"type:varchar(5)" json:"container_height,omitempty"`
+	ContainerType   string `gorm:"type:varchar(50)" json:"container_type,omitempty"`
+
+	ScannedBy string    `gorm:"type:varchar(100);not null" json:"scanned_by"`
+	ScannedAt time.Time `gorm:"type:timestamp with time zone" json:"scanned_at"`
+}
+
+// YardAuditFinding is a difference between the scans and the recorded
+// positions with its suggested correction. Missing containers only have the
+// recorded cell, unexpected ones only the cell they were found in.
+type YardAuditFinding struct {
+	ID              int    `gorm:"primaryKey" json:"id"`
+	AuditID         int    `gorm:"not null" json:"audit_id"`
+	FindingType     string `gorm:"type:varchar(20);not null" json:"finding_type"` // 'MISSING', 'UNEXPECTED', 'MISPLACED'
+	ContainerNumber string `gorm:"type:varchar(20);not null" json:"container_number"`
+	Action          string `gorm:"type:varchar(20);not null" json:"action"` // 'REMOVE', 'ADD', 'MOVE'
+	Status          string `gorm:"type:varchar(20);not null" json:"status"` // 'PENDING', 'APPLIED', 'REJECTED'
+
+	RecordedBlockID *int `gorm:"null" json:"recorded_block_id,omitempty"`
+	RecordedSlot    *int `gorm:"null" json:"recorded_slot,omitempty"`
+	RecordedRow     *int `gorm:"null" json:"recorded_row,omitempty"`
+	RecordedTier    *int `gorm:"null" json:"recorded_tier,omitempty"`
+
+	FoundBlockID *int `gorm:"null" json:"found_block_id,omitempty"`
+	FoundSlot    *int `gorm:"null" json:"found_slot,omitempty"`
+	FoundRow     *int `gorm:"null" json:"found_row,omitempty"`
+	FoundTier    *int `gorm:"null" json:"found_tier,omitempty"`
+
+	ContainerSize   string `gorm:"type:varchar(5)" json:"container_size,omitempty"`
+	ContainerHeight string `gorm:"type:varchar(5)" json:"container_height,omitempty"`
+	ContainerType   string `gorm:"type:varchar(50)" json:"container_type,omitempty"`
+
+	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
+}
+
+// SetRecorded stores the cell the inventory has the container in.
+func (f *YardAuditFinding) SetRecorded(blockID, slot, row, tier int) {
+	f.RecordedBlockID, f.RecordedSlot, f.RecordedRow, f.RecordedTier = &blockID, &slot, &row, &tier
+}
+
+// SetFound stores the cell the container was scanned in.
+func (f *YardAuditFinding) SetFound(blockID, slot, row, tier int) {
+	f.FoundBlockID, f.FoundSlot, f.FoundRow, f.FoundTier = &blockID, &slot, &row, &tier
+}
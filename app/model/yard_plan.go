@@ -4,6 +4,14 @@ import (
 	"time"
 )
 
+const (
+	YardPlanStatusDraft     = "DRAFT"
+	YardPlanStatusPublished = "PUBLISHED"
+	// YardPlanStatusRetired is a plan that no longer applies. Plans switched
+	// off by hand before plans had versions are migrated to it.
+	YardPlanStatusRetired = "RETIRED"
+)
+
 type YardPlan struct {
 	ID       int    `gorm:"primaryKey" json:"id"`
 	BlockID  int    `gorm:"not null" json:"block_id"`
@@ -19,10 +27,38 @@ type YardPlan struct {
 	ContainerType   string `gorm:"type:varchar(50);not null" json:"container_type"`
 
 	PriorityStackingDirection string `gorm:"type:varchar(50)" json:"priority_stacking_direction"`
-	IsActive                  bool   `gorm:"not null;default:true" json:"is_active"`
+
+	// IsActive is maintained by the plan scheduler: a published plan is active
+	// while the current time is inside [ValidFrom, ValidTo).
+	IsActive bool `gorm:"not null;default:true" json:"is_active"`
+
+	Version           int        `gorm:"not null;default:1" json:"version"`
+	PreviousVersionID *int       `gorm:"null" json:"previous_version_id,omitempty"`
+	Status            string     `gorm:"type:varchar(20);not null;default:PUBLISHED" json:"status"` // 'DRAFT', 'PUBLISHED', 'RETIRED'
+	ValidFrom         *time.Time `gorm:"type:timestamp with time zone" json:"valid_from,omitempty"`
+	ValidTo           *time.Time `gorm:"type:timestamp with time zone" json:"valid_to,omitempty"`
+	PublishedAt       *time.Time `gorm:"type:timestamp with time zone" json:"published_at,omitempty"`
+
+	// Revision is bumped by every update, it is the ETag of the plan and
+	// an update sent with an older If-Match is rejected.
+	Revision int `gorm:"not null;default:1" json:"revision"`
 
 	Block Block `gorm:"foreignKey:BlockID;references:ID" json:"block,omitempty"`
 
 	CreatedAt time.Time `gorm:"type:timestamp with time zone" json:"created_at"`
 	UpdatedAt time.Time `gorm:"type:timestamp with time zone" json:"updated_at"`
 }
+
+// IsValidAt reports whether a published plan applies at the given time.
+func (p *YardPlan) IsValidAt(at time.Time) bool {
+	if p.Status != YardPlanStatusPublished {
+		return false
+	}
+	if p.ValidFrom != nil && at.Before(*p.ValidFrom) {
+		return false
+	}
+	if p.ValidTo != nil && !at.Before(*p.ValidTo) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository is append-only, the audit log has no update or delete.
+type AuditLogRepository interface {
+	// Save inserts the log and its changes.
+	Save(db *gorm.DB, log *model.AuditLog) error
+	// Find lists logs newest first. Empty filters match every log, an entity
+	// filter matches logs that changed such an entity.
+	Find(db *gorm.DB, logs *[]model.AuditLog, entityType, entityID, actor string, from, to *time.Time, limit int) error
+	FindChanges(db *gorm.DB, changes *[]model.AuditLogChange, logIDs []int) error
+}
+
+type AuditLogRepositoryImpl struct {
+}
+
+func NewAuditLogRepository() AuditLogRepository {
+	return &AuditLogRepositoryImpl{}
+}
+
+func (r *AuditLogRepositoryImpl) Save(db *gorm.DB, log *model.AuditLog) error {
+	query := `INSERT INTO audit_logs (
+		actor, method, endpoint, path, request_payload, client_ip, status_code, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		log.Actor, log.Method, log.Endpoint, log.Path, log.RequestPayload, log.ClientIP, log.StatusCode, log.CreatedAt,
+	).Scan(&log.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if log.ID == 0 {
+		return errors.New("failed to insert audit log")
+	}
+
+	for i := range log.Changes {
+		change := &log.Changes[i]
+		change.AuditLogID = log.ID
+
+		query := `INSERT INTO audit_log_changes (
+			audit_log_id, entity_type, entity_id, before_state, after_state
+		) VALUES (?, ?, ?, ?, ?)
+		RETURNING id`
+
+		result := db.Raw(query,
+			change.AuditLogID, change.EntityType, change.EntityID, change.Before, change.After,
+		).Scan(&change.ID)
+
+		if result.Error != nil {
+			return result.Error
+		}
+		if change.ID == 0 {
+			return errors.New("failed to insert audit log change")
+		}
+	}
+	return nil
+}
+
+func (r *AuditLogRepositoryImpl) Find(db *gorm.DB, logs *[]model.AuditLog, entityType, entityID, actor string, from, to *time.Time, limit int) error {
+	query := `
+		SELECT * FROM audit_logs AS al
+		WHERE (? = '' OR al.actor = ?)
+		  AND (CAST(? AS TIMESTAMPTZ) IS NULL OR al.created_at >= ?)
+		  AND (CAST(? AS TIMESTAMPTZ) IS NULL OR al.created_at < ?)
+		  AND (? = '' OR EXISTS (
+			SELECT 1 FROM audit_log_changes AS alc
+			WHERE alc.audit_log_id = al.id
+			  AND alc.entity_type = ?
+			  AND (? = '' OR alc.entity_id = ?)
+		  ))
+		ORDER BY al.created_at DESC, al.id DESC
+		LIMIT ?`
+
+	err := db.Raw(query,
+		actor, actor, from, from, to, to, entityType, entityType, entityID, entityID, limit,
+	).Scan(logs).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *AuditLogRepositoryImpl) FindChanges(db *gorm.DB, changes *[]model.AuditLogChange, logIDs []int) error {
+	if len(logIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT id, audit_log_id, entity_type, entity_id, before_state AS before, after_state AS after
+		FROM audit_log_changes
+		WHERE audit_log_id IN (?)
+		ORDER BY audit_log_id ASC, id ASC`
+
+	err := db.Raw(query, logIDs).Scan(changes).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
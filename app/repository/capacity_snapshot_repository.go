@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type CapacitySnapshotRepository interface {
+	Save(db *gorm.DB, snapshot *model.CapacitySnapshot) error
+	DeleteByDate(db *gorm.DB, snapshotDate time.Time) error
+
+	FindHistory(db *gorm.DB, snapshots *[]model.CapacitySnapshot, level string, yardID, blockID, yardPlanID int, from, to time.Time) error
+}
+
+type CapacitySnapshotRepositoryImpl struct {
+}
+
+func NewCapacitySnapshotRepository() CapacitySnapshotRepository {
+	return &CapacitySnapshotRepositoryImpl{}
+}
+
+func (r *CapacitySnapshotRepositoryImpl) Save(db *gorm.DB, snapshot *model.CapacitySnapshot) error {
+	query := `INSERT INTO capacity_snapshots (
+		snapshot_date, level, yard_id, block_id, yard_plan_id,
+		total_teu, occupied_teu, reserved_teu, free_teu, overflow_teu, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result := db.Exec(query,
+		snapshot.SnapshotDate, snapshot.Level, snapshot.YardID, snapshot.BlockID, snapshot.YardPlanID,
+		snapshot.TotalTEU, snapshot.OccupiedTEU, snapshot.ReservedTEU, snapshot.FreeTEU, snapshot.OverflowTEU, snapshot.CreatedAt,
+	)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert capacity snapshot")
+	}
+	return result.Error
+}
+
+func (r *CapacitySnapshotRepositoryImpl) DeleteByDate(db *gorm.DB, snapshotDate time.Time) error {
+	return db.Exec("DELETE FROM capacity_snapshots WHERE snapshot_date = ?", snapshotDate).Error
+}
+
+func (r *CapacitySnapshotRepositoryImpl) FindHistory(db *gorm.DB, snapshots *[]model.CapacitySnapshot, level string, yardID, blockID, yardPlanID int, from, to time.Time) error {
+	query := `
+		SELECT * FROM capacity_snapshots
+		WHERE level = ? AND yard_id = ?
+		  AND (? = 0 OR block_id = ?)
+		  AND (? = 0 OR yard_plan_id = ?)
+		  AND snapshot_date BETWEEN ? AND ?
+		ORDER BY snapshot_date ASC, id ASC`
+
+	err := db.Raw(query, level, yardID, blockID, blockID, yardPlanID, yardPlanID, from, to).Scan(snapshots).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
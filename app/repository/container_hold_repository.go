@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type ContainerHoldRepository interface {
+	Save(db *gorm.DB, hold *model.ContainerHold) error
+	FindByID(db *gorm.DB, holdResult *model.ContainerHold, holdID int) error
+	FindByContainerNumber(db *gorm.DB, holds *[]model.ContainerHold, containerNumber string, activeOnly bool) error
+	Release(db *gorm.DB, hold *model.ContainerHold) error
+}
+
+type ContainerHoldRepositoryImpl struct {
+}
+
+func NewContainerHoldRepository() ContainerHoldRepository {
+	return &ContainerHoldRepositoryImpl{}
+}
+
+func (r *ContainerHoldRepositoryImpl) Save(db *gorm.DB, hold *model.ContainerHold) error {
+	query := `INSERT INTO container_holds (
+		container_number, hold_type, reason, created_at, released_at
+	) VALUES (?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		hold.ContainerNumber, hold.HoldType, hold.Reason, hold.CreatedAt, hold.ReleasedAt,
+	).Scan(&hold.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if hold.ID == 0 {
+		return errors.New("failed to insert container hold")
+	}
+	return nil
+}
+
+func (r *ContainerHoldRepositoryImpl) FindByID(db *gorm.DB, holdResult *model.ContainerHold, holdID int) error {
+	err := db.Raw("SELECT * FROM container_holds WHERE id = ?", holdID).Scan(holdResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || holdResult.ID == 0 {
+		return errors.New("container hold not found")
+	}
+	return err
+}
+
+func (r *ContainerHoldRepositoryImpl) FindByContainerNumber(db *gorm.DB, holds *[]model.ContainerHold, containerNumber string, activeOnly bool) error {
+	query := `
+		SELECT * FROM container_holds
+		WHERE container_number = ?
+		  AND (? = FALSE OR released_at IS NULL)
+		ORDER BY created_at ASC, id ASC`
+
+	err := db.Raw(query, containerNumber, activeOnly).Scan(holds).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerHoldRepositoryImpl) Release(db *gorm.DB, hold *model.ContainerHold) error {
+	result := db.Exec("UPDATE container_holds SET released_at = ? WHERE id = ? AND released_at IS NULL", hold.ReleasedAt, hold.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("container hold not found or already released")
+	}
+	return nil
+}
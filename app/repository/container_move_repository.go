@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type ContainerMoveRepository interface {
+	Save(db *gorm.DB, move *model.ContainerMove) error
+	FindByContainerNumber(db *gorm.DB, moves *[]model.ContainerMove, containerNumber string) error
+	// FindVisitMoves returns the placements, pickups and moves recorded up to
+	// until, filtered by container number and shipping line when they are not
+	// empty.
+	FindVisitMoves(db *gorm.DB, moves *[]model.ContainerMove, containerNumber, shippingLine string, until time.Time) error
+	// FindGateEvents returns the placements and pickups of the shipping line
+	// recorded in [from, to), each with the gate transaction completed closest
+	// to it within an hour. Placements of an inventory import are left out.
+	FindGateEvents(db *gorm.DB, events *[]model.ContainerGateEvent, shippingLine string, from, to time.Time) error
+	// FindVesselMoves returns the placements and pickups of the shipping line
+	// tagged with the vessel call, without the placements of an inventory
+	// import.
+	FindVesselMoves(db *gorm.DB, moves *[]model.ContainerMove, shippingLine, vessel, voyage string) error
+}
+
+type ContainerMoveRepositoryImpl struct {
+}
+
+func NewContainerMoveRepository() ContainerMoveRepository {
+	return &ContainerMoveRepositoryImpl{}
+}
+
+func (r *ContainerMoveRepositoryImpl) Save(db *gorm.DB, move *model.ContainerMove) error {
+	query := `INSERT INTO container_moves (
+		container_number, move_type,
+		from_block_id, from_slot, from_row, from_tier,
+		to_block_id, to_slot, to_row, to_tier,
+		container_size, container_height, container_type,
+		vessel, voyage, shipping_line, reason, arrived_at, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		move.ContainerNumber, move.MoveType,
+		move.FromBlockID, move.FromSlot, move.FromRow, move.FromTier,
+		move.ToBlockID, move.ToSlot, move.ToRow, move.ToTier,
+		move.ContainerSize, move.ContainerHeight, move.ContainerType,
+		move.Vessel, move.Voyage, move.ShippingLine, move.Reason, move.ArrivedAt, move.CreatedAt,
+	).Scan(&move.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if move.ID == 0 {
+		return errors.New("failed to insert container move")
+	}
+	return nil
+}
+
+func (r *ContainerMoveRepositoryImpl) FindByContainerNumber(db *gorm.DB, moves *[]model.ContainerMove, containerNumber string) error {
+	query := `
+		SELECT * FROM container_moves
+		WHERE container_number = ?
+		ORDER BY created_at ASC, id ASC`
+
+	err := db.Raw(query, containerNumber).Scan(moves).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerMoveRepositoryImpl) FindVisitMoves(db *gorm.DB, moves *[]model.ContainerMove, containerNumber, shippingLine string, until time.Time) error {
+	query := `
+		SELECT * FROM container_moves
+		WHERE move_type IN (?, ?, ?)
+		  AND COALESCE(arrived_at, created_at) <= ?
+		  AND (? = '' OR container_number = ?)
+		  AND (? = '' OR shipping_line = ?)
+		ORDER BY container_number ASC, created_at ASC, id ASC`
+
+	err := db.Raw(query,
+		model.MoveTypePlacement, model.MoveTypePickup, model.MoveTypeMove, until,
+		containerNumber, containerNumber, shippingLine, shippingLine,
+	).Scan(moves).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerMoveRepositoryImpl) FindGateEvents(db *gorm.DB, events *[]model.ContainerGateEvent, shippingLine string, from, to time.Time) error {
+	query := `
+		SELECT cm.*, COALESCE(y.name, '') AS yard_name,
+			COALESCE(gt.truck_plate, '') AS truck_plate,
+			COALESCE(gt.seal_numbers, '') AS seal_numbers,
+			COALESCE(gt.booking_reference, '') AS booking_reference
+		FROM container_moves AS cm
+		LEFT JOIN blocks AS b ON b.id = COALESCE(cm.to_block_id, cm.from_block_id)
+		LEFT JOIN yards AS y ON y.id = b.yard_id
+		LEFT JOIN LATERAL (
+			SELECT g.truck_plate, g.seal_numbers, g.booking_reference
+			FROM gate_transactions AS g
+			WHERE g.container_number = cm.container_number
+			  AND g.direction = CASE WHEN cm.move_type = ? THEN ? ELSE ? END
+			  AND g.status = ?
+			  AND g.completed_at BETWEEN cm.created_at - INTERVAL '1 hour' AND cm.created_at + INTERVAL '1 hour'
+			ORDER BY ABS(EXTRACT(EPOCH FROM (g.completed_at - cm.created_at))) ASC
+			LIMIT 1
+		) AS gt ON TRUE
+		WHERE cm.move_type IN (?, ?)
+		  AND COALESCE(cm.reason, '') <> ?
+		  AND cm.shipping_line = ?
+		  AND cm.created_at >= ? AND cm.created_at < ?
+		ORDER BY cm.created_at ASC, cm.id ASC`
+
+	err := db.Raw(query,
+		model.MoveTypePlacement, model.GateDirectionIn, model.GateDirectionOut, model.GateStatusCompleted,
+		model.MoveTypePlacement, model.MoveTypePickup, model.MoveReasonImport, shippingLine, from, to,
+	).Scan(events).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerMoveRepositoryImpl) FindVesselMoves(db *gorm.DB, moves *[]model.ContainerMove, shippingLine, vessel, voyage string) error {
+	query := `
+		SELECT * FROM container_moves
+		WHERE move_type IN (?, ?)
+		  AND COALESCE(reason, '') <> ?
+		  AND shipping_line = ? AND vessel = ? AND voyage = ?
+		ORDER BY created_at ASC, id ASC`
+
+	err := db.Raw(query, model.MoveTypePlacement, model.MoveTypePickup, model.MoveReasonImport, shippingLine, vessel, voyage).Scan(moves).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
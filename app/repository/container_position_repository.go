@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"strings"
+	"time"
 	"yard-planning/app/model"
 
 	"gorm.io/gorm"
@@ -10,12 +12,73 @@ import (
 type ContainerPositionRepository interface {
 	Save(db *gorm.DB, position *model.ContainerPosition) error
 	FindByContainerNumber(db *gorm.DB, positionResult *model.ContainerPosition, containerNumber string) error
+	FindDetailByContainerNumber(db *gorm.DB, detail *model.ContainerPositionDetail, containerNumber string) error
+	FindByBlockID(db *gorm.DB, positions *[]model.ContainerPosition, blockID int) error
+	FindByYardID(db *gorm.DB, positions *[]model.ContainerPosition, yardID int) error
+	FindByYardPlanID(db *gorm.DB, positions *[]model.ContainerPosition, yardPlanID int) error
+	FindByShippingLine(db *gorm.DB, positions *[]model.ContainerPosition, shippingLine string) error
+	FindDetailsArrivedBefore(db *gorm.DB, details *[]model.ContainerPositionDetail, before time.Time) error
+	// UpdatePosition and UpdateStatus only write a position still at the
+	// revision it was read with and store the new revision in it, a stale
+	// position returns ErrStaleRevision.
+	UpdatePosition(db *gorm.DB, position *model.ContainerPosition) error
+	UpdateStatus(db *gorm.DB, position *model.ContainerPosition) error
 	Delete(db *gorm.DB, containerID int) error
 
+	// CheckPositionAvailability counts the containers covering any of the
+	// slots at the row and tier, IsStackedAbove reports whether one covers
+	// any of them higher up. A 40ft container covers its slot and the next.
 	CheckPositionAvailability(db *gorm.DB, blockID, row, tier int, slotNumbers []int) (int64, error)
-	IsStackedAbove(db *gorm.DB, blockID, slot, row, tier int) (bool, error)
+	IsStackedAbove(db *gorm.DB, blockID, row, tier int, slotNumbers []int) (bool, error)
+
+	Search(db *gorm.DB, filter *ContainerSearchFilter, results *[]model.ContainerPositionDetail) error
+	Count(db *gorm.DB, filter *ContainerSearchFilter, total *int64) error
+	// EachDetail calls fn for every container matching the filter, ordered by
+	// yard, block and cell, without loading them all at once. Sort, cursor
+	// and limit of the filter are ignored.
+	EachDetail(db *gorm.DB, filter *ContainerSearchFilter, fn func(detail *model.ContainerPositionDetail) error) error
+	FindAllContainerNumbers(db *gorm.DB, numbers *[]string) error
+}
+
+// ContainerSearchFilter holds the criteria of an inventory search. Zero values
+// are ignored. AfterValue/AfterID is the keyset cursor: only rows sorting
+// after that (sort value, id) pair are returned.
+type ContainerSearchFilter struct {
+	YardName   string
+	BlockName  string
+	Size       string
+	Height     string
+	Type       string
+	Status     string
+	YardPlanID int
+
+	ArrivalFrom *time.Time
+	ArrivalTo   *time.Time
+	// ArrivalAfter excludes its own time, unlike ArrivalFrom
+	ArrivalAfter *time.Time
+
+	SortColumn string // 'arrival_date', 'container_number'
+	Descending bool
+	AfterValue any
+	AfterID    int
+	Limit      int
+}
+
+var containerSortColumns = map[string]string{
+	"arrival_date":     "cp.arrival_date",
+	"container_number": "cp.container_number",
 }
 
+// ErrStaleRevision is returned by a revision checked update when the row was
+// changed since it was read.
+var ErrStaleRevision = errors.New("record was changed by another request")
+
+const containerDetailSelect = `
+	SELECT cp.*, b.name AS block_name, y.id AS yard_id, y.name AS yard_name
+	FROM container_positions AS cp
+	JOIN blocks AS b ON b.id = cp.block_id
+	JOIN yards AS y ON y.id = b.yard_id`
+
 type ContainerPositionRepositoryImpl struct {
 }
 
@@ -27,13 +90,15 @@ func (r *ContainerPositionRepositoryImpl) Save(db *gorm.DB, position *model.Cont
 	query := `INSERT INTO container_positions (
 		container_number, block_id, slot_number, row_number, tier_number, 
 		container_size, container_height, container_type, container_status, 
-		arrival_date, yard_plan_id, created_at, updated_at
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		arrival_date, yard_plan_id, vessel, voyage, shipping_line, revision, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?)`
 
+	position.Revision = 1
 	result := db.Exec(query,
 		position.ContainerNumber, position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber,
 		position.ContainerSize, position.ContainerHeight, position.ContainerType, position.ContainerStatus,
-		position.ArrivalDate, position.YardPlanID, position.CreatedAt, position.UpdatedAt,
+		position.ArrivalDate, position.YardPlanID, position.Vessel, position.Voyage, position.ShippingLine,
+		position.CreatedAt, position.UpdatedAt,
 	)
 
 	if result.RowsAffected == 0 {
@@ -51,6 +116,124 @@ func (r *ContainerPositionRepositoryImpl) FindByContainerNumber(db *gorm.DB, pos
 	return err
 }
 
+func (r *ContainerPositionRepositoryImpl) FindDetailByContainerNumber(db *gorm.DB, detail *model.ContainerPositionDetail, containerNumber string) error {
+	err := db.Raw(containerDetailSelect+" WHERE cp.container_number = ?", containerNumber).Scan(detail).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || detail.ID == 0 {
+		return errors.New("container not found at any position")
+	}
+	return err
+}
+
+func (r *ContainerPositionRepositoryImpl) FindByBlockID(db *gorm.DB, positions *[]model.ContainerPosition, blockID int) error {
+	err := db.Raw("SELECT * FROM container_positions WHERE block_id = ?", blockID).Scan(positions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) FindByYardID(db *gorm.DB, positions *[]model.ContainerPosition, yardID int) error {
+	query := `
+		SELECT cp.* FROM container_positions AS cp
+		JOIN blocks AS b ON b.id = cp.block_id
+		WHERE b.yard_id = ?
+		ORDER BY cp.block_id, cp.slot_number, cp.row_number, cp.tier_number`
+
+	err := db.Raw(query, yardID).Scan(positions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) FindByYardPlanID(db *gorm.DB, positions *[]model.ContainerPosition, yardPlanID int) error {
+	query := `
+		SELECT * FROM container_positions
+		WHERE yard_plan_id = ?
+		ORDER BY slot_number, row_number, tier_number`
+
+	err := db.Raw(query, yardPlanID).Scan(positions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) FindByShippingLine(db *gorm.DB, positions *[]model.ContainerPosition, shippingLine string) error {
+	query := `
+		SELECT * FROM container_positions
+		WHERE shipping_line = ?
+		ORDER BY container_number ASC`
+
+	err := db.Raw(query, shippingLine).Scan(positions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) FindDetailsArrivedBefore(db *gorm.DB, details *[]model.ContainerPositionDetail, before time.Time) error {
+	query := containerDetailSelect + `
+		WHERE cp.arrival_date < ?
+		ORDER BY cp.arrival_date ASC, cp.id ASC`
+
+	err := db.Raw(query, before).Scan(details).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) UpdatePosition(db *gorm.DB, position *model.ContainerPosition) error {
+	query := `
+		UPDATE container_positions
+		SET block_id = ?, slot_number = ?, row_number = ?, tier_number = ?, yard_plan_id = ?, updated_at = ?,
+			revision = revision + 1
+		WHERE id = ? AND revision = ?
+		RETURNING revision`
+
+	var revision int
+	result := db.Raw(query,
+		position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber,
+		position.YardPlanID, position.UpdatedAt, position.ID, position.Revision,
+	).Scan(&revision)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if revision == 0 {
+		return ErrStaleRevision
+	}
+	position.Revision = revision
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) UpdateStatus(db *gorm.DB, position *model.ContainerPosition) error {
+	query := `
+		UPDATE container_positions
+		SET container_status = ?, updated_at = ?, revision = revision + 1
+		WHERE id = ? AND revision = ?
+		RETURNING revision`
+
+	var revision int
+	result := db.Raw(query, position.ContainerStatus, position.UpdatedAt, position.ID, position.Revision).Scan(&revision)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if revision == 0 {
+		return ErrStaleRevision
+	}
+	position.Revision = revision
+	return nil
+}
+
 func (r *ContainerPositionRepositoryImpl) Delete(db *gorm.DB, containerID int) error {
 	result := db.Exec("DELETE FROM container_positions WHERE id = ?", containerID)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
@@ -64,9 +247,10 @@ func (r *ContainerPositionRepositoryImpl) CheckPositionAvailability(db *gorm.DB,
 
 	query := `
 		SELECT COUNT(id) FROM container_positions
-		WHERE block_id = ? AND row_number = ? AND tier_number = ? AND slot_number IN (?)`
+		WHERE block_id = ? AND row_number = ? AND tier_number = ?
+		  AND (slot_number IN (?) OR (container_size = '40ft' AND slot_number + 1 IN (?)))`
 
-	result := db.Raw(query, blockID, row, tier, slotNumbers).Scan(&count)
+	result := db.Raw(query, blockID, row, tier, slotNumbers, slotNumbers).Scan(&count)
 
 	if result.Error != nil {
 		return 0, result.Error
@@ -75,17 +259,17 @@ func (r *ContainerPositionRepositoryImpl) CheckPositionAvailability(db *gorm.DB,
 	return count, nil
 }
 
-func (r *ContainerPositionRepositoryImpl) IsStackedAbove(db *gorm.DB, blockID, slot, row, tier int) (bool, error) {
+func (r *ContainerPositionRepositoryImpl) IsStackedAbove(db *gorm.DB, blockID, row, tier int, slotNumbers []int) (bool, error) {
 	var count int64
 
 	query := `
 		SELECT COUNT(id) FROM container_positions
-		WHERE block_id = ? 
-		  AND slot_number = ? 
-		  AND row_number = ? 
-		  AND tier_number > ?`
+		WHERE block_id = ?
+		  AND row_number = ?
+		  AND tier_number > ?
+		  AND (slot_number IN (?) OR (container_size = '40ft' AND slot_number + 1 IN (?)))`
 
-	result := db.Raw(query, blockID, slot, row, tier).Scan(&count)
+	result := db.Raw(query, blockID, row, tier, slotNumbers, slotNumbers).Scan(&count)
 
 	if result.Error != nil {
 		return false, result.Error
@@ -93,3 +277,130 @@ func (r *ContainerPositionRepositoryImpl) IsStackedAbove(db *gorm.DB, blockID, s
 
 	return count > 0, nil
 }
+
+func (r *ContainerPositionRepositoryImpl) Search(db *gorm.DB, filter *ContainerSearchFilter, results *[]model.ContainerPositionDetail) error {
+	where, args := containerSearchConditions(filter)
+
+	column, ok := containerSortColumns[filter.SortColumn]
+	if !ok {
+		column = containerSortColumns["arrival_date"]
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.AfterValue != nil {
+		where = append(where, "("+column+", cp.id) "+comparison+" (?, ?)")
+		args = append(args, filter.AfterValue, filter.AfterID)
+	}
+
+	query := containerDetailSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + column + " " + direction + ", cp.id " + direction + " LIMIT ?"
+	args = append(args, filter.Limit)
+
+	err := db.Raw(query, args...).Scan(results).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *ContainerPositionRepositoryImpl) Count(db *gorm.DB, filter *ContainerSearchFilter, total *int64) error {
+	where, args := containerSearchConditions(filter)
+
+	query := `
+		SELECT COUNT(cp.id)
+		FROM container_positions AS cp
+		JOIN blocks AS b ON b.id = cp.block_id
+		JOIN yards AS y ON y.id = b.yard_id`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	return db.Raw(query, args...).Scan(total).Error
+}
+
+func (r *ContainerPositionRepositoryImpl) EachDetail(db *gorm.DB, filter *ContainerSearchFilter, fn func(detail *model.ContainerPositionDetail) error) error {
+	where, args := containerSearchConditions(filter)
+
+	query := containerDetailSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY y.name, b.name, cp.slot_number, cp.row_number, cp.tier_number"
+
+	rows, err := db.Raw(query, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail model.ContainerPositionDetail
+		if err := db.ScanRows(rows, &detail); err != nil {
+			return err
+		}
+		if err := fn(&detail); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *ContainerPositionRepositoryImpl) FindAllContainerNumbers(db *gorm.DB, numbers *[]string) error {
+	err := db.Raw("SELECT container_number FROM container_positions").Scan(numbers).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func containerSearchConditions(filter *ContainerSearchFilter) ([]string, []any) {
+	var where []string
+	var args []any
+
+	add := func(condition string, value any) {
+		where = append(where, condition)
+		args = append(args, value)
+	}
+
+	if filter.YardName != "" {
+		add("y.name = ?", filter.YardName)
+	}
+	if filter.BlockName != "" {
+		add("b.name = ?", filter.BlockName)
+	}
+	if filter.Size != "" {
+		add("cp.container_size = ?", filter.Size)
+	}
+	if filter.Height != "" {
+		add("cp.container_height = ?", filter.Height)
+	}
+	if filter.Type != "" {
+		add("cp.container_type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		add("cp.container_status = ?", filter.Status)
+	}
+	if filter.YardPlanID != 0 {
+		add("cp.yard_plan_id = ?", filter.YardPlanID)
+	}
+	if filter.ArrivalFrom != nil {
+		add("cp.arrival_date >= ?", *filter.ArrivalFrom)
+	}
+	if filter.ArrivalTo != nil {
+		add("cp.arrival_date <= ?", *filter.ArrivalTo)
+	}
+	if filter.ArrivalAfter != nil {
+		add("cp.arrival_date > ?", *filter.ArrivalAfter)
+	}
+
+	return where, args
+}
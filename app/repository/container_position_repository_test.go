@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// errRollback ends a test transaction so nothing it wrote is kept.
+var errRollback = errors.New("rollback")
+
+// withTestPositions runs fn in a transaction against the Postgres database of
+// TEST_DATABASE_DSN, on a temporary container_positions table that shadows
+// the real one. The test is skipped without a database.
+func withTestPositions(t *testing.T, fn func(tx *gorm.DB)) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`CREATE TEMPORARY TABLE container_positions (
+			id SERIAL PRIMARY KEY,
+			container_number VARCHAR(20) NOT NULL,
+			block_id INTEGER NOT NULL,
+			slot_number INTEGER NOT NULL,
+			row_number INTEGER NOT NULL,
+			tier_number INTEGER NOT NULL,
+			container_size VARCHAR(5) NOT NULL
+		) ON COMMIT DROP`).Error
+		if err != nil {
+			return err
+		}
+
+		// Row 1: a 40ft at slots 1-2 tier 1 with a 20ft on its second slot,
+		// and a 20ft at slot 3 tier 1. Row 2: a 20ft at slot 2 tier 1 under
+		// a 40ft at slots 1-2 tier 2.
+		err = tx.Exec(`INSERT INTO container_positions (container_number, block_id, slot_number, row_number, tier_number, container_size) VALUES
+			('FORT0000001', 1, 1, 1, 1, '40ft'),
+			('TWEN0000002', 1, 2, 1, 2, '20ft'),
+			('TWEN0000003', 1, 3, 1, 1, '20ft'),
+			('TWEN0000004', 1, 2, 2, 1, '20ft'),
+			('FORT0000005', 1, 1, 2, 2, '40ft')`).Error
+		if err != nil {
+			return err
+		}
+
+		fn(tx)
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckPositionAvailability40ft(t *testing.T) {
+	withTestPositions(t, func(tx *gorm.DB) {
+		r := NewContainerPositionRepository()
+
+		tests := []struct {
+			name  string
+			row   int
+			tier  int
+			slots []int
+			want  int64
+		}{
+			{"first slot of the 40ft", 1, 1, []int{1}, 1},
+			{"second slot of the 40ft", 1, 1, []int{2}, 1},
+			{"both slots of the 40ft counted once", 1, 1, []int{1, 2}, 1},
+			{"40ft target over both boxes", 1, 1, []int{2, 3}, 2},
+			{"slot after the 40ft", 1, 1, []int{4}, 0},
+			{"other row", 3, 1, []int{1, 2}, 0},
+			{"second slot under a 40ft one tier up", 2, 2, []int{2}, 1},
+			{"tier above the 40ft", 1, 2, []int{1}, 0},
+		}
+
+		for _, tt := range tests {
+			count, err := r.CheckPositionAvailability(tx, 1, tt.row, tt.tier, tt.slots)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.want {
+				t.Errorf("%s: count = %d, want %d", tt.name, count, tt.want)
+			}
+		}
+	})
+}
+
+func TestIsStackedAbove40ft(t *testing.T) {
+	withTestPositions(t, func(tx *gorm.DB) {
+		r := NewContainerPositionRepository()
+
+		tests := []struct {
+			name  string
+			row   int
+			tier  int
+			slots []int
+			want  bool
+		}{
+			{"40ft with a 20ft on its second slot", 1, 1, []int{1, 2}, true},
+			{"20ft with nothing above", 1, 1, []int{3}, false},
+			{"top box", 1, 2, []int{2}, false},
+			{"20ft under a 40ft starting a slot before", 2, 1, []int{2}, true},
+			{"top 40ft", 2, 2, []int{1, 2}, false},
+		}
+
+		for _, tt := range tests {
+			stacked, err := r.IsStackedAbove(tx, 1, tt.row, tt.tier, tt.slots)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stacked != tt.want {
+				t.Errorf("%s: stacked = %v, want %v", tt.name, stacked, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type DwellAlertRepository interface {
+	// Save inserts the alert unless one already exists for the same container
+	// visit, reporting whether a new row was written.
+	Save(db *gorm.DB, alert *model.DwellAlert) (bool, error)
+	FindSince(db *gorm.DB, alerts *[]model.DwellAlert, since time.Time) error
+}
+
+type DwellAlertRepositoryImpl struct {
+}
+
+func NewDwellAlertRepository() DwellAlertRepository {
+	return &DwellAlertRepositoryImpl{}
+}
+
+func (r *DwellAlertRepositoryImpl) Save(db *gorm.DB, alert *model.DwellAlert) (bool, error) {
+	query := `INSERT INTO dwell_alerts (
+		container_number, arrival_date, yard_id, dwell_days, free_days, action, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (container_number, arrival_date) DO NOTHING
+	RETURNING id`
+
+	result := db.Raw(query,
+		alert.ContainerNumber, alert.ArrivalDate, alert.YardID, alert.DwellDays, alert.FreeDays,
+		alert.Action, alert.CreatedAt,
+	).Scan(&alert.ID)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return alert.ID != 0, nil
+}
+
+func (r *DwellAlertRepositoryImpl) FindSince(db *gorm.DB, alerts *[]model.DwellAlert, since time.Time) error {
+	query := `
+		SELECT * FROM dwell_alerts
+		WHERE created_at >= ?
+		ORDER BY created_at DESC, id DESC`
+
+	err := db.Raw(query, since).Scan(alerts).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
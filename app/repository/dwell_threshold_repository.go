@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type DwellThresholdRepository interface {
+	Save(db *gorm.DB, threshold *model.DwellThreshold) error
+	FindByID(db *gorm.DB, thresholdResult *model.DwellThreshold, thresholdID int) error
+	FindAll(db *gorm.DB, thresholds *[]model.DwellThreshold) error
+	Update(db *gorm.DB, threshold *model.DwellThreshold) error
+	Delete(db *gorm.DB, thresholdID int) error
+}
+
+type DwellThresholdRepositoryImpl struct {
+}
+
+func NewDwellThresholdRepository() DwellThresholdRepository {
+	return &DwellThresholdRepositoryImpl{}
+}
+
+func (r *DwellThresholdRepositoryImpl) Save(db *gorm.DB, threshold *model.DwellThreshold) error {
+	query := `INSERT INTO dwell_thresholds (
+		yard_id, container_type, free_days, action, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		threshold.YardID, threshold.ContainerType, threshold.FreeDays, threshold.Action,
+		threshold.CreatedAt, threshold.UpdatedAt,
+	).Scan(&threshold.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if threshold.ID == 0 {
+		return errors.New("failed to insert dwell threshold")
+	}
+	return nil
+}
+
+func (r *DwellThresholdRepositoryImpl) FindByID(db *gorm.DB, thresholdResult *model.DwellThreshold, thresholdID int) error {
+	err := db.Raw("SELECT * FROM dwell_thresholds WHERE id = ?", thresholdID).Scan(thresholdResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || thresholdResult.ID == 0 {
+		return errors.New("dwell threshold not found")
+	}
+	return err
+}
+
+func (r *DwellThresholdRepositoryImpl) FindAll(db *gorm.DB, thresholds *[]model.DwellThreshold) error {
+	err := db.Raw("SELECT * FROM dwell_thresholds ORDER BY id ASC").Scan(thresholds).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *DwellThresholdRepositoryImpl) Update(db *gorm.DB, threshold *model.DwellThreshold) error {
+	query := `
+		UPDATE dwell_thresholds
+		SET yard_id = ?, container_type = ?, free_days = ?, action = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		threshold.YardID, threshold.ContainerType, threshold.FreeDays, threshold.Action,
+		threshold.UpdatedAt, threshold.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("dwell threshold not found")
+	}
+	return nil
+}
+
+func (r *DwellThresholdRepositoryImpl) Delete(db *gorm.DB, thresholdID int) error {
+	result := db.Exec("DELETE FROM dwell_thresholds WHERE id = ?", thresholdID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("dwell threshold not found or already deleted")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type EDIPartnerRepository interface {
+	Save(db *gorm.DB, partner *model.EDIPartner) error
+	FindByID(db *gorm.DB, partnerResult *model.EDIPartner, partnerID int) error
+	FindByShippingLine(db *gorm.DB, partnerResult *model.EDIPartner, shippingLine string) error
+	FindAll(db *gorm.DB, partners *[]model.EDIPartner) error
+	Update(db *gorm.DB, partner *model.EDIPartner) error
+	Delete(db *gorm.DB, partnerID int) error
+}
+
+type EDIPartnerRepositoryImpl struct {
+}
+
+func NewEDIPartnerRepository() EDIPartnerRepository {
+	return &EDIPartnerRepositoryImpl{}
+}
+
+func (r *EDIPartnerRepositoryImpl) Save(db *gorm.DB, partner *model.EDIPartner) error {
+	query := `INSERT INTO edi_partners (
+		shipping_line, sender_id, recipient_id, timezone, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		partner.ShippingLine, partner.SenderID, partner.RecipientID, partner.Timezone, partner.CreatedAt, partner.UpdatedAt,
+	).Scan(&partner.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if partner.ID == 0 {
+		return errors.New("failed to insert EDI partner")
+	}
+	return nil
+}
+
+func (r *EDIPartnerRepositoryImpl) FindByID(db *gorm.DB, partnerResult *model.EDIPartner, partnerID int) error {
+	err := db.Raw("SELECT * FROM edi_partners WHERE id = ?", partnerID).Scan(partnerResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || partnerResult.ID == 0 {
+		return errors.New("EDI partner not found")
+	}
+	return err
+}
+
+func (r *EDIPartnerRepositoryImpl) FindByShippingLine(db *gorm.DB, partnerResult *model.EDIPartner, shippingLine string) error {
+	err := db.Raw("SELECT * FROM edi_partners WHERE shipping_line = ?", shippingLine).Scan(partnerResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || partnerResult.ID == 0 {
+		return errors.New("EDI partner not found")
+	}
+	return err
+}
+
+func (r *EDIPartnerRepositoryImpl) FindAll(db *gorm.DB, partners *[]model.EDIPartner) error {
+	err := db.Raw("SELECT * FROM edi_partners ORDER BY shipping_line ASC").Scan(partners).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *EDIPartnerRepositoryImpl) Update(db *gorm.DB, partner *model.EDIPartner) error {
+	query := `
+		UPDATE edi_partners
+		SET shipping_line = ?, sender_id = ?, recipient_id = ?, timezone = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query, partner.ShippingLine, partner.SenderID, partner.RecipientID, partner.Timezone, partner.UpdatedAt, partner.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("EDI partner not found")
+	}
+	return nil
+}
+
+func (r *EDIPartnerRepositoryImpl) Delete(db *gorm.DB, partnerID int) error {
+	result := db.Exec("DELETE FROM edi_partners WHERE id = ?", partnerID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("EDI partner not found or already deleted")
+	}
+	return nil
+}
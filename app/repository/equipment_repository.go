@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type EquipmentRepository interface {
+	// Save inserts the equipment and its block assignments.
+	Save(db *gorm.DB, equipment *model.Equipment) error
+	FindByID(db *gorm.DB, equipmentResult *model.Equipment, equipmentID int) error
+	FindByYardID(db *gorm.DB, equipments *[]model.Equipment, yardID int) error
+	// Update overwrites the equipment and replaces its block assignments.
+	Update(db *gorm.DB, equipment *model.Equipment) error
+	UpdateLocation(db *gorm.DB, equipmentID, blockID, slot int) error
+}
+
+type EquipmentRepositoryImpl struct {
+}
+
+func NewEquipmentRepository() EquipmentRepository {
+	return &EquipmentRepositoryImpl{}
+}
+
+func (r *EquipmentRepositoryImpl) Save(db *gorm.DB, equipment *model.Equipment) error {
+	query := `INSERT INTO equipments (
+		code, equipment_type, yard_id, active, current_block_id, current_slot, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		equipment.Code, equipment.EquipmentType, equipment.YardID, equipment.Active,
+		equipment.CurrentBlockID, equipment.CurrentSlot, equipment.CreatedAt, equipment.UpdatedAt,
+	).Scan(&equipment.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if equipment.ID == 0 {
+		return errors.New("failed to insert equipment")
+	}
+	return r.saveBlocks(db, equipment)
+}
+
+func (r *EquipmentRepositoryImpl) FindByID(db *gorm.DB, equipmentResult *model.Equipment, equipmentID int) error {
+	err := db.Raw("SELECT * FROM equipments WHERE id = ?", equipmentID).Scan(equipmentResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || equipmentResult.ID == 0 {
+		return errors.New("equipment not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT block_id FROM equipment_blocks
+		WHERE equipment_id = ?
+		ORDER BY block_id ASC`
+
+	return db.Raw(query, equipmentID).Scan(&equipmentResult.BlockIDs).Error
+}
+
+func (r *EquipmentRepositoryImpl) FindByYardID(db *gorm.DB, equipments *[]model.Equipment, yardID int) error {
+	err := db.Raw("SELECT * FROM equipments WHERE yard_id = ? ORDER BY code ASC", yardID).Scan(equipments).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return r.findBlocks(db, *equipments)
+}
+
+func (r *EquipmentRepositoryImpl) Update(db *gorm.DB, equipment *model.Equipment) error {
+	query := `
+		UPDATE equipments
+		SET code = ?, equipment_type = ?, active = ?, current_block_id = ?, current_slot = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		equipment.Code, equipment.EquipmentType, equipment.Active,
+		equipment.CurrentBlockID, equipment.CurrentSlot, equipment.UpdatedAt, equipment.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("equipment not found")
+	}
+
+	if err := db.Exec("DELETE FROM equipment_blocks WHERE equipment_id = ?", equipment.ID).Error; err != nil {
+		return err
+	}
+	return r.saveBlocks(db, equipment)
+}
+
+func (r *EquipmentRepositoryImpl) UpdateLocation(db *gorm.DB, equipmentID, blockID, slot int) error {
+	query := `
+		UPDATE equipments
+		SET current_block_id = ?, current_slot = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query, blockID, slot, time.Now(), equipmentID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("equipment not found")
+	}
+	return nil
+}
+
+func (r *EquipmentRepositoryImpl) saveBlocks(db *gorm.DB, equipment *model.Equipment) error {
+	for _, blockID := range equipment.BlockIDs {
+		if err := db.Exec("INSERT INTO equipment_blocks (equipment_id, block_id) VALUES (?, ?)", equipment.ID, blockID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *EquipmentRepositoryImpl) findBlocks(db *gorm.DB, equipments []model.Equipment) error {
+	if len(equipments) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(equipments))
+	index := make(map[int]int, len(equipments))
+	for i, equipment := range equipments {
+		ids = append(ids, equipment.ID)
+		index[equipment.ID] = i
+	}
+
+	var assignments []struct {
+		EquipmentID int
+		BlockID     int
+	}
+
+	query := `
+		SELECT equipment_id, block_id FROM equipment_blocks
+		WHERE equipment_id IN (?)
+		ORDER BY equipment_id ASC, block_id ASC`
+
+	if err := db.Raw(query, ids).Scan(&assignments).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		i := index[assignment.EquipmentID]
+		equipments[i].BlockIDs = append(equipments[i].BlockIDs, assignment.BlockID)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type GateTransactionRepository interface {
+	Save(db *gorm.DB, transaction *model.GateTransaction) error
+	FindByID(db *gorm.DB, transactionResult *model.GateTransaction, transactionID int) error
+	// FindByIDForUpdate is FindByID locking the transaction row until the
+	// transaction db ends.
+	FindByIDForUpdate(db *gorm.DB, transactionResult *model.GateTransaction, transactionID int) error
+	// FindPendingByContainerNumber returns the gate-in of the container that
+	// is still waiting for its placement.
+	FindPendingByContainerNumber(db *gorm.DB, transactionResult *model.GateTransaction, containerNumber string) error
+	FindPendingPlacements(db *gorm.DB, transactions *[]model.GateTransaction) error
+	UpdateStatus(db *gorm.DB, transaction *model.GateTransaction) error
+}
+
+type GateTransactionRepositoryImpl struct {
+}
+
+func NewGateTransactionRepository() GateTransactionRepository {
+	return &GateTransactionRepositoryImpl{}
+}
+
+func (r *GateTransactionRepositoryImpl) Save(db *gorm.DB, transaction *model.GateTransaction) error {
+	query := `INSERT INTO gate_transactions (
+		direction, status, container_number, yard_id,
+		container_size, container_height, container_type, vessel, voyage, shipping_line,
+		truck_plate, driver_name, driver_license, seal_numbers, damage_remarks,
+		booking_reference, release_reference,
+		block_id, slot_number, row_number, tier_number, reject_reason,
+		created_at, completed_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		transaction.Direction, transaction.Status, transaction.ContainerNumber, transaction.YardID,
+		transaction.ContainerSize, transaction.ContainerHeight, transaction.ContainerType,
+		transaction.Vessel, transaction.Voyage, transaction.ShippingLine,
+		transaction.TruckPlate, transaction.DriverName, transaction.DriverLicense,
+		transaction.SealNumbers, transaction.DamageRemarks,
+		transaction.BookingReference, transaction.ReleaseReference,
+		transaction.BlockID, transaction.SlotNumber, transaction.RowNumber, transaction.TierNumber, transaction.RejectReason,
+		transaction.CreatedAt, transaction.CompletedAt, transaction.UpdatedAt,
+	).Scan(&transaction.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if transaction.ID == 0 {
+		return errors.New("failed to insert gate transaction")
+	}
+	return nil
+}
+
+func (r *GateTransactionRepositoryImpl) FindByID(db *gorm.DB, transactionResult *model.GateTransaction, transactionID int) error {
+	err := db.Raw("SELECT * FROM gate_transactions WHERE id = ?", transactionID).Scan(transactionResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || transactionResult.ID == 0 {
+		return errors.New("gate transaction not found")
+	}
+	return err
+}
+
+func (r *GateTransactionRepositoryImpl) FindByIDForUpdate(db *gorm.DB, transactionResult *model.GateTransaction, transactionID int) error {
+	err := db.Raw("SELECT * FROM gate_transactions WHERE id = ? FOR UPDATE", transactionID).Scan(transactionResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || transactionResult.ID == 0 {
+		return errors.New("gate transaction not found")
+	}
+	return err
+}
+
+func (r *GateTransactionRepositoryImpl) FindPendingByContainerNumber(db *gorm.DB, transactionResult *model.GateTransaction, containerNumber string) error {
+	query := `
+		SELECT * FROM gate_transactions
+		WHERE container_number = ? AND status = ?
+		ORDER BY id DESC
+		LIMIT 1`
+
+	err := db.Raw(query, containerNumber, model.GateStatusPendingPlacement).Scan(transactionResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || transactionResult.ID == 0 {
+		return errors.New("pending gate transaction not found")
+	}
+	return err
+}
+
+func (r *GateTransactionRepositoryImpl) FindPendingPlacements(db *gorm.DB, transactions *[]model.GateTransaction) error {
+	query := `
+		SELECT * FROM gate_transactions
+		WHERE status = ? AND block_id IS NOT NULL
+		ORDER BY id ASC`
+
+	err := db.Raw(query, model.GateStatusPendingPlacement).Scan(transactions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *GateTransactionRepositoryImpl) UpdateStatus(db *gorm.DB, transaction *model.GateTransaction) error {
+	query := `
+		UPDATE gate_transactions
+		SET status = ?, block_id = ?, slot_number = ?, row_number = ?, tier_number = ?, reject_reason = ?,
+			completed_at = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		transaction.Status, transaction.BlockID, transaction.SlotNumber, transaction.RowNumber, transaction.TierNumber,
+		transaction.RejectReason, transaction.CompletedAt, transaction.UpdatedAt, transaction.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("gate transaction not found")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type IdempotencyKeyRepository interface {
+	// Claim inserts the key IN_PROGRESS and reports whether the caller owns
+	// it. An expired key, or a key of the same request whose lock is older
+	// than staleBefore, is claimed again.
+	Claim(db *gorm.DB, key *model.IdempotencyKey, staleBefore time.Time) (bool, error)
+	FindByKey(db *gorm.DB, key *model.IdempotencyKey, actor, idempotencyKey string) error
+	// Complete stores the response of a claimed key.
+	Complete(db *gorm.DB, key *model.IdempotencyKey) error
+	// Delete frees a claimed key so a retry runs the request again.
+	Delete(db *gorm.DB, keyID int) error
+	DeleteExpired(db *gorm.DB, now time.Time) (int64, error)
+}
+
+type IdempotencyKeyRepositoryImpl struct {
+}
+
+func NewIdempotencyKeyRepository() IdempotencyKeyRepository {
+	return &IdempotencyKeyRepositoryImpl{}
+}
+
+func (r *IdempotencyKeyRepositoryImpl) Claim(db *gorm.DB, key *model.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	query := `INSERT INTO idempotency_keys (
+		actor, idempotency_key, method, path, request_hash, status,
+		status_code, content_type, response_body, locked_at, created_at, expires_at
+	) VALUES (?, ?, ?, ?, ?, ?, 0, '', '', ?, ?, ?)
+	ON CONFLICT (actor, idempotency_key) DO UPDATE SET
+		method = EXCLUDED.method,
+		path = EXCLUDED.path,
+		request_hash = EXCLUDED.request_hash,
+		status = EXCLUDED.status,
+		status_code = 0,
+		content_type = '',
+		response_body = '',
+		locked_at = EXCLUDED.locked_at,
+		created_at = EXCLUDED.created_at,
+		expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	   OR (idempotency_keys.status = ?
+	       AND idempotency_keys.locked_at < ?
+	       AND idempotency_keys.request_hash = EXCLUDED.request_hash)
+	RETURNING id`
+
+	result := db.Raw(query,
+		key.Actor, key.Key, key.Method, key.Path, key.RequestHash, key.Status,
+		key.LockedAt, key.CreatedAt, key.ExpiresAt,
+		model.IdempotencyStatusInProgress, staleBefore,
+	).Scan(&key.ID)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return key.ID != 0, nil
+}
+
+func (r *IdempotencyKeyRepositoryImpl) FindByKey(db *gorm.DB, key *model.IdempotencyKey, actor, idempotencyKey string) error {
+	query := "SELECT * FROM idempotency_keys WHERE actor = ? AND idempotency_key = ?"
+
+	err := db.Raw(query, actor, idempotencyKey).Scan(key).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || key.ID == 0 {
+		return errors.New("idempotency key not found")
+	}
+	return err
+}
+
+func (r *IdempotencyKeyRepositoryImpl) Complete(db *gorm.DB, key *model.IdempotencyKey) error {
+	query := `UPDATE idempotency_keys
+		SET status = ?, status_code = ?, content_type = ?, response_body = ?
+		WHERE id = ? AND status = ?`
+
+	result := db.Exec(query,
+		key.Status, key.StatusCode, key.ContentType, key.ResponseBody,
+		key.ID, model.IdempotencyStatusInProgress,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("idempotency key is no longer in progress")
+	}
+	return nil
+}
+
+func (r *IdempotencyKeyRepositoryImpl) Delete(db *gorm.DB, keyID int) error {
+	result := db.Exec("DELETE FROM idempotency_keys WHERE id = ?", keyID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("idempotency key not found or already deleted")
+	}
+	return nil
+}
+
+func (r *IdempotencyKeyRepositoryImpl) DeleteExpired(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= ?", now)
+	return result.RowsAffected, result.Error
+}
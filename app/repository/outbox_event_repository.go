@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type OutboxEventRepository interface {
+	// Save is called with the transaction of the change the event describes.
+	Save(db *gorm.DB, event *model.OutboxEvent) error
+	FindByID(db *gorm.DB, eventResult *model.OutboxEvent, eventID int) error
+	// FindUndispatched locks the oldest events not yet turned into
+	// deliveries. Rows locked by another dispatcher are skipped.
+	FindUndispatched(db *gorm.DB, events *[]model.OutboxEvent, limit int) error
+	MarkDispatched(db *gorm.DB, eventIDs []int, dispatchedAt time.Time) error
+
+	// AssignStreamIDs numbers the committed events that have no stream ID
+	// yet. Concurrent callers are serialized, so stream IDs become visible in
+	// increasing order. db must be a transaction.
+	AssignStreamIDs(db *gorm.DB, limit int) (int64, error)
+	FindLatestStreamID(db *gorm.DB, streamID *int) error
+	// FindStreamEvents lists events of the given types by stream ID after
+	// afterID, up to untilID unless it is zero. yardID and blockID filter
+	// when not zero, a block matches both ends of a move.
+	FindStreamEvents(db *gorm.DB, events *[]model.OutboxEvent, afterID, untilID int, eventTypes []string, yardID, blockID, limit int) error
+}
+
+type OutboxEventRepositoryImpl struct {
+}
+
+func NewOutboxEventRepository() OutboxEventRepository {
+	return &OutboxEventRepositoryImpl{}
+}
+
+func (r *OutboxEventRepositoryImpl) Save(db *gorm.DB, event *model.OutboxEvent) error {
+	query := `INSERT INTO outbox_events (
+		event_type, aggregate_id, payload, yard_id, block_id, to_block_id, created_at, dispatched_at
+	) VALUES (?, ?, ?, (SELECT yard_id FROM blocks WHERE id = ?), ?, ?, ?, ?)
+	RETURNING id, yard_id`
+
+	result := db.Raw(query,
+		event.EventType, event.AggregateID, event.Payload, event.BlockID, event.BlockID, event.ToBlockID,
+		event.CreatedAt, event.DispatchedAt,
+	).Scan(event)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if event.ID == 0 {
+		return errors.New("failed to insert outbox event")
+	}
+	return nil
+}
+
+func (r *OutboxEventRepositoryImpl) FindByID(db *gorm.DB, eventResult *model.OutboxEvent, eventID int) error {
+	err := db.Raw("SELECT * FROM outbox_events WHERE id = ?", eventID).Scan(eventResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || eventResult.ID == 0 {
+		return errors.New("outbox event not found")
+	}
+	return err
+}
+
+func (r *OutboxEventRepositoryImpl) FindUndispatched(db *gorm.DB, events *[]model.OutboxEvent, limit int) error {
+	query := `
+		SELECT * FROM outbox_events
+		WHERE dispatched_at IS NULL
+		ORDER BY id ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`
+
+	err := db.Raw(query, limit).Scan(events).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *OutboxEventRepositoryImpl) MarkDispatched(db *gorm.DB, eventIDs []int, dispatchedAt time.Time) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	return db.Exec("UPDATE outbox_events SET dispatched_at = ? WHERE id IN (?)", dispatchedAt, eventIDs).Error
+}
+
+func (r *OutboxEventRepositoryImpl) AssignStreamIDs(db *gorm.DB, limit int) (int64, error) {
+	if err := db.Exec("SELECT pg_advisory_xact_lock(hashtext('outbox_events_stream'))").Error; err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE outbox_events
+		SET stream_id = nextval('outbox_events_stream_id_seq')
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE stream_id IS NULL
+			ORDER BY id ASC
+			LIMIT ?
+		)`
+
+	result := db.Exec(query, limit)
+	return result.RowsAffected, result.Error
+}
+
+func (r *OutboxEventRepositoryImpl) FindLatestStreamID(db *gorm.DB, streamID *int) error {
+	return db.Raw("SELECT COALESCE(MAX(stream_id), 0) FROM outbox_events").Scan(streamID).Error
+}
+
+func (r *OutboxEventRepositoryImpl) FindStreamEvents(db *gorm.DB, events *[]model.OutboxEvent, afterID, untilID int, eventTypes []string, yardID, blockID, limit int) error {
+	query := `
+		SELECT * FROM outbox_events
+		WHERE stream_id > ?
+		  AND (? = 0 OR stream_id <= ?)
+		  AND event_type IN (?)
+		  AND (? = 0 OR yard_id = ?)
+		  AND (? = 0 OR block_id = ? OR to_block_id = ?)
+		ORDER BY stream_id ASC
+		LIMIT ?`
+
+	err := db.Raw(query,
+		afterID, untilID, untilID, eventTypes, yardID, yardID, blockID, blockID, blockID, limit,
+	).Scan(events).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
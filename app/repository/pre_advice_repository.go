@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrPreAdviceNotFound is returned when a container has no pending
+// pre-advice.
+var ErrPreAdviceNotFound = errors.New("pending pre-advice not found")
+
+type PreAdviceRepository interface {
+	Save(db *gorm.DB, preAdvice *model.PreAdvice) error
+	FindByID(db *gorm.DB, preAdviceResult *model.PreAdvice, preAdviceID int) error
+	// FindPendingByContainerNumber returns ErrPreAdviceNotFound when the
+	// container has no pending pre-advice.
+	FindPendingByContainerNumber(db *gorm.DB, preAdviceResult *model.PreAdvice, containerNumber string) error
+	// FindPending lists pending pre-advices of a yard expected to arrive
+	// before until. A zero yardID means every yard.
+	FindPending(db *gorm.DB, preAdvices *[]model.PreAdvice, yardID int, until time.Time) error
+	FindProvisionalCells(db *gorm.DB, preAdvices *[]model.PreAdvice) error
+	UpdateStatus(db *gorm.DB, preAdvice *model.PreAdvice) error
+	// ExpireBefore marks pending pre-advices whose window ended before cutoff
+	// as EXPIRED, releasing their provisional cells. expired receives the
+	// pre-advices as they were before, with their provisional cells.
+	ExpireBefore(db *gorm.DB, expired *[]model.PreAdvice, cutoff time.Time) error
+}
+
+type PreAdviceRepositoryImpl struct {
+}
+
+func NewPreAdviceRepository() PreAdviceRepository {
+	return &PreAdviceRepositoryImpl{}
+}
+
+func (r *PreAdviceRepositoryImpl) Save(db *gorm.DB, preAdvice *model.PreAdvice) error {
+	query := `INSERT INTO pre_advices (
+		container_number, yard_id, status, source,
+		container_size, container_height, container_type, vessel, voyage, shipping_line,
+		gross_weight_kg, port_of_discharge, dangerous_goods,
+		expected_from, expected_to,
+		block_id, slot_number, row_number, tier_number,
+		arrived_at, mismatches, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		preAdvice.ContainerNumber, preAdvice.YardID, preAdvice.Status, preAdvice.Source,
+		preAdvice.ContainerSize, preAdvice.ContainerHeight, preAdvice.ContainerType,
+		preAdvice.Vessel, preAdvice.Voyage, preAdvice.ShippingLine,
+		preAdvice.GrossWeight, preAdvice.PortOfDischarge, preAdvice.DangerousGoods,
+		preAdvice.ExpectedFrom, preAdvice.ExpectedTo,
+		preAdvice.BlockID, preAdvice.SlotNumber, preAdvice.RowNumber, preAdvice.TierNumber,
+		preAdvice.ArrivedAt, preAdvice.Mismatches, preAdvice.CreatedAt, preAdvice.UpdatedAt,
+	).Scan(&preAdvice.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if preAdvice.ID == 0 {
+		return errors.New("failed to insert pre-advice")
+	}
+	return nil
+}
+
+func (r *PreAdviceRepositoryImpl) FindByID(db *gorm.DB, preAdviceResult *model.PreAdvice, preAdviceID int) error {
+	err := db.Raw("SELECT * FROM pre_advices WHERE id = ?", preAdviceID).Scan(preAdviceResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || preAdviceResult.ID == 0 {
+		return errors.New("pre-advice not found")
+	}
+	return err
+}
+
+func (r *PreAdviceRepositoryImpl) FindPendingByContainerNumber(db *gorm.DB, preAdviceResult *model.PreAdvice, containerNumber string) error {
+	query := `
+		SELECT * FROM pre_advices
+		WHERE container_number = ? AND status = ?
+		ORDER BY id DESC
+		LIMIT 1`
+
+	err := db.Raw(query, containerNumber, model.PreAdviceStatusPending).Scan(preAdviceResult).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if preAdviceResult.ID == 0 {
+		return ErrPreAdviceNotFound
+	}
+	return nil
+}
+
+func (r *PreAdviceRepositoryImpl) FindPending(db *gorm.DB, preAdvices *[]model.PreAdvice, yardID int, until time.Time) error {
+	query := `
+		SELECT * FROM pre_advices
+		WHERE status = ?
+		  AND (? = 0 OR yard_id = ?)
+		  AND expected_from <= ?
+		ORDER BY expected_from ASC, id ASC`
+
+	err := db.Raw(query, model.PreAdviceStatusPending, yardID, yardID, until).Scan(preAdvices).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *PreAdviceRepositoryImpl) FindProvisionalCells(db *gorm.DB, preAdvices *[]model.PreAdvice) error {
+	query := `
+		SELECT * FROM pre_advices
+		WHERE status = ? AND block_id IS NOT NULL
+		ORDER BY id ASC`
+
+	err := db.Raw(query, model.PreAdviceStatusPending).Scan(preAdvices).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *PreAdviceRepositoryImpl) UpdateStatus(db *gorm.DB, preAdvice *model.PreAdvice) error {
+	query := `
+		UPDATE pre_advices
+		SET status = ?, block_id = ?, slot_number = ?, row_number = ?, tier_number = ?,
+			arrived_at = ?, mismatches = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		preAdvice.Status, preAdvice.BlockID, preAdvice.SlotNumber, preAdvice.RowNumber, preAdvice.TierNumber,
+		preAdvice.ArrivedAt, preAdvice.Mismatches, preAdvice.UpdatedAt, preAdvice.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("pre-advice not found")
+	}
+	return nil
+}
+
+func (r *PreAdviceRepositoryImpl) ExpireBefore(db *gorm.DB, expired *[]model.PreAdvice, cutoff time.Time) error {
+	query := `
+		UPDATE pre_advices AS pa
+		SET status = ?, block_id = NULL, slot_number = NULL, row_number = NULL, tier_number = NULL, updated_at = ?
+		FROM pre_advices AS old
+		WHERE old.id = pa.id AND pa.status = ? AND pa.expected_to < ?
+		RETURNING old.*`
+
+	err := db.Raw(query, model.PreAdviceStatusExpired, time.Now(), model.PreAdviceStatusPending, cutoff).Scan(expired).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
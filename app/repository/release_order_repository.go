@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type ReleaseOrderRepository interface {
+	// Save inserts the release order and its containers.
+	Save(db *gorm.DB, releaseOrder *model.ReleaseOrder) error
+	FindByID(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, releaseOrderID int) error
+	FindByReleaseNumber(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, releaseNumber string) error
+	// FindOpenByContainerNumber finds the active release order, valid at
+	// now, whose entry for the container is not used yet. The one expiring
+	// first wins.
+	FindOpenByContainerNumber(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, containerNumber string, now time.Time) error
+	UpdateStatus(db *gorm.DB, releaseOrder *model.ReleaseOrder) error
+
+	// MarkPickedUp uses up the container's entry of the release order. It
+	// fails when the entry was already used, so a release cannot be spent
+	// twice by concurrent pickups.
+	MarkPickedUp(db *gorm.DB, releaseOrderID int, containerNumber string, pickedUpAt time.Time) error
+	CountRemaining(db *gorm.DB, releaseOrderID int) (int64, error)
+
+	// RecordFailedPin counts a wrong PIN. The maxAttempts-th one in a row
+	// locks the order until lockedUntil and starts the count over.
+	RecordFailedPin(db *gorm.DB, releaseOrderID int, maxAttempts int, lockedUntil time.Time, now time.Time) error
+	// ResetFailedPins clears the count after a correct PIN.
+	ResetFailedPins(db *gorm.DB, releaseOrderID int, now time.Time) error
+}
+
+type ReleaseOrderRepositoryImpl struct {
+}
+
+func NewReleaseOrderRepository() ReleaseOrderRepository {
+	return &ReleaseOrderRepositoryImpl{}
+}
+
+func (r *ReleaseOrderRepositoryImpl) Save(db *gorm.DB, releaseOrder *model.ReleaseOrder) error {
+	query := `INSERT INTO release_orders (
+		release_number, pin_hash, trucking_company, valid_until, status, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		releaseOrder.ReleaseNumber, releaseOrder.PinHash, releaseOrder.TruckingCompany,
+		releaseOrder.ValidUntil, releaseOrder.Status, releaseOrder.CreatedAt, releaseOrder.UpdatedAt,
+	).Scan(&releaseOrder.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if releaseOrder.ID == 0 {
+		return errors.New("failed to insert release order")
+	}
+
+	containerQuery := `INSERT INTO release_order_containers (release_order_id, container_number) VALUES (?, ?) RETURNING id`
+
+	for i := range releaseOrder.Containers {
+		container := &releaseOrder.Containers[i]
+		container.ReleaseOrderID = releaseOrder.ID
+
+		if err := db.Raw(containerQuery, container.ReleaseOrderID, container.ContainerNumber).Scan(&container.ID).Error; err != nil {
+			return err
+		}
+		if container.ID == 0 {
+			return errors.New("failed to insert release order container")
+		}
+	}
+	return nil
+}
+
+func (r *ReleaseOrderRepositoryImpl) FindByID(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, releaseOrderID int) error {
+	err := db.Raw("SELECT * FROM release_orders WHERE id = ?", releaseOrderID).Scan(releaseOrderResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || releaseOrderResult.ID == 0 {
+		return errors.New("release order not found")
+	}
+	if err != nil {
+		return err
+	}
+	return r.findContainers(db, releaseOrderResult)
+}
+
+func (r *ReleaseOrderRepositoryImpl) FindByReleaseNumber(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, releaseNumber string) error {
+	err := db.Raw("SELECT * FROM release_orders WHERE release_number = ?", releaseNumber).Scan(releaseOrderResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || releaseOrderResult.ID == 0 {
+		return errors.New("release order not found")
+	}
+	if err != nil {
+		return err
+	}
+	return r.findContainers(db, releaseOrderResult)
+}
+
+func (r *ReleaseOrderRepositoryImpl) FindOpenByContainerNumber(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, containerNumber string, now time.Time) error {
+	query := `
+		SELECT ro.* FROM release_orders ro
+		JOIN release_order_containers roc ON roc.release_order_id = ro.id
+		WHERE roc.container_number = ? AND roc.picked_up_at IS NULL
+		  AND ro.status = ? AND ro.valid_until >= ?
+		ORDER BY ro.valid_until ASC, ro.id ASC
+		LIMIT 1`
+
+	err := db.Raw(query, containerNumber, model.ReleaseStatusActive, now).Scan(releaseOrderResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || releaseOrderResult.ID == 0 {
+		return errors.New("release order not found")
+	}
+	if err != nil {
+		return err
+	}
+	return r.findContainers(db, releaseOrderResult)
+}
+
+func (r *ReleaseOrderRepositoryImpl) UpdateStatus(db *gorm.DB, releaseOrder *model.ReleaseOrder) error {
+	query := `
+		UPDATE release_orders
+		SET status = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query, releaseOrder.Status, releaseOrder.UpdatedAt, releaseOrder.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("release order not found")
+	}
+	return nil
+}
+
+func (r *ReleaseOrderRepositoryImpl) MarkPickedUp(db *gorm.DB, releaseOrderID int, containerNumber string, pickedUpAt time.Time) error {
+	query := `
+		UPDATE release_order_containers
+		SET picked_up_at = ?
+		WHERE release_order_id = ? AND container_number = ? AND picked_up_at IS NULL`
+
+	result := db.Exec(query, pickedUpAt, releaseOrderID, containerNumber)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("release already used for this container")
+	}
+	return nil
+}
+
+func (r *ReleaseOrderRepositoryImpl) CountRemaining(db *gorm.DB, releaseOrderID int) (int64, error) {
+	var count int64
+
+	query := `
+		SELECT COUNT(id) FROM release_order_containers
+		WHERE release_order_id = ? AND picked_up_at IS NULL`
+
+	if err := db.Raw(query, releaseOrderID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *ReleaseOrderRepositoryImpl) RecordFailedPin(db *gorm.DB, releaseOrderID int, maxAttempts int, lockedUntil time.Time, now time.Time) error {
+	query := `
+		UPDATE release_orders
+		SET failed_pin_attempts = CASE WHEN failed_pin_attempts + 1 >= ? THEN 0 ELSE failed_pin_attempts + 1 END,
+			pin_locked_until = CASE WHEN failed_pin_attempts + 1 >= ? THEN ? ELSE pin_locked_until END,
+			updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query, maxAttempts, maxAttempts, lockedUntil, now, releaseOrderID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("release order not found")
+	}
+	return nil
+}
+
+func (r *ReleaseOrderRepositoryImpl) ResetFailedPins(db *gorm.DB, releaseOrderID int, now time.Time) error {
+	query := `
+		UPDATE release_orders
+		SET failed_pin_attempts = 0, updated_at = ?
+		WHERE id = ? AND failed_pin_attempts > 0`
+
+	return db.Exec(query, now, releaseOrderID).Error
+}
+
+func (r *ReleaseOrderRepositoryImpl) findContainers(db *gorm.DB, releaseOrder *model.ReleaseOrder) error {
+	query := `
+		SELECT * FROM release_order_containers
+		WHERE release_order_id = ?
+		ORDER BY id ASC`
+
+	return db.Raw(query, releaseOrder.ID).Scan(&releaseOrder.Containers).Error
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type TariffRepository interface {
+	// Save inserts the tariff and its tiers.
+	Save(db *gorm.DB, tariff *model.Tariff) error
+	FindByID(db *gorm.DB, tariffResult *model.Tariff, tariffID int) error
+	FindAll(db *gorm.DB, tariffs *[]model.Tariff) error
+	// Update overwrites the tariff and replaces its tiers.
+	Update(db *gorm.DB, tariff *model.Tariff) error
+	Delete(db *gorm.DB, tariffID int) error
+}
+
+type TariffRepositoryImpl struct {
+}
+
+func NewTariffRepository() TariffRepository {
+	return &TariffRepositoryImpl{}
+}
+
+func (r *TariffRepositoryImpl) Save(db *gorm.DB, tariff *model.Tariff) error {
+	query := `INSERT INTO tariffs (
+		name, yard_id, container_size, container_type, free_days, reefer_daily_rate, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		tariff.Name, tariff.YardID, tariff.ContainerSize, tariff.ContainerType,
+		tariff.FreeDays, tariff.ReeferDailyRate, tariff.CreatedAt, tariff.UpdatedAt,
+	).Scan(&tariff.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if tariff.ID == 0 {
+		return errors.New("failed to insert tariff")
+	}
+	return r.saveTiers(db, tariff)
+}
+
+func (r *TariffRepositoryImpl) FindByID(db *gorm.DB, tariffResult *model.Tariff, tariffID int) error {
+	err := db.Raw("SELECT * FROM tariffs WHERE id = ?", tariffID).Scan(tariffResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || tariffResult.ID == 0 {
+		return errors.New("tariff not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT * FROM tariff_tiers
+		WHERE tariff_id = ?
+		ORDER BY from_day ASC`
+
+	return db.Raw(query, tariffID).Scan(&tariffResult.Tiers).Error
+}
+
+func (r *TariffRepositoryImpl) FindAll(db *gorm.DB, tariffs *[]model.Tariff) error {
+	err := db.Raw("SELECT * FROM tariffs ORDER BY id ASC").Scan(tariffs).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var tiers []model.TariffTier
+	err = db.Raw("SELECT * FROM tariff_tiers ORDER BY tariff_id ASC, from_day ASC").Scan(&tiers).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	index := make(map[int]int, len(*tariffs))
+	for i, tariff := range *tariffs {
+		index[tariff.ID] = i
+	}
+	for _, tier := range tiers {
+		if i, ok := index[tier.TariffID]; ok {
+			(*tariffs)[i].Tiers = append((*tariffs)[i].Tiers, tier)
+		}
+	}
+	return nil
+}
+
+func (r *TariffRepositoryImpl) Update(db *gorm.DB, tariff *model.Tariff) error {
+	query := `
+		UPDATE tariffs
+		SET name = ?, yard_id = ?, container_size = ?, container_type = ?,
+			free_days = ?, reefer_daily_rate = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		tariff.Name, tariff.YardID, tariff.ContainerSize, tariff.ContainerType,
+		tariff.FreeDays, tariff.ReeferDailyRate, tariff.UpdatedAt, tariff.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("tariff not found")
+	}
+
+	if err := db.Exec("DELETE FROM tariff_tiers WHERE tariff_id = ?", tariff.ID).Error; err != nil {
+		return err
+	}
+	return r.saveTiers(db, tariff)
+}
+
+func (r *TariffRepositoryImpl) Delete(db *gorm.DB, tariffID int) error {
+	result := db.Exec("DELETE FROM tariffs WHERE id = ?", tariffID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("tariff not found or already deleted")
+	}
+	return nil
+}
+
+func (r *TariffRepositoryImpl) saveTiers(db *gorm.DB, tariff *model.Tariff) error {
+	query := `INSERT INTO tariff_tiers (tariff_id, from_day, daily_rate) VALUES (?, ?, ?) RETURNING id`
+
+	for i := range tariff.Tiers {
+		tier := &tariff.Tiers[i]
+		tier.TariffID = tariff.ID
+
+		if err := db.Raw(query, tier.TariffID, tier.FromDay, tier.DailyRate).Scan(&tier.ID).Error; err != nil {
+			return err
+		}
+		if tier.ID == 0 {
+			return errors.New("failed to insert tariff tier")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Save(db *gorm.DB, webhook *model.Webhook) error
+	FindByID(db *gorm.DB, webhookResult *model.Webhook, webhookID int) error
+	FindAll(db *gorm.DB, webhooks *[]model.Webhook, activeOnly bool) error
+	Update(db *gorm.DB, webhook *model.Webhook) error
+	Delete(db *gorm.DB, webhookID int) error
+
+	// SaveDelivery queues an event for a webhook, once.
+	SaveDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error
+	FindDeliveryByID(db *gorm.DB, deliveryResult *model.WebhookDelivery, deliveryID int) error
+	// ClaimDueDeliveries picks pending deliveries of active webhooks whose
+	// next attempt is due and moves that attempt to leaseUntil, so a
+	// concurrent dispatcher does not send them as well.
+	ClaimDueDeliveries(db *gorm.DB, deliveries *[]model.WebhookDelivery, now, leaseUntil time.Time, limit int) error
+	UpdateDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error
+	// FindDeliveryDetails lists deliveries of a status, newest first,
+	// filtered by webhook when webhookID is not zero.
+	FindDeliveryDetails(db *gorm.DB, details *[]model.WebhookDeliveryDetail, status string, webhookID, limit int) error
+}
+
+type WebhookRepositoryImpl struct {
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &WebhookRepositoryImpl{}
+}
+
+func (r *WebhookRepositoryImpl) Save(db *gorm.DB, webhook *model.Webhook) error {
+	query := `INSERT INTO webhooks (
+		url, secret, event_types, active, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		webhook.URL, webhook.Secret, webhook.EventTypes, webhook.Active, webhook.CreatedAt, webhook.UpdatedAt,
+	).Scan(&webhook.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if webhook.ID == 0 {
+		return errors.New("failed to insert webhook")
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) FindByID(db *gorm.DB, webhookResult *model.Webhook, webhookID int) error {
+	err := db.Raw("SELECT * FROM webhooks WHERE id = ?", webhookID).Scan(webhookResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || webhookResult.ID == 0 {
+		return errors.New("webhook not found")
+	}
+	return err
+}
+
+func (r *WebhookRepositoryImpl) FindAll(db *gorm.DB, webhooks *[]model.Webhook, activeOnly bool) error {
+	query := `
+		SELECT * FROM webhooks
+		WHERE (? = FALSE OR active = TRUE)
+		ORDER BY id ASC`
+
+	err := db.Raw(query, activeOnly).Scan(webhooks).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) Update(db *gorm.DB, webhook *model.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = ?, secret = ?, event_types = ?, active = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		webhook.URL, webhook.Secret, webhook.EventTypes, webhook.Active, webhook.UpdatedAt, webhook.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) Delete(db *gorm.DB, webhookID int) error {
+	result := db.Exec("DELETE FROM webhooks WHERE id = ?", webhookID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("webhook not found or already deleted")
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) SaveDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (
+		webhook_id, event_id, status, attempts, next_attempt_at, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (webhook_id, event_id) DO NOTHING
+	RETURNING id`
+
+	return db.Raw(query,
+		delivery.WebhookID, delivery.EventID, delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, delivery.CreatedAt, delivery.UpdatedAt,
+	).Scan(&delivery.ID).Error
+}
+
+func (r *WebhookRepositoryImpl) FindDeliveryByID(db *gorm.DB, deliveryResult *model.WebhookDelivery, deliveryID int) error {
+	err := db.Raw("SELECT * FROM webhook_deliveries WHERE id = ?", deliveryID).Scan(deliveryResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || deliveryResult.ID == 0 {
+		return errors.New("webhook delivery not found")
+	}
+	return err
+}
+
+func (r *WebhookRepositoryImpl) ClaimDueDeliveries(db *gorm.DB, deliveries *[]model.WebhookDelivery, now, leaseUntil time.Time, limit int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT wd.id FROM webhook_deliveries AS wd
+			JOIN webhooks AS w ON w.id = wd.webhook_id
+			WHERE wd.status = ? AND wd.next_attempt_at <= ? AND w.active = TRUE
+			ORDER BY wd.next_attempt_at ASC, wd.id ASC
+			LIMIT ?
+			FOR UPDATE OF wd SKIP LOCKED
+		)
+		RETURNING *`
+
+	err := db.Raw(query, leaseUntil, model.DeliveryStatusPending, now, limit).Scan(deliveries).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) UpdateDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ?, updated_at = ?
+		WHERE id = ?`
+
+	result := db.Exec(query,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+		delivery.LastError, delivery.DeliveredAt, delivery.UpdatedAt, delivery.ID,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("webhook delivery not found")
+	}
+	return nil
+}
+
+func (r *WebhookRepositoryImpl) FindDeliveryDetails(db *gorm.DB, details *[]model.WebhookDeliveryDetail, status string, webhookID, limit int) error {
+	query := `
+		SELECT wd.*, w.url, e.event_type
+		FROM webhook_deliveries AS wd
+		JOIN webhooks AS w ON w.id = wd.webhook_id
+		JOIN outbox_events AS e ON e.id = wd.event_id
+		WHERE wd.status = ?
+		  AND (? = 0 OR wd.webhook_id = ?)
+		ORDER BY wd.updated_at DESC, wd.id DESC
+		LIMIT ?`
+
+	err := db.Raw(query, status, webhookID, webhookID, limit).Scan(details).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type WorkInstructionRepository interface {
+	Save(db *gorm.DB, instruction *model.WorkInstruction) error
+	FindByID(db *gorm.DB, instructionResult *model.WorkInstruction, instructionID int) error
+	// FindByIDForUpdate is FindByID locking the instruction row until the
+	// transaction db ends.
+	FindByIDForUpdate(db *gorm.DB, instructionResult *model.WorkInstruction, instructionID int) error
+	FindPendingByContainerNumber(db *gorm.DB, instructionResult *model.WorkInstruction, containerNumber string) error
+	// FindPendingDestinations returns the pending placements and moves, whose
+	// destination cells are held until they are confirmed or rejected.
+	FindPendingDestinations(db *gorm.DB, instructions *[]model.WorkInstruction) error
+	Find(db *gorm.DB, filter *WorkInstructionFilter, instructions *[]model.WorkInstruction) error
+	CountPendingByEquipment(db *gorm.DB, equipmentIDs []int) (map[int]int64, error)
+	// Assign stores the dispatcher's equipment and queue position of a
+	// pending instruction. Instructions completed in the meantime are left
+	// untouched.
+	Assign(db *gorm.DB, instructionID int, equipmentID, sequence *int) error
+
+	// Complete stores the outcome of a pending instruction. It fails when the
+	// instruction is no longer pending.
+	Complete(db *gorm.DB, instruction *model.WorkInstruction) error
+}
+
+// WorkInstructionFilter holds the criteria of a work queue listing. Zero
+// values are ignored, Unassigned only lists instructions without equipment.
+type WorkInstructionFilter struct {
+	YardID      int
+	BlockID     int
+	EquipmentID int
+	Unassigned  bool
+	Status      string
+
+	// Orders by queue position instead of creation time
+	SortBySequence bool
+}
+
+type WorkInstructionRepositoryImpl struct {
+}
+
+func NewWorkInstructionRepository() WorkInstructionRepository {
+	return &WorkInstructionRepositoryImpl{}
+}
+
+func (r *WorkInstructionRepositoryImpl) Save(db *gorm.DB, instruction *model.WorkInstruction) error {
+	query := `INSERT INTO work_instructions (
+		work_type, status, container_number, yard_id, block_id, equipment_id, priority, sequence, predecessor_id,
+		from_block_id, from_slot, from_row, from_tier, container_revision,
+		to_block_id, to_slot, to_row, to_tier,
+		container_size, container_height, container_type, vessel, voyage, shipping_line,
+		release_order_id, reason, operator, reject_reason, completed_at, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		instruction.WorkType, instruction.Status, instruction.ContainerNumber, instruction.YardID, instruction.BlockID, instruction.EquipmentID,
+		instruction.Priority, instruction.Sequence, instruction.PredecessorID,
+		instruction.FromBlockID, instruction.FromSlot, instruction.FromRow, instruction.FromTier, instruction.ContainerRevision,
+		instruction.ToBlockID, instruction.ToSlot, instruction.ToRow, instruction.ToTier,
+		instruction.ContainerSize, instruction.ContainerHeight, instruction.ContainerType,
+		instruction.Vessel, instruction.Voyage, instruction.ShippingLine,
+		instruction.ReleaseOrderID, instruction.Reason, instruction.Operator, instruction.RejectReason,
+		instruction.CompletedAt, instruction.CreatedAt, instruction.UpdatedAt,
+	).Scan(&instruction.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if instruction.ID == 0 {
+		return errors.New("failed to insert work instruction")
+	}
+	return nil
+}
+
+func (r *WorkInstructionRepositoryImpl) FindByID(db *gorm.DB, instructionResult *model.WorkInstruction, instructionID int) error {
+	err := db.Raw("SELECT * FROM work_instructions WHERE id = ?", instructionID).Scan(instructionResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || instructionResult.ID == 0 {
+		return errors.New("work instruction not found")
+	}
+	return err
+}
+
+func (r *WorkInstructionRepositoryImpl) FindByIDForUpdate(db *gorm.DB, instructionResult *model.WorkInstruction, instructionID int) error {
+	err := db.Raw("SELECT * FROM work_instructions WHERE id = ? FOR UPDATE", instructionID).Scan(instructionResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || instructionResult.ID == 0 {
+		return errors.New("work instruction not found")
+	}
+	return err
+}
+
+func (r *WorkInstructionRepositoryImpl) FindPendingByContainerNumber(db *gorm.DB, instructionResult *model.WorkInstruction, containerNumber string) error {
+	query := `
+		SELECT * FROM work_instructions
+		WHERE container_number = ? AND status = ?
+		ORDER BY id DESC LIMIT 1`
+
+	err := db.Raw(query, containerNumber, model.WorkStatusPending).Scan(instructionResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || instructionResult.ID == 0 {
+		return errors.New("no pending work instruction for container")
+	}
+	return err
+}
+
+func (r *WorkInstructionRepositoryImpl) FindPendingDestinations(db *gorm.DB, instructions *[]model.WorkInstruction) error {
+	query := `
+		SELECT * FROM work_instructions
+		WHERE status = ? AND to_block_id IS NOT NULL
+		ORDER BY id ASC`
+
+	err := db.Raw(query, model.WorkStatusPending).Scan(instructions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *WorkInstructionRepositoryImpl) Find(db *gorm.DB, filter *WorkInstructionFilter, instructions *[]model.WorkInstruction) error {
+	var where []string
+	var args []any
+
+	if filter.YardID != 0 {
+		where = append(where, "yard_id = ?")
+		args = append(args, filter.YardID)
+	}
+	if filter.BlockID != 0 {
+		where = append(where, "block_id = ?")
+		args = append(args, filter.BlockID)
+	}
+	if filter.EquipmentID != 0 {
+		where = append(where, "equipment_id = ?")
+		args = append(args, filter.EquipmentID)
+	}
+	if filter.Unassigned {
+		where = append(where, "equipment_id IS NULL")
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	query := "SELECT * FROM work_instructions"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if filter.SortBySequence {
+		query += " ORDER BY sequence ASC NULLS LAST, created_at ASC, id ASC"
+	} else {
+		query += " ORDER BY created_at ASC, id ASC"
+	}
+
+	err := db.Raw(query, args...).Scan(instructions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *WorkInstructionRepositoryImpl) CountPendingByEquipment(db *gorm.DB, equipmentIDs []int) (map[int]int64, error) {
+	counts := make(map[int]int64, len(equipmentIDs))
+	if len(equipmentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		EquipmentID int
+		Pending     int64
+	}
+
+	query := `
+		SELECT equipment_id, COUNT(id) AS pending FROM work_instructions
+		WHERE status = ? AND equipment_id IN (?)
+		GROUP BY equipment_id`
+
+	if err := db.Raw(query, model.WorkStatusPending, equipmentIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.EquipmentID] = row.Pending
+	}
+	return counts, nil
+}
+
+func (r *WorkInstructionRepositoryImpl) Assign(db *gorm.DB, instructionID int, equipmentID, sequence *int) error {
+	query := `
+		UPDATE work_instructions
+		SET equipment_id = ?, sequence = ?
+		WHERE id = ? AND status = ?`
+
+	return db.Exec(query, equipmentID, sequence, instructionID, model.WorkStatusPending).Error
+}
+
+func (r *WorkInstructionRepositoryImpl) Complete(db *gorm.DB, instruction *model.WorkInstruction) error {
+	query := `
+		UPDATE work_instructions
+		SET status = ?, equipment_id = ?, operator = ?, reject_reason = ?, completed_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?`
+
+	result := db.Exec(query,
+		instruction.Status, instruction.EquipmentID, instruction.Operator, instruction.RejectReason,
+		instruction.CompletedAt, instruction.UpdatedAt, instruction.ID, model.WorkStatusPending,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("work instruction not found or no longer pending")
+	}
+	return nil
+}
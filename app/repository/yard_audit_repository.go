@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"errors"
+	"yard-planning/app/model"
+
+	"gorm.io/gorm"
+)
+
+type YardAuditRepository interface {
+	Save(db *gorm.DB, audit *model.YardAudit) error
+	FindByID(db *gorm.DB, auditResult *model.YardAudit, auditID int) error
+	// Find lists the audits of the yard, filtered by status when it is not
+	// empty, newest first.
+	Find(db *gorm.DB, audits *[]model.YardAudit, yardID int, status string) error
+	// Close closes an open audit. It fails when the audit is already closed,
+	// so its corrections cannot be applied twice.
+	Close(db *gorm.DB, audit *model.YardAudit) error
+
+	// SaveScan stores a scan, replacing the earlier scan of the same cell and
+	// the earlier scan of the same container in the audit.
+	SaveScan(db *gorm.DB, scan *model.YardAuditScan) error
+	FindScans(db *gorm.DB, scans *[]model.YardAuditScan, auditID int) error
+
+	// ReplaceFindings stores the findings of a reconciliation in place of the
+	// ones of the previous run, along with the audit's ReconciledAt.
+	ReplaceFindings(db *gorm.DB, audit *model.YardAudit, findings []model.YardAuditFinding) error
+	FindFindings(db *gorm.DB, findings *[]model.YardAuditFinding, auditID int) error
+	// ResolveFindings sets the status of pending findings of the audit.
+	ResolveFindings(db *gorm.DB, auditID int, findingIDs []int, status string) error
+}
+
+type YardAuditRepositoryImpl struct {
+}
+
+func NewYardAuditRepository() YardAuditRepository {
+	return &YardAuditRepositoryImpl{}
+}
+
+func (r *YardAuditRepositoryImpl) Save(db *gorm.DB, audit *model.YardAudit) error {
+	query := `INSERT INTO yard_audits (
+		yard_id, block_id, status, created_by, closed_by, reconciled_at, closed_at, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		audit.YardID, audit.BlockID, audit.Status, audit.CreatedBy, audit.ClosedBy,
+		audit.ReconciledAt, audit.ClosedAt, audit.CreatedAt, audit.UpdatedAt,
+	).Scan(&audit.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if audit.ID == 0 {
+		return errors.New("failed to insert yard audit")
+	}
+	return nil
+}
+
+func (r *YardAuditRepositoryImpl) FindByID(db *gorm.DB, auditResult *model.YardAudit, auditID int) error {
+	err := db.Raw("SELECT * FROM yard_audits WHERE id = ?", auditID).Scan(auditResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || auditResult.ID == 0 {
+		return errors.New("yard audit not found")
+	}
+	return err
+}
+
+func (r *YardAuditRepositoryImpl) Find(db *gorm.DB, audits *[]model.YardAudit, yardID int, status string) error {
+	query := `
+		SELECT * FROM yard_audits
+		WHERE yard_id = ?
+		  AND (? = '' OR status = ?)
+		ORDER BY created_at DESC, id DESC`
+
+	err := db.Raw(query, yardID, status, status).Scan(audits).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *YardAuditRepositoryImpl) Close(db *gorm.DB, audit *model.YardAudit) error {
+	query := `
+		UPDATE yard_audits
+		SET status = ?, closed_by = ?, closed_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?`
+
+	result := db.Exec(query,
+		audit.Status, audit.ClosedBy, audit.ClosedAt, audit.UpdatedAt, audit.ID, model.AuditStatusOpen,
+	)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("yard audit not found or already closed")
+	}
+	return nil
+}
+
+func (r *YardAuditRepositoryImpl) SaveScan(db *gorm.DB, scan *model.YardAuditScan) error {
+	deleteQuery := `
+		DELETE FROM yard_audit_scans
+		WHERE audit_id = ?
+		  AND ((block_id = ? AND slot_number = ? AND row_number = ? AND tier_number = ?)
+		    OR (? <> '' AND container_number = ?))`
+
+	err := db.Exec(deleteQuery,
+		scan.AuditID, scan.BlockID, scan.SlotNumber, scan.RowNumber, scan.TierNumber,
+		scan.ContainerNumber, scan.ContainerNumber,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO yard_audit_scans (
+		audit_id, block_id, slot_number, row_number, tier_number, container_number,
+		container_size, container_height, container_type, scanned_by, scanned_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	result := db.Raw(query,
+		scan.AuditID, scan.BlockID, scan.SlotNumber, scan.RowNumber, scan.TierNumber, scan.ContainerNumber,
+		scan.ContainerSize, scan.ContainerHeight, scan.ContainerType, scan.ScannedBy, scan.ScannedAt,
+	).Scan(&scan.ID)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if scan.ID == 0 {
+		return errors.New("failed to insert yard audit scan")
+	}
+	return nil
+}
+
+func (r *YardAuditRepositoryImpl) FindScans(db *gorm.DB, scans *[]model.YardAuditScan, auditID int) error {
+	query := `
+		SELECT * FROM yard_audit_scans
+		WHERE audit_id = ?
+		ORDER BY block_id ASC, slot_number ASC, row_number ASC, tier_number ASC`
+
+	err := db.Raw(query, auditID).Scan(scans).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *YardAuditRepositoryImpl) ReplaceFindings(db *gorm.DB, audit *model.YardAudit, findings []model.YardAuditFinding) error {
+	if err := db.Exec("DELETE FROM yard_audit_findings WHERE audit_id = ?", audit.ID).Error; err != nil {
+		return err
+	}
+
+	query := `INSERT INTO yard_audit_findings (
+		audit_id, finding_type, container_number, action, status,
+		recorded_block_id, recorded_slot, recorded_row, recorded_tier,
+		found_block_id, found_slot, found_row, found_tier,
+		container_size, container_height, container_type, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	RETURNING id`
+
+	for i := range findings {
+		finding := &findings[i]
+		finding.AuditID = audit.ID
+
+		err := db.Raw(query,
+			finding.AuditID, finding.FindingType, finding.ContainerNumber, finding.Action, finding.Status,
+			finding.RecordedBlockID, finding.RecordedSlot, finding.RecordedRow, finding.RecordedTier,
+			finding.FoundBlockID, finding.FoundSlot, finding.FoundRow, finding.FoundTier,
+			finding.ContainerSize, finding.ContainerHeight, finding.ContainerType, finding.CreatedAt,
+		).Scan(&finding.ID).Error
+		if err != nil {
+			return err
+		}
+		if finding.ID == 0 {
+			return errors.New("failed to insert yard audit finding")
+		}
+	}
+
+	query = "UPDATE yard_audits SET reconciled_at = ?, updated_at = ? WHERE id = ?"
+	return db.Exec(query, audit.ReconciledAt, audit.UpdatedAt, audit.ID).Error
+}
+
+func (r *YardAuditRepositoryImpl) FindFindings(db *gorm.DB, findings *[]model.YardAuditFinding, auditID int) error {
+	query := `
+		SELECT * FROM yard_audit_findings
+		WHERE audit_id = ?
+		ORDER BY id ASC`
+
+	err := db.Raw(query, auditID).Scan(findings).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (r *YardAuditRepositoryImpl) ResolveFindings(db *gorm.DB, auditID int, findingIDs []int, status string) error {
+	if len(findingIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE yard_audit_findings
+		SET status = ?
+		WHERE audit_id = ? AND id IN (?) AND status = ?`
+
+	return db.Exec(query, status, auditID, findingIDs, model.FindingStatusPending).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 	"yard-planning/app/model"
 
 	"gorm.io/gorm"
@@ -10,14 +11,30 @@ import (
 type YardPlanRepository interface {
 	Save(db *gorm.DB, plan *model.YardPlan) error
 	FindByID(db *gorm.DB, planResult *model.YardPlan, planID int) error
+	// FindDraftVersion finds the draft created as the next version of a plan.
+	FindDraftVersion(db *gorm.DB, planResult *model.YardPlan, previousVersionID int) error
 	Delete(db *gorm.DB, planID int) error
+	// Update and UpdateStatus only write a plan still at the revision it was
+	// read with and store the new revision in it, a stale plan returns
+	// ErrStaleRevision.
+	Update(db *gorm.DB, plan *model.YardPlan) error
+	UpdateStatus(db *gorm.DB, plan *model.YardPlan) error
 
-	FindActivePlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int) error
+	FindPlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int) error
+	FindActivePlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int, at time.Time) error
 
 	FindOverlappingPlans(db *gorm.DB, plans *[]model.YardPlan, newPlan *model.YardPlan) error
-	FindApplicablePlan(db *gorm.DB, blockID, slot, row int, size, height, cType string) (*model.YardPlan, error)
+	FindApplicablePlan(db *gorm.DB, blockID, slot, row int, size, height, cType string, at time.Time) (*model.YardPlan, error)
+
+	SyncActiveFlags(db *gorm.DB, changed *[]model.YardPlan, at time.Time) error
 }
 
+// Open-ended validity windows are compared against these bounds.
+var (
+	validityLowerBound = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	validityUpperBound = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
 type YardPlanRepositoryImpl struct {
 }
 
@@ -29,19 +46,26 @@ func (r *YardPlanRepositoryImpl) Save(db *gorm.DB, plan *model.YardPlan) error {
 	query := `INSERT INTO yard_plans (
 		block_id, plan_name, slot_start, slot_end, row_start, row_end, 
 		container_size, container_height, container_type, priority_stacking_direction, 
-		is_active, created_at, updated_at
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		is_active, version, previous_version_id, status, valid_from, valid_to, published_at,
+		revision, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?)
+	RETURNING id`
 
-	result := db.Exec(query,
+	plan.Revision = 1
+	result := db.Raw(query,
 		plan.BlockID, plan.PlanName, plan.SlotStart, plan.SlotEnd, plan.RowStart, plan.RowEnd,
 		plan.ContainerSize, plan.ContainerHeight, plan.ContainerType, plan.PriorityStackingDirection,
-		plan.IsActive, plan.CreatedAt, plan.UpdatedAt,
-	)
+		plan.IsActive, plan.Version, plan.PreviousVersionID, plan.Status, plan.ValidFrom, plan.ValidTo, plan.PublishedAt,
+		plan.CreatedAt, plan.UpdatedAt,
+	).Scan(&plan.ID)
 
-	if result.RowsAffected == 0 {
+	if result.Error != nil {
+		return result.Error
+	}
+	if plan.ID == 0 {
 		return errors.New("failed to insert yard plan")
 	}
-	return result.Error
+	return nil
 }
 
 func (r *YardPlanRepositoryImpl) FindByID(db *gorm.DB, planResult *model.YardPlan, planID int) error {
@@ -53,6 +77,17 @@ func (r *YardPlanRepositoryImpl) FindByID(db *gorm.DB, planResult *model.YardPla
 	return err
 }
 
+func (r *YardPlanRepositoryImpl) FindDraftVersion(db *gorm.DB, planResult *model.YardPlan, previousVersionID int) error {
+	query := "SELECT * FROM yard_plans WHERE previous_version_id = ? AND status = 'DRAFT' LIMIT 1"
+
+	err := db.Raw(query, previousVersionID).Scan(planResult).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) || planResult.ID == 0 {
+		return errors.New("yard plan not found")
+	}
+	return err
+}
+
 func (r *YardPlanRepositoryImpl) Delete(db *gorm.DB, planID int) error {
 	result := db.Exec("DELETE FROM yard_plans WHERE id = ?", planID)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
@@ -61,10 +96,59 @@ func (r *YardPlanRepositoryImpl) Delete(db *gorm.DB, planID int) error {
 	return nil
 }
 
-func (r *YardPlanRepositoryImpl) FindActivePlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int) error {
+func (r *YardPlanRepositoryImpl) Update(db *gorm.DB, plan *model.YardPlan) error {
 	query := `
-		SELECT * FROM yard_plans 
-		WHERE block_id = ? AND is_active = TRUE
+		UPDATE yard_plans
+		SET plan_name = ?, slot_start = ?, slot_end = ?, row_start = ?, row_end = ?,
+			container_size = ?, container_height = ?, container_type = ?,
+			priority_stacking_direction = ?, updated_at = ?, revision = revision + 1
+		WHERE id = ? AND revision = ?
+		RETURNING revision`
+
+	var revision int
+	result := db.Raw(query,
+		plan.PlanName, plan.SlotStart, plan.SlotEnd, plan.RowStart, plan.RowEnd,
+		plan.ContainerSize, plan.ContainerHeight, plan.ContainerType,
+		plan.PriorityStackingDirection, plan.UpdatedAt, plan.ID, plan.Revision,
+	).Scan(&revision)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if revision == 0 {
+		return ErrStaleRevision
+	}
+	plan.Revision = revision
+	return nil
+}
+
+func (r *YardPlanRepositoryImpl) UpdateStatus(db *gorm.DB, plan *model.YardPlan) error {
+	query := `
+		UPDATE yard_plans
+		SET status = ?, valid_from = ?, valid_to = ?, published_at = ?, is_active = ?, updated_at = ?,
+			revision = revision + 1
+		WHERE id = ? AND revision = ?
+		RETURNING revision`
+
+	var revision int
+	result := db.Raw(query,
+		plan.Status, plan.ValidFrom, plan.ValidTo, plan.PublishedAt, plan.IsActive, plan.UpdatedAt, plan.ID, plan.Revision,
+	).Scan(&revision)
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if revision == 0 {
+		return ErrStaleRevision
+	}
+	plan.Revision = revision
+	return nil
+}
+
+func (r *YardPlanRepositoryImpl) FindPlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int) error {
+	query := `
+		SELECT * FROM yard_plans
+		WHERE block_id = ?
 		ORDER BY id ASC`
 
 	err := db.Raw(query, blockID).Scan(plans).Error
@@ -75,6 +159,22 @@ func (r *YardPlanRepositoryImpl) FindActivePlansByBlock(db *gorm.DB, plans *[]mo
 	return nil
 }
 
+func (r *YardPlanRepositoryImpl) FindActivePlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int, at time.Time) error {
+	query := `
+		SELECT * FROM yard_plans 
+		WHERE block_id = ? AND status = 'PUBLISHED'
+		  AND (valid_from IS NULL OR valid_from <= ?)
+		  AND (valid_to IS NULL OR valid_to > ?)
+		ORDER BY id ASC`
+
+	err := db.Raw(query, blockID, at, at).Scan(plans).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
 func (r *YardPlanRepositoryImpl) FindOverlappingPlans(db *gorm.DB, plans *[]model.YardPlan, newPlan *model.YardPlan) error {
 	query := `
 		SELECT * FROM yard_plans AS old_plan
@@ -93,14 +193,29 @@ func (r *YardPlanRepositoryImpl) FindOverlappingPlans(db *gorm.DB, plans *[]mode
 			-- Conflict Check: Hanya jika spesifikasi kontainer BERBEDA.
 			(old_plan.container_size <> ? OR
 			 old_plan.container_height <> ? OR
-			 old_plan.container_type <> ?)
+			 old_plan.container_type <> ?) AND
+
+			-- Validity Check: Hanya plan PUBLISHED dengan periode yang beririsan
+			old_plan.status = 'PUBLISHED' AND
+			COALESCE(old_plan.valid_to, ?) > ? AND
+			COALESCE(old_plan.valid_from, ?) < ?
 	`
 
+	validFrom, validTo := validityLowerBound, validityUpperBound
+	if newPlan.ValidFrom != nil {
+		validFrom = *newPlan.ValidFrom
+	}
+	if newPlan.ValidTo != nil {
+		validTo = *newPlan.ValidTo
+	}
+
 	err := db.Raw(query,
 		newPlan.BlockID, newPlan.ID,
 		newPlan.SlotStart, newPlan.SlotEnd,
 		newPlan.RowStart, newPlan.RowEnd,
 		newPlan.ContainerSize, newPlan.ContainerHeight, newPlan.ContainerType,
+		validityUpperBound, validFrom,
+		validityLowerBound, validTo,
 	).Scan(plans).Error
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -109,14 +224,16 @@ func (r *YardPlanRepositoryImpl) FindOverlappingPlans(db *gorm.DB, plans *[]mode
 	return nil
 }
 
-func (r *YardPlanRepositoryImpl) FindApplicablePlan(db *gorm.DB, blockID, slot, row int, size, height, cType string) (*model.YardPlan, error) {
+func (r *YardPlanRepositoryImpl) FindApplicablePlan(db *gorm.DB, blockID, slot, row int, size, height, cType string, at time.Time) (*model.YardPlan, error) {
 	var planResult model.YardPlan
 
 	query := `
 		SELECT * FROM yard_plans 
 		WHERE 
 			block_id = ? AND 
-			is_active = TRUE AND 
+			status = 'PUBLISHED' AND 
+			(valid_from IS NULL OR valid_from <= ?) AND 
+			(valid_to IS NULL OR valid_to > ?) AND 
 			
 			-- Cek Koordinat: Slot harus berada di antara start dan end
 			slot_start <= ? AND 
@@ -133,6 +250,7 @@ func (r *YardPlanRepositoryImpl) FindApplicablePlan(db *gorm.DB, blockID, slot,
 
 	err := db.Raw(query,
 		blockID,
+		at, at,
 		slot, slot,
 		row, row,
 		size, height, cType,
@@ -147,3 +265,26 @@ func (r *YardPlanRepositoryImpl) FindApplicablePlan(db *gorm.DB, blockID, slot,
 
 	return &planResult, nil
 }
+
+// SyncActiveFlags sets is_active on every plan according to its status and
+// validity window at the given time, returning the plans that changed.
+func (r *YardPlanRepositoryImpl) SyncActiveFlags(db *gorm.DB, changed *[]model.YardPlan, at time.Time) error {
+	query := `
+		UPDATE yard_plans
+		SET is_active = active.value, updated_at = ?, revision = yard_plans.revision + 1
+		FROM (
+			SELECT id, (status = 'PUBLISHED'
+				AND (valid_from IS NULL OR valid_from <= ?)
+				AND (valid_to IS NULL OR valid_to > ?)) AS value
+			FROM yard_plans
+		) AS active
+		WHERE yard_plans.id = active.id AND yard_plans.is_active <> active.value
+		RETURNING yard_plans.*`
+
+	err := db.Raw(query, at, at, at).Scan(changed).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
@@ -11,11 +11,16 @@ type YardRepository interface {
 	SaveYard(db *gorm.DB, yard *model.Yard) error
 	FindYardByName(db *gorm.DB, yardResult *model.Yard, name string) error
 	FindYardByID(db *gorm.DB, yardResult *model.Yard, yardID int) error
+	FindAllYards(db *gorm.DB, yardResults *[]model.Yard) error
 	DeleteYard(db *gorm.DB, yardID int) error
 
 	FindBlockByNameAndYardID(db *gorm.DB, blockResult *model.Block, blockName string, yardID int) error
 	FindBlocksByYardID(db *gorm.DB, blockResults *[]model.Block, yardID int) error
 	FindBlockByID(db *gorm.DB, blockResult *model.Block, blockID int) error
+	// LockYardBlocks locks the blocks of the yard until the transaction db
+	// ends. Everything that picks a free cell of the yard and reserves it
+	// takes the lock first, so two callers cannot hand out the same cell.
+	LockYardBlocks(db *gorm.DB, yardID int) error
 }
 
 type YardRepositoryImpl struct {
@@ -26,8 +31,8 @@ func NewYardRepository() YardRepository {
 }
 
 func (r *YardRepositoryImpl) SaveYard(db *gorm.DB, yard *model.Yard) error {
-	query := `INSERT INTO yards (name, location, created_at, updated_at) VALUES (?, ?, ?, ?)`
-	result := db.Exec(query, yard.Name, yard.Location, yard.CreatedAt, yard.UpdatedAt)
+	query := `INSERT INTO yards (name, location, timezone, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`
+	result := db.Exec(query, yard.Name, yard.Location, yard.Timezone, yard.CreatedAt, yard.UpdatedAt)
 
 	if result.RowsAffected == 0 {
 		return errors.New("failed to insert yard")
@@ -55,6 +60,15 @@ func (r *YardRepositoryImpl) FindYardByID(db *gorm.DB, yardResult *model.Yard, y
 	return err
 }
 
+func (r *YardRepositoryImpl) FindAllYards(db *gorm.DB, yardResults *[]model.Yard) error {
+	err := db.Raw("SELECT * FROM yards ORDER BY id ASC").Scan(yardResults).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
 func (r *YardRepositoryImpl) DeleteYard(db *gorm.DB, yardID int) error {
 	result := db.Exec("DELETE FROM yards WHERE id = ?", yardID)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
@@ -95,3 +109,13 @@ func (r *YardRepositoryImpl) FindBlockByID(db *gorm.DB, blockResult *model.Block
 
 	return nil
 }
+
+func (r *YardRepositoryImpl) LockYardBlocks(db *gorm.DB, yardID int) error {
+	var blockIDs []int
+	err := db.Raw("SELECT id FROM blocks WHERE yard_id = ? ORDER BY id ASC FOR UPDATE", yardID).Scan(&blockIDs).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	auditLogDefaultLimit = 100
+	// AuditPayloadLimit is the largest request body stored in the log.
+	AuditPayloadLimit = 64 << 10
+	auditMask         = "***"
+)
+
+// auditSecretWords mask every key of a logged JSON body that has one of
+// them as a word, at any depth: pin and release_pin but not shipping_line.
+var auditSecretWords = []string{"password", "passwd", "secret", "token", "pin", "credential", "credentials", "authorization", "apikey"}
+
+type AuditLogService interface {
+	// Record stores the audit log of a mutating request.
+	Record(ctx context.Context, request *web.AuditLogRequest) *response.CustomError
+	FindLogs(ctx context.Context, query *web.AuditLogQuery) ([]web.AuditLogResponse, *response.CustomError)
+}
+
+type AuditLogServiceImpl struct {
+	AuditLogRepository repository.AuditLogRepository
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+}
+
+func NewAuditLogService(auditLogRepo repository.AuditLogRepository, DB *gorm.DB, validate *validator.Validate) AuditLogService {
+	return &AuditLogServiceImpl{
+		AuditLogRepository: auditLogRepo,
+		DB:                 DB,
+		Validate:           validate,
+	}
+}
+
+func (s *AuditLogServiceImpl) Record(ctx context.Context, request *web.AuditLogRequest) *response.CustomError {
+	log := model.AuditLog{
+		Actor:          request.Actor,
+		Method:         request.Method,
+		Endpoint:       request.Endpoint,
+		Path:           request.Path,
+		RequestPayload: auditPayload(request),
+		ClientIP:       request.ClientIP,
+		StatusCode:     request.StatusCode,
+		CreatedAt:      time.Now(),
+	}
+
+	for _, change := range request.Changes {
+		log.Changes = append(log.Changes, model.AuditLogChange{
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			Before:     rawText(change.Before),
+			After:      rawText(change.After),
+		})
+	}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.AuditLogRepository.Save(tx, &log)
+	})
+	if txErr != nil {
+		return response.RepositoryError("Failed to write audit log: " + txErr.Error())
+	}
+	return nil
+}
+
+func (s *AuditLogServiceImpl) FindLogs(ctx context.Context, query *web.AuditLogQuery) ([]web.AuditLogResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return nil, response.BadRequestError("to must be after from.")
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = auditLogDefaultLimit
+	}
+
+	var logs []model.AuditLog
+	if err := s.AuditLogRepository.Find(s.DB, &logs, query.EntityType, query.EntityID, query.Actor, query.From, query.To, limit); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit logs: " + err.Error())
+	}
+
+	logIDs := make([]int, 0, len(logs))
+	for _, log := range logs {
+		logIDs = append(logIDs, log.ID)
+	}
+
+	var changes []model.AuditLogChange
+	if err := s.AuditLogRepository.FindChanges(s.DB, &changes, logIDs); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit log changes: " + err.Error())
+	}
+
+	changesByLog := make(map[int][]web.AuditLogChangeResponse, len(logs))
+	for _, change := range changes {
+		changesByLog[change.AuditLogID] = append(changesByLog[change.AuditLogID], web.AuditLogChangeResponse{
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			Before:     rawJSON(change.Before),
+			After:      rawJSON(change.After),
+		})
+	}
+
+	logResponses := make([]web.AuditLogResponse, 0, len(logs))
+	for _, log := range logs {
+		logChanges := changesByLog[log.ID]
+		if logChanges == nil {
+			logChanges = []web.AuditLogChangeResponse{}
+		}
+
+		logResponses = append(logResponses, web.AuditLogResponse{
+			ID:             log.ID,
+			Actor:          log.Actor,
+			Method:         log.Method,
+			Endpoint:       log.Endpoint,
+			Path:           log.Path,
+			RequestPayload: rawJSON(&log.RequestPayload),
+			ClientIP:       log.ClientIP,
+			StatusCode:     log.StatusCode,
+			CreatedAt:      log.CreatedAt,
+			Changes:        logChanges,
+		})
+	}
+	return logResponses, nil
+}
+
+// auditPayload returns the body to log as JSON. JSON bodies are stored with
+// their secrets masked, other and oversized bodies only by type and size.
+func auditPayload(request *web.AuditLogRequest) string {
+	if request.BodySize == 0 {
+		return ""
+	}
+
+	if strings.HasPrefix(request.ContentType, "application/json") && request.BodySize <= AuditPayloadLimit {
+		var body any
+		decoder := json.NewDecoder(bytes.NewReader(request.Body))
+		decoder.UseNumber()
+		if decoder.Decode(&body) == nil {
+			if masked, err := json.Marshal(maskSecrets(body)); err == nil {
+				return string(masked)
+			}
+		}
+	}
+
+	summary, _ := json.Marshal(map[string]any{
+		"content_type": request.ContentType,
+		"size":         request.BodySize,
+	})
+	return string(summary)
+}
+
+// maskSecrets replaces the values of secret keys at any depth.
+func maskSecrets(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if isSecretKey(key) {
+				value[key] = auditMask
+			} else {
+				value[key] = maskSecrets(field)
+			}
+		}
+	case []any:
+		for i := range value {
+			value[i] = maskSecrets(value[i])
+		}
+	}
+	return value
+}
+
+func isSecretKey(key string) bool {
+	words := keyWords(key)
+	for i, word := range words {
+		// Two words are also tried joined, api_key is an apikey
+		if slices.Contains(auditSecretWords, word) ||
+			(i+1 < len(words) && slices.Contains(auditSecretWords, word+words[i+1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyWords splits a JSON key into lower case words at every character that
+// is not a letter or digit and where camel case starts a new word.
+func keyWords(key string) []string {
+	var words []string
+	var word strings.Builder
+	lower := false
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+			lower = false
+			continue
+		}
+		if unicode.IsUpper(r) && lower && word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+		lower = unicode.IsLower(r) || unicode.IsDigit(r)
+		word.WriteRune(unicode.ToLower(r))
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+func rawText(data json.RawMessage) *string {
+	if len(data) == 0 {
+		return nil
+	}
+	text := string(data)
+	return &text
+}
+
+func rawJSON(text *string) json.RawMessage {
+	if text == nil || *text == "" {
+		return nil
+	}
+	return json.RawMessage(*text)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"yard-planning/app/web"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"password", `{"username":"op","password":"hunter2"}`, `{"password":"***","username":"op"}`},
+		{"key case and suffix", `{"Secret":"s","refresh_token":"t","API_TOKEN":"u"}`, `{"API_TOKEN":"***","Secret":"***","refresh_token":"***"}`},
+		{"nested object", `{"webhook":{"url":"https://example.com","secret":"s"}}`, `{"webhook":{"secret":"***","url":"https://example.com"}}`},
+		{"objects in an array", `[{"password":"a"},{"name":"b"}]`, `[{"password":"***"},{"name":"b"}]`},
+		{"secret object masked whole", `{"secret":{"key":"s"}}`, `{"secret":"***"}`},
+		{"numbers kept as sent", `{"slot":3,"weight":12.50}`, `{"slot":3,"weight":12.50}`},
+		{"no secrets", `{"container_number":"MSKU0000001"}`, `{"container_number":"MSKU0000001"}`},
+		{"release PIN", `{"release_number":"RO-1","pin":"482913"}`, `{"pin":"***","release_number":"RO-1"}`},
+		{"credential-like keys", `{"apiKey":"k","api_key":"k","Authorization":"Bearer t","credentials":{"user":"u"}}`,
+			`{"Authorization":"***","apiKey":"***","api_key":"***","credentials":"***"}`},
+		{"pin inside other words kept", `{"shipping_line":"MSC","pinned":true}`, `{"pinned":true,"shipping_line":"MSC"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &web.AuditLogRequest{ContentType: "application/json", Body: []byte(tt.body), BodySize: int64(len(tt.body))}
+			if got := auditPayload(request); got != tt.want {
+				t.Errorf("auditPayload = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditPayload(t *testing.T) {
+	oversized := `{"note":"` + strings.Repeat("x", AuditPayloadLimit) + `"}`
+
+	tests := []struct {
+		name    string
+		request web.AuditLogRequest
+		want    string
+	}{
+		{"no body", web.AuditLogRequest{ContentType: "application/json"}, ""},
+		{"JSON with charset", web.AuditLogRequest{ContentType: "application/json; charset=utf-8", Body: []byte(`{"token":"t"}`), BodySize: 13}, `{"token":"***"}`},
+		{"invalid JSON", web.AuditLogRequest{ContentType: "application/json", Body: []byte(`{"password":`), BodySize: 12}, `{"content_type":"application/json","size":12}`},
+		{"oversized JSON", web.AuditLogRequest{ContentType: "application/json", Body: []byte(oversized[:AuditPayloadLimit]), BodySize: int64(len(oversized))},
+			`{"content_type":"application/json","size":65547}`},
+		{"spreadsheet upload", web.AuditLogRequest{ContentType: "multipart/form-data; boundary=x", Body: []byte("--x"), BodySize: 2048},
+			`{"content_type":"multipart/form-data; boundary=x","size":2048}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auditPayload(&tt.request); got != tt.want {
+				t.Errorf("auditPayload = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"pin", true},
+		{"PIN", true},
+		{"release_pin", true},
+		{"releasePin", true},
+		{"password", true},
+		{"newPassword", true},
+		{"refresh-token", true},
+		{"X-API-Key", true},
+		{"client_secret", true},
+		{"shipping_line", false},
+		{"pinned", false},
+		{"spinner", false},
+		{"key", false},
+		{"idempotency_key", false},
+		{"tokens_used", false},
+		{"container_number", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isSecretKey(tt.key); got != tt.want {
+			t.Errorf("isSecretKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestAuditPayloadMasksReleasePIN(t *testing.T) {
+	pickup := web.PickupRequest{
+		YardName:        "YRD-UTAMA",
+		ContainerNumber: "MSKU0000001",
+		ReleaseNumber:   "RO-2026-0042",
+		PIN:             "482913",
+		TruckingCompany: "PT Angkut Jaya",
+	}
+
+	tests := []struct {
+		name string
+		body any
+	}{
+		{"pickup", pickup},
+		{"gate out", web.GateOutRequest{PickupRequest: pickup}},
+		{"release order", web.ReleaseOrderRequest{ReleaseNumber: "RO-2026-0042", PIN: "482913", TruckingCompany: "PT Angkut Jaya",
+			ValidUntil: time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC), ContainerNumbers: []string{"MSKU0000001"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload := auditPayload(&web.AuditLogRequest{ContentType: "application/json", Body: body, BodySize: int64(len(body))})
+			if strings.Contains(payload, "482913") {
+				t.Errorf("payload %s contains the PIN", payload)
+			}
+
+			var logged map[string]any
+			if err := json.Unmarshal([]byte(payload), &logged); err != nil {
+				t.Fatal(err)
+			}
+			if logged["pin"] != auditMask || logged["release_number"] != "RO-2026-0042" {
+				t.Errorf("payload %s, want the PIN masked and the release number kept", payload)
+			}
+		})
+	}
+}
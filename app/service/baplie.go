@@ -0,0 +1,318 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/web"
+	"yard-planning/helper/edifact"
+)
+
+// bapliePlan is the stowage plan of one BAPLIE message read by parseBAPLIE.
+type bapliePlan struct {
+	// SMDG version of the message, e.g. SMDG20 or SMDG31
+	Version      string
+	Vessel       string
+	Voyage       string
+	ShippingLine string
+	// Estimated arrival of the vessel, DTM 132 (DTM 178 when missing)
+	Arrival    *time.Time
+	Containers []baplieContainer
+}
+
+type baplieContainer struct {
+	// Bay-row-tier stowage position, LOC 147
+	Position        string
+	ContainerNumber string
+	SizeType        string
+	FullEmpty       string
+	// Container operator, NAD CA in SMDG 2.x and NAD CF in 3.x
+	Operator string
+
+	GrossWeight    int
+	LoadPort       string
+	DischargePort  string
+	DangerousGoods []baplieDangerousGood
+	verifiedMass   bool
+}
+
+type baplieDangerousGood struct {
+	IMDGClass string
+	UNNumber  string
+}
+
+// parseBAPLIE reads the stowage plans of a BAPLIE interchange, in the SMDG
+// 2.x (D.95B) or 3.x (D.13B) layout. Both start a stowage group with LOC+147,
+// the cargo segments may come before or after the EQD of the container. A
+// second EQD in the same group, e.g. bundled flat racks, shares the position
+// and ports.
+// Slots without an EQD are skipped. Dates are read in loc.
+func parseBAPLIE(data string, loc *time.Location) ([]bapliePlan, error) {
+	segments, err := edifact.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []bapliePlan
+	var current *bapliePlan
+	var slot *baplieContainer
+	var slots []baplieContainer
+
+	// Only stowage groups holding a container end up in the plan
+	flush := func() {
+		if current == nil {
+			return
+		}
+		for _, container := range slots {
+			if container.ContainerNumber != "" {
+				current.Containers = append(current.Containers, container)
+			}
+		}
+		slots, slot = nil, nil
+	}
+
+	for i := range segments {
+		segment := &segments[i]
+
+		switch segment.Tag {
+		case "UNH":
+			if messageType := segment.Value(1, 0); messageType != "BAPLIE" {
+				return nil, errors.New("message " + segment.Value(0, 0) + " is " + messageType + ", not BAPLIE")
+			}
+			flush()
+			plans = append(plans, bapliePlan{Version: segment.Value(1, 4)})
+			current = &plans[len(plans)-1]
+			continue
+		case "UNT":
+			flush()
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch segment.Tag {
+		case "TDT":
+			if segment.Value(0, 0) == "20" {
+				current.Voyage = segment.Value(1, 0)
+				current.ShippingLine = segment.Value(4, 0)
+				current.Vessel = segment.Value(7, 3)
+			}
+		case "RFF":
+			if segment.Value(0, 0) == "VON" && current.Voyage == "" {
+				current.Voyage = segment.Value(0, 1)
+			}
+		case "DTM":
+			// Only the vessel arrival of the header, before the first stowage group
+			qualifier := segment.Value(0, 0)
+			if slots != nil || (qualifier != "132" && qualifier != "178") || (qualifier == "178" && current.Arrival != nil) {
+				continue
+			}
+			arrival, err := parseEDIDateTime(segment.Value(0, 1), segment.Value(0, 2), loc)
+			if err != nil {
+				return nil, err
+			}
+			current.Arrival = &arrival
+		case "LOC":
+			if segment.Value(0, 0) == "147" {
+				flush()
+				slots = []baplieContainer{{Position: segment.Value(1, 0)}}
+				slot = &slots[0]
+				continue
+			}
+			if slot == nil {
+				continue
+			}
+			switch segment.Value(0, 0) {
+			case "9":
+				slot.LoadPort = segment.Value(1, 0)
+			case "11", "12":
+				slot.DischargePort = segment.Value(1, 0)
+			}
+		case "MEA":
+			if slot == nil {
+				continue
+			}
+			// SMDG 2.x sends MEA+WT++KGM:22000, 3.x MEA+AAE+VGM+KGM:22000.
+			// The verified gross mass wins over any other weight.
+			verified := segment.Value(1, 0) == "VGM"
+			if slot.GrossWeight != 0 && (slot.verifiedMass || !verified) {
+				continue
+			}
+			if weight, ok := baplieWeight(segment.Value(2, 0), segment.Value(2, 1)); ok {
+				slot.GrossWeight = weight
+				slot.verifiedMass = verified
+			}
+		case "DGS":
+			if slot != nil && segment.Value(0, 0) == "IMD" {
+				slot.DangerousGoods = append(slot.DangerousGoods, baplieDangerousGood{
+					IMDGClass: segment.Value(1, 0),
+					UNNumber:  segment.Value(2, 0),
+				})
+			}
+		case "EQD":
+			if slot == nil || segment.Value(0, 0) != "CN" {
+				continue
+			}
+			if slot.ContainerNumber != "" {
+				slots = append(slots, baplieContainer{Position: slot.Position, LoadPort: slot.LoadPort, DischargePort: slot.DischargePort})
+				slot = &slots[len(slots)-1]
+			}
+			slot.ContainerNumber = segment.Value(1, 0)
+			slot.SizeType = segment.Value(2, 0)
+			slot.FullEmpty = segment.Value(5, 0)
+		case "NAD":
+			if qualifier := segment.Value(0, 0); slot != nil && (qualifier == "CA" || qualifier == "CF") {
+				slot.Operator = segment.Value(1, 0)
+			}
+		}
+	}
+
+	if len(plans) == 0 {
+		return nil, errors.New("no BAPLIE message found")
+	}
+	return plans, nil
+}
+
+// baplieWeight converts a MEA value to whole kilograms, pounds included.
+func baplieWeight(unit, value string) (int, bool) {
+	weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || weight < 0 {
+		return 0, false
+	}
+
+	switch unit {
+	case "KGM":
+	case "TNE":
+		weight *= 1000
+	case "LBR":
+		weight *= 0.45359237
+	default:
+		return 0, false
+	}
+	return int(weight + 0.5), true
+}
+
+// baplieHazardousType is the yard plan type of dry containers with dangerous
+// goods, they are stacked in the hazmat area.
+const baplieHazardousType = "HAZMAT"
+
+// bapliePreAdvice turns a container discharged in this port into a pre-advice
+// request. The container operator is the shipping line, falling back to the
+// carrier of the vessel.
+func bapliePreAdvice(yardName string, plan *bapliePlan, container *baplieContainer, expectedFrom, expectedTo time.Time) web.PreAdviceRequest {
+	size, height, containerType := containerSpec(container.SizeType, container.FullEmpty)
+	if containerType == "DRY" && len(container.DangerousGoods) > 0 {
+		containerType = baplieHazardousType
+	}
+
+	shippingLine := container.Operator
+	if shippingLine == "" {
+		shippingLine = plan.ShippingLine
+	}
+
+	goods := make([]web.DangerousGoodRequest, 0, len(container.DangerousGoods))
+	for _, good := range container.DangerousGoods {
+		goods = append(goods, web.DangerousGoodRequest{IMDGClass: good.IMDGClass, UNNumber: good.UNNumber})
+	}
+
+	return web.PreAdviceRequest{
+		ContainerRequest: web.ContainerRequest{
+			YardName:        yardName,
+			ContainerNumber: container.ContainerNumber,
+			Size:            size,
+			Height:          height,
+			Type:            containerType,
+		},
+		Vessel:          plan.Vessel,
+		Voyage:          plan.Voyage,
+		ShippingLine:    shippingLine,
+		GrossWeight:     container.GrossWeight,
+		PortOfDischarge: strings.ToUpper(container.DischargePort),
+		DangerousGoods:  goods,
+		ExpectedFrom:    expectedFrom,
+		ExpectedTo:      expectedTo,
+	}
+}
+
+// baplieForecast sets the incoming containers against the free space of the
+// active yard plans, in the order the stowage plan lists them. A container
+// goes to the first plan of its specification with room left, plans of one
+// block may overlap so the free space of the specification caps what the
+// plans together take. The yard has room when every container found a plan.
+func baplieForecast(yard *web.YardCapacityReport, preAdvices []web.PreAdviceRequest) *web.BAPLIEForecastResponse {
+	specFree := make(map[capacitySpec]int, len(yard.BySpec))
+	for _, spec := range yard.BySpec {
+		specFree[capacitySpec{spec.ContainerSize, spec.ContainerHeight, spec.ContainerType}] += spec.FreeTEU
+	}
+
+	// A yard plan covers a single block, the report lists it once
+	var plans []*web.BAPLIEForecast
+	for _, block := range yard.Blocks {
+		for _, plan := range block.Plans {
+			plans = append(plans, &web.BAPLIEForecast{
+				YardPlanID:      plan.YardPlanID,
+				PlanName:        plan.PlanName,
+				ContainerSize:   plan.ContainerSize,
+				ContainerHeight: plan.ContainerHeight,
+				ContainerType:   plan.ContainerType,
+				FreeTEU:         plan.FreeTEU,
+			})
+		}
+	}
+
+	var unplanned []capacitySpec
+	short := make(map[capacitySpec]*web.BAPLIEForecast)
+	for _, preAdvice := range preAdvices {
+		spec := capacitySpec{preAdvice.Size, preAdvice.Height, preAdvice.Type}
+		teu := containerTEU(spec.size)
+
+		var forecast *web.BAPLIEForecast
+		if specFree[spec] >= teu {
+			for _, plan := range plans {
+				if plan.ContainerSize == spec.size && plan.ContainerHeight == spec.height && plan.ContainerType == spec.cType &&
+					plan.FreeTEU-plan.IncomingTEU >= teu {
+					forecast = plan
+					specFree[spec] -= teu
+					break
+				}
+			}
+		}
+		if forecast == nil {
+			forecast = short[spec]
+			if forecast == nil {
+				forecast = &web.BAPLIEForecast{ContainerSize: spec.size, ContainerHeight: spec.height, ContainerType: spec.cType}
+				short[spec] = forecast
+				unplanned = append(unplanned, spec)
+			}
+		}
+		forecast.Containers++
+		forecast.IncomingTEU += teu
+	}
+
+	forecastResponse := &web.BAPLIEForecastResponse{
+		Yard:    yard.Yard,
+		FreeTEU: yard.FreeTEU,
+		HasRoom: len(unplanned) == 0,
+		ByPlan:  make([]web.BAPLIEForecast, 0),
+	}
+	add := func(forecast *web.BAPLIEForecast) {
+		forecast.RemainingTEU = forecast.FreeTEU - forecast.IncomingTEU
+		forecast.HasRoom = forecast.RemainingTEU >= 0
+
+		forecastResponse.IncomingTEU += forecast.IncomingTEU
+		forecastResponse.ByPlan = append(forecastResponse.ByPlan, *forecast)
+	}
+	for _, plan := range plans {
+		if plan.Containers > 0 {
+			add(plan)
+		}
+	}
+	for _, spec := range unplanned {
+		add(short[spec])
+	}
+	return forecastResponse
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"yard-planning/app/web"
+)
+
+func TestParseBAPLIE(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name    string
+		data    string
+		want    []bapliePlan
+		wantErr bool
+	}{
+		{
+			name: "SMDG 2.x with cargo before EQD",
+			data: "UNB+UNOA:2+MSCU+IDTPKYARD+261019:0800+1'\n" +
+				"UNH+1+BAPLIE:D:95B:UN:SMDG20'\n" +
+				"BGM++123E+9'\n" +
+				"DTM+137:202610190800:203'\n" +
+				"TDT+20+123E+++MSC:172:20+++9XYZ:103::MV SINAR BUNDA'\n" +
+				"DTM+132:202610210600:203'\n" +
+				"LOC+147+0010182::5'\n" +
+				"MEA+WT++KGM:22000'\n" +
+				"LOC+9+SGSIN'\n" +
+				"LOC+11+IDTPK'\n" +
+				"EQD+CN+MSCU1234567+45G1+++5'\n" +
+				"NAD+CA+MAE:172:20'\n" +
+				"LOC+147+0020182::5'\n" +
+				"MEA+WT++LBR:1000'\n" +
+				"LOC+11+IDJKT'\n" +
+				"EQD+CN+MSCU7654321+22G1+++4'\n" +
+				"UNT+17+1'\n" +
+				"UNZ+1+1'",
+			want: []bapliePlan{{
+				Version:      "SMDG20",
+				Vessel:       "MV SINAR BUNDA",
+				Voyage:       "123E",
+				ShippingLine: "MSC",
+				Arrival:      timePtr(time.Date(2026, time.October, 21, 6, 0, 0, 0, jakarta)),
+				Containers: []baplieContainer{
+					{Position: "0010182", ContainerNumber: "MSCU1234567", SizeType: "45G1", FullEmpty: "5", Operator: "MAE", GrossWeight: 22000, LoadPort: "SGSIN", DischargePort: "IDTPK"},
+					{Position: "0020182", ContainerNumber: "MSCU7654321", SizeType: "22G1", FullEmpty: "4", GrossWeight: 454, DischargePort: "IDJKT"},
+				},
+			}},
+		},
+		{
+			name: "SMDG 3.x with cargo after EQD and VGM first",
+			data: "UNH+1+BAPLIE:D:13B:UN:SMDG31'" +
+				"TDT+20++++MSC'" +
+				"RFF+VON:456W'" +
+				"DTM+178:20261022:102'" +
+				"LOC+147+0010182::5'" +
+				"EQD+CN+MSCU1111111:6346:5+45G1:6346:5+++5'" +
+				"MEA+AAE+VGM+KGM:24100'" +
+				"MEA+AAE+AET+KGM:20000'" +
+				"LOC+11+IDTPK:139:6'" +
+				"DGS+IMD+8+1789'" +
+				"DGS+IMD+3+1203'" +
+				"NAD+CF+ONE'" +
+				"UNT+11+1'",
+			want: []bapliePlan{{
+				Version:      "SMDG31",
+				Voyage:       "456W",
+				ShippingLine: "MSC",
+				Arrival:      timePtr(time.Date(2026, time.October, 22, 0, 0, 0, 0, jakarta)),
+				Containers: []baplieContainer{{
+					Position:        "0010182",
+					ContainerNumber: "MSCU1111111",
+					SizeType:        "45G1",
+					FullEmpty:       "5",
+					Operator:        "ONE",
+					GrossWeight:     24100,
+					DischargePort:   "IDTPK",
+					DangerousGoods:  []baplieDangerousGood{{IMDGClass: "8", UNNumber: "1789"}, {IMDGClass: "3", UNNumber: "1203"}},
+					verifiedMass:    true,
+				}},
+			}},
+		},
+		{
+			name: "bundled flat racks share the slot and empty slots are skipped",
+			data: "UNH+1+BAPLIE:D:95B:UN:SMDG20'" +
+				"LOC+147+0010182'" +
+				"LOC+11+IDTPK'" +
+				"EQD+CN+MSCU0000001+42P1+++4'" +
+				"EQD+CN+MSCU0000002+42P1+++4'" +
+				"LOC+147+0030182'" +
+				"LOC+11+IDTPK'" +
+				"UNT+7+1'",
+			want: []bapliePlan{{
+				Version: "SMDG20",
+				Containers: []baplieContainer{
+					{Position: "0010182", ContainerNumber: "MSCU0000001", SizeType: "42P1", FullEmpty: "4", DischargePort: "IDTPK"},
+					{Position: "0010182", ContainerNumber: "MSCU0000002", SizeType: "42P1", FullEmpty: "4", DischargePort: "IDTPK"},
+				},
+			}},
+		},
+		{
+			name: "DTM inside a stowage group is not the arrival",
+			data: "UNH+1+BAPLIE:D:95B:UN:SMDG20'" +
+				"LOC+147+0010182'" +
+				"DTM+132:202610210600:203'" +
+				"EQD+CN+MSCU1234567+22G1+++5'" +
+				"UNT+5+1'",
+			want: []bapliePlan{{
+				Version:    "SMDG20",
+				Containers: []baplieContainer{{Position: "0010182", ContainerNumber: "MSCU1234567", SizeType: "22G1", FullEmpty: "5"}},
+			}},
+		},
+		{name: "other message type", data: "UNH+1+COARRI:D:95B:UN'UNT+2+1'", wantErr: true},
+		{name: "invalid arrival", data: "UNH+1+BAPLIE:D:95B:UN:SMDG20'DTM+132:21-10-2026:203'UNT+3+1'", wantErr: true},
+		{name: "no message", data: "UNB+UNOA:2+MSCU+IDTPKYARD'UNZ+0+1'", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans, err := parseBAPLIE(tt.data, jakarta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBAPLIE error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(plans, tt.want) {
+				t.Errorf("parseBAPLIE =\n%+v\nwant\n%+v", plans, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaplieWeight(t *testing.T) {
+	tests := []struct {
+		unit, value string
+		want        int
+		wantOK      bool
+	}{
+		{"KGM", "22000", 22000, true},
+		{"KGM", " 2150.6 ", 2151, true},
+		{"TNE", "24.1", 24100, true},
+		{"LBR", "1000", 454, true},
+		{"GRM", "1000", 0, false},
+		{"KGM", "-5", 0, false},
+		{"KGM", "heavy", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := baplieWeight(tt.unit, tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("baplieWeight(%q, %q) = %d, %v, want %d, %v", tt.unit, tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBapliePreAdvice(t *testing.T) {
+	from := time.Date(2026, time.October, 21, 6, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	plan := &bapliePlan{Vessel: "MV SINAR BUNDA", Voyage: "123E", ShippingLine: "MSC"}
+
+	tests := []struct {
+		name      string
+		container baplieContainer
+		wantType  string
+		wantLine  string
+		wantGoods []web.DangerousGoodRequest
+	}{
+		{
+			name:      "dry container of the carrier",
+			container: baplieContainer{ContainerNumber: "MSCU1234567", SizeType: "45G1", FullEmpty: "5", DischargePort: "idtpk"},
+			wantType:  "DRY",
+			wantLine:  "MSC",
+			wantGoods: []web.DangerousGoodRequest{},
+		},
+		{
+			name: "dry container with dangerous goods goes to hazmat",
+			container: baplieContainer{ContainerNumber: "MSCU1234567", SizeType: "22G1", FullEmpty: "5", DischargePort: "IDTPK", Operator: "ONE",
+				DangerousGoods: []baplieDangerousGood{{IMDGClass: "3", UNNumber: "1203"}}},
+			wantType:  baplieHazardousType,
+			wantLine:  "ONE",
+			wantGoods: []web.DangerousGoodRequest{{IMDGClass: "3", UNNumber: "1203"}},
+		},
+		{
+			name: "reefer with dangerous goods keeps its type",
+			container: baplieContainer{ContainerNumber: "MSCU1234567", SizeType: "45R1", FullEmpty: "5", DischargePort: "IDTPK",
+				DangerousGoods: []baplieDangerousGood{{IMDGClass: "2.2", UNNumber: "1977"}}},
+			wantType:  "Reefer",
+			wantLine:  "MSC",
+			wantGoods: []web.DangerousGoodRequest{{IMDGClass: "2.2", UNNumber: "1977"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preAdvice := bapliePreAdvice("YRD-IMPORT", plan, &tt.container, from, to)
+
+			if preAdvice.YardName != "YRD-IMPORT" || preAdvice.ContainerNumber != tt.container.ContainerNumber {
+				t.Errorf("container = %s in %s, want %s in YRD-IMPORT", preAdvice.ContainerNumber, preAdvice.YardName, tt.container.ContainerNumber)
+			}
+			if preAdvice.Type != tt.wantType || preAdvice.ShippingLine != tt.wantLine {
+				t.Errorf("type %q of %q, want %q of %q", preAdvice.Type, preAdvice.ShippingLine, tt.wantType, tt.wantLine)
+			}
+			if preAdvice.PortOfDischarge != "IDTPK" || preAdvice.Vessel != plan.Vessel || preAdvice.Voyage != plan.Voyage {
+				t.Errorf("discharge %s from %s/%s, want IDTPK from %s/%s", preAdvice.PortOfDischarge, preAdvice.Vessel, preAdvice.Voyage, plan.Vessel, plan.Voyage)
+			}
+			if !reflect.DeepEqual(preAdvice.DangerousGoods, tt.wantGoods) {
+				t.Errorf("dangerous goods = %+v, want %+v", preAdvice.DangerousGoods, tt.wantGoods)
+			}
+			if !preAdvice.ExpectedFrom.Equal(from) || !preAdvice.ExpectedTo.Equal(to) {
+				t.Errorf("expected %v - %v, want %v - %v", preAdvice.ExpectedFrom, preAdvice.ExpectedTo, from, to)
+			}
+		})
+	}
+}
+
+func TestBaplieForecast(t *testing.T) {
+	plan := func(id int, name, size, containerType string, free int) web.PlanCapacityReport {
+		return web.PlanCapacityReport{
+			YardPlanID:      id,
+			PlanName:        name,
+			ContainerSize:   size,
+			ContainerHeight: "8.6ft",
+			ContainerType:   containerType,
+			CapacityFigures: web.CapacityFigures{FreeTEU: free},
+		}
+	}
+	spec := func(size, containerType string, free int) web.CapacitySpecReport {
+		return web.CapacitySpecReport{ContainerSize: size, ContainerHeight: "8.6ft", ContainerType: containerType, CapacityFigures: web.CapacityFigures{FreeTEU: free}}
+	}
+	incoming := func(size, containerType string, count int) []web.PreAdviceRequest {
+		preAdvices := make([]web.PreAdviceRequest, count)
+		for i := range preAdvices {
+			preAdvices[i].Size, preAdvices[i].Height, preAdvices[i].Type = size, "8.6ft", containerType
+		}
+		return preAdvices
+	}
+
+	yard := &web.YardCapacityReport{
+		Yard:            "YRD-IMPORT",
+		CapacityFigures: web.CapacityFigures{FreeTEU: 9},
+		BySpec:          []web.CapacitySpecReport{spec("20ft", "DRY", 5), spec("40ft", "Reefer", 4)},
+		Blocks: []web.BlockCapacityReport{
+			{Plans: []web.PlanCapacityReport{plan(1, "DRY-A", "20ft", "DRY", 2), plan(2, "REEFER-A", "40ft", "Reefer", 4)}},
+			{Plans: []web.PlanCapacityReport{plan(3, "DRY-B", "20ft", "DRY", 3)}},
+		},
+	}
+	// Both plans of block LC01 cover the same cells, the specification has
+	// only 2 TEU free
+	overlapping := &web.YardCapacityReport{
+		Yard:            "YRD-IMPORT",
+		CapacityFigures: web.CapacityFigures{FreeTEU: 2},
+		BySpec:          []web.CapacitySpecReport{spec("20ft", "DRY", 2)},
+		Blocks: []web.BlockCapacityReport{
+			{Plans: []web.PlanCapacityReport{plan(1, "DRY-A", "20ft", "DRY", 2), plan(2, "DRY-ALL", "20ft", "DRY", 2)}},
+		},
+	}
+
+	// want lists plan, containers and remaining TEU per row
+	tests := []struct {
+		name        string
+		yard        *web.YardCapacityReport
+		preAdvices  []web.PreAdviceRequest
+		want        [][3]int
+		wantHasRoom bool
+	}{
+		{"first plan takes what fits", yard, incoming("20ft", "DRY", 2), [][3]int{{1, 2, 0}}, true},
+		{"next plan takes the rest", yard, incoming("20ft", "DRY", 4), [][3]int{{1, 2, 0}, {3, 2, 1}}, true},
+		{"containers without a plan", yard, append(incoming("20ft", "DRY", 6), incoming("40ft", "Reefer", 3)...), [][3]int{{1, 2, 0}, {2, 2, 0}, {3, 3, 0}, {0, 1, -1}, {0, 1, -2}}, false},
+		{"no plan of the specification", yard, incoming("40ft", "Flat Rack", 1), [][3]int{{0, 1, -2}}, false},
+		{"overlapping plans do not count twice", overlapping, incoming("20ft", "DRY", 3), [][3]int{{1, 2, 0}, {0, 1, -1}}, false},
+		{"nothing incoming", yard, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := baplieForecast(tt.yard, tt.preAdvices)
+
+			if forecast.HasRoom != tt.wantHasRoom {
+				t.Errorf("has room = %v, want %v", forecast.HasRoom, tt.wantHasRoom)
+			}
+			if len(forecast.ByPlan) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d: %+v", len(forecast.ByPlan), len(tt.want), forecast.ByPlan)
+			}
+			incomingTEU := 0
+			for i, want := range tt.want {
+				got := forecast.ByPlan[i]
+				if got.YardPlanID != want[0] || got.Containers != want[1] || got.RemainingTEU != want[2] {
+					t.Errorf("row %d = plan %d with %d containers and %d TEU left, want plan %d with %d containers and %d TEU left",
+						i, got.YardPlanID, got.Containers, got.RemainingTEU, want[0], want[1], want[2])
+				}
+				if got.HasRoom != (want[2] >= 0) {
+					t.Errorf("row %d has room = %v with %d TEU left", i, got.HasRoom, got.RemainingTEU)
+				}
+				incomingTEU += got.IncomingTEU
+			}
+			if forecast.IncomingTEU != incomingTEU || forecast.FreeTEU != tt.yard.FreeTEU {
+				t.Errorf("totals = %d incoming of %d free, want %d of %d", forecast.IncomingTEU, forecast.FreeTEU, incomingTEU, tt.yard.FreeTEU)
+			}
+		})
+	}
+}
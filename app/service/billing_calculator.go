@@ -0,0 +1,160 @@
+package service
+
+import (
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/web"
+)
+
+const billingDateFormat = "2006-01-02"
+
+// storageVisit is one stay of a container in a yard, from its placement to
+// its pickup. A move to another yard ends the visit and starts the next one,
+// which keeps counting days from the first arrival so that free days and
+// tiers carry over. Departure is nil while the container is still there.
+type storageVisit struct {
+	ContainerNumber string
+	ShippingLine    string
+	ContainerSize   string
+	ContainerType   string
+	YardID          int
+	Yard            string
+	// Time zone of the yard, its calendar days are billed
+	Location *time.Location
+	Arrival  time.Time
+	// Move into the yard for a visit that did not start with the arrival.
+	// The day of the move is billed to the previous yard.
+	Start     *time.Time
+	Departure *time.Time
+}
+
+// civilDate is the calendar day of t in loc, as midnight UTC so that day
+// differences are exact.
+func civilDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// calculateStorage prices the days of visit that fall inside the billing
+// period [from, to], both calendar days given as by civilDate. Days are
+// counted in the time zone of the yard, the arrival day is day 1 and the
+// departure day is charged in full. An open visit is billed up to the day of
+// now at most. Days up to the tariff's free days cost nothing; each later day
+// costs the rate of the last tier started by then. Reefers additionally pay
+// the reefer rate for every billed day. It returns false when no day of the
+// visit falls in the period.
+func calculateStorage(tariff *model.Tariff, visit *storageVisit, from, to, now time.Time) (web.InvoiceLine, bool) {
+	loc := visit.Location
+	arrival := civilDate(visit.Arrival, loc)
+
+	periodEnd := to
+	if visit.Departure != nil {
+		if departure := civilDate(*visit.Departure, loc); departure.Before(periodEnd) {
+			periodEnd = departure
+		}
+	} else if today := civilDate(now, loc); today.Before(periodEnd) {
+		periodEnd = today
+	}
+
+	firstDay := 1
+	if visit.Start != nil {
+		firstDay = daysBetween(arrival, civilDate(*visit.Start, loc)) + 2
+	}
+
+	fromDay := max(daysBetween(arrival, from)+1, firstDay)
+	toDay := daysBetween(arrival, periodEnd) + 1
+	if toDay < fromDay {
+		return web.InvoiceLine{}, false
+	}
+
+	line := web.InvoiceLine{
+		ContainerNumber: visit.ContainerNumber,
+		ShippingLine:    visit.ShippingLine,
+		ContainerSize:   visit.ContainerSize,
+		ContainerType:   visit.ContainerType,
+		Yard:            visit.Yard,
+		ArrivalDate:     visit.Arrival,
+		DepartureDate:   visit.Departure,
+		BilledFrom:      arrival.AddDate(0, 0, fromDay-1).Format(billingDateFormat),
+		BilledTo:        arrival.AddDate(0, 0, toDay-1).Format(billingDateFormat),
+		FromDay:         fromDay,
+		ToDay:           toDay,
+		Days:            toDay - fromDay + 1,
+		StorageCharges:  []web.StorageCharge{},
+	}
+
+	if tariff == nil {
+		line.Note = "No tariff matches this container."
+		return line, true
+	}
+
+	line.TariffID = &tariff.ID
+	line.TariffName = tariff.Name
+
+	for day := fromDay; day <= toDay; day++ {
+		if day <= tariff.FreeDays {
+			line.FreeDaysUsed++
+			continue
+		}
+
+		rate := tierRate(tariff.Tiers, day)
+		charges := line.StorageCharges
+		if n := len(charges); n > 0 && charges[n-1].ToDay == day-1 && charges[n-1].DailyRate == rate {
+			charges[n-1].ToDay = day
+			charges[n-1].Days++
+			charges[n-1].Amount += rate
+		} else {
+			line.StorageCharges = append(charges, web.StorageCharge{
+				FromDay:   day,
+				ToDay:     day,
+				Days:      1,
+				DailyRate: rate,
+				Amount:    rate,
+			})
+		}
+		line.StorageAmount += rate
+	}
+
+	if strings.EqualFold(visit.ContainerType, "Reefer") {
+		line.ReeferDays = line.Days
+		line.ReeferAmount = int64(line.Days) * tariff.ReeferDailyRate
+	}
+
+	line.Amount = line.StorageAmount + line.ReeferAmount
+	return line, true
+}
+
+// tierRate is the daily rate of the last tier that has started by day. Tiers
+// must be sorted by FromDay. Days before the first tier are not charged.
+func tierRate(tiers []model.TariffTier, day int) int64 {
+	var rate int64
+	for _, tier := range tiers {
+		if tier.FromDay > day {
+			break
+		}
+		rate = tier.DailyRate
+	}
+	return rate
+}
+
+// findTariff returns the most specific tariff matching the visit, or nil.
+// Ties go to the tariff created first so the result does not depend on
+// anything but the stored tariffs.
+func findTariff(tariffs []model.Tariff, visit *storageVisit) *model.Tariff {
+	var best *model.Tariff
+	for i := range tariffs {
+		tariff := &tariffs[i]
+		if !tariff.Matches(visit.YardID, visit.ContainerSize, visit.ContainerType) {
+			continue
+		}
+		if best == nil || tariff.Specificity() > best.Specificity() {
+			best = tariff
+		}
+	}
+	return best
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"yard-planning/app/model"
+)
+
+func TestCalculateStorage(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, jakarta)
+	}
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	tariff := &model.Tariff{
+		ID:              1,
+		Name:            "Dry",
+		FreeDays:        3,
+		ReeferDailyRate: 500,
+		Tiers: []model.TariffTier{
+			{FromDay: 4, DailyRate: 1000},
+			{FromDay: 8, DailyRate: 2000},
+		},
+	}
+	now := local(time.October, 5, 12)
+
+	tests := []struct {
+		name     string
+		visit    storageVisit
+		from, to time.Time
+
+		wantOK             bool
+		wantFrom, wantTo   int
+		wantBilled         [2]string
+		wantFree           int
+		wantStorage, total int64
+	}{
+		{
+			name:       "free days only",
+			visit:      storageVisit{Arrival: local(time.October, 1, 10), Departure: timePtr(local(time.October, 3, 9))},
+			from:       date(time.October, 1),
+			to:         date(time.October, 31),
+			wantOK:     true,
+			wantFrom:   1,
+			wantTo:     3,
+			wantBilled: [2]string{"2026-10-01", "2026-10-03"},
+			wantFree:   3,
+		},
+		{
+			name:        "both tiers",
+			visit:       storageVisit{Arrival: local(time.September, 1, 10), Departure: timePtr(local(time.September, 10, 9))},
+			from:        date(time.September, 1),
+			to:          date(time.September, 30),
+			wantOK:      true,
+			wantFrom:    1,
+			wantTo:      10,
+			wantBilled:  [2]string{"2026-09-01", "2026-09-10"},
+			wantFree:    3,
+			wantStorage: 4*1000 + 3*2000,
+			total:       4*1000 + 3*2000,
+		},
+		{
+			name:        "open visit across the month boundary stops today",
+			visit:       storageVisit{Arrival: local(time.September, 28, 22)},
+			from:        date(time.October, 1),
+			to:          date(time.October, 31),
+			wantOK:      true,
+			wantFrom:    4,
+			wantTo:      8,
+			wantBilled:  [2]string{"2026-10-01", "2026-10-05"},
+			wantStorage: 4*1000 + 2000,
+			total:       4*1000 + 2000,
+		},
+		{
+			name:   "visit ended the month before",
+			visit:  storageVisit{Arrival: local(time.September, 20, 8), Departure: timePtr(local(time.September, 30, 23))},
+			from:   date(time.October, 1),
+			to:     date(time.October, 31),
+			wantOK: false,
+		},
+		{
+			name:       "days are counted in the yard time zone",
+			visit:      storageVisit{Arrival: time.Date(2026, time.September, 30, 20, 0, 0, 0, time.UTC), Departure: timePtr(local(time.October, 1, 18))},
+			from:       date(time.October, 1),
+			to:         date(time.October, 1),
+			wantOK:     true,
+			wantFrom:   1,
+			wantTo:     1,
+			wantBilled: [2]string{"2026-10-01", "2026-10-01"},
+			wantFree:   1,
+		},
+		{
+			name: "move to another yard keeps counting from the arrival",
+			visit: storageVisit{
+				Arrival:   local(time.September, 1, 10),
+				Start:     timePtr(local(time.September, 3, 15)),
+				Departure: timePtr(local(time.September, 6, 9)),
+			},
+			from:        date(time.September, 1),
+			to:          date(time.September, 30),
+			wantOK:      true,
+			wantFrom:    4,
+			wantTo:      6,
+			wantBilled:  [2]string{"2026-09-04", "2026-09-06"},
+			wantStorage: 3 * 1000,
+			total:       3 * 1000,
+		},
+		{
+			name:        "reefer pays every billed day",
+			visit:       storageVisit{ContainerType: "Reefer", Arrival: local(time.September, 1, 10), Departure: timePtr(local(time.September, 4, 9))},
+			from:        date(time.September, 1),
+			to:          date(time.September, 30),
+			wantOK:      true,
+			wantFrom:    1,
+			wantTo:      4,
+			wantBilled:  [2]string{"2026-09-01", "2026-09-04"},
+			wantFree:    3,
+			wantStorage: 1000,
+			total:       1000 + 4*500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.visit.Location = jakarta
+			line, ok := calculateStorage(tariff, &tt.visit, tt.from, tt.to, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+
+			if line.FromDay != tt.wantFrom || line.ToDay != tt.wantTo {
+				t.Errorf("days %d..%d, want %d..%d", line.FromDay, line.ToDay, tt.wantFrom, tt.wantTo)
+			}
+			if line.BilledFrom != tt.wantBilled[0] || line.BilledTo != tt.wantBilled[1] {
+				t.Errorf("billed %s..%s, want %s..%s", line.BilledFrom, line.BilledTo, tt.wantBilled[0], tt.wantBilled[1])
+			}
+			if line.FreeDaysUsed != tt.wantFree {
+				t.Errorf("free days used = %d, want %d", line.FreeDaysUsed, tt.wantFree)
+			}
+			if line.StorageAmount != tt.wantStorage || line.Amount != tt.total {
+				t.Errorf("storage %d, total %d, want %d, %d", line.StorageAmount, line.Amount, tt.wantStorage, tt.total)
+			}
+		})
+	}
+}
+
+func TestTierRate(t *testing.T) {
+	tiers := []model.TariffTier{{FromDay: 4, DailyRate: 1000}, {FromDay: 8, DailyRate: 2000}}
+
+	tests := []struct {
+		day  int
+		want int64
+	}{
+		{1, 0},
+		{3, 0},
+		{4, 1000},
+		{7, 1000},
+		{8, 2000},
+		{30, 2000},
+	}
+
+	for _, tt := range tests {
+		if got := tierRate(tiers, tt.day); got != tt.want {
+			t.Errorf("tierRate(day %d) = %d, want %d", tt.day, got, tt.want)
+		}
+	}
+}
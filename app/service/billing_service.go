@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type BillingService interface {
+	CreateTariff(ctx context.Context, request *web.TariffRequest) (*web.TariffResponse, *response.CustomError)
+	UpdateTariff(ctx context.Context, tariffID int, request *web.TariffRequest) (*web.TariffResponse, *response.CustomError)
+	DeleteTariff(ctx context.Context, tariffID int) *response.CustomError
+	FindTariffs(ctx context.Context) ([]web.TariffResponse, *response.CustomError)
+
+	// PreviewInvoice prices the storage of one container or of every
+	// container of a shipping line over a billing period. Nothing is stored.
+	PreviewInvoice(ctx context.Context, query *web.InvoicePreviewQuery) (*web.InvoicePreviewResponse, *response.CustomError)
+}
+
+type BillingServiceImpl struct {
+	YardRepository              repository.YardRepository
+	TariffRepository            repository.TariffRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	ContainerMoveRepository     repository.ContainerMoveRepository
+	Currency                    string
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewBillingService(
+	yardRepo repository.YardRepository,
+	tariffRepo repository.TariffRepository,
+	containerRepo repository.ContainerPositionRepository,
+	moveRepo repository.ContainerMoveRepository,
+	currency string,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) BillingService {
+	return &BillingServiceImpl{
+		YardRepository:              yardRepo,
+		TariffRepository:            tariffRepo,
+		ContainerPositionRepository: containerRepo,
+		ContainerMoveRepository:     moveRepo,
+		Currency:                    currency,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *BillingServiceImpl) CreateTariff(ctx context.Context, request *web.TariffRequest) (*web.TariffResponse, *response.CustomError) {
+	now := time.Now()
+	tariff := model.Tariff{CreatedAt: now}
+
+	yardName, customErr := s.applyTariffRequest(&tariff, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.TariffRepository.Save(tx, &tariff)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to create tariff: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityTariff, tariff.ID, nil, tariff)
+
+	tariffResponse := toTariffResponse(&tariff, yardName)
+	return &tariffResponse, nil
+}
+
+func (s *BillingServiceImpl) UpdateTariff(ctx context.Context, tariffID int, request *web.TariffRequest) (*web.TariffResponse, *response.CustomError) {
+	var tariff model.Tariff
+	if err := s.TariffRepository.FindByID(s.DB, &tariff, tariffID); err != nil {
+		return nil, response.NotFoundError("Tariff not found.")
+	}
+	before := tariff
+
+	yardName, customErr := s.applyTariffRequest(&tariff, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.TariffRepository.Update(tx, &tariff)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to update tariff: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityTariff, tariff.ID, before, tariff)
+
+	tariffResponse := toTariffResponse(&tariff, yardName)
+	return &tariffResponse, nil
+}
+
+func (s *BillingServiceImpl) DeleteTariff(ctx context.Context, tariffID int) *response.CustomError {
+	var tariff model.Tariff
+	if err := s.TariffRepository.FindByID(s.DB, &tariff, tariffID); err != nil {
+		return response.NotFoundError("Tariff not found.")
+	}
+
+	if err := s.TariffRepository.Delete(s.DB, tariffID); err != nil {
+		return response.NotFoundError("Tariff not found.")
+	}
+	auditlog.Record(ctx, model.AuditEntityTariff, tariffID, tariff, nil)
+	return nil
+}
+
+func (s *BillingServiceImpl) FindTariffs(ctx context.Context) ([]web.TariffResponse, *response.CustomError) {
+	var tariffs []model.Tariff
+	if err := s.TariffRepository.FindAll(s.DB, &tariffs); err != nil {
+		return nil, response.RepositoryError("Failed to fetch tariffs: " + err.Error())
+	}
+
+	yardNames, _, err := s.yardIndex()
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch yards: " + err.Error())
+	}
+
+	tariffResponses := make([]web.TariffResponse, 0, len(tariffs))
+	for i := range tariffs {
+		yardName := ""
+		if tariffs[i].YardID != nil {
+			yardName = yardNames[*tariffs[i].YardID]
+		}
+		tariffResponses = append(tariffResponses, toTariffResponse(&tariffs[i], yardName))
+	}
+
+	return tariffResponses, nil
+}
+
+func (s *BillingServiceImpl) PreviewInvoice(ctx context.Context, query *web.InvoicePreviewQuery) (*web.InvoicePreviewResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	if (query.ContainerNumber == "") == (query.ShippingLine == "") {
+		return nil, response.BadRequestError("Provide either container_number or shipping_line.")
+	}
+
+	// The period is made of calendar days, each visit is billed in the time
+	// zone of its yard.
+	from := civilDate(query.From, query.From.Location())
+	to := civilDate(query.To, query.To.Location())
+	now := time.Now()
+
+	var tariffs []model.Tariff
+	if err := s.TariffRepository.FindAll(s.DB, &tariffs); err != nil {
+		return nil, response.RepositoryError("Failed to fetch tariffs: " + err.Error())
+	}
+
+	// Moves are loaded a day past the period so the last day is complete in
+	// every time zone, later moves only end visits after the period.
+	visits, err := s.findVisits(s.DB, query.ContainerNumber, query.ShippingLine, to.AddDate(0, 0, 2))
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch container visits: " + err.Error())
+	}
+
+	if query.ContainerNumber != "" && len(visits) == 0 {
+		return nil, response.NotFoundError("Container has no recorded visit.")
+	}
+
+	invoice := &web.InvoicePreviewResponse{
+		ContainerNumber: query.ContainerNumber,
+		ShippingLine:    query.ShippingLine,
+		From:            query.From.Format(billingDateFormat),
+		To:              query.To.Format(billingDateFormat),
+		Currency:        s.Currency,
+		Lines:           []web.InvoiceLine{},
+	}
+
+	for i := range visits {
+		line, ok := calculateStorage(findTariff(tariffs, &visits[i]), &visits[i], from, to, now)
+		if !ok {
+			continue
+		}
+		invoice.Lines = append(invoice.Lines, line)
+		invoice.Total += line.Amount
+	}
+
+	return invoice, nil
+}
+
+// findVisits rebuilds container visits from the placements, pickups and moves
+// in the move history. A visit ends at the pickup, at a move to another yard,
+// which starts the visit there, or at the next placement when the pickup was
+// not recorded. Containers in the yard that predate the history get an open
+// visit starting at their arrival date. Pickups without a recorded placement
+// cannot be priced and are left out.
+func (s *BillingServiceImpl) findVisits(db *gorm.DB, containerNumber, shippingLine string, until time.Time) ([]storageVisit, error) {
+	_, blockYards, err := s.yardIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []model.ContainerMove
+	if err := s.ContainerMoveRepository.FindVisitMoves(db, &moves, containerNumber, shippingLine, until); err != nil {
+		return nil, err
+	}
+
+	var positions []model.ContainerPosition
+	if containerNumber != "" {
+		var position model.ContainerPosition
+		if err := s.ContainerPositionRepository.FindByContainerNumber(db, &position, containerNumber); err == nil {
+			positions = append(positions, position)
+		}
+	} else if err := s.ContainerPositionRepository.FindByShippingLine(db, &positions, shippingLine); err != nil {
+		return nil, err
+	}
+
+	var visits []storageVisit
+	open := make(map[string]*storageVisit)
+
+	closeVisit := func(visit *storageVisit, at time.Time) {
+		visit.Departure = &at
+		visits = append(visits, *visit)
+		delete(open, visit.ContainerNumber)
+	}
+
+	for i := range moves {
+		move := &moves[i]
+		visit, isOpen := open[move.ContainerNumber]
+
+		switch move.MoveType {
+		case model.MoveTypePlacement:
+			if isOpen {
+				closeVisit(visit, move.CreatedAt)
+			}
+
+			visit := &storageVisit{
+				ContainerNumber: move.ContainerNumber,
+				ShippingLine:    move.ShippingLine,
+				ContainerSize:   move.ContainerSize,
+				ContainerType:   move.ContainerType,
+				Location:        time.UTC,
+				Arrival:         move.CreatedAt,
+			}
+			// An imported container arrived before its placement was recorded
+			if move.ArrivedAt != nil {
+				visit.Arrival = *move.ArrivedAt
+			}
+			if move.ToBlockID != nil {
+				setVisitYard(visit, blockYards[*move.ToBlockID])
+			}
+			open[move.ContainerNumber] = visit
+
+		case model.MoveTypePickup:
+			if isOpen {
+				closeVisit(visit, move.CreatedAt)
+			}
+
+		case model.MoveTypeMove:
+			if !isOpen || move.ToBlockID == nil {
+				continue
+			}
+			yard, ok := blockYards[*move.ToBlockID]
+			if !ok || yard.ID == visit.YardID {
+				continue
+			}
+
+			next := *visit
+			closeVisit(visit, move.CreatedAt)
+
+			start := move.CreatedAt
+			next.Start = &start
+			setVisitYard(&next, yard)
+			open[move.ContainerNumber] = &next
+		}
+	}
+
+	for _, visit := range open {
+		visits = append(visits, *visit)
+	}
+
+	for i := range positions {
+		position := &positions[i]
+		if _, ok := open[position.ContainerNumber]; ok || position.ArrivalDate.After(until) {
+			continue
+		}
+
+		visit := storageVisit{
+			ContainerNumber: position.ContainerNumber,
+			ShippingLine:    position.ShippingLine,
+			ContainerSize:   position.ContainerSize,
+			ContainerType:   position.ContainerType,
+			Location:        time.UTC,
+			Arrival:         position.ArrivalDate,
+		}
+		setVisitYard(&visit, blockYards[position.BlockID])
+		visits = append(visits, visit)
+	}
+
+	sort.Slice(visits, func(i, j int) bool {
+		if visits[i].ContainerNumber != visits[j].ContainerNumber {
+			return visits[i].ContainerNumber < visits[j].ContainerNumber
+		}
+		return visits[i].Arrival.Before(visits[j].Arrival)
+	})
+
+	return visits, nil
+}
+
+// setVisitYard bills the visit in yard. An unknown yard, zero, keeps the
+// visit without yard in UTC.
+func setVisitYard(visit *storageVisit, yard model.Yard) {
+	if yard.ID == 0 {
+		return
+	}
+	visit.YardID, visit.Yard = yard.ID, yard.Name
+	visit.Location = yard.TimeLocation()
+}
+
+// yardIndex maps yard IDs to names and block IDs to their yard.
+func (s *BillingServiceImpl) yardIndex() (map[int]string, map[int]model.Yard, error) {
+	var yards []model.Yard
+	if err := s.YardRepository.FindAllYards(s.DB, &yards); err != nil {
+		return nil, nil, err
+	}
+
+	yardNames := make(map[int]string, len(yards))
+	blockYards := make(map[int]model.Yard)
+	for _, yard := range yards {
+		yardNames[yard.ID] = yard.Name
+
+		var blocks []model.Block
+		if err := s.YardRepository.FindBlocksByYardID(s.DB, &blocks, yard.ID); err != nil {
+			return nil, nil, err
+		}
+		for _, block := range blocks {
+			blockYards[block.ID] = yard
+		}
+	}
+
+	return yardNames, blockYards, nil
+}
+
+// applyTariffRequest validates request and copies it onto tariff, returning
+// the yard name for the response.
+func (s *BillingServiceImpl) applyTariffRequest(tariff *model.Tariff, request *web.TariffRequest) (string, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return "", response.BadRequestError(err.Error())
+	}
+
+	tiers := make([]model.TariffTier, 0, len(request.Tiers))
+	for i, tier := range request.Tiers {
+		if i > 0 && tier.FromDay <= request.Tiers[i-1].FromDay {
+			return "", response.BadRequestError("Tariff tiers must be sorted by from_day without duplicates.")
+		}
+		tiers = append(tiers, model.TariffTier{FromDay: tier.FromDay, DailyRate: tier.DailyRate})
+	}
+
+	tariff.Name = request.Name
+	tariff.ContainerSize = request.ContainerSize
+	tariff.ContainerType = request.ContainerType
+	tariff.FreeDays = request.FreeDays
+	tariff.ReeferDailyRate = request.ReeferDailyRate
+	tariff.Tiers = tiers
+	tariff.UpdatedAt = time.Now()
+
+	if request.YardName == "" {
+		tariff.YardID = nil
+		return "", nil
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return "", response.NotFoundError("Yard not found.")
+	}
+
+	tariff.YardID = &yard.ID
+	return yard.Name, nil
+}
+
+func toTariffResponse(tariff *model.Tariff, yardName string) web.TariffResponse {
+	tiers := make([]web.TariffTierResponse, 0, len(tariff.Tiers))
+	for _, tier := range tariff.Tiers {
+		tiers = append(tiers, web.TariffTierResponse{FromDay: tier.FromDay, DailyRate: tier.DailyRate})
+	}
+
+	return web.TariffResponse{
+		ID:              tariff.ID,
+		Name:            tariff.Name,
+		YardID:          tariff.YardID,
+		Yard:            yardName,
+		ContainerSize:   tariff.ContainerSize,
+		ContainerType:   tariff.ContainerType,
+		FreeDays:        tariff.FreeDays,
+		ReeferDailyRate: tariff.ReeferDailyRate,
+		Tiers:           tiers,
+	}
+}
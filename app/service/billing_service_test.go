@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+
+	"gorm.io/gorm"
+)
+
+// memoryYards serves yards and their blocks from memory.
+type memoryYards struct {
+	repository.YardRepository
+
+	yards  []model.Yard
+	blocks []model.Block
+}
+
+func (r *memoryYards) FindAllYards(db *gorm.DB, yards *[]model.Yard) error {
+	*yards = append((*yards)[:0], r.yards...)
+	return nil
+}
+
+func (r *memoryYards) FindBlocksByYardID(db *gorm.DB, blocks *[]model.Block, yardID int) error {
+	for _, block := range r.blocks {
+		if block.YardID == yardID {
+			*blocks = append(*blocks, block)
+		}
+	}
+	return nil
+}
+
+type memoryVisitMoves struct {
+	repository.ContainerMoveRepository
+	moves []model.ContainerMove
+}
+
+func (r *memoryVisitMoves) FindVisitMoves(db *gorm.DB, moves *[]model.ContainerMove, containerNumber, shippingLine string, until time.Time) error {
+	*moves = append((*moves)[:0], r.moves...)
+	return nil
+}
+
+type noPositions struct {
+	repository.ContainerPositionRepository
+}
+
+func (r *noPositions) FindByContainerNumber(db *gorm.DB, position *model.ContainerPosition, containerNumber string) error {
+	return errors.New("container position not found")
+}
+
+func TestFindVisitsClosesEachVisit(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.October, d, 10, 0, 0, 0, time.UTC) }
+	intPtr := func(v int) *int { return &v }
+	timePtr := func(t time.Time) *time.Time { return &t }
+	move := func(moveType string, at time.Time, fromBlockID, toBlockID *int) model.ContainerMove {
+		return model.ContainerMove{ContainerNumber: "MSKU1234565", MoveType: moveType, FromBlockID: fromBlockID, ToBlockID: toBlockID, CreatedAt: at}
+	}
+
+	s := &BillingServiceImpl{
+		YardRepository: &memoryYards{
+			yards:  []model.Yard{{ID: 1, Name: "YRD-UTAMA", Timezone: "Asia/Jakarta"}, {ID: 2, Name: "YRD-LONGTERM", Timezone: "Asia/Jakarta"}},
+			blocks: []model.Block{{ID: 1, YardID: 1}, {ID: 2, YardID: 1}, {ID: 3, YardID: 2}},
+		},
+		ContainerMoveRepository: &memoryVisitMoves{moves: []model.ContainerMove{
+			move(model.MoveTypePlacement, day(1), nil, intPtr(1)),
+			move(model.MoveTypeMove, day(2), intPtr(1), intPtr(2)),
+			move(model.MoveTypeMove, day(5), intPtr(2), intPtr(3)),
+			// The pickup from the long term yard was not recorded
+			move(model.MoveTypePlacement, day(10), nil, intPtr(1)),
+		}},
+		ContainerPositionRepository: &noPositions{},
+	}
+
+	visits, err := s.findVisits(nil, "MSKU1234565", "", day(31))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		yard      string
+		arrival   time.Time
+		start     *time.Time
+		departure *time.Time
+	}{
+		{"YRD-UTAMA", day(1), nil, timePtr(day(5))},
+		{"YRD-LONGTERM", day(1), timePtr(day(5)), timePtr(day(10))},
+		{"YRD-UTAMA", day(10), nil, nil},
+	}
+
+	if len(visits) != len(want) {
+		t.Fatalf("got %d visits, want %d: %+v", len(visits), len(want), visits)
+	}
+	sameTime := func(got, want *time.Time) bool {
+		if got == nil || want == nil {
+			return got == want
+		}
+		return got.Equal(*want)
+	}
+	for i, w := range want {
+		visit := visits[i]
+		if visit.Yard != w.yard || !visit.Arrival.Equal(w.arrival) || !sameTime(visit.Start, w.start) || !sameTime(visit.Departure, w.departure) {
+			t.Errorf("visit %d = %s arrival %v start %v departure %v, want %s arrival %v start %v departure %v",
+				i, visit.Yard, visit.Arrival, visit.Start, visit.Departure, w.yard, w.arrival, w.start, w.departure)
+		}
+		if visit.Location.String() != "Asia/Jakarta" {
+			t.Errorf("visit %d billed in %s, want Asia/Jakarta", i, visit.Location)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"yard-planning/app/web"
+)
+
+// RenderBlockViewASCII draws the bay and top views as plain text. Occupied
+// cells show the container number and size, a 40ft tail is drawn as
+// "<number", reserved cells as "*number" and empty cells show "." followed
+// by their plan code.
+func RenderBlockViewASCII(view *web.BlockViewResponse) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Block %s (%d slots x %d rows x %d tiers)\n\n", view.Block, view.Slots, view.Rows, view.Tiers)
+
+	sb.WriteString("Plans:\n")
+	if len(view.Plans) == 0 {
+		sb.WriteString("  (no active yard plan)\n")
+	}
+	for _, plan := range view.Plans {
+		fmt.Fprintf(&sb, "  %-3s #%d %s  S%d-%d R%d-%d  %s %s %s\n",
+			plan.Code, plan.ID, plan.PlanName, plan.SlotStart, plan.SlotEnd, plan.RowStart, plan.RowEnd,
+			plan.ContainerSize, plan.ContainerHeight, plan.ContainerType)
+	}
+
+	width := 6
+	for _, bay := range view.Bays {
+		for _, tier := range bay.Tiers {
+			for _, cell := range tier {
+				if l := len(asciiCellLabel(cell)); l > width {
+					width = l
+				}
+			}
+		}
+	}
+
+	for _, bay := range view.Bays {
+		fmt.Fprintf(&sb, "\nBay view, slot %02d\n", bay.Slot)
+
+		sb.WriteString("      ")
+		for r := 1; r <= view.Rows; r++ {
+			fmt.Fprintf(&sb, "  %-*s", width, fmt.Sprintf("R%d", r))
+		}
+		sb.WriteString("\n")
+
+		for t := len(bay.Tiers); t >= 1; t-- {
+			fmt.Fprintf(&sb, "  T%-2d |", t)
+			for _, cell := range bay.Tiers[t-1] {
+				fmt.Fprintf(&sb, " %-*s|", width, asciiCellLabel(cell))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(view.Top) > 0 {
+		sb.WriteString("\nTop view (stack height + plan code)\n")
+
+		sb.WriteString("      ")
+		for sl := 1; sl <= view.Slots; sl++ {
+			fmt.Fprintf(&sb, " S%02d ", sl)
+		}
+		sb.WriteString("\n")
+
+		for r, row := range view.Top {
+			fmt.Fprintf(&sb, "  R%-2d |", r+1)
+			for _, cell := range row {
+				code := cell.PlanCode
+				if code == "" {
+					code = "-"
+				}
+				fmt.Fprintf(&sb, " %d%-2s|", cell.StackHeight, code)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+func asciiCellLabel(cell web.BlockViewCell) string {
+	if cell.ContainerNumber == "" && cell.ReservedFor != "" {
+		return "*" + cell.ReservedFor
+	}
+	if cell.ContainerNumber == "" {
+		return "." + cell.PlanCode
+	}
+	if cell.IsTail {
+		return "<" + cell.ContainerNumber
+	}
+	return cell.ContainerNumber + " " + strings.TrimSuffix(cell.ContainerSize, "ft")
+}
+
+const (
+	svgCellWidth  = 110
+	svgCellHeight = 26
+	svgMargin     = 40
+)
+
+var svgPlanColors = []string{"#dbeafe", "#dcfce7", "#fef3c7", "#fce7f3", "#ede9fe", "#cffafe", "#fee2e2", "#e0e7ff"}
+
+// RenderBlockViewSVG draws the same views as RenderBlockViewASCII as an SVG
+// image, plan areas are shaded with one color per plan code and reserved
+// cells get a dashed outline.
+func RenderBlockViewSVG(view *web.BlockViewResponse) string {
+	colors := make(map[string]string)
+	for i, plan := range view.Plans {
+		colors[plan.Code] = svgPlanColors[i%len(svgPlanColors)]
+	}
+
+	var body strings.Builder
+	y := svgMargin
+	width := svgMargin*2 + svgCellWidth*max(view.Rows, view.Slots)
+
+	fmt.Fprintf(&body, `<text x="%d" y="%d" font-size="16" font-weight="bold">Block %s</text>`+"\n",
+		svgMargin, y, html.EscapeString(view.Block))
+	y += 20
+
+	for _, plan := range view.Plans {
+		fmt.Fprintf(&body, `<rect x="%d" y="%d" width="14" height="14" fill="%s" stroke="#555"/>`+"\n", svgMargin, y, colors[plan.Code])
+		fmt.Fprintf(&body, `<text x="%d" y="%d" font-size="12">%s  %s (S%d-%d R%d-%d, %s %s %s)</text>`+"\n",
+			svgMargin+20, y+12, plan.Code, html.EscapeString(plan.PlanName),
+			plan.SlotStart, plan.SlotEnd, plan.RowStart, plan.RowEnd,
+			plan.ContainerSize, plan.ContainerHeight, html.EscapeString(plan.ContainerType))
+		y += 18
+	}
+	y += 10
+
+	if len(view.Top) > 0 {
+		fmt.Fprintf(&body, `<text x="%d" y="%d" font-size="14" font-weight="bold">Top view</text>`+"\n", svgMargin, y+14)
+		y += 24
+
+		for r, row := range view.Top {
+			for sl, cell := range row {
+				label := fmt.Sprintf("R%d S%d: %d", r+1, sl+1, cell.StackHeight)
+				writeSVGCell(&body, svgMargin+sl*svgCellWidth, y, colors[cell.PlanCode], cell.StackHeight > 0, false, label)
+			}
+			y += svgCellHeight
+		}
+		y += 20
+	}
+
+	for _, bay := range view.Bays {
+		fmt.Fprintf(&body, `<text x="%d" y="%d" font-size="14" font-weight="bold">Bay view, slot %02d</text>`+"\n", svgMargin, y+14, bay.Slot)
+		y += 24
+
+		for t := len(bay.Tiers); t >= 1; t-- {
+			for r, cell := range bay.Tiers[t-1] {
+				label := ""
+				if cell.ContainerNumber != "" || cell.ReservedFor != "" {
+					label = asciiCellLabel(cell)
+				}
+				writeSVGCell(&body, svgMargin+r*svgCellWidth, y, colors[cell.PlanCode], cell.ContainerNumber != "", cell.ReservedFor != "", label)
+			}
+			y += svgCellHeight
+		}
+		y += 20
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace">`+"\n", width, y+svgMargin)
+	sb.WriteString(body.String())
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+func writeSVGCell(sb *strings.Builder, x, y int, fill string, occupied, reserved bool, label string) {
+	if fill == "" {
+		fill = "#ffffff"
+	}
+	stroke := "#999"
+	if occupied {
+		stroke = "#111"
+	}
+	dash := ""
+	if reserved {
+		stroke = "#b45309"
+		dash = ` stroke-dasharray="4 2"`
+	}
+
+	fmt.Fprintf(sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s"%s/>`+"\n",
+		x, y, svgCellWidth, svgCellHeight, fill, stroke, dash)
+	if label != "" {
+		fmt.Fprintf(sb, `<text x="%d" y="%d" font-size="11">%s</text>`+"\n", x+4, y+17, html.EscapeString(label))
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type BlockViewService interface {
+	ViewBlock(ctx context.Context, blockID int, query *web.BlockViewQuery) (*web.BlockViewResponse, *response.CustomError)
+}
+
+type BlockViewServiceImpl struct {
+	YardRepository              repository.YardRepository
+	YardPlanRepository          repository.YardPlanRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewBlockViewService(
+	yardRepo repository.YardRepository,
+	planRepo repository.YardPlanRepository,
+	containerRepo repository.ContainerPositionRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) BlockViewService {
+	return &BlockViewServiceImpl{
+		YardRepository:              yardRepo,
+		YardPlanRepository:          planRepo,
+		ContainerPositionRepository: containerRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *BlockViewServiceImpl) ViewBlock(ctx context.Context, blockID int, query *web.BlockViewQuery) (*web.BlockViewResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var block model.Block
+	if err := s.YardRepository.FindBlockByID(s.DB, &block, blockID); err != nil {
+		return nil, response.NotFoundError("Block not found.")
+	}
+
+	if query.Slot > block.Slots {
+		return nil, response.BadRequestError("Slot is outside the Block dimensions.")
+	}
+
+	var plans []model.YardPlan
+	if err := s.YardPlanRepository.FindActivePlansByBlock(s.DB, &plans, block.ID, time.Now()); err != nil {
+		return nil, response.RepositoryError("Failed to fetch yard plans: " + err.Error())
+	}
+
+	var positions []model.ContainerPosition
+	if err := s.ContainerPositionRepository.FindByBlockID(s.DB, &positions, block.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch container positions: " + err.Error())
+	}
+
+	reservations, err := loadReservations(s.DB, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch reservations: " + err.Error())
+	}
+
+	view := &web.BlockViewResponse{
+		BlockID: block.ID,
+		Block:   block.Name,
+		Slots:   block.Slots,
+		Rows:    block.Rows,
+		Tiers:   block.Tiers,
+		Plans:   []web.BlockViewPlan{},
+	}
+
+	for i, plan := range plans {
+		view.Plans = append(view.Plans, web.BlockViewPlan{
+			Code:            planCode(i),
+			ID:              plan.ID,
+			PlanName:        plan.PlanName,
+			SlotStart:       plan.SlotStart,
+			SlotEnd:         plan.SlotEnd,
+			RowStart:        plan.RowStart,
+			RowEnd:          plan.RowEnd,
+			ContainerSize:   plan.ContainerSize,
+			ContainerHeight: plan.ContainerHeight,
+			ContainerType:   plan.ContainerType,
+		})
+	}
+
+	// cells[slot-1][row-1][tier-1]
+	cells := make([][][]web.BlockViewCell, block.Slots)
+	for sl := 1; sl <= block.Slots; sl++ {
+		cells[sl-1] = make([][]web.BlockViewCell, block.Rows)
+		for r := 1; r <= block.Rows; r++ {
+			cells[sl-1][r-1] = make([]web.BlockViewCell, block.Tiers)
+			code := planCodeAt(view.Plans, sl, r)
+			for t := 1; t <= block.Tiers; t++ {
+				cells[sl-1][r-1][t-1] = web.BlockViewCell{Slot: sl, Row: r, Tier: t, PlanCode: code}
+			}
+		}
+	}
+
+	for _, position := range positions {
+		if !coversBlockCells(&block, &position) {
+			view.OutOfBlock = append(view.OutOfBlock, position.ContainerNumber)
+			continue
+		}
+		for i, slot := range coveredSlots(&position) {
+			cell := &cells[slot-1][position.RowNumber-1][position.TierNumber-1]
+			cell.ContainerNumber = position.ContainerNumber
+			cell.ContainerSize = position.ContainerSize
+			cell.ContainerHeight = position.ContainerHeight
+			cell.ContainerType = position.ContainerType
+			cell.IsTail = i > 0
+		}
+	}
+
+	for i := range reservations {
+		reserved := reservations[i].position()
+		if reserved.BlockID != block.ID || !coversBlockCells(&block, reserved) {
+			continue
+		}
+		for _, slot := range coveredSlots(reserved) {
+			cell := &cells[slot-1][reserved.RowNumber-1][reserved.TierNumber-1]
+			if cell.ContainerNumber != "" {
+				continue
+			}
+			cell.ReservedFor = reservations[i].ContainerNumber
+			cell.ReservationSource = reservations[i].Source
+		}
+	}
+
+	viewMode := query.View
+	if viewMode == "" {
+		viewMode = "all"
+	}
+
+	if viewMode == "all" || viewMode == "bay" {
+		for sl := 1; sl <= block.Slots; sl++ {
+			if query.Slot != 0 && sl != query.Slot {
+				continue
+			}
+
+			bay := web.BayView{Slot: sl, Tiers: make([][]web.BlockViewCell, block.Tiers)}
+			for t := 1; t <= block.Tiers; t++ {
+				bay.Tiers[t-1] = make([]web.BlockViewCell, block.Rows)
+				for r := 1; r <= block.Rows; r++ {
+					bay.Tiers[t-1][r-1] = cells[sl-1][r-1][t-1]
+				}
+			}
+			view.Bays = append(view.Bays, bay)
+		}
+	}
+
+	if viewMode == "all" || viewMode == "top" {
+		view.Top = make([][]web.TopViewCell, block.Rows)
+		for r := 1; r <= block.Rows; r++ {
+			view.Top[r-1] = make([]web.TopViewCell, block.Slots)
+			for sl := 1; sl <= block.Slots; sl++ {
+				top := web.TopViewCell{Slot: sl, Row: r, PlanCode: cells[sl-1][r-1][0].PlanCode}
+				for t := 1; t <= block.Tiers; t++ {
+					if number := cells[sl-1][r-1][t-1].ContainerNumber; number != "" {
+						top.StackHeight = t
+						top.TopNumber = number
+					}
+				}
+				view.Top[r-1][sl-1] = top
+			}
+		}
+	}
+
+	return view, nil
+}
+
+// planCode gives plans the short legend codes A, B, ... Z, AA, AB, ...
+func planCode(index int) string {
+	code := ""
+	for index >= 0 {
+		code = string(rune('A'+index%26)) + code
+		index = index/26 - 1
+	}
+	return code
+}
+
+func planCodeAt(plans []web.BlockViewPlan, slot, row int) string {
+	for _, plan := range plans {
+		if slot >= plan.SlotStart && slot <= plan.SlotEnd && row >= plan.RowStart && row <= plan.RowEnd {
+			return plan.Code
+		}
+	}
+	return ""
+}
+
+// coversBlockCells reports whether every cell of the position lies inside the
+// block dimensions.
+func coversBlockCells(block *model.Block, position *model.ContainerPosition) bool {
+	if position.RowNumber < 1 || position.RowNumber > block.Rows || position.TierNumber < 1 || position.TierNumber > block.Tiers {
+		return false
+	}
+	for _, slot := range coveredSlots(position) {
+		if slot < 1 || slot > block.Slots {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+	"yard-planning/app/model"
+)
+
+func TestCoversBlockCells(t *testing.T) {
+	block := &model.Block{Slots: 10, Rows: 4, Tiers: 3}
+
+	tests := []struct {
+		name     string
+		position model.ContainerPosition
+		want     bool
+	}{
+		{"20ft inside", model.ContainerPosition{SlotNumber: 10, RowNumber: 4, TierNumber: 3, ContainerSize: "20ft"}, true},
+		{"40ft inside", model.ContainerPosition{SlotNumber: 9, RowNumber: 1, TierNumber: 1, ContainerSize: "40ft"}, true},
+		{"40ft tail past the last slot", model.ContainerPosition{SlotNumber: 10, RowNumber: 1, TierNumber: 1, ContainerSize: "40ft"}, false},
+		{"parked by a yard audit", model.ContainerPosition{ID: 7, SlotNumber: 1, RowNumber: 1, TierNumber: -7, ContainerSize: "20ft"}, false},
+		{"slot zero", model.ContainerPosition{SlotNumber: 0, RowNumber: 1, TierNumber: 1, ContainerSize: "20ft"}, false},
+		{"row past the block", model.ContainerPosition{SlotNumber: 1, RowNumber: 5, TierNumber: 1, ContainerSize: "20ft"}, false},
+		{"tier past the block", model.ContainerPosition{SlotNumber: 1, RowNumber: 1, TierNumber: 4, ContainerSize: "20ft"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := coversBlockCells(block, &tt.position); got != tt.want {
+				t.Errorf("coversBlockCells() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type CapacityReportService interface {
+	CapacityReport(ctx context.Context, query *web.CapacityReportQuery) (*web.CapacityReportResponse, *response.CustomError)
+
+	// TakeSnapshot stores today's figures of every yard, block and plan,
+	// replacing a snapshot already taken today.
+	TakeSnapshot(ctx context.Context) (*web.CapacitySnapshotResult, *response.CustomError)
+	SnapshotHistory(ctx context.Context, query *web.CapacityHistoryQuery) ([]web.CapacitySnapshotResponse, *response.CustomError)
+}
+
+type CapacityReportServiceImpl struct {
+	YardRepository              repository.YardRepository
+	YardPlanRepository          repository.YardPlanRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	CapacitySnapshotRepository  repository.CapacitySnapshotRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewCapacityReportService(
+	yardRepo repository.YardRepository,
+	planRepo repository.YardPlanRepository,
+	containerRepo repository.ContainerPositionRepository,
+	snapshotRepo repository.CapacitySnapshotRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) CapacityReportService {
+	return &CapacityReportServiceImpl{
+		YardRepository:              yardRepo,
+		YardPlanRepository:          planRepo,
+		ContainerPositionRepository: containerRepo,
+		CapacitySnapshotRepository:  snapshotRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *CapacityReportServiceImpl) CapacityReport(ctx context.Context, query *web.CapacityReportQuery) (*web.CapacityReportResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yards []model.Yard
+	if query.YardName != "" {
+		var yard model.Yard
+		if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+			return nil, response.NotFoundError("Yard not found.")
+		}
+		yards = append(yards, yard)
+	} else if err := s.YardRepository.FindAllYards(s.DB, &yards); err != nil {
+		return nil, response.RepositoryError("Failed to fetch yards: " + err.Error())
+	}
+
+	now := time.Now()
+	reportResponse := &web.CapacityReportResponse{
+		GeneratedAt: now,
+		Yards:       make([]web.YardCapacityReport, 0, len(yards)),
+	}
+
+	for i := range yards {
+		yardReport, err := s.buildYardReport(s.DB, &yards[i], now)
+		if err != nil {
+			return nil, response.RepositoryError("Failed to compute capacity: " + err.Error())
+		}
+		reportResponse.Yards = append(reportResponse.Yards, *yardReport)
+	}
+
+	return reportResponse, nil
+}
+
+func (s *CapacityReportServiceImpl) TakeSnapshot(ctx context.Context) (*web.CapacitySnapshotResult, *response.CustomError) {
+	var yards []model.Yard
+	if err := s.YardRepository.FindAllYards(s.DB, &yards); err != nil {
+		return nil, response.RepositoryError("Failed to fetch yards: " + err.Error())
+	}
+
+	now := time.Now()
+	snapshotDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	count := 0
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.CapacitySnapshotRepository.DeleteByDate(tx, snapshotDate); err != nil {
+			return err
+		}
+
+		save := func(level string, yardID int, blockID, planID *int, figures web.CapacityFigures) error {
+			snapshot := model.CapacitySnapshot{
+				SnapshotDate: snapshotDate,
+				Level:        level,
+				YardID:       yardID,
+				BlockID:      blockID,
+				YardPlanID:   planID,
+				TotalTEU:     figures.TotalTEU,
+				OccupiedTEU:  figures.OccupiedTEU,
+				ReservedTEU:  figures.ReservedTEU,
+				FreeTEU:      figures.FreeTEU,
+				OverflowTEU:  figures.OverflowTEU,
+				CreatedAt:    now,
+			}
+			count++
+			return s.CapacitySnapshotRepository.Save(tx, &snapshot)
+		}
+
+		for i := range yards {
+			yardReport, err := s.buildYardReport(tx, &yards[i], now)
+			if err != nil {
+				return err
+			}
+
+			if err := save(model.CapacityLevelYard, yardReport.YardID, nil, nil, yardReport.CapacityFigures); err != nil {
+				return err
+			}
+
+			for _, blockReport := range yardReport.Blocks {
+				blockID := blockReport.BlockID
+				if err := save(model.CapacityLevelBlock, yardReport.YardID, &blockID, nil, blockReport.CapacityFigures); err != nil {
+					return err
+				}
+
+				for _, planReport := range blockReport.Plans {
+					planID := planReport.YardPlanID
+					if err := save(model.CapacityLevelPlan, yardReport.YardID, &blockID, &planID, planReport.CapacityFigures); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to take capacity snapshot: " + txErr.Error())
+	}
+
+	result := &web.CapacitySnapshotResult{
+		SnapshotDate: snapshotDate.Format("2006-01-02"),
+		Snapshots:    count,
+	}
+	auditlog.Record(ctx, model.AuditEntityCapacitySnapshot, result.SnapshotDate, nil, result)
+
+	return result, nil
+}
+
+func (s *CapacityReportServiceImpl) SnapshotHistory(ctx context.Context, query *web.CapacityHistoryQuery) ([]web.CapacitySnapshotResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	to := time.Now()
+	if query.To != nil {
+		to = *query.To
+	}
+	from := to.AddDate(0, 0, -30)
+	if query.From != nil {
+		from = *query.From
+	}
+
+	level := model.CapacityLevelYard
+	if query.YardPlanID != 0 {
+		level = model.CapacityLevelPlan
+	} else if query.BlockID != 0 {
+		level = model.CapacityLevelBlock
+	}
+
+	var snapshots []model.CapacitySnapshot
+	if err := s.CapacitySnapshotRepository.FindHistory(s.DB, &snapshots, level, yard.ID, query.BlockID, query.YardPlanID, from, to); err != nil {
+		return nil, response.RepositoryError("Failed to fetch capacity history: " + err.Error())
+	}
+
+	history := make([]web.CapacitySnapshotResponse, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		figures := web.CapacityFigures{
+			TotalTEU:    snapshot.TotalTEU,
+			OccupiedTEU: snapshot.OccupiedTEU,
+			ReservedTEU: snapshot.ReservedTEU,
+			FreeTEU:     snapshot.FreeTEU,
+			OverflowTEU: snapshot.OverflowTEU,
+		}
+		setUtilization(&figures)
+
+		history = append(history, web.CapacitySnapshotResponse{
+			SnapshotDate:    snapshot.SnapshotDate.Format("2006-01-02"),
+			Level:           snapshot.Level,
+			YardID:          snapshot.YardID,
+			BlockID:         snapshot.BlockID,
+			YardPlanID:      snapshot.YardPlanID,
+			CapacityFigures: figures,
+		})
+	}
+
+	return history, nil
+}
+
+type capacitySpec struct {
+	size, height, cType string
+}
+
+// capacitySpecs aggregates figures per container specification while keeping
+// the order in which specifications were first seen.
+type capacitySpecs struct {
+	order   []capacitySpec
+	figures map[capacitySpec]*web.CapacityFigures
+}
+
+func newCapacitySpecs() *capacitySpecs {
+	return &capacitySpecs{figures: make(map[capacitySpec]*web.CapacityFigures)}
+}
+
+func (c *capacitySpecs) add(spec capacitySpec, figures web.CapacityFigures) {
+	if _, ok := c.figures[spec]; !ok {
+		c.order = append(c.order, spec)
+		c.figures[spec] = &web.CapacityFigures{}
+	}
+	addFigures(c.figures[spec], figures)
+}
+
+func (c *capacitySpecs) reports() []web.CapacitySpecReport {
+	reports := make([]web.CapacitySpecReport, 0, len(c.order))
+	for _, spec := range c.order {
+		figures := *c.figures[spec]
+		setUtilization(&figures)
+		reports = append(reports, web.CapacitySpecReport{
+			ContainerSize:   spec.size,
+			ContainerHeight: spec.height,
+			ContainerType:   spec.cType,
+			CapacityFigures: figures,
+		})
+	}
+	return reports
+}
+
+// buildYardReport computes the figures of every active plan in the yard and
+// rolls them up per block, per yard and per container specification. Plan
+// totals come from planCapacity, so slots a plan cannot use are excluded.
+// Cells held by pending gate-ins and pre-advices count as reserved.
+func (s *CapacityReportServiceImpl) buildYardReport(db *gorm.DB, yard *model.Yard, at time.Time) (*web.YardCapacityReport, error) {
+	var blocks []model.Block
+	if err := s.YardRepository.FindBlocksByYardID(db, &blocks, yard.ID); err != nil {
+		return nil, err
+	}
+
+	reservations, err := loadReservations(db, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	yardReport := &web.YardCapacityReport{
+		YardID: yard.ID,
+		Yard:   yard.Name,
+		Blocks: make([]web.BlockCapacityReport, 0, len(blocks)),
+	}
+	yardSpecs := newCapacitySpecs()
+
+	for i := range blocks {
+		block := &blocks[i]
+
+		var plans []model.YardPlan
+		if err := s.YardPlanRepository.FindActivePlansByBlock(db, &plans, block.ID, at); err != nil {
+			return nil, err
+		}
+
+		var positions []model.ContainerPosition
+		if err := s.ContainerPositionRepository.FindByBlockID(db, &positions, block.ID); err != nil {
+			return nil, err
+		}
+
+		blockReport := web.BlockCapacityReport{
+			BlockID: block.ID,
+			Block:   block.Name,
+			Plans:   make([]web.PlanCapacityReport, 0, len(plans)),
+		}
+		blockSpecs := newCapacitySpecs()
+
+		planIndex := make(map[int]int, len(plans))
+		for i := range plans {
+			planIndex[plans[i].ID] = i
+			blockReport.Plans = append(blockReport.Plans, web.PlanCapacityReport{
+				YardPlanID:      plans[i].ID,
+				PlanName:        plans[i].PlanName,
+				ContainerSize:   plans[i].ContainerSize,
+				ContainerHeight: plans[i].ContainerHeight,
+				ContainerType:   plans[i].ContainerType,
+				CapacityFigures: web.CapacityFigures{TotalTEU: planCapacity(&plans[i], block).TEU},
+			})
+		}
+
+		for i := range positions {
+			position := &positions[i]
+			teu := containerTEU(position.ContainerSize)
+
+			if plan := findCoveringPlan(plans, position); plan != nil {
+				blockReport.Plans[planIndex[plan.ID]].OccupiedTEU += teu
+				continue
+			}
+
+			overflow := web.CapacityFigures{OverflowTEU: teu}
+			addFigures(&blockReport.CapacityFigures, overflow)
+			blockSpecs.add(capacitySpec{position.ContainerSize, position.ContainerHeight, position.ContainerType}, overflow)
+		}
+
+		// A reservation outside every active plan has no capacity to hold, it
+		// is left out rather than reported as overflow of a box not yet here.
+		for i := range reservations {
+			if reservations[i].BlockID != block.ID {
+				continue
+			}
+			if plan := findCoveringPlan(plans, reservations[i].position()); plan != nil {
+				blockReport.Plans[planIndex[plan.ID]].ReservedTEU += containerTEU(reservations[i].ContainerSize)
+			}
+		}
+
+		// Plans of the same specification may overlap, the block totals
+		// count every cell once.
+		planSpecs := newCapacitySpecs()
+		for i := range blockReport.Plans {
+			planReport := &blockReport.Plans[i]
+			planReport.FreeTEU = max(planReport.TotalTEU-planReport.OccupiedTEU-planReport.ReservedTEU, 0)
+			setUtilization(&planReport.CapacityFigures)
+
+			planSpecs.add(capacitySpec{planReport.ContainerSize, planReport.ContainerHeight, planReport.ContainerType}, web.CapacityFigures{
+				OccupiedTEU: planReport.OccupiedTEU,
+				ReservedTEU: planReport.ReservedTEU,
+			})
+		}
+
+		specTEU := distinctPlanTEU(plans, block)
+		for _, spec := range planSpecs.order {
+			figures := *planSpecs.figures[spec]
+			figures.TotalTEU = specTEU[spec]
+			figures.FreeTEU = max(figures.TotalTEU-figures.OccupiedTEU-figures.ReservedTEU, 0)
+
+			addFigures(&blockReport.CapacityFigures, figures)
+			blockSpecs.add(spec, figures)
+		}
+
+		setUtilization(&blockReport.CapacityFigures)
+		blockReport.BySpec = blockSpecs.reports()
+
+		addFigures(&yardReport.CapacityFigures, blockReport.CapacityFigures)
+		for _, spec := range blockSpecs.order {
+			yardSpecs.add(spec, *blockSpecs.figures[spec])
+		}
+
+		yardReport.Blocks = append(yardReport.Blocks, blockReport)
+	}
+
+	setUtilization(&yardReport.CapacityFigures)
+	yardReport.BySpec = yardSpecs.reports()
+
+	return yardReport, nil
+}
+
+// distinctPlanTEU is the capacity of the plans per container specification,
+// counting a cell covered by several plans once. A 40ft plan only uses the
+// slot pairs from its first slot, as in planCapacity, and two cells hold one
+// 40ft position.
+func distinctPlanTEU(plans []model.YardPlan, block *model.Block) map[capacitySpec]int {
+	type specRow struct {
+		spec capacitySpec
+		row  int
+	}
+	covered := make(map[specRow]map[int]bool)
+
+	for i := range plans {
+		plan := &plans[i]
+		spec := capacitySpec{plan.ContainerSize, plan.ContainerHeight, plan.ContainerType}
+		for row := plan.RowStart; row <= plan.RowEnd; row++ {
+			key := specRow{spec, row}
+			if covered[key] == nil {
+				covered[key] = make(map[int]bool)
+			}
+			lastSlot := plan.SlotEnd
+			if spec.size == "40ft" {
+				lastSlot -= (plan.SlotEnd - plan.SlotStart + 1) % 2
+			}
+			for slot := plan.SlotStart; slot <= lastSlot; slot++ {
+				covered[key][slot] = true
+			}
+		}
+	}
+
+	teu := make(map[capacitySpec]int)
+	for key, slots := range covered {
+		positions := len(slots)
+		if key.spec.size == "40ft" {
+			positions /= 2
+		}
+		teu[key.spec] += positions * block.Tiers * containerTEU(key.spec.size)
+	}
+	return teu
+}
+
+// containerTEU is the number of twenty-foot equivalent units of a container.
+func containerTEU(size string) int {
+	if size == "40ft" {
+		return 2
+	}
+	return 1
+}
+
+func addFigures(dst *web.CapacityFigures, src web.CapacityFigures) {
+	dst.TotalTEU += src.TotalTEU
+	dst.OccupiedTEU += src.OccupiedTEU
+	dst.ReservedTEU += src.ReservedTEU
+	dst.FreeTEU += src.FreeTEU
+	dst.OverflowTEU += src.OverflowTEU
+}
+
+// setUtilization stores the share of the total that is occupied or reserved.
+func setUtilization(figures *web.CapacityFigures) {
+	if figures.TotalTEU == 0 {
+		figures.Utilization = 0
+		return
+	}
+	used := float64(figures.OccupiedTEU+figures.ReservedTEU) / float64(figures.TotalTEU)
+	figures.Utilization = math.Round(used*10000) / 10000
+}
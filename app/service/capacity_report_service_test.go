@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+	"yard-planning/app/model"
+)
+
+func TestDistinctPlanTEU(t *testing.T) {
+	block := &model.Block{Slots: 10, Rows: 5, Tiers: 4}
+	dry20 := capacitySpec{"20ft", "8.6ft", "DRY"}
+	dry40 := capacitySpec{"40ft", "9.6ft", "DRY"}
+
+	plan := func(spec capacitySpec, slotStart, slotEnd, rowStart, rowEnd int) model.YardPlan {
+		return model.YardPlan{
+			SlotStart: slotStart, SlotEnd: slotEnd, RowStart: rowStart, RowEnd: rowEnd,
+			ContainerSize: spec.size, ContainerHeight: spec.height, ContainerType: spec.cType,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		plans []model.YardPlan
+		want  map[capacitySpec]int
+	}{
+		{
+			name:  "single plan matches planCapacity",
+			plans: []model.YardPlan{plan(dry20, 1, 4, 1, 2)},
+			want:  map[capacitySpec]int{dry20: 4 * 2 * 4},
+		},
+		{
+			name:  "overlapping plans count shared cells once",
+			plans: []model.YardPlan{plan(dry20, 1, 4, 1, 2), plan(dry20, 3, 6, 2, 3)},
+			// row 1: slots 1-4, row 2: slots 1-6, row 3: slots 3-6
+			want: map[capacitySpec]int{dry20: (4 + 6 + 4) * 4},
+		},
+		{
+			name:  "identical plans",
+			plans: []model.YardPlan{plan(dry20, 1, 10, 1, 5), plan(dry20, 1, 10, 1, 5)},
+			want:  map[capacitySpec]int{dry20: 10 * 5 * 4},
+		},
+		{
+			name:  "40ft odd slot is not counted",
+			plans: []model.YardPlan{plan(dry40, 5, 9, 1, 1)},
+			want:  map[capacitySpec]int{dry40: 2 * 4 * 2},
+		},
+		{
+			name:  "adjacent 40ft plans keep their odd slots unused",
+			plans: []model.YardPlan{plan(dry40, 1, 3, 1, 1), plan(dry40, 4, 6, 1, 1)},
+			want:  map[capacitySpec]int{dry40: 2 * 4 * 2},
+		},
+		{
+			name:  "overlapping 40ft plans",
+			plans: []model.YardPlan{plan(dry40, 1, 4, 1, 1), plan(dry40, 2, 5, 1, 1)},
+			want:  map[capacitySpec]int{dry40: 2 * 4 * 2},
+		},
+		{
+			name:  "specifications are kept apart",
+			plans: []model.YardPlan{plan(dry20, 1, 4, 1, 1), plan(dry40, 5, 10, 1, 1)},
+			want:  map[capacitySpec]int{dry20: 4 * 4, dry40: 3 * 4 * 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distinctPlanTEU(tt.plans, block)
+			if len(got) != len(tt.want) {
+				t.Fatalf("distinctPlanTEU() = %v, want %v", got, tt.want)
+			}
+			for spec, teu := range tt.want {
+				if got[spec] != teu {
+					t.Errorf("TEU of %v = %d, want %d", spec, got[spec], teu)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/helper/edifact"
+)
+
+// COARRI document name codes of the BGM segment.
+const (
+	coarriDischarge = "44"
+	coarriLoad      = "46"
+)
+
+// coarriArrivalWindow is how long after the vessel ETA discharged containers
+// are expected in the yard.
+const coarriArrivalWindow = 48 * time.Hour
+
+// coarriMessage is one COARRI message read by parseCOARRI.
+type coarriMessage struct {
+	DocumentCode string
+	Vessel       string
+	Voyage       string
+	ShippingLine string
+	// Estimated arrival of the vessel, DTM 132
+	Arrival    *time.Time
+	Containers []coarriContainer
+}
+
+type coarriContainer struct {
+	ContainerNumber string
+	SizeType        string
+	FullEmpty       string
+}
+
+// buildCOARRI writes one COARRI D.95B interchange for a vessel call: a
+// discharge report of the containers placed from the vessel and a load report
+// of the containers picked up for it, skipping a report without containers.
+// It returns the interchange and its message count.
+func buildCOARRI(partner *model.EDIPartner, vessel, voyage string, moves []model.ContainerMove, prepared time.Time) (string, int) {
+	reference := prepared.Format("060102150405")
+
+	var discharged, loaded []model.ContainerMove
+	for _, move := range moves {
+		if move.MoveType == model.MoveTypePlacement {
+			discharged = append(discharged, move)
+		} else {
+			loaded = append(loaded, move)
+		}
+	}
+
+	writer := &edifact.Writer{}
+	writer.BeginInterchange(partner.SenderID, partner.RecipientID, reference, prepared)
+
+	messages := 0
+	for _, report := range []struct {
+		documentCode string
+		suffix       string
+		moves        []model.ContainerMove
+	}{
+		{coarriDischarge, "DIS", discharged},
+		{coarriLoad, "LOA", loaded},
+	} {
+		if len(report.moves) == 0 {
+			continue
+		}
+		messages++
+
+		writer.BeginMessage(reference+strconv.Itoa(messages), edifact.E("COARRI", "D", "95B", "UN", "ITG13"))
+		writer.Segment("BGM", edifact.E(report.documentCode), edifact.E(reference+report.suffix), edifact.E("9"))
+		writer.Segment("DTM", edifact.E("137", edifact.DateTime(prepared), "203"))
+		writer.Segment("TDT",
+			edifact.E("20"), edifact.E(voyage), edifact.E("1"), edifact.E(),
+			edifact.E(partner.ShippingLine, "172", "20"), edifact.E(), edifact.E(),
+			edifact.E("", "", "", vessel),
+		)
+		writer.Segment("RFF", edifact.E("VON", voyage))
+		writer.Segment("NAD", edifact.E("CA"), edifact.E(partner.ShippingLine, "172", "20"))
+
+		for _, move := range report.moves {
+			writer.Segment("EQD",
+				edifact.E("CN"),
+				edifact.E(move.ContainerNumber),
+				edifact.E(isoSizeType(move.ContainerSize, move.ContainerHeight, move.ContainerType), "102", "5"),
+				edifact.E(),
+				edifact.E(),
+				edifact.E(fullEmptyIndicator(move.ContainerType)),
+			)
+			writer.Segment("DTM", edifact.E("203", edifact.DateTime(move.CreatedAt.In(prepared.Location())), "203"))
+		}
+
+		writer.Segment("CNT", edifact.E("16", strconv.Itoa(len(report.moves))))
+		writer.EndMessage()
+	}
+
+	writer.EndInterchange()
+	return writer.String(), messages
+}
+
+// parseCOARRI reads the messages of a COARRI interchange, dates in loc.
+// Vessel and voyage come from the TDT segment of the main carriage, the
+// voyage falling back to RFF+VON.
+func parseCOARRI(data string, loc *time.Location) ([]coarriMessage, error) {
+	segments, err := edifact.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []coarriMessage
+	var current *coarriMessage
+
+	for i := range segments {
+		segment := &segments[i]
+
+		switch segment.Tag {
+		case "UNH":
+			if messageType := segment.Value(1, 0); messageType != "COARRI" {
+				return nil, errors.New("message " + segment.Value(0, 0) + " is " + messageType + ", not COARRI")
+			}
+			messages = append(messages, coarriMessage{})
+			current = &messages[len(messages)-1]
+			continue
+		case "UNT":
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch segment.Tag {
+		case "BGM":
+			current.DocumentCode = segment.Value(0, 0)
+		case "TDT":
+			if segment.Value(0, 0) == "20" {
+				current.Voyage = segment.Value(1, 0)
+				current.ShippingLine = segment.Value(4, 0)
+				current.Vessel = segment.Value(7, 3)
+			}
+		case "RFF":
+			if segment.Value(0, 0) == "VON" && current.Voyage == "" {
+				current.Voyage = segment.Value(0, 1)
+			}
+		case "DTM":
+			// Only the message level ETA, container DTMs follow an EQD
+			if segment.Value(0, 0) == "132" && len(current.Containers) == 0 {
+				arrival, err := parseEDIDateTime(segment.Value(0, 1), segment.Value(0, 2), loc)
+				if err != nil {
+					return nil, err
+				}
+				current.Arrival = &arrival
+			}
+		case "EQD":
+			if segment.Value(0, 0) == "CN" {
+				current.Containers = append(current.Containers, coarriContainer{
+					ContainerNumber: segment.Value(1, 0),
+					SizeType:        segment.Value(2, 0),
+					FullEmpty:       segment.Value(5, 0),
+				})
+			}
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil, errors.New("no COARRI message found")
+	}
+	return messages, nil
+}
+
+// parseEDIDateTime reads a DTM value in format 203 (CCYYMMDDHHMM) or 102
+// (CCYYMMDD) as a time in loc.
+func parseEDIDateTime(value, format string, loc *time.Location) (time.Time, error) {
+	layout := "200601021504"
+	if format == "102" {
+		layout = "20060102"
+	}
+
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, errors.New("invalid DTM value " + value + " in format " + format)
+	}
+	return t, nil
+}
+
+// containerSpec reverses isoSizeType: the size, height and type of an ISO
+// 6346 size-type code. Unknown lengths are returned as the raw code so that
+// request validation rejects them. Empty containers get the EMPTY type.
+func containerSpec(sizeType, fullEmpty string) (size, height, containerType string) {
+	if len(sizeType) != 4 {
+		return sizeType, "", ""
+	}
+
+	switch sizeType[0] {
+	case '2':
+		size = "20ft"
+	case '4':
+		size = "40ft"
+	default:
+		size = sizeType
+	}
+
+	height = "8.6ft"
+	if sizeType[1] == '5' {
+		height = "9.6ft"
+	}
+
+	// Older stowage plans still use the numeric type groups of ISO 6346:1984
+	switch strings.ToUpper(sizeType[2:3]) {
+	case "R", "3":
+		containerType = "Reefer"
+	case "U", "5":
+		containerType = "Open Top"
+	case "P", "6":
+		containerType = "Flat Rack"
+	case "T", "7":
+		containerType = "Tank"
+	default:
+		containerType = "DRY"
+	}
+	if fullEmpty == "4" {
+		containerType = "EMPTY"
+	}
+
+	return size, height, containerType
+}
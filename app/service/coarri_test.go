@@ -0,0 +1,169 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+)
+
+func TestParseCOARRI(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name    string
+		data    string
+		want    []coarriMessage
+		wantErr bool
+	}{
+		{
+			name: "discharge with ETA",
+			data: "UNB+UNOA:2+MSCU+IDTPKYARD+261020:0800+1'\n" +
+				"UNH+1+COARRI:D:95B:UN:ITG13'\n" +
+				"BGM+44+REF1+9'\n" +
+				"TDT+20+123E+1++MSC:172:20+++:::MV SINAR BUNDA'\n" +
+				"DTM+132:202610210630:203'\n" +
+				"EQD+CN+MSCU1234565+22G1:102:5+++5'\n" +
+				"DTM+203:202610220900:203'\n" +
+				"EQD+CN+MSCU7654321+45R1:102:5+++4'\n" +
+				"UNT+8+1'\n" +
+				"UNZ+1+1'",
+			want: []coarriMessage{{
+				DocumentCode: coarriDischarge,
+				Vessel:       "MV SINAR BUNDA",
+				Voyage:       "123E",
+				ShippingLine: "MSC",
+				Arrival:      timePtr(time.Date(2026, time.October, 21, 6, 30, 0, 0, jakarta)),
+				Containers: []coarriContainer{
+					{ContainerNumber: "MSCU1234565", SizeType: "22G1", FullEmpty: "5"},
+					{ContainerNumber: "MSCU7654321", SizeType: "45R1", FullEmpty: "4"},
+				},
+			}},
+		},
+		{
+			name: "voyage from RFF and date only ETA",
+			data: "UNH+1+COARRI:D:95B:UN'" +
+				"BGM+44+REF1+9'" +
+				"TDT+20++1++MSC:172:20+++:::MV SINAR BUNDA'" +
+				"RFF+VON:456W'" +
+				"DTM+132:20261021:102'" +
+				"UNT+5+1'",
+			want: []coarriMessage{{
+				DocumentCode: coarriDischarge,
+				Vessel:       "MV SINAR BUNDA",
+				Voyage:       "456W",
+				ShippingLine: "MSC",
+				Arrival:      timePtr(time.Date(2026, time.October, 21, 0, 0, 0, 0, jakarta)),
+			}},
+		},
+		{
+			name: "segments outside a message are ignored",
+			data: "UNB+UNOA:2+MSCU+IDTPKYARD'" +
+				"EQD+CN+MSCU0000000'" +
+				"UNH+1+COARRI:D:95B:UN'BGM+46+REF2+9'EQD+CN+MSCU1234565+22G1+++5'UNT+4+1'" +
+				"UNH+2+COARRI:D:95B:UN'BGM+44+REF3+9'UNT+3+2'",
+			want: []coarriMessage{
+				{DocumentCode: coarriLoad, Containers: []coarriContainer{{ContainerNumber: "MSCU1234565", SizeType: "22G1", FullEmpty: "5"}}},
+				{DocumentCode: coarriDischarge},
+			},
+		},
+		{name: "other message type", data: "UNH+1+CODECO:D:95B:UN'UNT+2+1'", wantErr: true},
+		{name: "invalid ETA", data: "UNH+1+COARRI:D:95B:UN'DTM+132:2026-10-21:203'UNT+3+1'", wantErr: true},
+		{name: "no message", data: "UNB+UNOA:2+MSCU+IDTPKYARD'UNZ+0+1'", wantErr: true},
+		{name: "not EDIFACT", data: "container,yard", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := parseCOARRI(tt.data, jakarta)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCOARRI error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(messages, tt.want) {
+				t.Errorf("parseCOARRI =\n%+v\nwant\n%+v", messages, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCOARRIReadsBuildCOARRI(t *testing.T) {
+	partner := &model.EDIPartner{ShippingLine: "MSC", SenderID: "IDTPKYARD", RecipientID: "MSCU"}
+	moves := []model.ContainerMove{
+		{ContainerNumber: "MSCU1234565", MoveType: model.MoveTypePlacement, ContainerSize: "20ft", ContainerHeight: "8.6ft", ContainerType: "Dry"},
+		{ContainerNumber: "MSCU7654321", MoveType: model.MoveTypePickup, ContainerSize: "40ft", ContainerHeight: "9.6ft", ContainerType: "Reefer"},
+	}
+
+	content, _ := buildCOARRI(partner, "MV Sinar Bunda", "123E", moves, time.Date(2026, time.October, 20, 8, 0, 0, 0, time.UTC))
+	messages, err := parseCOARRI(content, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		documentCode, containerNumber, sizeType string
+	}{
+		{coarriDischarge, "MSCU1234565", "22G1"},
+		{coarriLoad, "MSCU7654321", "45R1"},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("got %d messages, want %d", len(messages), len(want))
+	}
+	for i, w := range want {
+		message := messages[i]
+		if message.DocumentCode != w.documentCode || message.Vessel != "MV SINAR BUNDA" || message.Voyage != "123E" || message.ShippingLine != "MSC" {
+			t.Errorf("message %d = %s %s/%s by %s, want %s MV SINAR BUNDA/123E by MSC",
+				i, message.DocumentCode, message.Vessel, message.Voyage, message.ShippingLine, w.documentCode)
+		}
+		if len(message.Containers) != 1 || message.Containers[0].ContainerNumber != w.containerNumber || message.Containers[0].SizeType != w.sizeType {
+			t.Errorf("message %d containers = %+v, want %s %s", i, message.Containers, w.containerNumber, w.sizeType)
+		}
+	}
+}
+
+func TestContainerSpec(t *testing.T) {
+	tests := []struct {
+		sizeType, fullEmpty         string
+		size, height, containerType string
+	}{
+		{"22G1", "5", "20ft", "8.6ft", "DRY"},
+		{"45G1", "5", "40ft", "9.6ft", "DRY"},
+		{"45R1", "5", "40ft", "9.6ft", "Reefer"},
+		{"2232", "5", "20ft", "8.6ft", "Reefer"},
+		{"22U1", "5", "20ft", "8.6ft", "Open Top"},
+		{"4551", "5", "40ft", "9.6ft", "Open Top"},
+		{"42P1", "5", "40ft", "8.6ft", "Flat Rack"},
+		{"22t6", "5", "20ft", "8.6ft", "Tank"},
+		{"22G1", "4", "20ft", "8.6ft", "EMPTY"},
+		{"L5G1", "5", "L5G1", "9.6ft", "DRY"},
+		{"22G", "5", "22G", "", ""},
+		{"", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		size, height, containerType := containerSpec(tt.sizeType, tt.fullEmpty)
+		if size != tt.size || height != tt.height || containerType != tt.containerType {
+			t.Errorf("containerSpec(%q, %q) = %q, %q, %q, want %q, %q, %q",
+				tt.sizeType, tt.fullEmpty, size, height, containerType, tt.size, tt.height, tt.containerType)
+		}
+	}
+}
+
+func TestContainerSpecReversesIsoSizeType(t *testing.T) {
+	for _, size := range []string{"20ft", "40ft"} {
+		for _, height := range []string{"8.6ft", "9.6ft"} {
+			for _, containerType := range []string{"Reefer", "Open Top", "Flat Rack", "Tank"} {
+				gotSize, gotHeight, gotType := containerSpec(isoSizeType(size, height, containerType), "5")
+				if gotSize != size || gotHeight != height || gotType != containerType {
+					t.Errorf("%s %s %s came back as %s %s %s", size, height, containerType, gotSize, gotHeight, gotType)
+				}
+			}
+		}
+	}
+}
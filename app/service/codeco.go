@@ -0,0 +1,137 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/helper/edifact"
+)
+
+// CODECO document name codes of the BGM segment.
+const (
+	codecoGateIn  = "34"
+	codecoGateOut = "36"
+)
+
+// buildCODECO writes one CODECO D.95B interchange for the partner: a gate-in
+// report of the placements and a gate-out report of the pickups, skipping a
+// report without containers. It returns the interchange and its message count.
+func buildCODECO(partner *model.EDIPartner, events []model.ContainerGateEvent, prepared time.Time) (string, int) {
+	reference := prepared.Format("060102150405")
+
+	var gateIn, gateOut []model.ContainerGateEvent
+	for _, event := range events {
+		if event.MoveType == model.MoveTypePlacement {
+			gateIn = append(gateIn, event)
+		} else {
+			gateOut = append(gateOut, event)
+		}
+	}
+
+	writer := &edifact.Writer{}
+	writer.BeginInterchange(partner.SenderID, partner.RecipientID, reference, prepared)
+
+	messages := 0
+	for _, report := range []struct {
+		documentCode string
+		suffix       string
+		events       []model.ContainerGateEvent
+	}{
+		{codecoGateIn, "IN", gateIn},
+		{codecoGateOut, "OUT", gateOut},
+	} {
+		if len(report.events) == 0 {
+			continue
+		}
+		messages++
+
+		writer.BeginMessage(reference+strconv.Itoa(messages), edifact.E("CODECO", "D", "95B", "UN", "ITG14"))
+		writer.Segment("BGM", edifact.E(report.documentCode), edifact.E(reference+report.suffix), edifact.E("9"))
+		writer.Segment("DTM", edifact.E("137", edifact.DateTime(prepared), "203"))
+		writer.Segment("NAD", edifact.E("MS"), edifact.E(partner.SenderID))
+		writer.Segment("NAD", edifact.E("CF"), edifact.E(partner.ShippingLine, "160", "ZZZ"))
+
+		for _, event := range report.events {
+			event.CreatedAt = event.CreatedAt.In(prepared.Location())
+			writeCODECOContainer(writer, &event)
+		}
+
+		writer.Segment("CNT", edifact.E("16", strconv.Itoa(len(report.events))))
+		writer.EndMessage()
+	}
+
+	writer.EndInterchange()
+	return writer.String(), messages
+}
+
+// writeCODECOContainer writes the equipment group of one container. The
+// truck is the pre-carriage of a gate-in and the on-carriage of a gate-out.
+func writeCODECOContainer(writer *edifact.Writer, event *model.ContainerGateEvent) {
+	writer.Segment("EQD",
+		edifact.E("CN"),
+		edifact.E(event.ContainerNumber),
+		edifact.E(isoSizeType(event.ContainerSize, event.ContainerHeight, event.ContainerType), "102", "5"),
+		edifact.E(),
+		edifact.E(),
+		edifact.E(fullEmptyIndicator(event.ContainerType)),
+	)
+	if event.BookingReference != "" {
+		writer.Segment("RFF", edifact.E("BN", event.BookingReference))
+	}
+	writer.Segment("DTM", edifact.E("7", edifact.DateTime(event.CreatedAt), "203"))
+	if event.YardName != "" {
+		writer.Segment("LOC", edifact.E("165"), edifact.E(event.YardName, "TER", "ZZZ"))
+	}
+	for _, seal := range strings.Split(event.SealNumbers, ",") {
+		if seal = strings.TrimSpace(seal); seal != "" {
+			writer.Segment("SEL", edifact.E(seal), edifact.E("CA"))
+		}
+	}
+	if event.TruckPlate != "" {
+		stage := "1"
+		if event.MoveType == model.MoveTypePickup {
+			stage = "3"
+		}
+		writer.Segment("TDT",
+			edifact.E(stage), edifact.E(), edifact.E("3"), edifact.E(), edifact.E(), edifact.E(), edifact.E(),
+			edifact.E(event.TruckPlate, "146"),
+		)
+	}
+}
+
+// isoSizeType returns the ISO 6346 size-type code: length, height and type
+// group, e.g. 22G1 for a 20ft 8.6ft dry container.
+func isoSizeType(size, height, containerType string) string {
+	length := "2"
+	if size == "40ft" {
+		length = "4"
+	}
+
+	heightCode := "2"
+	if height == "9.6ft" {
+		heightCode = "5"
+	}
+
+	typeCode := "G1"
+	switch strings.ToUpper(containerType) {
+	case "REEFER":
+		typeCode = "R1"
+	case "OPEN TOP":
+		typeCode = "U1"
+	case "FLAT RACK":
+		typeCode = "P1"
+	case "TANK":
+		typeCode = "T1"
+	}
+
+	return length + heightCode + typeCode
+}
+
+// fullEmptyIndicator returns 4 for empty and 5 for full containers.
+func fullEmptyIndicator(containerType string) string {
+	if strings.EqualFold(containerType, "EMPTY") {
+		return "4"
+	}
+	return "5"
+}
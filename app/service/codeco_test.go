@@ -0,0 +1,91 @@
+package service
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/name, or rewrites the file when the
+// tests run with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update to create it", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from the golden file:\n--- got\n%s\n--- want\n%s", name, got, want)
+	}
+}
+
+func TestBuildCODECO(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepared := time.Date(2026, time.October, 31, 23, 30, 0, 0, jakarta)
+	partner := &model.EDIPartner{ShippingLine: "MSC", SenderID: "IDJKTTPK", RecipientID: "MSCU", Timezone: "Asia/Jakarta"}
+
+	event := func(moveType, containerNumber, size, height, containerType string, at time.Time) model.ContainerGateEvent {
+		return model.ContainerGateEvent{
+			ContainerMove: model.ContainerMove{
+				ContainerNumber: containerNumber,
+				MoveType:        moveType,
+				ContainerSize:   size,
+				ContainerHeight: height,
+				ContainerType:   containerType,
+				ShippingLine:    "MSC",
+				CreatedAt:       at,
+			},
+			YardName: "YRD-UTAMA",
+		}
+	}
+
+	gateIn := event(model.MoveTypePlacement, "MSCU1234565", "20ft", "8.6ft", "Dry", time.Date(2026, time.October, 1, 1, 15, 0, 0, time.UTC))
+	gateIn.TruckPlate = "B 1234 XYZ"
+	gateIn.SealNumbers = "SL001, SL002"
+	gateIn.BookingReference = "BK+2026'01"
+
+	gateOut := event(model.MoveTypePickup, "MSCU7654321", "40ft", "9.6ft", "Reefer", time.Date(2026, time.October, 31, 15, 45, 0, 0, time.UTC))
+	gateOut.TruckPlate = "B 9876 ABC"
+
+	// Moved inside the yard, it has no gate transaction
+	emptyIn := event(model.MoveTypePlacement, "MSCU1111111", "20ft", "8.6ft", "Empty", time.Date(2026, time.October, 15, 3, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		golden       string
+		events       []model.ContainerGateEvent
+		wantMessages int
+	}{
+		{"codeco_gate_in_out.edi", []model.ContainerGateEvent{gateIn, emptyIn, gateOut}, 2},
+		{"codeco_gate_in_only.edi", []model.ContainerGateEvent{gateIn}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			content, messages := buildCODECO(partner, tt.events, prepared)
+			if messages != tt.wantMessages {
+				t.Errorf("messages = %d, want %d", messages, tt.wantMessages)
+			}
+			checkGolden(t, tt.golden, content)
+		})
+	}
+}
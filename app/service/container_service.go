@@ -1,12 +1,20 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"yard-planning/app/cache"
 	"yard-planning/app/model"
 	"yard-planning/app/repository"
 	"yard-planning/app/web"
+	"yard-planning/helper"
+	"yard-planning/helper/auditlog"
 	"yard-planning/response"
 
 	"github.com/go-playground/validator/v10"
@@ -16,15 +24,88 @@ import (
 type ContainerService interface {
 	SuggestPosition(ctx context.Context, request *web.ContainerRequest) (*web.PositionResponse, *response.CustomError)
 
-	PlaceContainer(ctx context.Context, request *web.PlacementRequest) (*web.PositionResponse, *response.CustomError)
+	// SuggestPositionTx is SuggestPosition reading through the transaction
+	// tx of the caller. A caller that locked the yard with LockYardBlocks
+	// keeps the cell free until it stores its reservation in tx.
+	SuggestPositionTx(tx *gorm.DB, request *web.ContainerRequest) (*web.PositionResponse, *response.CustomError)
 
-	PickupContainer(ctx context.Context, request *web.PickupRequest) (*web.GeneralResponse, *response.CustomError)
+	// CheckPlacement runs every check of PlaceContainerTx without storing the
+	// container.
+	CheckPlacement(ctx context.Context, request *web.PlacementRequest) (*web.PositionResponse, *response.CustomError)
+
+	// PlaceContainerTx, PickupContainerTx, CompletePickupTx and
+	// MoveContainerTx make the change in the transaction tx of the caller,
+	// so it commits together with the caller's own writes. The cache update
+	// and the audit record are added to after, which the caller runs once tx
+	// committed.
+	PlaceContainerTx(ctx context.Context, tx *gorm.DB, request *web.PlacementRequest, after *AfterCommit) (*web.PositionResponse, *response.CustomError)
+	PickupContainerTx(ctx context.Context, tx *gorm.DB, request *web.PickupRequest, after *AfterCommit) *response.CustomError
+	CompletePickupTx(ctx context.Context, tx *gorm.DB, containerNumber string, releaseOrderID int, after *AfterCommit) *response.CustomError
+	MoveContainerTx(ctx context.Context, tx *gorm.DB, request *web.MoveRequest, after *AfterCommit) (*web.PositionResponse, *response.CustomError)
+
+	// AuthorizePickup checks the yard, the holds and the release order of a
+	// pickup without performing it and returns the ID of the release order.
+	// CompletePickupTx then removes the container under that release order.
+	AuthorizePickup(ctx context.Context, request *web.PickupRequest) (int, *response.CustomError)
+
+	// CheckMove runs every check of MoveContainerTx without moving the
+	// container.
+	CheckMove(ctx context.Context, request *web.MoveRequest) (*web.PositionResponse, *response.CustomError)
+
+	// SuggestTransfer finds a cell in another yard for a container already in
+	// the yard, from the active plans of that yard.
+	SuggestTransfer(ctx context.Context, containerNumber, yardName string) (*web.PositionResponse, *response.CustomError)
+
+	SuggestPositions(ctx context.Context, request *web.BatchContainerRequest) (*web.BatchResponse, *response.CustomError)
+
+	// SuggestPositionsTx is SuggestPositions reading through the transaction
+	// tx of the caller, see SuggestPositionTx.
+	SuggestPositionsTx(tx *gorm.DB, request *web.BatchContainerRequest) (*web.BatchResponse, *response.CustomError)
+
+	// CheckPlacements runs the checks of CheckPlacement for a batch, as if
+	// the containers were placed together.
+	CheckPlacements(ctx context.Context, request *web.BatchPlacementRequest) (*web.BatchResponse, *response.CustomError)
+}
+
+// AfterCommit collects the cache updates and audit records of container
+// changes made in a transaction of the caller. They must only run once the
+// transaction committed, a rolled back change drops them.
+type AfterCommit struct {
+	actions []func()
+}
+
+func (a *AfterCommit) add(action func()) {
+	a.actions = append(a.actions, action)
+}
+
+// Run performs the collected actions in order.
+func (a *AfterCommit) Run() {
+	for _, action := range a.actions {
+		action()
+	}
+	a.actions = nil
+}
+
+// cellKey identifies a single cell (slot, row, tier) inside a block.
+type cellKey struct {
+	BlockID int
+	Slot    int
+	Row     int
+	Tier    int
 }
 
 type ContainerServiceImpl struct {
 	YardRepository              repository.YardRepository
 	YardPlanRepository          repository.YardPlanRepository
 	ContainerPositionRepository repository.ContainerPositionRepository
+	ContainerMoveRepository     repository.ContainerMoveRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	ReleaseOrderRepository      repository.ReleaseOrderRepository
+	ContainerHoldRepository     repository.ContainerHoldRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	OccupancyCache              cache.BlockOccupancyCache
 	DB                          *gorm.DB
 	Validate                    *validator.Validate
 }
@@ -33,6 +114,14 @@ func NewContainerService(
 	yardRepo repository.YardRepository,
 	planRepo repository.YardPlanRepository,
 	containerRepo repository.ContainerPositionRepository,
+	moveRepo repository.ContainerMoveRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	releaseRepo repository.ReleaseOrderRepository,
+	holdRepo repository.ContainerHoldRepository,
+	outboxRepo repository.OutboxEventRepository,
+	occupancyCache cache.BlockOccupancyCache,
 	DB *gorm.DB,
 	validate *validator.Validate,
 ) ContainerService {
@@ -40,12 +129,127 @@ func NewContainerService(
 		YardRepository:              yardRepo,
 		YardPlanRepository:          planRepo,
 		ContainerPositionRepository: containerRepo,
+		ContainerMoveRepository:     moveRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		ReleaseOrderRepository:      releaseRepo,
+		ContainerHoldRepository:     holdRepo,
+		OutboxEventRepository:       outboxRepo,
+		OccupancyCache:              occupancyCache,
 		DB:                          DB,
 		Validate:                    validate,
 	}
 }
 
 func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web.ContainerRequest) (*web.PositionResponse, *response.CustomError) {
+	return s.SuggestPositionTx(s.DB, request)
+}
+
+func (s *ContainerServiceImpl) SuggestPositionTx(tx *gorm.DB, request *web.ContainerRequest) (*web.PositionResponse, *response.CustomError) {
+	reserved, err := s.reservedCells(tx)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch reserved cells: " + err.Error())
+	}
+
+	return s.suggestPosition(tx, request, reserved)
+}
+
+func (s *ContainerServiceImpl) SuggestTransfer(ctx context.Context, containerNumber, yardName string) (*web.PositionResponse, *response.CustomError) {
+	var container model.ContainerPosition
+	if err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &container, containerNumber); err != nil {
+		return nil, response.NotFoundError("Container not found at any position.")
+	}
+
+	reserved, err := s.reservedCells(s.DB)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch reserved cells: " + err.Error())
+	}
+
+	return s.findPosition(s.DB, &web.ContainerRequest{
+		YardName:        yardName,
+		ContainerNumber: container.ContainerNumber,
+		Size:            container.ContainerSize,
+		Height:          container.ContainerHeight,
+		Type:            container.ContainerType,
+	}, reserved)
+}
+
+// reservedCells collects the cells promised to containers that are not in
+// the yard yet, see loadReservations.
+func (s *ContainerServiceImpl) reservedCells(db *gorm.DB) (map[cellKey]bool, error) {
+	reservations, err := loadReservations(db, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[cellKey]bool, len(reservations))
+	for _, cell := range reservations {
+		reserveCells(reserved, &web.PositionResponse{
+			BlockID: cell.BlockID,
+			Slot:    cell.Slot,
+			Row:     cell.Row,
+			Tier:    cell.Tier,
+		}, cell.ContainerSize)
+	}
+	return reserved, nil
+}
+
+// provisionalPosition returns the cell held by the container's pending
+// pre-advice when it still suits the request and is free, nil otherwise.
+func (s *ContainerServiceImpl) provisionalPosition(db *gorm.DB, request *web.ContainerRequest) (*web.PositionResponse, *response.CustomError) {
+	var preAdvice model.PreAdvice
+	if err := s.PreAdviceRepository.FindPendingByContainerNumber(db, &preAdvice, request.ContainerNumber); err != nil {
+		if errors.Is(err, repository.ErrPreAdviceNotFound) {
+			return nil, nil
+		}
+		return nil, response.RepositoryError("Failed to fetch pre-advice: " + err.Error())
+	}
+	if preAdvice.BlockID == nil {
+		return nil, nil
+	}
+
+	if preAdvice.ContainerSize != request.Size || preAdvice.ContainerHeight != request.Height || preAdvice.ContainerType != request.Type {
+		return nil, nil
+	}
+
+	var block model.Block
+	if err := s.YardRepository.FindBlockByID(db, &block, *preAdvice.BlockID); err != nil {
+		return nil, nil
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(db, &yard, request.YardName); err != nil || yard.ID != block.YardID {
+		return nil, nil
+	}
+	if request.BlockName != "" && request.BlockName != block.Name {
+		return nil, nil
+	}
+
+	slot, row, tier := *preAdvice.SlotNumber, *preAdvice.RowNumber, *preAdvice.TierNumber
+	slotNumbersToCheck := []int{slot}
+	if request.Size == "40ft" {
+		slotNumbersToCheck = append(slotNumbersToCheck, slot+1)
+	}
+
+	if isFree, err := s.OccupancyCache.IsFree(db, &block, row, tier, slotNumbersToCheck); err != nil || !isFree {
+		return nil, nil
+	}
+
+	position := &web.PositionResponse{
+		Block:   block.Name,
+		Slot:    slot,
+		Row:     row,
+		Tier:    tier,
+		BlockID: block.ID,
+	}
+	if plan, err := s.YardPlanRepository.FindApplicablePlan(db, block.ID, slot, row, request.Size, request.Height, request.Type, time.Now()); err == nil && plan != nil {
+		position.YardPlanID = &plan.ID
+	}
+	return position, nil
+}
+
+func (s *ContainerServiceImpl) suggestPosition(db *gorm.DB, request *web.ContainerRequest, reserved map[cellKey]bool) (*web.PositionResponse, *response.CustomError) {
 	if err := s.Validate.Struct(request); err != nil {
 		return nil, response.BadRequestError(err.Error())
 	}
@@ -56,13 +260,13 @@ func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web
 
 	// check container if exist
 	var existingPosition model.ContainerPosition
-	err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &existingPosition, request.ContainerNumber)
+	err := s.ContainerPositionRepository.FindByContainerNumber(db, &existingPosition, request.ContainerNumber)
 
 	if err == nil && existingPosition.ID > 0 {
 		blockName := ""
 		if existingPosition.BlockID > 0 {
 			var block model.Block
-			s.YardRepository.FindBlockByID(s.DB, &block, existingPosition.BlockID)
+			s.YardRepository.FindBlockByID(db, &block, existingPosition.BlockID)
 			blockName = block.Name
 		}
 
@@ -73,9 +277,25 @@ func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web
 		)
 	}
 
+	position, customErr := s.provisionalPosition(db, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if position != nil {
+		return position, nil
+	}
+
+	return s.findPosition(db, request, reserved)
+}
+
+// findPosition searches the active yard plans for the first empty position
+// matching the container specification. Cells listed in reserved are treated
+// as occupied, which lets callers plan several containers without handing
+// out the same cell twice.
+func (s *ContainerServiceImpl) findPosition(db *gorm.DB, request *web.ContainerRequest, reserved map[cellKey]bool) (*web.PositionResponse, *response.CustomError) {
 	// Find yard
 	var yard model.Yard
-	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+	if err := s.YardRepository.FindYardByName(db, &yard, request.YardName); err != nil {
 		return nil, response.NotFoundError("Yard not found.")
 	}
 
@@ -84,13 +304,13 @@ func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web
 	// Find blocks
 	if request.BlockName != "" {
 		var block model.Block
-		err = s.YardRepository.FindBlockByNameAndYardID(s.DB, &block, request.BlockName, yard.ID)
+		err := s.YardRepository.FindBlockByNameAndYardID(db, &block, request.BlockName, yard.ID)
 		if err != nil {
 			return nil, response.NotFoundError("Block not found in this Yard.")
 		}
 		blocks = append(blocks, block)
 	} else {
-		err = s.YardRepository.FindBlocksByYardID(s.DB, &blocks, yard.ID)
+		err := s.YardRepository.FindBlocksByYardID(db, &blocks, yard.ID)
 		if err != nil {
 			return nil, response.GeneralError("Failed to fetch blocks for the yard: " + err.Error())
 		}
@@ -104,7 +324,7 @@ func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web
 	for _, block := range blocks {
 
 		var activePlans []model.YardPlan
-		if err := s.YardPlanRepository.FindActivePlansByBlock(s.DB, &activePlans, block.ID); err != nil {
+		if err := s.YardPlanRepository.FindActivePlansByBlock(db, &activePlans, block.ID, time.Now()); err != nil {
 			continue
 		}
 
@@ -135,25 +355,24 @@ func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web
 							slotNumbersToCheck = append(slotNumbersToCheck, slotNum+1)
 						}
 
-						// Check available container, 0 = empty
-						count, err := s.ContainerPositionRepository.CheckPositionAvailability(
-							s.DB,
-							block.ID,
-							r,
-							t,
-							slotNumbersToCheck,
-						)
+						if isReserved(reserved, block.ID, r, t, slotNumbersToCheck) {
+							continue
+						}
+
+						// Check available container against the block snapshot
+						isFree, err := s.OccupancyCache.IsFree(db, &block, r, t, slotNumbersToCheck)
 
 						if err != nil {
 							return nil, response.GeneralError("Database check failed.")
 						}
 
-						if count == 0 {
+						if isFree {
 							return &web.PositionResponse{
 								Block:      block.Name,
 								Slot:       slotNum,
 								Row:        r,
 								Tier:       t,
+								BlockID:    block.ID,
 								YardPlanID: &plan.ID,
 							}, nil
 						}
@@ -166,62 +385,230 @@ func (s *ContainerServiceImpl) SuggestPosition(ctx context.Context, request *web
 	return nil, response.GeneralError("No empty position found matching the active yard plans criteria.")
 }
 
-func (s *ContainerServiceImpl) PlaceContainer(ctx context.Context, request *web.PlacementRequest) (*web.PositionResponse, *response.CustomError) {
+// isReserved reports whether any of the given slots at row/tier of the block
+// has already been handed out in the current planning run.
+func isReserved(reserved map[cellKey]bool, blockID, row, tier int, slotNumbers []int) bool {
+	for _, slot := range slotNumbers {
+		if reserved[cellKey{BlockID: blockID, Slot: slot, Row: row, Tier: tier}] {
+			return true
+		}
+	}
+	return false
+}
+
+// reserveCells marks every cell a container of the given size occupies when
+// it starts at position.
+func reserveCells(reserved map[cellKey]bool, position *web.PositionResponse, size string) {
+	reserved[cellKey{BlockID: position.BlockID, Slot: position.Slot, Row: position.Row, Tier: position.Tier}] = true
+	if size == "40ft" {
+		reserved[cellKey{BlockID: position.BlockID, Slot: position.Slot + 1, Row: position.Row, Tier: position.Tier}] = true
+	}
+}
+
+func (s *ContainerServiceImpl) CheckPlacement(ctx context.Context, request *web.PlacementRequest) (*web.PositionResponse, *response.CustomError) {
+	var position *web.PositionResponse
+
+	customErr, txErr := s.dryRun(func(tx *gorm.DB) *response.CustomError {
+		var placeErr *response.CustomError
+		position, placeErr = s.PlaceContainerTx(ctx, tx, request, &AfterCommit{})
+		return placeErr
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to place container: " + txErr.Error())
+	}
+
+	return position, nil
+}
+
+func (s *ContainerServiceImpl) PlaceContainerTx(ctx context.Context, tx *gorm.DB, request *web.PlacementRequest, after *AfterCommit) (*web.PositionResponse, *response.CustomError) {
 	if err := s.Validate.Struct(request); err != nil {
 		return nil, response.BadRequestError(err.Error())
 	}
 
+	position, placed, customErr := s.placeContainer(tx, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	after.add(func() {
+		s.OccupancyCache.Occupy(position.BlockID, position.Slot, position.Row, position.Tier, request.Size)
+		auditlog.Record(ctx, model.AuditEntityContainer, placed.ContainerNumber, nil, placed)
+	})
+	return position, nil
+}
+
+var errDryRun = errors.New("dry run")
+
+// dryRun runs fn in a transaction that is always rolled back, which turns fn
+// into a check of what it would store.
+func (s *ContainerServiceImpl) dryRun(fn func(tx *gorm.DB) *response.CustomError) (*response.CustomError, error) {
+	var customErr *response.CustomError
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if customErr = fn(tx); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return errDryRun
+	})
+
+	if errors.Is(txErr, errDryRun) {
+		txErr = nil
+	}
+	return customErr, txErr
+}
+
+// placeContainer validates the requested position and stores the container
+// using db, which is expected to be a transaction owned by the caller. It
+// also returns the stored container.
+func (s *ContainerServiceImpl) placeContainer(db *gorm.DB, request *web.PlacementRequest) (*web.PositionResponse, *model.ContainerPosition, *response.CustomError) {
 	// Check if container is exist
 	var existingPosition model.ContainerPosition
-	err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &existingPosition, request.ContainerNumber)
+	err := s.ContainerPositionRepository.FindByContainerNumber(db, &existingPosition, request.ContainerNumber)
 
 	if err == nil && existingPosition.ID > 0 {
-		return nil, response.GeneralError("Container number " + request.ContainerNumber + " is already placed in the Yard.")
+		return nil, nil, response.GeneralError("Container number " + request.ContainerNumber + " is already placed in the Yard.")
 	}
 
 	// Check yard 3. Cari Yard, Block, dan Cek Batasan Block
 	var yard model.Yard
-	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
-		return nil, response.NotFoundError("Yard not found.")
+	if err := s.YardRepository.FindYardByName(db, &yard, request.YardName); err != nil {
+		return nil, nil, response.NotFoundError("Yard not found.")
 	}
 
 	//check block
 	var block model.Block
-	if err := s.YardRepository.FindBlockByNameAndYardID(s.DB, &block, request.BlockName, yard.ID); err != nil {
-		return nil, response.NotFoundError("Block not found in the specified Yard.")
+	if err := s.YardRepository.FindBlockByNameAndYardID(db, &block, request.BlockName, yard.ID); err != nil {
+		return nil, nil, response.NotFoundError("Block not found in the specified Yard.")
 	}
 
 	slot := request.Slot
 	row := request.Row
 	tier := request.Tier
 	size := request.Size
-	is40ft := size == "40ft"
 
+	if customErr := s.checkTargetCell(db, &block, slot, row, tier, size); customErr != nil {
+		return nil, nil, customErr
+	}
+
+	// Fill in what the pre-advice announced and the request left out
+	vessel, voyage, shippingLine := request.Vessel, request.Voyage, request.ShippingLine
+
+	var preAdvice model.PreAdvice
+	err = s.PreAdviceRepository.FindPendingByContainerNumber(db, &preAdvice, request.ContainerNumber)
+	if err != nil && !errors.Is(err, repository.ErrPreAdviceNotFound) {
+		return nil, nil, response.RepositoryError("Failed to fetch pre-advice: " + err.Error())
+	}
+	hasPreAdvice := err == nil
+	if hasPreAdvice {
+		vessel = cmp.Or(vessel, preAdvice.Vessel)
+		voyage = cmp.Or(voyage, preAdvice.Voyage)
+		shippingLine = cmp.Or(shippingLine, preAdvice.ShippingLine)
+	}
+
+	// Check and get yard_plan
+	var yardPlanID *int = nil
+	yardPlan, err := s.YardPlanRepository.FindApplicablePlan(db, block.ID, slot, row, size, request.Height, request.Type, time.Now())
+	if err == nil && yardPlan != nil {
+		yardPlanID = &yardPlan.ID
+	}
+
+	newPosition := model.ContainerPosition{
+		ContainerNumber: request.ContainerNumber,
+		BlockID:         block.ID,
+		SlotNumber:      slot,
+		RowNumber:       row,
+		TierNumber:      tier,
+
+		ContainerSize:   size,
+		ContainerHeight: request.Height,
+		ContainerType:   request.Type,
+		ContainerStatus: model.ContainerStatusStorage,
+
+		ArrivalDate: time.Now(),
+		YardPlanID:  yardPlanID,
+		Vessel:      vessel,
+		Voyage:      voyage,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+
+		ShippingLine: shippingLine,
+	}
+
+	if saveErr := s.ContainerPositionRepository.Save(db, &newPosition); saveErr != nil {
+		return nil, nil, response.RepositoryError("Failed to place container: " + saveErr.Error())
+	}
+
+	move := newContainerMove(model.MoveTypePlacement, &newPosition, nil, &newPosition, "")
+	if saveErr := saveContainerMove(db, s.ContainerMoveRepository, s.OutboxEventRepository, &move); saveErr != nil {
+		return nil, nil, response.RepositoryError("Failed to record container move: " + saveErr.Error())
+	}
+
+	position := &web.PositionResponse{
+		Block:      block.Name,
+		Slot:       newPosition.SlotNumber,
+		Row:        newPosition.RowNumber,
+		Tier:       newPosition.TierNumber,
+		BlockID:    block.ID,
+		YardPlanID: yardPlanID,
+	}
+
+	if hasPreAdvice {
+		mismatches := preAdviceMismatches(&preAdvice, &newPosition, yard.ID)
+		provisional := preAdviceReservation(&preAdvice)
+
+		preAdvice.Status = model.PreAdviceStatusArrived
+		preAdvice.ArrivedAt = &newPosition.ArrivalDate
+		preAdvice.Mismatches = strings.Join(mismatches, ",")
+		preAdvice.UpdatedAt = time.Now()
+		preAdvice.ClearPosition()
+
+		if err := s.PreAdviceRepository.UpdateStatus(db, &preAdvice); err != nil {
+			return nil, nil, response.RepositoryError("Failed to update pre-advice: " + err.Error())
+		}
+		if err := publishReservationChanged(db, s.OutboxEventRepository, provisional, ReservationChangeReleased); err != nil {
+			return nil, nil, response.RepositoryError("Failed to publish reservation change: " + err.Error())
+		}
+
+		position.PreAdviceID = &preAdvice.ID
+		position.Mismatches = mismatches
+	}
+
+	return position, &newPosition, nil
+}
+
+// checkTargetCell checks that a container of the given size fits into the
+// block at the cell and that the cell, and the second slot of a 40ft
+// container, is not occupied.
+func (s *ContainerServiceImpl) checkTargetCell(db *gorm.DB, block *model.Block, slot, row, tier int, size string) *response.CustomError {
 	if slot > block.Slots || row > block.Rows || tier > block.Tiers || slot < 1 || row < 1 || tier < 1 {
-		return nil, response.BadRequestError("Placement position is outside the Block dimensions.")
+		return response.BadRequestError("Placement position is outside the Block dimensions.")
 	}
 
 	// placement and availability check
 	slotNumbersToCheck := []int{slot}
 
-	if is40ft {
+	if size == "40ft" {
 		//must be placed in odd numbered slot
 		if slot%2 == 0 {
-			return nil, response.BadRequestError("40ft containers must start at an odd Slot number (Slot N).")
+			return response.BadRequestError("40ft containers must start at an odd Slot number (Slot N).")
 		}
 
 		//needs 2 slots
 		nextSlot := slot + 1
 
 		if nextSlot > block.Slots {
-			return nil, response.BadRequestError("Not enough space for 40ft container (requires Slot " + strconv.Itoa(nextSlot) + ").")
+			return response.BadRequestError("Not enough space for 40ft container (requires Slot " + strconv.Itoa(nextSlot) + ").")
 		}
 		slotNumbersToCheck = append(slotNumbersToCheck, nextSlot)
 	}
 
 	// check availabiltiy in database
 	count, err := s.ContainerPositionRepository.CheckPositionAvailability(
-		s.DB,
+		db,
 		block.ID,
 		row,
 		tier,
@@ -229,101 +616,549 @@ func (s *ContainerServiceImpl) PlaceContainer(ctx context.Context, request *web.
 	)
 
 	if err != nil {
-		return nil, response.GeneralError("Database check failed: " + err.Error())
+		return response.GeneralError("Database check failed: " + err.Error())
 	}
 
 	if count > 0 {
-		return nil, response.GeneralError("Position already occupied by other container(s).")
+		// The suggestion came from a stale snapshot, drop it so it gets reloaded.
+		s.OccupancyCache.Invalidate(block.ID)
+		return response.GeneralError("Position already occupied by other container(s).")
 	}
+	return nil
+}
 
-	// Check and get yard_plan
-	var yardPlanID *int = nil
-	yardPlan, err := s.YardPlanRepository.FindApplicablePlan(s.DB, block.ID, slot, row, size, request.Height, request.Type)
-	if err == nil && yardPlan != nil {
-		yardPlanID = &yardPlan.ID
+// preAdviceMismatches lists the announced fields the arriving container does
+// not match. Vessel and voyage are only compared when both sides know them.
+func preAdviceMismatches(preAdvice *model.PreAdvice, position *model.ContainerPosition, yardID int) []string {
+	var mismatches []string
+	if preAdvice.YardID != yardID {
+		mismatches = append(mismatches, "yard")
 	}
+	if preAdvice.ContainerSize != position.ContainerSize {
+		mismatches = append(mismatches, "container_size")
+	}
+	if preAdvice.ContainerHeight != position.ContainerHeight {
+		mismatches = append(mismatches, "container_height")
+	}
+	if preAdvice.ContainerType != position.ContainerType {
+		mismatches = append(mismatches, "container_type")
+	}
+	if preAdvice.Vessel != "" && position.Vessel != "" && preAdvice.Vessel != position.Vessel {
+		mismatches = append(mismatches, "vessel")
+	}
+	if preAdvice.Voyage != "" && position.Voyage != "" && preAdvice.Voyage != position.Voyage {
+		mismatches = append(mismatches, "voyage")
+	}
+	return mismatches
+}
 
-	var newPosition model.ContainerPosition
+func (s *ContainerServiceImpl) PickupContainerTx(ctx context.Context, tx *gorm.DB, request *web.PickupRequest, after *AfterCommit) *response.CustomError {
+	container, releaseOrder, customErr := s.authorizePickup(tx, request)
+	if customErr != nil {
+		return customErr
+	}
 
-	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
-		newPosition = model.ContainerPosition{
-			ContainerNumber: request.ContainerNumber,
-			BlockID:         block.ID,
-			SlotNumber:      slot,
-			RowNumber:       row,
-			TierNumber:      tier,
-
-			ContainerSize:   size,
-			ContainerHeight: request.Height,
-			ContainerType:   request.Type,
-			ContainerStatus: "STORAGE",
-
-			ArrivalDate: time.Now(),
-			YardPlanID:  yardPlanID,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
+	return s.pickup(ctx, tx, container, releaseOrder, after)
+}
 
-		if saveErr := s.ContainerPositionRepository.Save(tx, &newPosition); saveErr != nil {
-			return saveErr
-		}
-		return nil
-	})
+func (s *ContainerServiceImpl) AuthorizePickup(ctx context.Context, request *web.PickupRequest) (int, *response.CustomError) {
+	_, releaseOrder, customErr := s.authorizePickup(s.DB, request)
+	if customErr != nil {
+		return 0, customErr
+	}
 
-	if txErr != nil {
-		return nil, response.RepositoryError("Failed to place container: " + txErr.Error())
+	return releaseOrder.ID, nil
+}
+
+func (s *ContainerServiceImpl) CompletePickupTx(ctx context.Context, tx *gorm.DB, containerNumber string, releaseOrderID int, after *AfterCommit) *response.CustomError {
+	return s.completePickup(ctx, tx, containerNumber, releaseOrderID, after)
+}
+
+// completePickup removes a container whose pickup was authorized under the
+// release order using tx.
+func (s *ContainerServiceImpl) completePickup(ctx context.Context, tx *gorm.DB, containerNumber string, releaseOrderID int, after *AfterCommit) *response.CustomError {
+	var container model.ContainerPosition
+	if err := s.ContainerPositionRepository.FindByContainerNumber(tx, &container, containerNumber); err != nil {
+		return response.NotFoundError("Container not found at any position or already picked up.")
 	}
 
-	return &web.PositionResponse{
-		Block: block.Name,
-		Slot:  newPosition.SlotNumber,
-		Row:   newPosition.RowNumber,
-		Tier:  newPosition.TierNumber,
-	}, nil
+	var releaseOrder model.ReleaseOrder
+	if err := s.ReleaseOrderRepository.FindByID(tx, &releaseOrder, releaseOrderID); err != nil {
+		return response.NotFoundError("Release order not found.")
+	}
+
+	// The release was valid when the pickup was authorized, only a
+	// cancellation or a pickup through another channel revokes it.
+	if releaseOrder.Status != model.ReleaseStatusActive {
+		return response.ConflictError("Release order is " + strings.ToLower(releaseOrder.Status) + ".")
+	}
+
+	entry := releaseOrder.Container(containerNumber)
+	if entry == nil || entry.PickedUpAt != nil {
+		return response.ConflictError("Release order was already used for container " + containerNumber + ".")
+	}
+
+	// A hold may have been set since the pickup was authorized
+	if customErr := checkNoHolds(tx, s.ContainerHoldRepository, containerNumber); customErr != nil {
+		return customErr
+	}
+
+	return s.pickup(ctx, tx, &container, &releaseOrder, after)
 }
 
-func (s *ContainerServiceImpl) PickupContainer(ctx context.Context, request *web.PickupRequest) (*web.GeneralResponse, *response.CustomError) {
+// authorizePickup finds the container in the requested yard, checks it is not
+// on hold and finds the release order that allows the pickup.
+func (s *ContainerServiceImpl) authorizePickup(db *gorm.DB, request *web.PickupRequest) (*model.ContainerPosition, *model.ReleaseOrder, *response.CustomError) {
 	if err := s.Validate.Struct(request); err != nil {
-		return nil, response.BadRequestError(err.Error())
+		return nil, nil, response.BadRequestError(err.Error())
 	}
 
 	// check container
-	var container model.ContainerPosition
-	err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &container, request.ContainerNumber)
+	var detail model.ContainerPositionDetail
+	err := s.ContainerPositionRepository.FindDetailByContainerNumber(db, &detail, request.ContainerNumber)
 	if err != nil {
-		return nil, response.NotFoundError("Container not found at any position or already picked up.")
+		return nil, nil, response.NotFoundError("Container not found at any position or already picked up.")
+	}
+
+	if detail.YardName != request.YardName {
+		return nil, nil, response.NotFoundError("Container " + request.ContainerNumber + " is not in yard " + request.YardName + ".")
 	}
 
+	// check holds
+	if customErr := checkNoHolds(db, s.ContainerHoldRepository, request.ContainerNumber); customErr != nil {
+		return nil, nil, customErr
+	}
+
+	// check release
+	releaseOrder, customErr := s.authorizeRelease(db, request, time.Now())
+	if customErr != nil {
+		return nil, nil, customErr
+	}
+
+	return &detail.ContainerPosition, releaseOrder, nil
+}
+
+// pickup removes the container and uses up its entry of the release order
+// using tx, which is expected to be a transaction owned by the caller.
+func (s *ContainerServiceImpl) pickup(ctx context.Context, tx *gorm.DB, container *model.ContainerPosition, releaseOrder *model.ReleaseOrder, after *AfterCommit) *response.CustomError {
 	// check stacking
 	isStacked, err := s.ContainerPositionRepository.IsStackedAbove(
-		s.DB,
+		tx,
 		container.BlockID,
-		container.SlotNumber,
 		container.RowNumber,
 		container.TierNumber,
+		coveredSlots(container),
 	)
 
 	if err != nil {
-		return nil, response.GeneralError("Database check failed: " + err.Error())
+		return response.GeneralError("Database check failed: " + err.Error())
+	}
+
+	if isStacked {
+		return response.GeneralError("Conflict: Cannot perform pickup. Another container is stacked on top.")
+	}
+
+	if err := useRelease(tx, s.ReleaseOrderRepository, releaseOrder, container.ContainerNumber, time.Now()); err != nil {
+		return response.RepositoryError("Failed to perform container pickup: " + err.Error())
+	}
+
+	if err := s.ContainerPositionRepository.Delete(tx, container.ID); err != nil {
+		return response.RepositoryError("Failed to perform container pickup: " + err.Error())
+	}
+
+	move := newContainerMove(model.MoveTypePickup, container, container, nil, "Release "+releaseOrder.ReleaseNumber)
+	if err := saveContainerMove(tx, s.ContainerMoveRepository, s.OutboxEventRepository, &move); err != nil {
+		return response.RepositoryError("Failed to record container move: " + err.Error())
+	}
+
+	after.add(func() {
+		s.OccupancyCache.Release(container.BlockID, container.SlotNumber, container.RowNumber, container.TierNumber, container.ContainerSize)
+		auditlog.Record(ctx, model.AuditEntityContainer, container.ContainerNumber, container, nil)
+	})
+	return nil
+}
+
+func (s *ContainerServiceImpl) CheckMove(ctx context.Context, request *web.MoveRequest) (*web.PositionResponse, *response.CustomError) {
+	var position *web.PositionResponse
+
+	customErr, txErr := s.dryRun(func(tx *gorm.DB) *response.CustomError {
+		var moveErr *response.CustomError
+		position, moveErr = s.MoveContainerTx(ctx, tx, request, &AfterCommit{})
+		return moveErr
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to move container: " + txErr.Error())
+	}
+
+	return position, nil
+}
+
+func (s *ContainerServiceImpl) MoveContainerTx(ctx context.Context, tx *gorm.DB, request *web.MoveRequest, after *AfterCommit) (*web.PositionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	position, from, to, customErr := s.moveContainer(tx, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	after.add(func() {
+		s.OccupancyCache.Release(from.BlockID, from.SlotNumber, from.RowNumber, from.TierNumber, from.ContainerSize)
+		s.OccupancyCache.Occupy(to.BlockID, to.SlotNumber, to.RowNumber, to.TierNumber, to.ContainerSize)
+		auditlog.Record(ctx, model.AuditEntityContainer, to.ContainerNumber, from, to)
+	})
+	return position, nil
+}
+
+// containerChange is a container before and after a change made inside a
+// transaction, kept to be logged once the transaction committed.
+type containerChange struct {
+	before *model.ContainerPosition
+	after  *model.ContainerPosition
+}
+
+// moveContainer relocates a container inside its yard, or to the yard named
+// by ToYardName, using db, which is expected to be a transaction owned by the
+// caller. It returns the positions before and after the move.
+func (s *ContainerServiceImpl) moveContainer(db *gorm.DB, request *web.MoveRequest) (*web.PositionResponse, model.ContainerPosition, model.ContainerPosition, *response.CustomError) {
+	var detail model.ContainerPositionDetail
+	if err := s.ContainerPositionRepository.FindDetailByContainerNumber(db, &detail, request.ContainerNumber); err != nil {
+		return nil, model.ContainerPosition{}, model.ContainerPosition{}, response.NotFoundError("Container not found at any position.")
+	}
+	from := detail.ContainerPosition
+
+	if detail.YardName != request.YardName {
+		return nil, from, from, response.NotFoundError("Container " + request.ContainerNumber + " is not in yard " + request.YardName + ".")
+	}
+
+	if request.Revision != 0 && from.Revision != request.Revision {
+		return nil, from, from, staleContainerError(&from)
 	}
 
+	targetYardID := detail.YardID
+	if request.ToYardName != "" && request.ToYardName != detail.YardName {
+		var targetYard model.Yard
+		if err := s.YardRepository.FindYardByName(db, &targetYard, request.ToYardName); err != nil {
+			return nil, from, from, response.NotFoundError("Yard " + request.ToYardName + " not found.")
+		}
+		targetYardID = targetYard.ID
+	}
+
+	var block model.Block
+	if err := s.YardRepository.FindBlockByNameAndYardID(db, &block, request.BlockName, targetYardID); err != nil {
+		return nil, from, from, response.NotFoundError("Block not found in the specified Yard.")
+	}
+
+	isStacked, err := s.ContainerPositionRepository.IsStackedAbove(db, from.BlockID, from.RowNumber, from.TierNumber, coveredSlots(&from))
+	if err != nil {
+		return nil, from, from, response.GeneralError("Database check failed: " + err.Error())
+	}
 	if isStacked {
-		return nil, response.GeneralError("Conflict: Cannot perform pickup. Another container is stacked on top.")
+		return nil, from, from, response.GeneralError("Conflict: Cannot move container. Another container is stacked on top.")
+	}
+
+	if customErr := s.checkTargetCell(db, &block, request.Slot, request.Row, request.Tier, from.ContainerSize); customErr != nil {
+		return nil, from, from, customErr
+	}
+
+	to := from
+	to.BlockID = block.ID
+	to.SlotNumber = request.Slot
+	to.RowNumber = request.Row
+	to.TierNumber = request.Tier
+	to.YardPlanID = nil
+	to.UpdatedAt = time.Now()
+
+	yardPlan, err := s.YardPlanRepository.FindApplicablePlan(db, block.ID, to.SlotNumber, to.RowNumber, to.ContainerSize, to.ContainerHeight, to.ContainerType, time.Now())
+	if err == nil && yardPlan != nil {
+		to.YardPlanID = &yardPlan.ID
+	}
+
+	if err := s.ContainerPositionRepository.UpdatePosition(db, &to); err != nil {
+		if errors.Is(err, repository.ErrStaleRevision) {
+			return nil, from, to, changedContainerError(to.ContainerNumber)
+		}
+		return nil, from, to, response.RepositoryError("Failed to update container position: " + err.Error())
+	}
+
+	history := newContainerMove(model.MoveTypeMove, &to, &from, &to, request.Reason)
+	if err := saveContainerMove(db, s.ContainerMoveRepository, s.OutboxEventRepository, &history); err != nil {
+		return nil, from, to, response.RepositoryError("Failed to record container move: " + err.Error())
+	}
+
+	return &web.PositionResponse{
+		Block:      block.Name,
+		Slot:       to.SlotNumber,
+		Row:        to.RowNumber,
+		Tier:       to.TierNumber,
+		BlockID:    block.ID,
+		YardPlanID: to.YardPlanID,
+	}, from, to, nil
+}
+
+// staleContainerError rejects a change sent with an If-Match other than the
+// current revision of the container.
+func staleContainerError(position *model.ContainerPosition) *response.CustomError {
+	customErr := response.PreconditionFailedError("Container " + position.ContainerNumber + " was changed since it was read, reload it and retry.")
+	customErr.AdditionalInfo = map[string]any{
+		"revision": position.Revision,
+	}
+	return customErr
+}
+
+// changedContainerError rejects an update of a container that another
+// request changed after it was read in the transaction.
+func changedContainerError(containerNumber string) *response.CustomError {
+	return response.PreconditionFailedError("Container " + containerNumber + " was changed by another request, reload it and retry.")
+}
+
+// releasePinAttempts wrong PINs in a row lock a release order for
+// releasePinLockout, so a PIN cannot be guessed at the gate.
+const (
+	releasePinAttempts = 5
+	releasePinLockout  = 15 * time.Minute
+)
+
+// useRelease uses up the entry of the container in the release order and
+// completes the order once every container left.
+func useRelease(tx *gorm.DB, releaseRepo repository.ReleaseOrderRepository, releaseOrder *model.ReleaseOrder, containerNumber string, now time.Time) error {
+	if err := releaseRepo.MarkPickedUp(tx, releaseOrder.ID, containerNumber, now); err != nil {
+		return err
+	}
+
+	remaining, err := releaseRepo.CountRemaining(tx, releaseOrder.ID)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		releaseOrder.Status = model.ReleaseStatusCompleted
+		releaseOrder.UpdatedAt = now
+		return releaseRepo.UpdateStatus(tx, releaseOrder)
+	}
+	return nil
+}
+
+// authorizeRelease checks the PIN of the presented release order, then that
+// the order is active, still valid, issued to the trucking company and not
+// yet used for the container. Only an unknown order or a wrong PIN is
+// Unauthorized, the other failures are BadRequest or Conflict.
+func (s *ContainerServiceImpl) authorizeRelease(db *gorm.DB, request *web.PickupRequest, now time.Time) (*model.ReleaseOrder, *response.CustomError) {
+	var releaseOrder model.ReleaseOrder
+	if err := s.ReleaseOrderRepository.FindByReleaseNumber(db, &releaseOrder, request.ReleaseNumber); err != nil {
+		return nil, response.UnauthorizedError("Release order not found or PIN is invalid.")
+	}
+
+	if releaseOrder.PinLocked(now) {
+		return nil, response.TooManyRequestsError("Release order is locked after too many wrong PINs until " + releaseOrder.PinLockedUntil.Format(time.RFC3339) + ".")
+	}
+
+	if err := helper.CheckPasswordHash(releaseOrder.PinHash, request.PIN); err != nil {
+		// Counted outside db, the transaction of the caller rolls back on
+		// this error and would take the attempt with it.
+		if err := s.ReleaseOrderRepository.RecordFailedPin(s.DB, releaseOrder.ID, releasePinAttempts, now.Add(releasePinLockout), now); err != nil {
+			return nil, response.RepositoryError("Failed to record PIN attempt: " + err.Error())
+		}
+		return nil, response.UnauthorizedError("Release order not found or PIN is invalid.")
+	}
+
+	if releaseOrder.FailedPinAttempts > 0 {
+		if err := s.ReleaseOrderRepository.ResetFailedPins(db, releaseOrder.ID, now); err != nil {
+			return nil, response.RepositoryError("Failed to reset PIN attempts: " + err.Error())
+		}
+	}
+
+	if releaseOrder.Status != model.ReleaseStatusActive {
+		return nil, response.ConflictError("Release order is " + strings.ToLower(releaseOrder.Status) + ".")
+	}
+
+	if now.After(releaseOrder.ValidUntil) {
+		return nil, response.BadRequestError("Release order expired on " + releaseOrder.ValidUntil.Format(time.RFC3339) + ".")
+	}
+
+	if !releaseOrder.AllowsCompany(request.TruckingCompany) {
+		return nil, response.BadRequestError("Release order is not issued to " + request.TruckingCompany + ".")
+	}
+
+	container := releaseOrder.Container(request.ContainerNumber)
+	if container == nil {
+		return nil, response.BadRequestError("Release order does not cover container " + request.ContainerNumber + ".")
+	}
+	if container.PickedUpAt != nil {
+		return nil, response.ConflictError("Release order was already used for container " + request.ContainerNumber + ".")
+	}
+
+	return &releaseOrder, nil
+}
+
+func (s *ContainerServiceImpl) SuggestPositions(ctx context.Context, request *web.BatchContainerRequest) (*web.BatchResponse, *response.CustomError) {
+	return s.SuggestPositionsTx(s.DB, request)
+}
+
+func (s *ContainerServiceImpl) SuggestPositionsTx(tx *gorm.DB, request *web.BatchContainerRequest) (*web.BatchResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	results := make([]web.BatchItemResult, len(request.Containers))
+
+	// 40ft containers need two adjacent free slots, so plan them before the
+	// 20ft ones to keep the pairs from being broken up by single boxes.
+	order := make([]int, len(request.Containers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return request.Containers[order[a]].Size == "40ft" && request.Containers[order[b]].Size != "40ft"
+	})
+
+	reserved, err := s.reservedCells(tx)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch reserved cells: " + err.Error())
+	}
+	seen := make(map[string]bool)
+
+	for _, i := range order {
+		item := &request.Containers[i]
+		results[i].ContainerNumber = item.ContainerNumber
+
+		if seen[item.ContainerNumber] {
+			results[i].Error = "Container number " + item.ContainerNumber + " appears more than once in the batch."
+			continue
+		}
+		seen[item.ContainerNumber] = true
+
+		position, customErr := s.suggestPosition(tx, item, reserved)
+		if customErr != nil {
+			results[i].Error = customErr.Message
+			continue
+		}
+
+		reserveCells(reserved, position, item.Size)
+		results[i].Success = true
+		results[i].Position = position
+	}
+
+	return newBatchResponse("", results), nil
+}
+
+func (s *ContainerServiceImpl) CheckPlacements(ctx context.Context, request *web.BatchPlacementRequest) (*web.BatchResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	mode := request.Mode
+	if mode == "" {
+		mode = web.BatchModeAllOrNothing
 	}
 
+	results := make([]web.BatchItemResult, len(request.Containers))
+	failed := 0
+
 	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
-		if deleteErr := s.ContainerPositionRepository.Delete(tx, container.ID); deleteErr != nil {
-			return deleteErr
+		for i := range request.Containers {
+			item := &request.Containers[i]
+			results[i].ContainerNumber = item.ContainerNumber
+
+			if err := s.Validate.Struct(item); err != nil {
+				results[i].Error = err.Error()
+				failed++
+				continue
+			}
+
+			// Every item runs behind its own savepoint so a failed insert does
+			// not abort the surrounding transaction for the remaining items.
+			savepoint := fmt.Sprintf("batch_item_%d", i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+
+			position, _, customErr := s.placeContainer(tx, item)
+			if customErr != nil {
+				if err := tx.RollbackTo(savepoint).Error; err != nil {
+					return err
+				}
+				results[i].Error = customErr.Message
+				failed++
+				continue
+			}
+
+			results[i].Success = true
+			results[i].Position = position
 		}
 
-		return nil
+		if mode == web.BatchModeAllOrNothing && failed > 0 {
+			return errBatchRolledBack
+		}
+		return errDryRun
 	})
 
-	if txErr != nil {
-		return nil, response.RepositoryError("Failed to perform container pickup: " + txErr.Error())
+	if errors.Is(txErr, errDryRun) {
+		return newBatchResponse(mode, results), nil
+	}
+
+	if errors.Is(txErr, errBatchRolledBack) {
+		for i := range results {
+			if results[i].Success {
+				results[i].Success = false
+				results[i].Position = nil
+				results[i].Error = "Rolled back because another container in the batch failed."
+			}
+		}
+
+		customErr := response.BadRequestError("Batch placement rolled back: " + strconv.Itoa(failed) + " container(s) could not be placed.")
+		customErr.AdditionalInfo = newBatchResponse(mode, results)
+		return nil, customErr
+	}
+
+	return nil, response.RepositoryError("Failed to place containers: " + txErr.Error())
+}
+
+var errBatchRolledBack = errors.New("batch rolled back")
+
+func newBatchResponse(mode string, results []web.BatchItemResult) *web.BatchResponse {
+	batchResponse := &web.BatchResponse{
+		Mode:    mode,
+		Total:   len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Success {
+			batchResponse.Succeeded++
+		} else {
+			batchResponse.Failed++
+		}
+	}
+	return batchResponse
+}
+
+// newContainerMove builds a history entry for container. from and to are the
+// positions before and after the move, either of them may be nil.
+func newContainerMove(moveType string, container, from, to *model.ContainerPosition, reason string) model.ContainerMove {
+	move := model.ContainerMove{
+		ContainerNumber: container.ContainerNumber,
+		MoveType:        moveType,
+		ContainerSize:   container.ContainerSize,
+		ContainerHeight: container.ContainerHeight,
+		ContainerType:   container.ContainerType,
+		Vessel:          container.Vessel,
+		Voyage:          container.Voyage,
+		ShippingLine:    container.ShippingLine,
+		Reason:          reason,
+		CreatedAt:       time.Now(),
 	}
 
-	return &web.GeneralResponse{
-		Message: "Success: Container picked up successfully.",
-	}, nil
+	if from != nil {
+		fromBlockID, fromSlot, fromRow, fromTier := from.BlockID, from.SlotNumber, from.RowNumber, from.TierNumber
+		move.FromBlockID, move.FromSlot, move.FromRow, move.FromTier = &fromBlockID, &fromSlot, &fromRow, &fromTier
+	}
+	if to != nil {
+		toBlockID, toSlot, toRow, toTier := to.BlockID, to.SlotNumber, to.RowNumber, to.TierNumber
+		move.ToBlockID, move.ToSlot, move.ToRow, move.ToTier = &toBlockID, &toSlot, &toRow, &toTier
+	}
+	return move
 }
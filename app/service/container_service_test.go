@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// memoryReleaseOrders serves one release order and records the PIN
+// attempts written for it.
+type memoryReleaseOrders struct {
+	repository.ReleaseOrderRepository
+
+	releaseOrder model.ReleaseOrder
+	failed       int
+	lockedUntil  *time.Time
+	resets       int
+}
+
+func (r *memoryReleaseOrders) FindByReleaseNumber(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, releaseNumber string) error {
+	*releaseOrderResult = r.releaseOrder
+	return nil
+}
+
+func (r *memoryReleaseOrders) RecordFailedPin(db *gorm.DB, releaseOrderID int, maxAttempts int, lockedUntil time.Time, now time.Time) error {
+	r.failed++
+	if r.releaseOrder.FailedPinAttempts+r.failed >= maxAttempts {
+		r.lockedUntil = &lockedUntil
+	}
+	return nil
+}
+
+func (r *memoryReleaseOrders) ResetFailedPins(db *gorm.DB, releaseOrderID int, now time.Time) error {
+	r.resets++
+	return nil
+}
+
+func TestAuthorizeRelease(t *testing.T) {
+	pinHash, err := helper.HashPassword("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, time.October, 10, 9, 0, 0, 0, time.UTC)
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	active := func() model.ReleaseOrder {
+		return model.ReleaseOrder{
+			ID:              1,
+			ReleaseNumber:   "DO-2026-0001",
+			PinHash:         pinHash,
+			TruckingCompany: "PT Angkut Jaya",
+			ValidUntil:      now.Add(24 * time.Hour),
+			Status:          model.ReleaseStatusActive,
+			Containers:      []model.ReleaseOrderContainer{{ContainerNumber: "ALFI000005"}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		change     func(*model.ReleaseOrder)
+		pin        string
+		company    string
+		wantStatus int
+		wantFailed int
+		wantLocked bool
+		wantResets int
+	}{
+		{name: "valid", wantStatus: http.StatusOK},
+		{name: "wrong pin", pin: "0000", wantStatus: http.StatusUnauthorized, wantFailed: 1},
+		{
+			name:       "last wrong pin locks the order",
+			change:     func(r *model.ReleaseOrder) { r.FailedPinAttempts = releasePinAttempts - 1 },
+			pin:        "0000",
+			wantStatus: http.StatusUnauthorized,
+			wantFailed: 1,
+			wantLocked: true,
+		},
+		{
+			name:       "locked order rejects the right pin",
+			change:     func(r *model.ReleaseOrder) { r.PinLockedUntil = timePtr(now.Add(time.Minute)) },
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "lock ran out",
+			change:     func(r *model.ReleaseOrder) { r.PinLockedUntil = timePtr(now.Add(-time.Minute)) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "right pin resets the count",
+			change:     func(r *model.ReleaseOrder) { r.FailedPinAttempts = 2 },
+			wantStatus: http.StatusOK,
+			wantResets: 1,
+		},
+		{
+			name:       "expired",
+			change:     func(r *model.ReleaseOrder) { r.ValidUntil = now.Add(-time.Hour) },
+			wantStatus: http.StatusBadRequest,
+		},
+		{name: "other trucking company", company: "PT Lain", wantStatus: http.StatusBadRequest},
+		{
+			name:       "container not covered",
+			change:     func(r *model.ReleaseOrder) { r.Containers[0].ContainerNumber = "ALFI000006" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "cancelled",
+			change:     func(r *model.ReleaseOrder) { r.Status = model.ReleaseStatusCancelled },
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "already used",
+			change:     func(r *model.ReleaseOrder) { r.Containers[0].PickedUpAt = timePtr(now.Add(-time.Hour)) },
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releaseOrder := active()
+			if tt.change != nil {
+				tt.change(&releaseOrder)
+			}
+			releaseOrders := &memoryReleaseOrders{releaseOrder: releaseOrder}
+			s := &ContainerServiceImpl{ReleaseOrderRepository: releaseOrders}
+
+			request := &web.PickupRequest{
+				ContainerNumber: "ALFI000005",
+				ReleaseNumber:   "DO-2026-0001",
+				PIN:             "1234",
+				TruckingCompany: "PT Angkut Jaya",
+			}
+			if tt.pin != "" {
+				request.PIN = tt.pin
+			}
+			if tt.company != "" {
+				request.TruckingCompany = tt.company
+			}
+
+			_, customErr := s.authorizeRelease(nil, request, now)
+			status := http.StatusOK
+			if customErr != nil {
+				status = customErr.StatusCode
+			}
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, customErr)
+			}
+			if releaseOrders.failed != tt.wantFailed {
+				t.Errorf("recorded %d failed PINs, want %d", releaseOrders.failed, tt.wantFailed)
+			}
+			if (releaseOrders.lockedUntil != nil) != tt.wantLocked {
+				t.Errorf("locked = %v, want %v", releaseOrders.lockedUntil != nil, tt.wantLocked)
+			}
+			if releaseOrders.resets != tt.wantResets {
+				t.Errorf("reset %d times, want %d", releaseOrders.resets, tt.wantResets)
+			}
+		})
+	}
+}
+
+func (r *memoryReleaseOrders) FindByID(db *gorm.DB, releaseOrderResult *model.ReleaseOrder, releaseOrderID int) error {
+	*releaseOrderResult = r.releaseOrder
+	return nil
+}
+
+func (r *memoryReleaseOrders) MarkPickedUp(db *gorm.DB, releaseOrderID int, containerNumber string, pickedUpAt time.Time) error {
+	return nil
+}
+
+func (r *memoryReleaseOrders) CountRemaining(db *gorm.DB, releaseOrderID int) (int64, error) {
+	return 1, nil
+}
+
+// memoryHolds serves the same holds for every container.
+type memoryHolds struct {
+	repository.ContainerHoldRepository
+	holds []model.ContainerHold
+}
+
+func (r memoryHolds) FindByContainerNumber(db *gorm.DB, holds *[]model.ContainerHold, containerNumber string, activeOnly bool) error {
+	*holds = r.holds
+	return nil
+}
+
+// pickupPositions serves one container in yard DEPO-A and records whether it
+// was removed.
+type pickupPositions struct {
+	repository.ContainerPositionRepository
+	container model.ContainerPosition
+	deleted   bool
+}
+
+func (r *pickupPositions) FindDetailByContainerNumber(db *gorm.DB, detail *model.ContainerPositionDetail, containerNumber string) error {
+	*detail = model.ContainerPositionDetail{ContainerPosition: r.container, YardID: 1, YardName: "DEPO-A"}
+	return nil
+}
+
+func (r *pickupPositions) FindByContainerNumber(db *gorm.DB, container *model.ContainerPosition, containerNumber string) error {
+	*container = r.container
+	return nil
+}
+
+func (r *pickupPositions) IsStackedAbove(db *gorm.DB, blockID, row, tier int, slotNumbers []int) (bool, error) {
+	return false, nil
+}
+
+func (r *pickupPositions) Delete(db *gorm.DB, containerID int) error {
+	r.deleted = true
+	return nil
+}
+
+func TestPickupChecksHolds(t *testing.T) {
+	pinHash, err := helper.HashPassword("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	customsHold := []model.ContainerHold{{ID: 3, ContainerNumber: "ALFI000005", HoldType: "CUSTOMS", Reason: "Inspection"}}
+
+	tests := []struct {
+		name        string
+		complete    bool
+		holds       []model.ContainerHold
+		wantStatus  int
+		wantDeleted bool
+	}{
+		{name: "authorize without holds", wantStatus: http.StatusOK},
+		{name: "authorize on hold", holds: customsHold, wantStatus: http.StatusConflict},
+		{name: "complete without holds", complete: true, wantStatus: http.StatusOK, wantDeleted: true},
+		{name: "complete after a hold was set", complete: true, holds: customsHold, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions := &pickupPositions{container: model.ContainerPosition{
+				ID: 9, ContainerNumber: "ALFI000005", ContainerSize: "20ft", BlockID: 1, SlotNumber: 1, RowNumber: 1, TierNumber: 1,
+			}}
+			s := &ContainerServiceImpl{
+				ContainerPositionRepository: positions,
+				ContainerMoveRepository:     eventMoves{},
+				ReleaseOrderRepository: &memoryReleaseOrders{releaseOrder: model.ReleaseOrder{
+					ID:              1,
+					ReleaseNumber:   "DO-2026-0001",
+					PinHash:         pinHash,
+					TruckingCompany: "PT Angkut Jaya",
+					ValidUntil:      time.Now().Add(24 * time.Hour),
+					Status:          model.ReleaseStatusActive,
+					Containers:      []model.ReleaseOrderContainer{{ContainerNumber: "ALFI000005"}},
+				}},
+				ContainerHoldRepository: memoryHolds{holds: tt.holds},
+				OutboxEventRepository:   &eventOutbox{},
+				Validate:                validator.New(),
+			}
+
+			var customErr *response.CustomError
+			if tt.complete {
+				customErr = s.completePickup(context.Background(), nil, "ALFI000005", 1, &AfterCommit{})
+			} else {
+				_, _, customErr = s.authorizePickup(nil, &web.PickupRequest{
+					YardName:        "DEPO-A",
+					ContainerNumber: "ALFI000005",
+					ReleaseNumber:   "DO-2026-0001",
+					PIN:             "1234",
+					TruckingCompany: "PT Angkut Jaya",
+				})
+			}
+
+			status := http.StatusOK
+			if customErr != nil {
+				status = customErr.StatusCode
+			}
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, customErr)
+			}
+			if positions.deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", positions.deleted, tt.wantDeleted)
+			}
+			if tt.holds != nil {
+				info, _ := customErr.AdditionalInfo.(map[string]any)
+				if holds, _ := info["holds"].([]web.HoldResponse); len(holds) != 1 || holds[0].ID != 3 {
+					t.Errorf("additional info = %v, want the hold", customErr.AdditionalInfo)
+				}
+			}
+		})
+	}
+}
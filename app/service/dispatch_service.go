@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type DispatchService interface {
+	// Dispatch recomputes the equipment queues of the yard with the configured
+	// strategy and stores the assignments. It runs whenever jobs are queued or
+	// finished and when equipment changes.
+	Dispatch(ctx context.Context, yardID int) (*web.DispatchPlanResponse, *response.CustomError)
+	DispatchYard(ctx context.Context, query *web.DispatchQuery) (*web.DispatchPlanResponse, *response.CustomError)
+	// SimulateDispatch runs the strategy on the current queues without storing
+	// anything.
+	SimulateDispatch(ctx context.Context, query *web.DispatchQuery) (*web.DispatchPlanResponse, *response.CustomError)
+}
+
+type DispatchServiceImpl struct {
+	YardRepository            repository.YardRepository
+	EquipmentRepository       repository.EquipmentRepository
+	WorkInstructionRepository repository.WorkInstructionRepository
+	Strategy                  DispatchStrategy
+	DB                        *gorm.DB
+	Validate                  *validator.Validate
+
+	// Serializes dispatch runs, two runs could otherwise store interleaved queues
+	mutex sync.Mutex
+}
+
+func NewDispatchService(
+	yardRepo repository.YardRepository,
+	equipmentRepo repository.EquipmentRepository,
+	workRepo repository.WorkInstructionRepository,
+	strategy DispatchStrategy,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) DispatchService {
+	return &DispatchServiceImpl{
+		YardRepository:            yardRepo,
+		EquipmentRepository:       equipmentRepo,
+		WorkInstructionRepository: workRepo,
+		Strategy:                  strategy,
+		DB:                        DB,
+		Validate:                  validate,
+	}
+}
+
+func (s *DispatchServiceImpl) Dispatch(ctx context.Context, yardID int) (*web.DispatchPlanResponse, *response.CustomError) {
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByID(s.DB, &yard, yardID); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	return s.dispatch(&yard)
+}
+
+func (s *DispatchServiceImpl) DispatchYard(ctx context.Context, query *web.DispatchQuery) (*web.DispatchPlanResponse, *response.CustomError) {
+	yard, customErr := s.findYard(query)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return s.dispatch(yard)
+}
+
+func (s *DispatchServiceImpl) SimulateDispatch(ctx context.Context, query *web.DispatchQuery) (*web.DispatchPlanResponse, *response.CustomError) {
+	yard, customErr := s.findYard(query)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	planResponse, _, customErr := s.plan(yard)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	planResponse.Simulated = true
+	return planResponse, nil
+}
+
+func (s *DispatchServiceImpl) dispatch(yard *model.Yard) (*web.DispatchPlanResponse, *response.CustomError) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	planResponse, assignments, customErr := s.plan(yard)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, equipmentPlan := range planResponse.Equipment {
+			for _, job := range equipmentPlan.Jobs {
+				assignment := assignments[job.WorkInstructionID]
+				if err := s.WorkInstructionRepository.Assign(tx, job.WorkInstructionID, &assignment.EquipmentID, &assignment.Sequence); err != nil {
+					return err
+				}
+			}
+		}
+		for _, instructionID := range planResponse.Unassigned {
+			if err := s.WorkInstructionRepository.Assign(tx, instructionID, nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to store dispatch plan: " + txErr.Error())
+	}
+
+	return planResponse, nil
+}
+
+// plan runs the strategy on the active equipment and pending jobs of the yard.
+func (s *DispatchServiceImpl) plan(yard *model.Yard) (*web.DispatchPlanResponse, map[int]DispatchAssignment, *response.CustomError) {
+	var blocks []model.Block
+	if err := s.YardRepository.FindBlocksByYardID(s.DB, &blocks, yard.ID); err != nil {
+		return nil, nil, response.RepositoryError("Failed to fetch blocks: " + err.Error())
+	}
+
+	var equipments []model.Equipment
+	if err := s.EquipmentRepository.FindByYardID(s.DB, &equipments, yard.ID); err != nil {
+		return nil, nil, response.RepositoryError("Failed to fetch equipment: " + err.Error())
+	}
+
+	var instructions []model.WorkInstruction
+	filter := repository.WorkInstructionFilter{YardID: yard.ID, Status: model.WorkStatusPending}
+	if err := s.WorkInstructionRepository.Find(s.DB, &filter, &instructions); err != nil {
+		return nil, nil, response.RepositoryError("Failed to fetch work instructions: " + err.Error())
+	}
+
+	units := make([]DispatchUnit, 0, len(equipments))
+	for _, equipment := range equipments {
+		if !equipment.Active {
+			continue
+		}
+
+		unit := DispatchUnit{EquipmentID: equipment.ID, BlockIDs: equipment.BlockIDs}
+		if equipment.CurrentBlockID != nil && equipment.CurrentSlot != nil {
+			unit.Located, unit.BlockID, unit.Slot = true, *equipment.CurrentBlockID, *equipment.CurrentSlot
+		}
+		units = append(units, unit)
+	}
+
+	planned := s.Strategy.Plan(units, newDispatchJobs(instructions), NewYardLayout(blocks))
+
+	assignments := make(map[int]DispatchAssignment, len(planned))
+	byEquipment := make(map[int][]DispatchAssignment)
+	for _, assignment := range planned {
+		assignments[assignment.InstructionID] = assignment
+		byEquipment[assignment.EquipmentID] = append(byEquipment[assignment.EquipmentID], assignment)
+	}
+
+	instructionsByID := make(map[int]*model.WorkInstruction, len(instructions))
+	for i := range instructions {
+		instructionsByID[instructions[i].ID] = &instructions[i]
+	}
+
+	planResponse := &web.DispatchPlanResponse{
+		Yard:       yard.Name,
+		Strategy:   s.Strategy.Name(),
+		Equipment:  make([]web.DispatchEquipmentResponse, 0, len(units)),
+		Unassigned: []int{},
+	}
+
+	for _, equipment := range equipments {
+		if !equipment.Active {
+			continue
+		}
+
+		equipmentPlan := web.DispatchEquipmentResponse{
+			EquipmentID: equipment.ID,
+			Code:        equipment.Code,
+			Jobs:        make([]web.DispatchJobResponse, 0, len(byEquipment[equipment.ID])),
+		}
+		for _, assignment := range byEquipment[equipment.ID] {
+			instruction := instructionsByID[assignment.InstructionID]
+			equipmentPlan.Jobs = append(equipmentPlan.Jobs, web.DispatchJobResponse{
+				WorkInstructionID: instruction.ID,
+				ContainerNumber:   instruction.ContainerNumber,
+				WorkType:          instruction.WorkType,
+				Priority:          instruction.Priority,
+				Sequence:          assignment.Sequence,
+				TravelDistance:    assignment.Travel,
+				CarryDistance:     assignment.Carry,
+			})
+			equipmentPlan.TotalDistance += assignment.Travel + assignment.Carry
+		}
+
+		planResponse.TotalDistance += equipmentPlan.TotalDistance
+		planResponse.Equipment = append(planResponse.Equipment, equipmentPlan)
+	}
+
+	for _, instruction := range instructions {
+		if _, ok := assignments[instruction.ID]; !ok {
+			planResponse.Unassigned = append(planResponse.Unassigned, instruction.ID)
+		}
+	}
+
+	return planResponse, assignments, nil
+}
+
+func (s *DispatchServiceImpl) findYard(query *web.DispatchQuery) (*model.Yard, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+	return &yard, nil
+}
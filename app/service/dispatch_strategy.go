@@ -0,0 +1,260 @@
+package service
+
+import (
+	"math"
+	"yard-planning/app/model"
+)
+
+// dispatchSlotLength is the length of one 20ft slot in meters, gaps included.
+const dispatchSlotLength = 6.5
+
+// dispatchPriorityWeight is the travel distance in meters one priority level
+// is worth: a job one level higher is preferred over a job up to that much
+// closer.
+const dispatchPriorityWeight = 100.0
+
+// DispatchUnit is an active equipment as the dispatcher sees it. Located is
+// false when the equipment has not finished a job yet, it is then assumed to
+// be wherever its first job starts.
+type DispatchUnit struct {
+	EquipmentID int
+	BlockIDs    []int
+	Located     bool
+	BlockID     int
+	Slot        int
+}
+
+// DispatchJob is a pending work instruction. BlockID is the working block, a
+// unit can only take jobs of the blocks it serves. After lists the jobs that
+// touch the same stacks and have to be done first.
+type DispatchJob struct {
+	InstructionID int
+	BlockID       int
+	Priority      int
+
+	StartBlockID int
+	StartSlot    int
+	EndBlockID   int
+	EndSlot      int
+
+	After []int
+}
+
+// DispatchAssignment puts a job at position Sequence (from 1) of the queue of
+// an equipment. Travel is the empty drive to the job, Carry the drive with
+// the container.
+type DispatchAssignment struct {
+	InstructionID int
+	EquipmentID   int
+	Sequence      int
+	Travel        float64
+	Carry         float64
+}
+
+// DispatchStrategy orders the pending jobs of a yard into equipment queues.
+// Jobs missing from the result stay unassigned.
+type DispatchStrategy interface {
+	Name() string
+	Plan(units []DispatchUnit, jobs []DispatchJob, layout *YardLayout) []DispatchAssignment
+}
+
+// YardLayout measures travel between cells of a yard.
+type YardLayout struct {
+	blocks map[int]model.Block
+}
+
+func NewYardLayout(blocks []model.Block) *YardLayout {
+	layout := &YardLayout{blocks: make(map[int]model.Block, len(blocks))}
+	for _, block := range blocks {
+		layout.blocks[block.ID] = block
+	}
+	return layout
+}
+
+// Distance is the driving distance in meters between two slots, along the
+// yard lanes. Unknown blocks are treated as zero distance.
+func (l *YardLayout) Distance(fromBlockID, fromSlot, toBlockID, toSlot int) float64 {
+	from, ok := l.blocks[fromBlockID]
+	if !ok {
+		return 0
+	}
+	to, ok := l.blocks[toBlockID]
+	if !ok {
+		return 0
+	}
+
+	fromX := float64(from.PosX) + float64(fromSlot-1)*dispatchSlotLength
+	toX := float64(to.PosX) + float64(toSlot-1)*dispatchSlotLength
+	return math.Abs(fromX-toX) + math.Abs(float64(from.PosY-to.PosY))
+}
+
+// GreedyDispatchStrategy repeatedly hands out the job an equipment can start
+// earliest, in meters driven, less the priority bonus. A job is only started
+// once the jobs it depends on are finished.
+type GreedyDispatchStrategy struct {
+}
+
+func NewGreedyDispatchStrategy() DispatchStrategy {
+	return &GreedyDispatchStrategy{}
+}
+
+func (g *GreedyDispatchStrategy) Name() string {
+	return "greedy"
+}
+
+func (g *GreedyDispatchStrategy) Plan(units []DispatchUnit, jobs []DispatchJob, layout *YardLayout) []DispatchAssignment {
+	type unitState struct {
+		unit     DispatchUnit
+		serves   map[int]bool
+		located  bool
+		blockID  int
+		slot     int
+		busy     float64
+		sequence int
+	}
+
+	states := make([]*unitState, 0, len(units))
+	served := make(map[int]bool)
+	for _, unit := range units {
+		state := &unitState{unit: unit, serves: make(map[int]bool, len(unit.BlockIDs)), located: unit.Located, blockID: unit.BlockID, slot: unit.Slot}
+		for _, blockID := range unit.BlockIDs {
+			state.serves[blockID] = true
+			served[blockID] = true
+		}
+		states = append(states, state)
+	}
+
+	// Predecessors nobody can work would block their successors forever
+	assignable := make(map[int]bool, len(jobs))
+	for _, job := range jobs {
+		assignable[job.InstructionID] = served[job.BlockID]
+	}
+
+	finished := make(map[int]float64, len(jobs))
+	done := make([]bool, len(jobs))
+	var assignments []DispatchAssignment
+
+	for {
+		bestJob, bestUnit := -1, -1
+		var bestScore, bestStart, bestTravel float64
+
+		for j, job := range jobs {
+			if done[j] || !assignable[job.InstructionID] {
+				continue
+			}
+
+			ready, readyAt := true, 0.0
+			for _, id := range job.After {
+				if !assignable[id] {
+					continue
+				}
+				at, ok := finished[id]
+				if !ok {
+					ready = false
+					break
+				}
+				readyAt = max(readyAt, at)
+			}
+			if !ready {
+				continue
+			}
+
+			for u, state := range states {
+				if !state.serves[job.BlockID] {
+					continue
+				}
+
+				travel := 0.0
+				if state.located {
+					travel = layout.Distance(state.blockID, state.slot, job.StartBlockID, job.StartSlot)
+				}
+				start := max(state.busy+travel, readyAt)
+				score := start - float64(job.Priority)*dispatchPriorityWeight
+
+				// Jobs come oldest first, so ties go to the older job
+				if bestJob == -1 || score < bestScore {
+					bestJob, bestUnit = j, u
+					bestScore, bestStart, bestTravel = score, start, travel
+				}
+			}
+		}
+
+		if bestJob == -1 {
+			return assignments
+		}
+
+		job, state := jobs[bestJob], states[bestUnit]
+		carry := layout.Distance(job.StartBlockID, job.StartSlot, job.EndBlockID, job.EndSlot)
+
+		state.busy = bestStart + carry
+		state.located, state.blockID, state.slot = true, job.EndBlockID, job.EndSlot
+		state.sequence++
+
+		done[bestJob] = true
+		finished[job.InstructionID] = state.busy
+		assignments = append(assignments, DispatchAssignment{
+			InstructionID: job.InstructionID,
+			EquipmentID:   state.unit.EquipmentID,
+			Sequence:      state.sequence,
+			Travel:        bestTravel,
+			Carry:         carry,
+		})
+	}
+}
+
+// newDispatchJobs turns pending instructions, oldest first, into dispatcher
+// jobs. A job depends on the latest earlier job touching one of its stacks,
+// so stacking order is kept, and on the instruction it is chained to.
+func newDispatchJobs(instructions []model.WorkInstruction) []DispatchJob {
+	type stackKey struct {
+		BlockID, Slot, Row int
+	}
+
+	lastOnStack := make(map[stackKey]int)
+	jobs := make([]DispatchJob, 0, len(instructions))
+
+	for i := range instructions {
+		instruction := &instructions[i]
+		startBlockID, startSlot := instruction.Start()
+		endBlockID, endSlot := instruction.End()
+
+		var stacks []stackKey
+		if instruction.FromBlockID != nil {
+			for _, cell := range coveredCells(*instruction.FromBlockID, *instruction.FromSlot, *instruction.FromRow, *instruction.FromTier, instruction.ContainerSize) {
+				stacks = append(stacks, stackKey{BlockID: cell.BlockID, Slot: cell.Slot, Row: cell.Row})
+			}
+		}
+		if instruction.ToBlockID != nil {
+			for _, cell := range coveredCells(*instruction.ToBlockID, *instruction.ToSlot, *instruction.ToRow, *instruction.ToTier, instruction.ContainerSize) {
+				stacks = append(stacks, stackKey{BlockID: cell.BlockID, Slot: cell.Slot, Row: cell.Row})
+			}
+		}
+
+		job := DispatchJob{
+			InstructionID: instruction.ID,
+			BlockID:       instruction.BlockID,
+			Priority:      instruction.Priority,
+			StartBlockID:  startBlockID,
+			StartSlot:     startSlot,
+			EndBlockID:    endBlockID,
+			EndSlot:       endSlot,
+		}
+
+		seen := make(map[int]bool)
+		if instruction.PredecessorID != nil {
+			seen[*instruction.PredecessorID] = true
+			job.After = append(job.After, *instruction.PredecessorID)
+		}
+		for _, stack := range stacks {
+			if previous, ok := lastOnStack[stack]; ok && !seen[previous] {
+				seen[previous] = true
+				job.After = append(job.After, previous)
+			}
+			lastOnStack[stack] = instruction.ID
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs
+}
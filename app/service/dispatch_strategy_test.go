@@ -0,0 +1,128 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"yard-planning/app/model"
+)
+
+func TestGreedyDispatchStrategyPlan(t *testing.T) {
+	layout := NewYardLayout([]model.Block{
+		{ID: 1, PosX: 0, PosY: 0},
+		{ID: 2, PosX: 0, PosY: 50},
+		{ID: 3, PosX: 200, PosY: 0},
+	})
+
+	unit := func(equipmentID int, blockIDs []int, blockID, slot int) DispatchUnit {
+		return DispatchUnit{EquipmentID: equipmentID, BlockIDs: blockIDs, Located: true, BlockID: blockID, Slot: slot}
+	}
+	job := func(instructionID, blockID, slot int, after ...int) DispatchJob {
+		return DispatchJob{
+			InstructionID: instructionID,
+			BlockID:       blockID,
+			StartBlockID:  blockID,
+			StartSlot:     slot,
+			EndBlockID:    blockID,
+			EndSlot:       slot,
+			After:         after,
+		}
+	}
+
+	// want lists instruction, equipment and sequence of every assignment
+	tests := []struct {
+		name  string
+		units []DispatchUnit
+		jobs  []DispatchJob
+		want  [][3]int
+	}{
+		{
+			name:  "nearest unit wins",
+			units: []DispatchUnit{unit(1, []int{1}, 1, 1), unit(2, []int{1}, 1, 20)},
+			jobs:  []DispatchJob{job(10, 1, 18)},
+			want:  [][3]int{{10, 2, 1}},
+		},
+		{
+			name:  "unit not serving the block is skipped",
+			units: []DispatchUnit{unit(1, []int{1}, 1, 1), unit(2, []int{2}, 1, 18)},
+			jobs:  []DispatchJob{job(10, 1, 18), job(11, 3, 1)},
+			want:  [][3]int{{10, 1, 1}},
+		},
+		{
+			name:  "no units",
+			units: nil,
+			jobs:  []DispatchJob{job(10, 1, 18)},
+			want:  nil,
+		},
+		{
+			name:  "single pending job",
+			units: []DispatchUnit{unit(1, []int{1}, 1, 1)},
+			jobs:  []DispatchJob{job(10, 1, 10)},
+			want:  [][3]int{{10, 1, 1}},
+		},
+		{
+			name:  "closer job arriving goes first",
+			units: []DispatchUnit{unit(1, []int{1}, 1, 1)},
+			jobs:  []DispatchJob{job(10, 1, 10), job(11, 1, 2)},
+			want:  [][3]int{{11, 1, 1}, {10, 1, 2}},
+		},
+		{
+			name:  "successor waits for the job on its stack",
+			units: []DispatchUnit{unit(1, []int{1}, 1, 1)},
+			jobs:  []DispatchJob{job(10, 1, 30), job(11, 1, 30, 10), job(12, 1, 2)},
+			want:  [][3]int{{12, 1, 1}, {10, 1, 2}, {11, 1, 3}},
+		},
+		{
+			name:  "finished job frees its successor",
+			units: []DispatchUnit{unit(1, []int{1}, 1, 30)},
+			jobs:  []DispatchJob{job(11, 1, 30), job(12, 1, 2)},
+			want:  [][3]int{{11, 1, 1}, {12, 1, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments := NewGreedyDispatchStrategy().Plan(tt.units, tt.jobs, layout)
+
+			if len(assignments) != len(tt.want) {
+				t.Fatalf("got %d assignments, want %d: %+v", len(assignments), len(tt.want), assignments)
+			}
+			for i, want := range tt.want {
+				got := assignments[i]
+				if got.InstructionID != want[0] || got.EquipmentID != want[1] || got.Sequence != want[2] {
+					t.Errorf("assignment %d = job %d to equipment %d as #%d, want job %d to equipment %d as #%d",
+						i, got.InstructionID, got.EquipmentID, got.Sequence, want[0], want[1], want[2])
+				}
+			}
+		})
+	}
+}
+
+func TestNewDispatchJobs(t *testing.T) {
+	move := func(id, fromSlot, toSlot int, predecessorID *int) model.WorkInstruction {
+		instruction := model.WorkInstruction{ID: id, WorkType: model.WorkTypeMove, BlockID: 1, ContainerSize: "20ft", PredecessorID: predecessorID}
+		instruction.SetFrom(1, fromSlot, 1, 1)
+		instruction.SetTo(1, toSlot, 1, 1)
+		return instruction
+	}
+	first := 10
+
+	tests := []struct {
+		name         string
+		instructions []model.WorkInstruction
+		wantAfter    []int
+	}{
+		{"other stacks", []model.WorkInstruction{move(10, 1, 2, nil), move(11, 3, 4, nil)}, nil},
+		{"shared stack", []model.WorkInstruction{move(10, 1, 2, nil), move(11, 2, 4, nil)}, []int{10}},
+		{"chained", []model.WorkInstruction{move(10, 1, 2, nil), move(11, 3, 4, &first)}, []int{10}},
+		{"chained on a shared stack", []model.WorkInstruction{move(10, 1, 2, nil), move(11, 2, 4, &first)}, []int{10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := newDispatchJobs(tt.instructions)
+			if got := jobs[len(jobs)-1].After; !slices.Equal(got, tt.wantAfter) {
+				t.Errorf("after = %v, want %v", got, tt.wantAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+
+	"gorm.io/gorm"
+)
+
+// Changes reported by PlanChanged events.
+const (
+	PlanChangeCreated        = "CREATED"
+	PlanChangeVersionCreated = "VERSION_CREATED"
+	PlanChangeUpdated        = "UPDATED"
+	PlanChangePublished      = "PUBLISHED"
+	PlanChangeSuperseded     = "SUPERSEDED"
+	PlanChangeActivated      = "ACTIVATED"
+	PlanChangeDeactivated    = "DEACTIVATED"
+)
+
+// Changes reported by ReservationChanged events. A reservation is released
+// when the container arrives in its cell as well as when it is cancelled.
+const (
+	ReservationChangeReserved = "RESERVED"
+	ReservationChangeReleased = "RELEASED"
+)
+
+// moveEventTypes maps the move types of the history to their domain event.
+var moveEventTypes = map[string]string{
+	model.MoveTypePlacement: model.EventContainerPlaced,
+	model.MoveTypePickup:    model.EventContainerPickedUp,
+	model.MoveTypeMove:      model.EventContainerMoved,
+}
+
+// planChangedEvent is the data of a PlanChanged event.
+type planChangedEvent struct {
+	Change string                `json:"change"`
+	Plan   *web.YardPlanResponse `json:"plan"`
+}
+
+// reservationChangedEvent is the data of a ReservationChanged event.
+type reservationChangedEvent struct {
+	Change          string `json:"change"`
+	Source          string `json:"source"`
+	ContainerNumber string `json:"container_number"`
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+	BlockID         int    `json:"block_id"`
+	Slot            int    `json:"slot"`
+	Row             int    `json:"row"`
+	Tier            int    `json:"tier"`
+}
+
+// publishEvent writes a domain event to the outbox. db must be the
+// transaction of the change, so the event is stored only when the change is.
+// blockID and toBlockID locate the event for stream filters and may be nil.
+func publishEvent(db *gorm.DB, outboxRepo repository.OutboxEventRepository, eventType, aggregateID string, blockID, toBlockID *int, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	event := &model.OutboxEvent{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     string(payload),
+		BlockID:     blockID,
+		ToBlockID:   toBlockID,
+		CreatedAt:   time.Now(),
+	}
+	return outboxRepo.Save(db, event)
+}
+
+// saveContainerMove records a move in the history and publishes it as the
+// matching container event.
+func saveContainerMove(db *gorm.DB, moveRepo repository.ContainerMoveRepository, outboxRepo repository.OutboxEventRepository, move *model.ContainerMove) error {
+	if err := moveRepo.Save(db, move); err != nil {
+		return err
+	}
+
+	blockID, toBlockID := move.FromBlockID, move.ToBlockID
+	if blockID == nil {
+		blockID, toBlockID = toBlockID, nil
+	} else if toBlockID != nil && *toBlockID == *blockID {
+		toBlockID = nil
+	}
+	return publishEvent(db, outboxRepo, moveEventTypes[move.MoveType], move.ContainerNumber, blockID, toBlockID, move)
+}
+
+func publishPlanChanged(db *gorm.DB, outboxRepo repository.OutboxEventRepository, plan *model.YardPlan, change string) error {
+	return publishEvent(db, outboxRepo, model.EventPlanChanged, strconv.Itoa(plan.ID), &plan.BlockID, nil, planChangedEvent{
+		Change: change,
+		Plan:   toYardPlanResponse(plan),
+	})
+}
+
+// publishReservationChanged publishes that a cell was promised to a
+// container or is no longer. A nil reservation, a pre-advice without a
+// provisional cell for example, publishes nothing.
+func publishReservationChanged(db *gorm.DB, outboxRepo repository.OutboxEventRepository, r *reservation, change string) error {
+	if r == nil {
+		return nil
+	}
+
+	return publishEvent(db, outboxRepo, model.EventReservationChanged, r.ContainerNumber, &r.BlockID, nil, reservationChangedEvent{
+		Change:          change,
+		Source:          r.Source,
+		ContainerNumber: r.ContainerNumber,
+		ContainerSize:   r.ContainerSize,
+		ContainerHeight: r.ContainerHeight,
+		ContainerType:   r.ContainerType,
+		BlockID:         r.BlockID,
+		Slot:            r.Slot,
+		Row:             r.Row,
+		Tier:            r.Tier,
+	})
+}
@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type DwellService interface {
+	CreateThreshold(ctx context.Context, request *web.DwellThresholdRequest) (*web.DwellThresholdResponse, *response.CustomError)
+	UpdateThreshold(ctx context.Context, thresholdID int, request *web.DwellThresholdRequest) (*web.DwellThresholdResponse, *response.CustomError)
+	DeleteThreshold(ctx context.Context, thresholdID int) *response.CustomError
+	FindThresholds(ctx context.Context) ([]web.DwellThresholdResponse, *response.CustomError)
+
+	LongStayReport(ctx context.Context, query *web.LongStayQuery) (*web.LongStayReportResponse, *response.CustomError)
+
+	// EnforceThresholds raises an alert for every overdue container whose
+	// threshold asks for one, publishing it as a DwellExceeded event, and
+	// marks containers LONG_STAY where configured. Long stay containers are
+	// queued for a move to the long term yard while a cell is free there.
+	EnforceThresholds(ctx context.Context) (*web.DwellEnforcementResponse, *response.CustomError)
+	FindAlerts(ctx context.Context, query *web.DwellAlertQuery) ([]web.DwellAlertResponse, *response.CustomError)
+}
+
+type DwellServiceImpl struct {
+	ContainerService            ContainerService
+	WorkInstructionService      WorkInstructionService
+	YardRepository              repository.YardRepository
+	DwellThresholdRepository    repository.DwellThresholdRepository
+	DwellAlertRepository        repository.DwellAlertRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	DefaultFreeDays             int
+	// Yard long stay containers are moved to, empty keeps them in place
+	LongTermYard string
+	DB           *gorm.DB
+	Validate     *validator.Validate
+}
+
+func NewDwellService(
+	containerService ContainerService,
+	workInstructionService WorkInstructionService,
+	yardRepo repository.YardRepository,
+	thresholdRepo repository.DwellThresholdRepository,
+	alertRepo repository.DwellAlertRepository,
+	containerRepo repository.ContainerPositionRepository,
+	workRepo repository.WorkInstructionRepository,
+	outboxRepo repository.OutboxEventRepository,
+	defaultFreeDays int,
+	longTermYard string,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) DwellService {
+	return &DwellServiceImpl{
+		ContainerService:            containerService,
+		WorkInstructionService:      workInstructionService,
+		YardRepository:              yardRepo,
+		DwellThresholdRepository:    thresholdRepo,
+		DwellAlertRepository:        alertRepo,
+		ContainerPositionRepository: containerRepo,
+		WorkInstructionRepository:   workRepo,
+		OutboxEventRepository:       outboxRepo,
+		DefaultFreeDays:             defaultFreeDays,
+		LongTermYard:                longTermYard,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *DwellServiceImpl) CreateThreshold(ctx context.Context, request *web.DwellThresholdRequest) (*web.DwellThresholdResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	now := time.Now()
+	threshold := model.DwellThreshold{
+		ContainerType: request.ContainerType,
+		FreeDays:      request.FreeDays,
+		Action:        request.Action,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	yardName, customErr := s.resolveThresholdYard(&threshold, request.YardName)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	if err := s.DwellThresholdRepository.Save(s.DB, &threshold); err != nil {
+		return nil, response.RepositoryError("Failed to create dwell threshold: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityDwellThreshold, threshold.ID, nil, threshold)
+
+	thresholdResponse := toDwellThresholdResponse(&threshold, yardName)
+	return &thresholdResponse, nil
+}
+
+func (s *DwellServiceImpl) UpdateThreshold(ctx context.Context, thresholdID int, request *web.DwellThresholdRequest) (*web.DwellThresholdResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var threshold model.DwellThreshold
+	if err := s.DwellThresholdRepository.FindByID(s.DB, &threshold, thresholdID); err != nil {
+		return nil, response.NotFoundError("Dwell threshold not found.")
+	}
+	before := threshold
+
+	threshold.ContainerType = request.ContainerType
+	threshold.FreeDays = request.FreeDays
+	threshold.Action = request.Action
+	threshold.UpdatedAt = time.Now()
+
+	yardName, customErr := s.resolveThresholdYard(&threshold, request.YardName)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	if err := s.DwellThresholdRepository.Update(s.DB, &threshold); err != nil {
+		return nil, response.RepositoryError("Failed to update dwell threshold: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityDwellThreshold, threshold.ID, before, threshold)
+
+	thresholdResponse := toDwellThresholdResponse(&threshold, yardName)
+	return &thresholdResponse, nil
+}
+
+func (s *DwellServiceImpl) DeleteThreshold(ctx context.Context, thresholdID int) *response.CustomError {
+	var threshold model.DwellThreshold
+	if err := s.DwellThresholdRepository.FindByID(s.DB, &threshold, thresholdID); err != nil {
+		return response.NotFoundError("Dwell threshold not found.")
+	}
+
+	if err := s.DwellThresholdRepository.Delete(s.DB, thresholdID); err != nil {
+		return response.NotFoundError("Dwell threshold not found.")
+	}
+	auditlog.Record(ctx, model.AuditEntityDwellThreshold, thresholdID, threshold, nil)
+	return nil
+}
+
+func (s *DwellServiceImpl) FindThresholds(ctx context.Context) ([]web.DwellThresholdResponse, *response.CustomError) {
+	var thresholds []model.DwellThreshold
+	if err := s.DwellThresholdRepository.FindAll(s.DB, &thresholds); err != nil {
+		return nil, response.RepositoryError("Failed to fetch dwell thresholds: " + err.Error())
+	}
+
+	var yards []model.Yard
+	if err := s.YardRepository.FindAllYards(s.DB, &yards); err != nil {
+		return nil, response.RepositoryError("Failed to fetch yards: " + err.Error())
+	}
+
+	yardNames := make(map[int]string, len(yards))
+	for _, yard := range yards {
+		yardNames[yard.ID] = yard.Name
+	}
+
+	thresholdResponses := make([]web.DwellThresholdResponse, 0, len(thresholds))
+	for i := range thresholds {
+		yardName := ""
+		if thresholds[i].YardID != nil {
+			yardName = yardNames[*thresholds[i].YardID]
+		}
+		thresholdResponses = append(thresholdResponses, toDwellThresholdResponse(&thresholds[i], yardName))
+	}
+
+	return thresholdResponses, nil
+}
+
+func (s *DwellServiceImpl) LongStayReport(ctx context.Context, query *web.LongStayQuery) (*web.LongStayReportResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	now := time.Now()
+	overdue, err := s.findOverdue(s.DB, now)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to compute dwell times: " + err.Error())
+	}
+
+	items := make([]web.LongStayContainer, 0, len(overdue))
+	for _, container := range overdue {
+		if query.YardName != "" && container.Yard != query.YardName {
+			continue
+		}
+		if query.ContainerType != "" && container.ContainerType != query.ContainerType {
+			continue
+		}
+		items = append(items, container)
+	}
+
+	return &web.LongStayReportResponse{
+		GeneratedAt: now,
+		Total:       len(items),
+		Items:       items,
+	}, nil
+}
+
+func (s *DwellServiceImpl) EnforceThresholds(ctx context.Context) (*web.DwellEnforcementResponse, *response.CustomError) {
+	now := time.Now()
+	enforcement := &web.DwellEnforcementResponse{}
+
+	// Changes are recorded once the transaction committed
+	var alerted []model.DwellAlert
+	var marked []containerChange
+	var longStay []web.LongStayContainer
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		overdue, err := s.findOverdue(tx, now)
+		if err != nil {
+			return err
+		}
+		enforcement.Overdue = len(overdue)
+
+		for _, container := range overdue {
+			if container.Action == model.DwellActionReport {
+				continue
+			}
+
+			alert := model.DwellAlert{
+				ContainerNumber: container.ContainerNumber,
+				ArrivalDate:     container.ArrivalDate,
+				YardID:          container.YardID,
+				DwellDays:       container.DwellDays,
+				FreeDays:        container.FreeDays,
+				Action:          container.Action,
+				CreatedAt:       now,
+			}
+
+			created, err := s.DwellAlertRepository.Save(tx, &alert)
+			if err != nil {
+				return err
+			}
+			if created {
+				if err := publishEvent(tx, s.OutboxEventRepository, model.EventDwellExceeded, container.ContainerNumber, &container.BlockID, nil, container); err != nil {
+					return err
+				}
+				alerted = append(alerted, alert)
+				enforcement.Alerted++
+			}
+
+			if container.Action != model.DwellActionMarkLongStay {
+				continue
+			}
+			longStay = append(longStay, container)
+			if container.ContainerStatus == model.ContainerStatusLongStay {
+				continue
+			}
+
+			var position model.ContainerPosition
+			if err := s.ContainerPositionRepository.FindByContainerNumber(tx, &position, container.ContainerNumber); err != nil {
+				return err
+			}
+
+			before := position
+			position.ContainerStatus = model.ContainerStatusLongStay
+			position.UpdatedAt = now
+			if err := s.ContainerPositionRepository.UpdateStatus(tx, &position); err != nil {
+				return err
+			}
+			marked = append(marked, containerChange{&before, &position})
+			enforcement.MarkedLongStay++
+		}
+		return nil
+	})
+
+	if errors.Is(txErr, repository.ErrStaleRevision) {
+		return nil, response.PreconditionFailedError("A container was changed by another request while dwell thresholds were enforced, retry.")
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to enforce dwell thresholds: " + txErr.Error())
+	}
+
+	for _, alert := range alerted {
+		auditlog.Record(ctx, model.AuditEntityDwellAlert, alert.ID, nil, alert)
+	}
+	for _, change := range marked {
+		auditlog.Record(ctx, model.AuditEntityContainer, change.after.ContainerNumber, change.before, change.after)
+	}
+
+	for _, container := range longStay {
+		if s.queueTransfer(ctx, &container) {
+			enforcement.QueuedTransfers++
+		}
+	}
+
+	return enforcement, nil
+}
+
+// queueTransfer queues the move of a long stay container to the long term
+// yard and reports whether it did. Containers already there or with a
+// pending instruction are left alone. A container that cannot be moved now,
+// for lack of a free cell for example, is only logged and tried again on the
+// next run.
+func (s *DwellServiceImpl) queueTransfer(ctx context.Context, container *web.LongStayContainer) bool {
+	if s.LongTermYard == "" || container.Yard == s.LongTermYard {
+		return false
+	}
+
+	var pending model.WorkInstruction
+	if err := s.WorkInstructionRepository.FindPendingByContainerNumber(s.DB, &pending, container.ContainerNumber); err == nil {
+		return false
+	}
+
+	position, customErr := s.ContainerService.SuggestTransfer(ctx, container.ContainerNumber, s.LongTermYard)
+	if customErr != nil {
+		log.Printf("dwell: no cell in %s for container %s: %s", s.LongTermYard, container.ContainerNumber, customErr.Message)
+		return false
+	}
+
+	_, customErr = s.WorkInstructionService.QueueMove(ctx, &web.MoveRequest{
+		YardName:        container.Yard,
+		ToYardName:      s.LongTermYard,
+		ContainerNumber: container.ContainerNumber,
+		BlockName:       position.Block,
+		Slot:            position.Slot,
+		Row:             position.Row,
+		Tier:            position.Tier,
+		Reason:          "LONG_STAY: " + strconv.Itoa(container.DwellDays) + " days, free time is " + strconv.Itoa(container.FreeDays) + " days",
+	})
+	if customErr != nil {
+		log.Printf("dwell: failed to queue the move of container %s to %s: %s", container.ContainerNumber, s.LongTermYard, customErr.Message)
+		return false
+	}
+	return true
+}
+
+func (s *DwellServiceImpl) FindAlerts(ctx context.Context, query *web.DwellAlertQuery) ([]web.DwellAlertResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	since := time.Now().AddDate(0, 0, -7)
+	if query.Since != nil {
+		since = *query.Since
+	}
+
+	var alerts []model.DwellAlert
+	if err := s.DwellAlertRepository.FindSince(s.DB, &alerts, since); err != nil {
+		return nil, response.RepositoryError("Failed to fetch dwell alerts: " + err.Error())
+	}
+
+	alertResponses := make([]web.DwellAlertResponse, 0, len(alerts))
+	for _, alert := range alerts {
+		alertResponses = append(alertResponses, web.DwellAlertResponse{
+			ID:              alert.ID,
+			ContainerNumber: alert.ContainerNumber,
+			ArrivalDate:     alert.ArrivalDate,
+			YardID:          alert.YardID,
+			DwellDays:       alert.DwellDays,
+			FreeDays:        alert.FreeDays,
+			Action:          alert.Action,
+			CreatedAt:       alert.CreatedAt,
+		})
+	}
+
+	return alertResponses, nil
+}
+
+// findOverdue lists every container whose dwell time is past the free days of
+// its threshold, longest overdue first. Only containers older than the
+// smallest configured free time are loaded.
+func (s *DwellServiceImpl) findOverdue(db *gorm.DB, now time.Time) ([]web.LongStayContainer, error) {
+	var thresholds []model.DwellThreshold
+	if err := s.DwellThresholdRepository.FindAll(db, &thresholds); err != nil {
+		return nil, err
+	}
+
+	minFreeDays := s.DefaultFreeDays
+	for _, threshold := range thresholds {
+		minFreeDays = min(minFreeDays, threshold.FreeDays)
+	}
+
+	var details []model.ContainerPositionDetail
+	if err := s.ContainerPositionRepository.FindDetailsArrivedBefore(db, &details, now.AddDate(0, 0, -minFreeDays)); err != nil {
+		return nil, err
+	}
+
+	var overdue []web.LongStayContainer
+	for i := range details {
+		detail := &details[i]
+
+		container := web.LongStayContainer{
+			ContainerDetailResponse: toContainerDetailResponse(detail, now),
+			FreeDays:                s.DefaultFreeDays,
+			Action:                  model.DwellActionReport,
+		}
+
+		if threshold := findDwellThreshold(thresholds, detail.YardID, detail.ContainerType); threshold != nil {
+			container.FreeDays = threshold.FreeDays
+			container.ThresholdID = &threshold.ID
+			container.Action = threshold.Action
+		}
+
+		container.OverdueDays = container.DwellDays - container.FreeDays
+		if container.OverdueDays > 0 {
+			overdue = append(overdue, container)
+		}
+	}
+
+	sort.SliceStable(overdue, func(i, j int) bool {
+		return overdue[i].OverdueDays > overdue[j].OverdueDays
+	})
+
+	return overdue, nil
+}
+
+// findDwellThreshold returns the most specific threshold matching the
+// container, or nil when only the default free time applies.
+func findDwellThreshold(thresholds []model.DwellThreshold, yardID int, containerType string) *model.DwellThreshold {
+	var best *model.DwellThreshold
+	for i := range thresholds {
+		threshold := &thresholds[i]
+		if !threshold.Matches(yardID, containerType) {
+			continue
+		}
+		if best == nil || threshold.Specificity() > best.Specificity() {
+			best = threshold
+		}
+	}
+	return best
+}
+
+// resolveThresholdYard sets the threshold's yard from its name, clearing it
+// when the name is empty, and returns the yard name for the response.
+func (s *DwellServiceImpl) resolveThresholdYard(threshold *model.DwellThreshold, yardName string) (string, *response.CustomError) {
+	if yardName == "" {
+		threshold.YardID = nil
+		return "", nil
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, yardName); err != nil {
+		return "", response.NotFoundError("Yard not found.")
+	}
+
+	threshold.YardID = &yard.ID
+	return yard.Name, nil
+}
+
+// dwellDays is the number of whole days a container has been in the yard.
+func dwellDays(arrival, now time.Time) int {
+	return int(now.Sub(arrival).Hours() / 24)
+}
+
+func toDwellThresholdResponse(threshold *model.DwellThreshold, yardName string) web.DwellThresholdResponse {
+	return web.DwellThresholdResponse{
+		ID:            threshold.ID,
+		YardID:        threshold.YardID,
+		Yard:          yardName,
+		ContainerType: threshold.ContainerType,
+		FreeDays:      threshold.FreeDays,
+		Action:        threshold.Action,
+	}
+}
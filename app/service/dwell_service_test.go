@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"gorm.io/gorm"
+)
+
+type fakeTransferContainers struct {
+	ContainerService
+}
+
+func (f *fakeTransferContainers) SuggestTransfer(ctx context.Context, containerNumber, yardName string) (*web.PositionResponse, *response.CustomError) {
+	return &web.PositionResponse{Block: "L01", Slot: 3, Row: 2, Tier: 1}, nil
+}
+
+type fakeTransferInstructions struct {
+	WorkInstructionService
+	queued []web.MoveRequest
+}
+
+func (f *fakeTransferInstructions) QueueMove(ctx context.Context, request *web.MoveRequest) (*web.WorkInstructionResponse, *response.CustomError) {
+	f.queued = append(f.queued, *request)
+	return &web.WorkInstructionResponse{}, nil
+}
+
+type fakePendingInstructions struct {
+	repository.WorkInstructionRepository
+	pending map[string]bool
+}
+
+func (f *fakePendingInstructions) FindPendingByContainerNumber(db *gorm.DB, instruction *model.WorkInstruction, containerNumber string) error {
+	if f.pending[containerNumber] {
+		return nil
+	}
+	return errors.New("work instruction not found")
+}
+
+func TestQueueTransferMovesLongStayToLongTermYard(t *testing.T) {
+	tests := []struct {
+		name         string
+		longTermYard string
+		yard         string
+		pending      bool
+		wantQueued   bool
+	}{
+		{"long stay in another yard", "YRD-LONGTERM", "YRD-A", false, true},
+		{"already in the long term yard", "YRD-LONGTERM", "YRD-LONGTERM", false, false},
+		{"pending instruction", "YRD-LONGTERM", "YRD-A", true, false},
+		{"moves disabled", "", "YRD-A", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instructions := &fakeTransferInstructions{}
+			s := &DwellServiceImpl{
+				ContainerService:          &fakeTransferContainers{},
+				WorkInstructionService:    instructions,
+				WorkInstructionRepository: &fakePendingInstructions{pending: map[string]bool{"MSKU1234565": tt.pending}},
+				LongTermYard:              tt.longTermYard,
+			}
+
+			container := &web.LongStayContainer{
+				ContainerDetailResponse: web.ContainerDetailResponse{ContainerNumber: "MSKU1234565", Yard: tt.yard, DwellDays: 40},
+				FreeDays:                30,
+			}
+
+			if got := s.queueTransfer(context.Background(), container); got != tt.wantQueued {
+				t.Fatalf("queueTransfer = %v, want %v", got, tt.wantQueued)
+			}
+			if !tt.wantQueued {
+				if len(instructions.queued) != 0 {
+					t.Fatalf("queued %d moves, want none", len(instructions.queued))
+				}
+				return
+			}
+
+			move := instructions.queued[0]
+			if move.YardName != tt.yard || move.ToYardName != tt.longTermYard {
+				t.Errorf("move from %q to %q, want from %q to %q", move.YardName, move.ToYardName, tt.yard, tt.longTermYard)
+			}
+			if move.BlockName != "L01" || move.Slot != 3 || move.Row != 2 || move.Tier != 1 {
+				t.Errorf("move target = %s S%d R%d T%d, want the suggested cell", move.BlockName, move.Slot, move.Row, move.Tier)
+			}
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// defaultEDITimezone is the time zone of a partner created without one.
+const defaultEDITimezone = "Asia/Jakarta"
+
+type EDIService interface {
+	CreatePartner(ctx context.Context, request *web.EDIPartnerRequest) (*web.EDIPartnerResponse, *response.CustomError)
+	UpdatePartner(ctx context.Context, partnerID int, request *web.EDIPartnerRequest) (*web.EDIPartnerResponse, *response.CustomError)
+	DeletePartner(ctx context.Context, partnerID int) *response.CustomError
+	FindPartners(ctx context.Context) ([]web.EDIPartnerResponse, *response.CustomError)
+
+	// ExportCODECO builds the CODECO gate report of a shipping line from its
+	// placements and pickups in the period.
+	ExportCODECO(ctx context.Context, query *web.CODECOQuery) (*web.EDIDocument, *response.CustomError)
+	// ExportCOARRI builds the discharge and load reports of a vessel call from
+	// the placements and pickups tagged with it.
+	ExportCOARRI(ctx context.Context, query *web.COARRIQuery) (*web.EDIDocument, *response.CustomError)
+	// ImportCOARRI reads the discharge messages of a COARRI interchange and
+	// pre-advises their containers in the yard.
+	ImportCOARRI(ctx context.Context, query *web.COARRIImportQuery, data string) (*web.COARRIImportResponse, *response.CustomError)
+	// ImportBAPLIE reads the stowage plan of an arriving vessel, forecasts the
+	// yard space its discharge needs and pre-advises the containers.
+	ImportBAPLIE(ctx context.Context, query *web.BAPLIEImportQuery, data string) (*web.BAPLIEImportResponse, *response.CustomError)
+}
+
+type EDIServiceImpl struct {
+	YardRepository          repository.YardRepository
+	EDIPartnerRepository    repository.EDIPartnerRepository
+	ContainerMoveRepository repository.ContainerMoveRepository
+	PreAdviceService        PreAdviceService
+	CapacityReportService   CapacityReportService
+	DB                      *gorm.DB
+	Validate                *validator.Validate
+}
+
+func NewEDIService(
+	yardRepo repository.YardRepository,
+	partnerRepo repository.EDIPartnerRepository,
+	moveRepo repository.ContainerMoveRepository,
+	preAdviceService PreAdviceService,
+	capacityReportService CapacityReportService,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) EDIService {
+	return &EDIServiceImpl{
+		YardRepository:          yardRepo,
+		EDIPartnerRepository:    partnerRepo,
+		ContainerMoveRepository: moveRepo,
+		PreAdviceService:        preAdviceService,
+		CapacityReportService:   capacityReportService,
+		DB:                      DB,
+		Validate:                validate,
+	}
+}
+
+func (s *EDIServiceImpl) CreatePartner(ctx context.Context, request *web.EDIPartnerRequest) (*web.EDIPartnerResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var existing model.EDIPartner
+	if err := s.EDIPartnerRepository.FindByShippingLine(s.DB, &existing, request.ShippingLine); err == nil {
+		return nil, response.BadRequestError("EDI partner for shipping line " + request.ShippingLine + " already exists.")
+	}
+
+	now := time.Now()
+	partner := model.EDIPartner{
+		ShippingLine: request.ShippingLine,
+		SenderID:     request.SenderID,
+		RecipientID:  request.RecipientID,
+		Timezone:     cmp.Or(request.Timezone, defaultEDITimezone),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.EDIPartnerRepository.Save(s.DB, &partner); err != nil {
+		return nil, response.RepositoryError("Failed to create EDI partner: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityEDIPartner, partner.ID, nil, partner)
+
+	partnerResponse := toEDIPartnerResponse(&partner)
+	return &partnerResponse, nil
+}
+
+func (s *EDIServiceImpl) UpdatePartner(ctx context.Context, partnerID int, request *web.EDIPartnerRequest) (*web.EDIPartnerResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var partner model.EDIPartner
+	if err := s.EDIPartnerRepository.FindByID(s.DB, &partner, partnerID); err != nil {
+		return nil, response.NotFoundError("EDI partner not found.")
+	}
+	before := partner
+
+	var existing model.EDIPartner
+	if err := s.EDIPartnerRepository.FindByShippingLine(s.DB, &existing, request.ShippingLine); err == nil && existing.ID != partner.ID {
+		return nil, response.BadRequestError("EDI partner for shipping line " + request.ShippingLine + " already exists.")
+	}
+
+	partner.ShippingLine = request.ShippingLine
+	partner.SenderID = request.SenderID
+	partner.RecipientID = request.RecipientID
+	partner.Timezone = cmp.Or(request.Timezone, defaultEDITimezone)
+	partner.UpdatedAt = time.Now()
+
+	if err := s.EDIPartnerRepository.Update(s.DB, &partner); err != nil {
+		return nil, response.RepositoryError("Failed to update EDI partner: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityEDIPartner, partner.ID, before, partner)
+
+	partnerResponse := toEDIPartnerResponse(&partner)
+	return &partnerResponse, nil
+}
+
+func (s *EDIServiceImpl) DeletePartner(ctx context.Context, partnerID int) *response.CustomError {
+	var partner model.EDIPartner
+	if err := s.EDIPartnerRepository.FindByID(s.DB, &partner, partnerID); err != nil {
+		return response.NotFoundError("EDI partner not found.")
+	}
+
+	if err := s.EDIPartnerRepository.Delete(s.DB, partnerID); err != nil {
+		return response.NotFoundError("EDI partner not found.")
+	}
+	auditlog.Record(ctx, model.AuditEntityEDIPartner, partnerID, partner, nil)
+	return nil
+}
+
+func (s *EDIServiceImpl) FindPartners(ctx context.Context) ([]web.EDIPartnerResponse, *response.CustomError) {
+	var partners []model.EDIPartner
+	if err := s.EDIPartnerRepository.FindAll(s.DB, &partners); err != nil {
+		return nil, response.RepositoryError("Failed to fetch EDI partners: " + err.Error())
+	}
+
+	partnerResponses := make([]web.EDIPartnerResponse, 0, len(partners))
+	for i := range partners {
+		partnerResponses = append(partnerResponses, toEDIPartnerResponse(&partners[i]))
+	}
+	return partnerResponses, nil
+}
+
+func (s *EDIServiceImpl) ExportCODECO(ctx context.Context, query *web.CODECOQuery) (*web.EDIDocument, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var partner model.EDIPartner
+	if err := s.EDIPartnerRepository.FindByShippingLine(s.DB, &partner, query.ShippingLine); err != nil {
+		return nil, response.NotFoundError("No EDI partner configured for shipping line " + query.ShippingLine + ".")
+	}
+
+	// The period covers whole days of the partner, the last day included
+	loc := partner.TimeLocation()
+	from := time.Date(query.From.Year(), query.From.Month(), query.From.Day(), 0, 0, 0, 0, loc)
+	to := time.Date(query.To.Year(), query.To.Month(), query.To.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+	var events []model.ContainerGateEvent
+	if err := s.ContainerMoveRepository.FindGateEvents(s.DB, &events, query.ShippingLine, from, to); err != nil {
+		return nil, response.RepositoryError("Failed to fetch gate events: " + err.Error())
+	}
+	if len(events) == 0 {
+		return nil, response.NotFoundError("No placements or pickups of shipping line " + query.ShippingLine + " in the period.")
+	}
+
+	content, messages := buildCODECO(&partner, events, time.Now().In(loc))
+
+	fileName := "CODECO_" + strings.ReplaceAll(query.ShippingLine, " ", "_") + "_" +
+		query.From.Format(billingDateFormat) + "_" + query.To.Format(billingDateFormat) + ".edi"
+
+	return &web.EDIDocument{
+		FileName:   fileName,
+		Content:    content,
+		Messages:   messages,
+		Containers: len(events),
+	}, nil
+}
+
+func (s *EDIServiceImpl) ExportCOARRI(ctx context.Context, query *web.COARRIQuery) (*web.EDIDocument, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var partner model.EDIPartner
+	if err := s.EDIPartnerRepository.FindByShippingLine(s.DB, &partner, query.ShippingLine); err != nil {
+		return nil, response.NotFoundError("No EDI partner configured for shipping line " + query.ShippingLine + ".")
+	}
+
+	var moves []model.ContainerMove
+	if err := s.ContainerMoveRepository.FindVesselMoves(s.DB, &moves, query.ShippingLine, query.Vessel, query.Voyage); err != nil {
+		return nil, response.RepositoryError("Failed to fetch vessel moves: " + err.Error())
+	}
+	if len(moves) == 0 {
+		return nil, response.NotFoundError("No placements or pickups of shipping line " + query.ShippingLine + " tagged with vessel " + query.Vessel + " voyage " + query.Voyage + ".")
+	}
+
+	content, messages := buildCOARRI(&partner, query.Vessel, query.Voyage, moves, time.Now().In(partner.TimeLocation()))
+
+	fileName := "COARRI_" + strings.ReplaceAll(query.Vessel, " ", "_") + "_" + strings.ReplaceAll(query.Voyage, " ", "_") + ".edi"
+
+	return &web.EDIDocument{
+		FileName:   fileName,
+		Content:    content,
+		Messages:   messages,
+		Containers: len(moves),
+	}, nil
+}
+
+func (s *EDIServiceImpl) ImportCOARRI(ctx context.Context, query *web.COARRIImportQuery, data string) (*web.COARRIImportResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	// Message dates are local to the port, which is the yard's
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	messages, err := parseCOARRI(data, yard.TimeLocation())
+	if err != nil {
+		return nil, response.BadRequestError("Invalid COARRI interchange: " + err.Error())
+	}
+
+	batch := &web.BatchPreAdviceRequest{}
+	discharges := 0
+
+	for _, message := range messages {
+		if message.DocumentCode != coarriDischarge {
+			continue
+		}
+		discharges++
+
+		expectedFrom := query.ExpectedFrom
+		if expectedFrom == nil {
+			expectedFrom = message.Arrival
+		}
+		if expectedFrom == nil {
+			return nil, response.BadRequestError("Discharge message for vessel " + message.Vessel + " has no ETA (DTM+132), provide expected_from.")
+		}
+
+		expectedTo := expectedFrom.Add(coarriArrivalWindow)
+		if query.ExpectedTo != nil {
+			expectedTo = *query.ExpectedTo
+		}
+
+		for _, container := range message.Containers {
+			size, height, containerType := containerSpec(container.SizeType, container.FullEmpty)
+
+			batch.PreAdvices = append(batch.PreAdvices, web.PreAdviceRequest{
+				ContainerRequest: web.ContainerRequest{
+					YardName:        query.YardName,
+					ContainerNumber: container.ContainerNumber,
+					Size:            size,
+					Height:          height,
+					Type:            containerType,
+				},
+				Vessel:       message.Vessel,
+				Voyage:       message.Voyage,
+				ShippingLine: message.ShippingLine,
+				ExpectedFrom: *expectedFrom,
+				ExpectedTo:   expectedTo,
+			})
+		}
+	}
+
+	if discharges == 0 {
+		return nil, response.BadRequestError("The interchange has no discharge message (BGM+" + coarriDischarge + ").")
+	}
+	if len(batch.PreAdvices) == 0 {
+		return nil, response.BadRequestError("The discharge messages list no containers.")
+	}
+
+	batchResponse, customErr := s.PreAdviceService.CreatePreAdvices(ctx, model.PreAdviceSourceCOARRI, batch)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return &web.COARRIImportResponse{
+		Messages:   discharges,
+		PreAdvices: batchResponse,
+	}, nil
+}
+
+func (s *EDIServiceImpl) ImportBAPLIE(ctx context.Context, query *web.BAPLIEImportQuery, data string) (*web.BAPLIEImportResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	plans, err := parseBAPLIE(data, yard.TimeLocation())
+	if err != nil {
+		return nil, response.BadRequestError("Invalid BAPLIE interchange: " + err.Error())
+	}
+
+	importResponse := &web.BAPLIEImportResponse{Messages: len(plans)}
+	batch := &web.BatchPreAdviceRequest{}
+
+	for _, plan := range plans {
+		importResponse.Containers += len(plan.Containers)
+
+		var discharging []baplieContainer
+		for _, container := range plan.Containers {
+			if strings.EqualFold(container.DischargePort, query.Port) {
+				discharging = append(discharging, container)
+			}
+		}
+		if len(discharging) == 0 {
+			continue
+		}
+		importResponse.Discharging += len(discharging)
+
+		expectedFrom := query.ExpectedFrom
+		if expectedFrom == nil {
+			expectedFrom = plan.Arrival
+		}
+		if expectedFrom == nil {
+			// The forecast does not need the arrival window
+			if !query.ForecastOnly {
+				return nil, response.BadRequestError("Stowage plan for vessel " + plan.Vessel + " has no ETA (DTM+132), provide expected_from.")
+			}
+			expectedFrom = &time.Time{}
+		}
+
+		expectedTo := expectedFrom.Add(coarriArrivalWindow)
+		if query.ExpectedTo != nil {
+			expectedTo = *query.ExpectedTo
+		}
+
+		for _, container := range discharging {
+			batch.PreAdvices = append(batch.PreAdvices, bapliePreAdvice(query.YardName, &plan, &container, *expectedFrom, expectedTo))
+		}
+	}
+
+	if importResponse.Discharging == 0 {
+		return nil, response.BadRequestError("The stowage plan has no container discharged in port " + query.Port + " (LOC+11).")
+	}
+
+	report, customErr := s.CapacityReportService.CapacityReport(ctx, &web.CapacityReportQuery{YardName: query.YardName})
+	if customErr != nil {
+		return nil, customErr
+	}
+	importResponse.Forecast = baplieForecast(&report.Yards[0], batch.PreAdvices)
+
+	if query.ForecastOnly {
+		return importResponse, nil
+	}
+
+	batchResponse, customErr := s.PreAdviceService.CreatePreAdvices(ctx, model.PreAdviceSourceBAPLIE, batch)
+	if customErr != nil {
+		return nil, customErr
+	}
+	importResponse.PreAdvices = batchResponse
+
+	return importResponse, nil
+}
+
+func toEDIPartnerResponse(partner *model.EDIPartner) web.EDIPartnerResponse {
+	return web.EDIPartnerResponse{
+		ID:           partner.ID,
+		ShippingLine: partner.ShippingLine,
+		SenderID:     partner.SenderID,
+		RecipientID:  partner.RecipientID,
+		Timezone:     partner.Timezone,
+		UpdatedAt:    partner.UpdatedAt,
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type EquipmentService interface {
+	CreateEquipment(ctx context.Context, request *web.EquipmentRequest) (*web.EquipmentResponse, *response.CustomError)
+	UpdateEquipment(ctx context.Context, equipmentID int, request *web.EquipmentRequest) (*web.EquipmentResponse, *response.CustomError)
+	FindEquipment(ctx context.Context, query *web.EquipmentQuery) ([]web.EquipmentResponse, *response.CustomError)
+}
+
+type EquipmentServiceImpl struct {
+	YardRepository            repository.YardRepository
+	EquipmentRepository       repository.EquipmentRepository
+	WorkInstructionRepository repository.WorkInstructionRepository
+	DispatchService           DispatchService
+	DB                        *gorm.DB
+	Validate                  *validator.Validate
+}
+
+func NewEquipmentService(
+	yardRepo repository.YardRepository,
+	equipmentRepo repository.EquipmentRepository,
+	workRepo repository.WorkInstructionRepository,
+	dispatchService DispatchService,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) EquipmentService {
+	return &EquipmentServiceImpl{
+		YardRepository:            yardRepo,
+		EquipmentRepository:       equipmentRepo,
+		WorkInstructionRepository: workRepo,
+		DispatchService:           dispatchService,
+		DB:                        DB,
+		Validate:                  validate,
+	}
+}
+
+func (s *EquipmentServiceImpl) CreateEquipment(ctx context.Context, request *web.EquipmentRequest) (*web.EquipmentResponse, *response.CustomError) {
+	equipment, customErr := s.applyEquipmentRequest(request)
+	if customErr != nil {
+		return nil, customErr
+	}
+	equipment.CreatedAt = equipment.UpdatedAt
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.EquipmentRepository.Save(tx, equipment)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to create equipment: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityEquipment, equipment.ID, nil, equipment)
+	s.redispatch(ctx, equipment.YardID)
+
+	blockNames, err := s.blockNames(equipment.YardID)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch blocks: " + err.Error())
+	}
+
+	equipmentResponse := toEquipmentResponse(equipment, blockNames, 0)
+	return &equipmentResponse, nil
+}
+
+func (s *EquipmentServiceImpl) UpdateEquipment(ctx context.Context, equipmentID int, request *web.EquipmentRequest) (*web.EquipmentResponse, *response.CustomError) {
+	var existing model.Equipment
+	if err := s.EquipmentRepository.FindByID(s.DB, &existing, equipmentID); err != nil {
+		return nil, response.NotFoundError("Equipment not found.")
+	}
+
+	equipment, customErr := s.applyEquipmentRequest(request)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if equipment.YardID != existing.YardID {
+		return nil, response.BadRequestError("Equipment cannot be moved to another yard.")
+	}
+	equipment.ID = existing.ID
+	equipment.CreatedAt = existing.CreatedAt
+	if request.CurrentBlock == "" {
+		equipment.CurrentBlockID, equipment.CurrentSlot = existing.CurrentBlockID, existing.CurrentSlot
+	}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.EquipmentRepository.Update(tx, equipment)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to update equipment: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityEquipment, equipment.ID, existing, equipment)
+	s.redispatch(ctx, equipment.YardID)
+
+	blockNames, err := s.blockNames(equipment.YardID)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch blocks: " + err.Error())
+	}
+
+	pending, err := s.WorkInstructionRepository.CountPendingByEquipment(s.DB, []int{equipment.ID})
+	if err != nil {
+		return nil, response.RepositoryError("Failed to count pending jobs: " + err.Error())
+	}
+
+	equipmentResponse := toEquipmentResponse(equipment, blockNames, pending[equipment.ID])
+	return &equipmentResponse, nil
+}
+
+func (s *EquipmentServiceImpl) FindEquipment(ctx context.Context, query *web.EquipmentQuery) ([]web.EquipmentResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var equipments []model.Equipment
+	if err := s.EquipmentRepository.FindByYardID(s.DB, &equipments, yard.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch equipment: " + err.Error())
+	}
+
+	blockNames, err := s.blockNames(yard.ID)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch blocks: " + err.Error())
+	}
+
+	ids := make([]int, 0, len(equipments))
+	for _, equipment := range equipments {
+		ids = append(ids, equipment.ID)
+	}
+	pending, err := s.WorkInstructionRepository.CountPendingByEquipment(s.DB, ids)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to count pending jobs: " + err.Error())
+	}
+
+	equipmentResponses := make([]web.EquipmentResponse, 0, len(equipments))
+	for i := range equipments {
+		equipmentResponses = append(equipmentResponses, toEquipmentResponse(&equipments[i], blockNames, pending[equipments[i].ID]))
+	}
+
+	return equipmentResponses, nil
+}
+
+// applyEquipmentRequest validates the request and resolves its yard and
+// block names.
+func (s *EquipmentServiceImpl) applyEquipmentRequest(request *web.EquipmentRequest) (*model.Equipment, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	equipment := &model.Equipment{
+		Code:          request.Code,
+		EquipmentType: request.EquipmentType,
+		YardID:        yard.ID,
+		Active:        request.Active == nil || *request.Active,
+		UpdatedAt:     time.Now(),
+	}
+
+	seen := make(map[int]bool, len(request.Blocks))
+	for _, blockName := range request.Blocks {
+		var block model.Block
+		if err := s.YardRepository.FindBlockByNameAndYardID(s.DB, &block, blockName, yard.ID); err != nil {
+			return nil, response.NotFoundError("Block " + blockName + " not found in the specified Yard.")
+		}
+		if !seen[block.ID] {
+			seen[block.ID] = true
+			equipment.BlockIDs = append(equipment.BlockIDs, block.ID)
+		}
+	}
+
+	if request.CurrentBlock != "" {
+		var block model.Block
+		if err := s.YardRepository.FindBlockByNameAndYardID(s.DB, &block, request.CurrentBlock, yard.ID); err != nil {
+			return nil, response.NotFoundError("Block " + request.CurrentBlock + " not found in the specified Yard.")
+		}
+		if request.CurrentSlot > block.Slots {
+			return nil, response.BadRequestError("Current slot exceeds the " + strconv.Itoa(block.Slots) + " slots of block " + block.Name + ".")
+		}
+		equipment.CurrentBlockID, equipment.CurrentSlot = &block.ID, &request.CurrentSlot
+	}
+
+	return equipment, nil
+}
+
+// redispatch recomputes the queues of the yard after its equipment changed.
+// The change is already stored, so a failure is only logged.
+func (s *EquipmentServiceImpl) redispatch(ctx context.Context, yardID int) {
+	if _, customErr := s.DispatchService.Dispatch(ctx, yardID); customErr != nil {
+		log.Printf("dispatch: yard %d: %s", yardID, customErr.Message)
+	}
+}
+
+func (s *EquipmentServiceImpl) blockNames(yardID int) (map[int]string, error) {
+	var blocks []model.Block
+	if err := s.YardRepository.FindBlocksByYardID(s.DB, &blocks, yardID); err != nil {
+		return nil, err
+	}
+
+	blockNames := make(map[int]string, len(blocks))
+	for _, block := range blocks {
+		blockNames[block.ID] = block.Name
+	}
+	return blockNames, nil
+}
+
+func toEquipmentResponse(equipment *model.Equipment, blockNames map[int]string, pendingJobs int64) web.EquipmentResponse {
+	equipmentResponse := web.EquipmentResponse{
+		ID:            equipment.ID,
+		Code:          equipment.Code,
+		EquipmentType: equipment.EquipmentType,
+		YardID:        equipment.YardID,
+		Active:        equipment.Active,
+		Blocks:        make([]string, 0, len(equipment.BlockIDs)),
+		PendingJobs:   pendingJobs,
+	}
+
+	for _, blockID := range equipment.BlockIDs {
+		equipmentResponse.Blocks = append(equipmentResponse.Blocks, blockNames[blockID])
+	}
+	if equipment.CurrentBlockID != nil && equipment.CurrentSlot != nil {
+		equipmentResponse.CurrentBlock = blockNames[*equipment.CurrentBlockID]
+		equipmentResponse.CurrentSlot = *equipment.CurrentSlot
+	}
+	return equipmentResponse
+}
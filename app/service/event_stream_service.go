@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	streamBatch = 500
+	// Events buffered per client. A client that falls further behind is
+	// disconnected and catches up from its last event ID on reconnect.
+	streamBuffer    = 256
+	streamKeepAlive = 15 * time.Second
+)
+
+// streamEventTypes are the events sent to live streams.
+var streamEventTypes = []string{
+	model.EventContainerPlaced, model.EventContainerPickedUp, model.EventContainerMoved, model.EventReservationChanged,
+}
+
+// ErrStreamBehind ends a stream whose client could not keep up.
+var ErrStreamBehind = errors.New("stream client fell behind")
+
+type EventStreamService interface {
+	// Subscribe resolves the filter of a stream client and registers it for
+	// new events.
+	Subscribe(ctx context.Context, query *web.EventStreamQuery) (*EventSubscription, *response.CustomError)
+	// Stream sends the events after the last event ID of the subscription
+	// and then the new events until ctx is done. send is called with nil
+	// every streamKeepAlive while there are no events.
+	Stream(ctx context.Context, subscription *EventSubscription, send func(event *web.StreamEvent) error) error
+	// Broadcast numbers the committed outbox events for streaming and hands
+	// the new ones to the subscribers. It is run every second by the
+	// scheduler on every instance.
+	Broadcast(ctx context.Context) (int, *response.CustomError)
+}
+
+// EventSubscription is one connected stream client.
+type EventSubscription struct {
+	yardID  int
+	blockID int
+
+	// Catch-up range: the client's last event and the last event broadcast
+	// when it subscribed. Later events arrive on events.
+	afterID int
+	untilID int
+	events  chan *model.OutboxEvent
+}
+
+func (sub *EventSubscription) matches(event *model.OutboxEvent) bool {
+	if sub.yardID != 0 && (event.YardID == nil || *event.YardID != sub.yardID) {
+		return false
+	}
+	if sub.blockID != 0 {
+		return (event.BlockID != nil && *event.BlockID == sub.blockID) ||
+			(event.ToBlockID != nil && *event.ToBlockID == sub.blockID)
+	}
+	return true
+}
+
+type EventStreamServiceImpl struct {
+	YardRepository        repository.YardRepository
+	OutboxEventRepository repository.OutboxEventRepository
+	DB                    *gorm.DB
+	Validate              *validator.Validate
+
+	mu          sync.Mutex
+	started     bool
+	lastID      int
+	subscribers map[*EventSubscription]bool
+}
+
+func NewEventStreamService(
+	yardRepo repository.YardRepository,
+	outboxRepo repository.OutboxEventRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) EventStreamService {
+	return &EventStreamServiceImpl{
+		YardRepository:        yardRepo,
+		OutboxEventRepository: outboxRepo,
+		DB:                    DB,
+		Validate:              validate,
+		subscribers:           make(map[*EventSubscription]bool),
+	}
+}
+
+func (s *EventStreamServiceImpl) Subscribe(ctx context.Context, query *web.EventStreamQuery) (*EventSubscription, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	subscription := &EventSubscription{
+		afterID: query.LastEventID,
+		events:  make(chan *model.OutboxEvent, streamBuffer),
+	}
+
+	if query.YardName != "" {
+		var yard model.Yard
+		if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+			return nil, response.NotFoundError("Yard not found.")
+		}
+		subscription.yardID = yard.ID
+	}
+
+	if query.BlockName != "" {
+		if subscription.yardID == 0 {
+			return nil, response.BadRequestError("A block filter requires the yard.")
+		}
+		var block model.Block
+		if err := s.YardRepository.FindBlockByNameAndYardID(s.DB, &block, query.BlockName, subscription.yardID); err != nil {
+			return nil, response.NotFoundError("Block not found in the specified Yard.")
+		}
+		subscription.blockID = block.ID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.start(); err != nil {
+		return nil, response.RepositoryError("Failed to open event stream: " + err.Error())
+	}
+	subscription.untilID = s.lastID
+	s.subscribers[subscription] = true
+
+	return subscription, nil
+}
+
+func (s *EventStreamServiceImpl) Stream(ctx context.Context, subscription *EventSubscription, send func(event *web.StreamEvent) error) error {
+	defer s.unsubscribe(subscription)
+
+	// Without a last event ID the client only wants what happens from now on
+	if subscription.afterID > 0 {
+		for afterID := subscription.afterID; afterID < subscription.untilID; {
+			var events []model.OutboxEvent
+			err := s.OutboxEventRepository.FindStreamEvents(s.DB, &events, afterID, subscription.untilID, streamEventTypes,
+				subscription.yardID, subscription.blockID, streamBatch)
+			if err != nil {
+				return err
+			}
+
+			for i := range events {
+				if err := send(toStreamEvent(&events[i])); err != nil {
+					return err
+				}
+				afterID = *events[i].StreamID
+			}
+			if len(events) < streamBatch {
+				break
+			}
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-subscription.events:
+			if !ok {
+				return ErrStreamBehind
+			}
+			if err := send(toStreamEvent(event)); err != nil {
+				return err
+			}
+			keepAlive.Reset(streamKeepAlive)
+
+		case <-keepAlive.C:
+			if err := send(nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *EventStreamServiceImpl) Broadcast(ctx context.Context) (int, *response.CustomError) {
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := s.OutboxEventRepository.AssignStreamIDs(tx, streamBatch)
+		return err
+	})
+	if txErr != nil {
+		return 0, response.RepositoryError("Failed to number stream events: " + txErr.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		if err := s.start(); err != nil {
+			return 0, response.RepositoryError("Failed to start event stream: " + err.Error())
+		}
+		return 0, nil
+	}
+
+	broadcast := 0
+	for {
+		var events []model.OutboxEvent
+		if err := s.OutboxEventRepository.FindStreamEvents(s.DB, &events, s.lastID, 0, streamEventTypes, 0, 0, streamBatch); err != nil {
+			return broadcast, response.RepositoryError("Failed to fetch stream events: " + err.Error())
+		}
+
+		for i := range events {
+			event := &events[i]
+			s.lastID = *event.StreamID
+
+			for subscription := range s.subscribers {
+				if !subscription.matches(event) {
+					continue
+				}
+
+				select {
+				case subscription.events <- event:
+				default:
+					delete(s.subscribers, subscription)
+					close(subscription.events)
+				}
+			}
+		}
+
+		broadcast += len(events)
+		if len(events) < streamBatch {
+			return broadcast, nil
+		}
+	}
+}
+
+// start makes the stream begin at the latest numbered event, so clients
+// only get older events through their last event ID. s.mu must be held.
+func (s *EventStreamServiceImpl) start() error {
+	if s.started {
+		return nil
+	}
+	if err := s.OutboxEventRepository.FindLatestStreamID(s.DB, &s.lastID); err != nil {
+		return err
+	}
+	s.started = true
+	return nil
+}
+
+func (s *EventStreamServiceImpl) unsubscribe(subscription *EventSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers[subscription] {
+		delete(s.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+func toStreamEvent(event *model.OutboxEvent) *web.StreamEvent {
+	return &web.StreamEvent{
+		ID:          *event.StreamID,
+		Type:        event.EventType,
+		AggregateID: event.AggregateID,
+		YardID:      event.YardID,
+		BlockID:     event.BlockID,
+		ToBlockID:   event.ToBlockID,
+		OccurredAt:  event.CreatedAt,
+		Data:        json.RawMessage(event.Payload),
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+
+	"gorm.io/gorm"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	yard, otherYard := 1, 2
+	block, otherBlock := 5, 6
+	event := func(yardID, blockID, toBlockID *int) *model.OutboxEvent {
+		return &model.OutboxEvent{EventType: model.EventContainerMoved, YardID: yardID, BlockID: blockID, ToBlockID: toBlockID}
+	}
+
+	tests := []struct {
+		name         string
+		subscription EventSubscription
+		event        *model.OutboxEvent
+		want         bool
+	}{
+		{"no filter", EventSubscription{}, event(nil, nil, nil), true},
+		{"yard", EventSubscription{yardID: yard}, event(&yard, &block, nil), true},
+		{"other yard", EventSubscription{yardID: yard}, event(&otherYard, &block, nil), false},
+		{"event without yard", EventSubscription{yardID: yard}, event(nil, nil, nil), false},
+		{"block", EventSubscription{yardID: yard, blockID: block}, event(&yard, &block, nil), true},
+		{"other block", EventSubscription{yardID: yard, blockID: block}, event(&yard, &otherBlock, nil), false},
+		{"move into the block", EventSubscription{yardID: yard, blockID: block}, event(&yard, &otherBlock, &block), true},
+		{"move out of the block", EventSubscription{yardID: yard, blockID: block}, event(&yard, &block, &otherBlock), true},
+		{"event without block", EventSubscription{yardID: yard, blockID: block}, event(&yard, nil, nil), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.subscription.matches(tt.event); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStreamEvent(t *testing.T) {
+	streamID, yardID, blockID := 42, 1, 5
+	createdAt := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	event := &model.OutboxEvent{
+		ID:          7,
+		StreamID:    &streamID,
+		EventType:   model.EventContainerPlaced,
+		AggregateID: "MSKU0000001",
+		Payload:     `{"container_number":"MSKU0000001"}`,
+		YardID:      &yardID,
+		BlockID:     &blockID,
+		CreatedAt:   createdAt,
+	}
+
+	got := toStreamEvent(event)
+	if got.ID != streamID {
+		t.Errorf("ID = %d, want the stream ID %d", got.ID, streamID)
+	}
+	if got.Type != event.EventType || got.AggregateID != event.AggregateID || !got.OccurredAt.Equal(createdAt) {
+		t.Errorf("event = %+v", got)
+	}
+	if got.YardID != &yardID || got.BlockID != &blockID || got.ToBlockID != nil {
+		t.Errorf("location = %v/%v/%v", got.YardID, got.BlockID, got.ToBlockID)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":42,"type":"ContainerPlaced","aggregate_id":"MSKU0000001","yard_id":1,"block_id":5,` +
+		`"occurred_at":"2026-10-19T10:00:00Z","data":{"container_number":"MSKU0000001"}}`
+	if string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+}
+
+// eventOutbox keeps the events saved instead of writing them.
+type eventOutbox struct {
+	repository.OutboxEventRepository
+	events []*model.OutboxEvent
+}
+
+func (o *eventOutbox) Save(db *gorm.DB, event *model.OutboxEvent) error {
+	o.events = append(o.events, event)
+	return nil
+}
+
+type eventMoves struct {
+	repository.ContainerMoveRepository
+}
+
+func (eventMoves) Save(db *gorm.DB, move *model.ContainerMove) error {
+	return nil
+}
+
+func TestSaveContainerMove(t *testing.T) {
+	block, otherBlock := 5, 6
+
+	tests := []struct {
+		name          string
+		move          model.ContainerMove
+		wantType      string
+		wantBlock     *int
+		wantToBlockID *int
+	}{
+		{"placement", model.ContainerMove{MoveType: model.MoveTypePlacement, ToBlockID: &block}, model.EventContainerPlaced, &block, nil},
+		{"pickup", model.ContainerMove{MoveType: model.MoveTypePickup, FromBlockID: &block}, model.EventContainerPickedUp, &block, nil},
+		{"move within a block", model.ContainerMove{MoveType: model.MoveTypeMove, FromBlockID: &block, ToBlockID: &block}, model.EventContainerMoved, &block, nil},
+		{"move between blocks", model.ContainerMove{MoveType: model.MoveTypeMove, FromBlockID: &block, ToBlockID: &otherBlock}, model.EventContainerMoved, &block, &otherBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &eventOutbox{}
+			tt.move.ContainerNumber = "MSKU0000001"
+			if err := saveContainerMove(nil, eventMoves{}, outbox, &tt.move); err != nil {
+				t.Fatal(err)
+			}
+			if len(outbox.events) != 1 {
+				t.Fatalf("published %d events, want 1", len(outbox.events))
+			}
+
+			event := outbox.events[0]
+			if event.EventType != tt.wantType || event.AggregateID != "MSKU0000001" {
+				t.Errorf("event %s for %s, want %s", event.EventType, event.AggregateID, tt.wantType)
+			}
+			if !sameBlock(event.BlockID, tt.wantBlock) || !sameBlock(event.ToBlockID, tt.wantToBlockID) {
+				t.Errorf("blocks %v -> %v, want %v -> %v", event.BlockID, event.ToBlockID, tt.wantBlock, tt.wantToBlockID)
+			}
+		})
+	}
+}
+
+func sameBlock(got, want *int) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+	return *got == *want
+}
+
+func TestReservations(t *testing.T) {
+	block, slot, row, tier := 5, 3, 2, 1
+	spec := func(r *reservation) bool {
+		return r.ContainerNumber == "MSKU0000001" && r.ContainerSize == "20ft" && r.BlockID == block &&
+			r.Slot == slot && r.Row == row && r.Tier == tier
+	}
+
+	tests := []struct {
+		name       string
+		got        *reservation
+		wantSource string
+	}{
+		{"work instruction", workReservation(&model.WorkInstruction{ContainerNumber: "MSKU0000001", ContainerSize: "20ft",
+			ToBlockID: &block, ToSlot: &slot, ToRow: &row, ToTier: &tier}), ReservationSourceWork},
+		{"pickup instruction", workReservation(&model.WorkInstruction{ContainerNumber: "MSKU0000001"}), ""},
+		{"gate-in", gateInReservation(&model.GateTransaction{ContainerNumber: "MSKU0000001", ContainerSize: "20ft",
+			BlockID: &block, SlotNumber: &slot, RowNumber: &row, TierNumber: &tier}), ReservationSourceGateIn},
+		{"gate-in without a cell", gateInReservation(&model.GateTransaction{ContainerNumber: "MSKU0000001"}), ""},
+		{"pre-advice", preAdviceReservation(&model.PreAdvice{ContainerNumber: "MSKU0000001", ContainerSize: "20ft",
+			BlockID: &block, SlotNumber: &slot, RowNumber: &row, TierNumber: &tier}), ReservationSourcePreAdvice},
+		{"pre-advice without a cell", preAdviceReservation(&model.PreAdvice{ContainerNumber: "MSKU0000001"}), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantSource == "" {
+				if tt.got != nil {
+					t.Errorf("reservation = %+v, want none", tt.got)
+				}
+				return
+			}
+			if tt.got == nil || tt.got.Source != tt.wantSource || !spec(tt.got) {
+				t.Errorf("reservation = %+v, want %s at %d-%d-%d-%d", tt.got, tt.wantSource, block, slot, row, tier)
+			}
+		})
+	}
+}
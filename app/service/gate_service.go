@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type GateService interface {
+	// GateIn records the truck and plans a cell for the container. The
+	// placement stays pending, holding the cell, until it is confirmed.
+	GateIn(ctx context.Context, request *web.GateInRequest) (*web.GateTransactionResponse, *response.CustomError)
+	ConfirmPlacement(ctx context.Context, transactionID int, request *web.ConfirmGatePlacementRequest) (*web.GateTransactionResponse, *response.CustomError)
+	CancelGateIn(ctx context.Context, transactionID int) (*web.GateTransactionResponse, *response.CustomError)
+
+	// GateOut checks holds and picks the container up. Gate-outs refused for
+	// a 4xx reason, such as a hold or an invalid release, are recorded as
+	// REJECTED transactions.
+	GateOut(ctx context.Context, request *web.GateOutRequest) (*web.GateTransactionResponse, *response.CustomError)
+	FindTransaction(ctx context.Context, transactionID int) (*web.GateTransactionResponse, *response.CustomError)
+}
+
+type GateServiceImpl struct {
+	ContainerService            ContainerService
+	YardRepository              repository.YardRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewGateService(
+	containerService ContainerService,
+	yardRepo repository.YardRepository,
+	containerRepo repository.ContainerPositionRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	outboxRepo repository.OutboxEventRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) GateService {
+	return &GateServiceImpl{
+		ContainerService:            containerService,
+		YardRepository:              yardRepo,
+		ContainerPositionRepository: containerRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		OutboxEventRepository:       outboxRepo,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *GateServiceImpl) GateIn(ctx context.Context, request *web.GateInRequest) (*web.GateTransactionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	now := time.Now()
+	transaction := newGateTransaction(model.GateDirectionIn, yard.ID, request.ContainerNumber, &request.TruckRequest, now)
+	transaction.Status = model.GateStatusPendingPlacement
+	transaction.ContainerSize = request.Size
+	transaction.ContainerHeight = request.Height
+	transaction.ContainerType = request.Type
+	transaction.Vessel = request.Vessel
+	transaction.Voyage = request.Voyage
+	transaction.ShippingLine = request.ShippingLine
+	transaction.BookingReference = request.BookingReference
+
+	// The suggested cell is reserved by the gate transaction, the yard stays
+	// locked until it is stored so no other suggestion hands out the cell.
+	var position *web.PositionResponse
+	var customErr *response.CustomError
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardRepository.LockYardBlocks(tx, yard.ID); err != nil {
+			return err
+		}
+
+		var pending model.GateTransaction
+		if err := s.GateTransactionRepository.FindPendingByContainerNumber(tx, &pending, request.ContainerNumber); err == nil {
+			customErr = response.BadRequestError("Container " + request.ContainerNumber + " already has a gate-in waiting for placement.")
+			return errors.New(customErr.Message)
+		}
+
+		if position, customErr = s.ContainerService.SuggestPositionTx(tx, &request.ContainerRequest); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		transaction.SetPosition(position.BlockID, position.Slot, position.Row, position.Tier)
+
+		if err := s.GateTransactionRepository.Save(tx, &transaction); err != nil {
+			return err
+		}
+		return publishReservationChanged(tx, s.OutboxEventRepository, gateInReservation(&transaction), ReservationChangeReserved)
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to record gate-in: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityGateTransaction, transaction.ID, nil, transaction)
+
+	transactionResponse := toGateTransactionResponse(&transaction, yard.Name, position.Block)
+	return &transactionResponse, nil
+}
+
+func (s *GateServiceImpl) ConfirmPlacement(ctx context.Context, transactionID int, request *web.ConfirmGatePlacementRequest) (*web.GateTransactionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	if request.BlockName != "" && (request.Slot == 0 || request.Row == 0 || request.Tier == 0) {
+		return nil, response.BadRequestError("Slot, row and tier are required when a block is given.")
+	}
+
+	// The placement and the completed gate-in are stored together, with the
+	// yard locked so the cell cannot be reserved for another container meanwhile
+	var transaction *model.GateTransaction
+	var yard *model.Yard
+	var position *web.PositionResponse
+	var before model.GateTransaction
+	var customErr *response.CustomError
+	after := &AfterCommit{}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if transaction, yard, customErr = s.findPendingGateIn(tx, transactionID); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		if err := s.YardRepository.LockYardBlocks(tx, yard.ID); err != nil {
+			return err
+		}
+
+		holders, err := loadCellHolders(tx, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+		if err != nil {
+			return err
+		}
+
+		placement := &web.PlacementRequest{
+			YardName:        yard.Name,
+			ContainerNumber: transaction.ContainerNumber,
+			Size:            transaction.ContainerSize,
+			Height:          transaction.ContainerHeight,
+			Type:            transaction.ContainerType,
+			Vessel:          transaction.Vessel,
+			Voyage:          transaction.Voyage,
+			ShippingLine:    transaction.ShippingLine,
+		}
+
+		if request.BlockName != "" {
+			placement.BlockName, placement.Slot, placement.Row, placement.Tier = request.BlockName, request.Slot, request.Row, request.Tier
+		} else {
+			var block model.Block
+			if err := s.YardRepository.FindBlockByID(tx, &block, *transaction.BlockID); err != nil {
+				customErr = response.NotFoundError("Planned block not found.")
+				return errors.New(customErr.Message)
+			}
+			placement.BlockName, placement.Slot, placement.Row, placement.Tier = block.Name, *transaction.SlotNumber, *transaction.RowNumber, *transaction.TierNumber
+		}
+
+		if position, customErr = s.ContainerService.PlaceContainerTx(ctx, tx, placement, after); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		// A cell given by the client may be held by a pending placement, a
+		// pre-advice or another gate-in, the planned cell is held by this one
+		if customErr = checkCellHolder(holders, transaction.ContainerNumber, position, transaction.ContainerSize); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		planned := gateInReservation(transaction)
+		before = *transaction
+
+		now := time.Now()
+		transaction.Status = model.GateStatusCompleted
+		transaction.SetPosition(position.BlockID, position.Slot, position.Row, position.Tier)
+		transaction.CompletedAt = &now
+		transaction.UpdatedAt = now
+
+		if err := s.GateTransactionRepository.UpdateStatus(tx, transaction); err != nil {
+			return err
+		}
+		return publishReservationChanged(tx, s.OutboxEventRepository, planned, ReservationChangeReleased)
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to confirm the gate-in placement: " + txErr.Error())
+	}
+	after.Run()
+	auditlog.Record(ctx, model.AuditEntityGateTransaction, transaction.ID, before, transaction)
+
+	transactionResponse := toGateTransactionResponse(transaction, yard.Name, position.Block)
+	return &transactionResponse, nil
+}
+
+func (s *GateServiceImpl) CancelGateIn(ctx context.Context, transactionID int) (*web.GateTransactionResponse, *response.CustomError) {
+	var transaction *model.GateTransaction
+	var yard *model.Yard
+	var before model.GateTransaction
+	var customErr *response.CustomError
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if transaction, yard, customErr = s.findPendingGateIn(tx, transactionID); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		before = *transaction
+		transaction.Status = model.GateStatusCancelled
+		transaction.UpdatedAt = time.Now()
+
+		if err := s.GateTransactionRepository.UpdateStatus(tx, transaction); err != nil {
+			return err
+		}
+		return publishReservationChanged(tx, s.OutboxEventRepository, gateInReservation(transaction), ReservationChangeReleased)
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to cancel gate-in: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityGateTransaction, transaction.ID, before, transaction)
+
+	transactionResponse := toGateTransactionResponse(transaction, yard.Name, s.blockName(transaction.BlockID))
+	return &transactionResponse, nil
+}
+
+func (s *GateServiceImpl) GateOut(ctx context.Context, request *web.GateOutRequest) (*web.GateTransactionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var container model.ContainerPosition
+	if err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &container, request.ContainerNumber); err != nil {
+		return nil, response.NotFoundError("Container not found at any position or already picked up.")
+	}
+
+	var block model.Block
+	if err := s.YardRepository.FindBlockByID(s.DB, &block, container.BlockID); err != nil || block.YardID != yard.ID {
+		return nil, response.BadRequestError("Container " + request.ContainerNumber + " is not in yard " + yard.Name + ".")
+	}
+
+	now := time.Now()
+	transaction := newGateTransaction(model.GateDirectionOut, yard.ID, request.ContainerNumber, &request.TruckRequest, now)
+	transaction.ContainerSize = container.ContainerSize
+	transaction.ContainerHeight = container.ContainerHeight
+	transaction.ContainerType = container.ContainerType
+	transaction.Vessel = container.Vessel
+	transaction.Voyage = container.Voyage
+	transaction.ShippingLine = container.ShippingLine
+	transaction.ReleaseReference = request.ReleaseNumber
+	transaction.SetPosition(container.BlockID, container.SlotNumber, container.RowNumber, container.TierNumber)
+
+	// The pickup and the completed gate-out are stored together. The pickup
+	// checks the holds with the blocks locked, a hold set meanwhile waits.
+	var pickupErr *response.CustomError
+	after := &AfterCommit{}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardRepository.LockYardBlocks(tx, yard.ID); err != nil {
+			return err
+		}
+
+		if pickupErr = s.ContainerService.PickupContainerTx(ctx, tx, &request.PickupRequest, after); pickupErr != nil {
+			return errors.New(pickupErr.Message)
+		}
+
+		transaction.Status = model.GateStatusCompleted
+		transaction.CompletedAt = &now
+		return s.GateTransactionRepository.Save(tx, &transaction)
+	})
+
+	if pickupErr != nil {
+		return nil, s.rejectGateOut(ctx, &transaction, pickupErr)
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to perform the gate-out: " + txErr.Error())
+	}
+	after.Run()
+	auditlog.Record(ctx, model.AuditEntityGateTransaction, transaction.ID, nil, transaction)
+
+	transactionResponse := toGateTransactionResponse(&transaction, yard.Name, block.Name)
+	return &transactionResponse, nil
+}
+
+func (s *GateServiceImpl) FindTransaction(ctx context.Context, transactionID int) (*web.GateTransactionResponse, *response.CustomError) {
+	var transaction model.GateTransaction
+	if err := s.GateTransactionRepository.FindByID(s.DB, &transaction, transactionID); err != nil {
+		return nil, response.NotFoundError("Gate transaction not found.")
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByID(s.DB, &yard, transaction.YardID); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	transactionResponse := toGateTransactionResponse(&transaction, yard.Name, s.blockName(transaction.BlockID))
+	return &transactionResponse, nil
+}
+
+// rejectGateOut stores a gate-out refused with a 4xx error, a hold, a wrong
+// PIN or an invalid release for example, as REJECTED. Server errors are not
+// a decision about the truck and are not recorded. customErr is returned
+// unchanged either way.
+func (s *GateServiceImpl) rejectGateOut(ctx context.Context, transaction *model.GateTransaction, customErr *response.CustomError) *response.CustomError {
+	if customErr.StatusCode < http.StatusBadRequest || customErr.StatusCode >= http.StatusInternalServerError {
+		return customErr
+	}
+
+	transaction.Status = model.GateStatusRejected
+	transaction.RejectReason = customErr.Message
+
+	if err := s.GateTransactionRepository.Save(s.DB, transaction); err != nil {
+		log.Printf("gate: failed to record the rejected gate-out of %s: %v", transaction.ContainerNumber, err)
+		return customErr
+	}
+	auditlog.Record(ctx, model.AuditEntityGateTransaction, transaction.ID, nil, transaction)
+	return customErr
+}
+
+// findPendingGateIn loads the gate-in and its yard using db, locking the
+// gate-in until the transaction db ends so it is completed only once.
+func (s *GateServiceImpl) findPendingGateIn(db *gorm.DB, transactionID int) (*model.GateTransaction, *model.Yard, *response.CustomError) {
+	var transaction model.GateTransaction
+	if err := s.GateTransactionRepository.FindByIDForUpdate(db, &transaction, transactionID); err != nil {
+		return nil, nil, response.NotFoundError("Gate transaction not found.")
+	}
+
+	if transaction.Direction != model.GateDirectionIn || transaction.Status != model.GateStatusPendingPlacement {
+		return nil, nil, response.BadRequestError("Gate transaction is not a gate-in waiting for placement.")
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByID(db, &yard, transaction.YardID); err != nil {
+		return nil, nil, response.NotFoundError("Yard not found.")
+	}
+
+	return &transaction, &yard, nil
+}
+
+func (s *GateServiceImpl) blockName(blockID *int) string {
+	if blockID == nil {
+		return ""
+	}
+	var block model.Block
+	s.YardRepository.FindBlockByID(s.DB, &block, *blockID)
+	return block.Name
+}
+
+func newGateTransaction(direction string, yardID int, containerNumber string, truck *web.TruckRequest, now time.Time) model.GateTransaction {
+	return model.GateTransaction{
+		Direction:       direction,
+		ContainerNumber: containerNumber,
+		YardID:          yardID,
+		TruckPlate:      truck.TruckPlate,
+		DriverName:      truck.DriverName,
+		DriverLicense:   truck.DriverLicense,
+		SealNumbers:     strings.Join(truck.SealNumbers, ","),
+		DamageRemarks:   truck.DamageRemarks,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func toGateTransactionResponse(transaction *model.GateTransaction, yardName, blockName string) web.GateTransactionResponse {
+	transactionResponse := web.GateTransactionResponse{
+		ID:              transaction.ID,
+		Direction:       transaction.Direction,
+		Status:          transaction.Status,
+		ContainerNumber: transaction.ContainerNumber,
+		Yard:            yardName,
+
+		ContainerSize:   transaction.ContainerSize,
+		ContainerHeight: transaction.ContainerHeight,
+		ContainerType:   transaction.ContainerType,
+
+		TruckPlate:    transaction.TruckPlate,
+		DriverName:    transaction.DriverName,
+		DriverLicense: transaction.DriverLicense,
+		SealNumbers:   []string{},
+		DamageRemarks: transaction.DamageRemarks,
+
+		BookingReference: transaction.BookingReference,
+		ReleaseReference: transaction.ReleaseReference,
+
+		RejectReason: transaction.RejectReason,
+		CreatedAt:    transaction.CreatedAt,
+		CompletedAt:  transaction.CompletedAt,
+	}
+
+	if transaction.SealNumbers != "" {
+		transactionResponse.SealNumbers = strings.Split(transaction.SealNumbers, ",")
+	}
+
+	if transaction.BlockID != nil {
+		transactionResponse.Position = &web.PositionResponse{
+			Block:   blockName,
+			Slot:    *transaction.SlotNumber,
+			Row:     *transaction.RowNumber,
+			Tier:    *transaction.TierNumber,
+			BlockID: *transaction.BlockID,
+		}
+	}
+
+	return transactionResponse
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/response"
+
+	"gorm.io/gorm"
+)
+
+// gateRecorder keeps the gate transactions saved.
+type gateRecorder struct {
+	repository.GateTransactionRepository
+	saved []model.GateTransaction
+}
+
+func (r *gateRecorder) Save(db *gorm.DB, transaction *model.GateTransaction) error {
+	transaction.ID = len(r.saved) + 1
+	r.saved = append(r.saved, *transaction)
+	return nil
+}
+
+func TestRejectGateOut(t *testing.T) {
+	onHold := response.ConflictError("Container GATE000001 is on hold.")
+	onHold.AdditionalInfo = map[string]any{"holds": []int{3}}
+
+	tests := []struct {
+		name         string
+		customErr    *response.CustomError
+		wantRejected bool
+	}{
+		{"hold", onHold, true},
+		{"wrong pin", response.UnauthorizedError("Release order not found or PIN is invalid."), true},
+		{"expired release", response.BadRequestError("Release order has expired."), true},
+		{"pin locked", response.TooManyRequestsError("Too many wrong PINs."), true},
+		{"stacked above", response.GeneralError("Conflict: Cannot perform pickup. Another container is stacked on top."), false},
+		{"database failure", response.RepositoryError("Failed to perform container pickup: connection reset"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gates := &gateRecorder{}
+			s := &GateServiceImpl{GateTransactionRepository: gates}
+			transaction := model.GateTransaction{Direction: model.GateDirectionOut, ContainerNumber: "GATE000001"}
+			want := *tt.customErr
+
+			got := s.rejectGateOut(context.Background(), &transaction, tt.customErr)
+			if got != tt.customErr || got.StatusCode != want.StatusCode || got.Message != want.Message || got.Code != want.Code {
+				t.Errorf("returned %+v, want the original %+v", got, want)
+			}
+
+			if !tt.wantRejected {
+				if len(gates.saved) != 0 {
+					t.Errorf("recorded %+v, want nothing", gates.saved)
+				}
+				return
+			}
+			if len(gates.saved) != 1 || gates.saved[0].Status != model.GateStatusRejected || gates.saved[0].RejectReason != tt.customErr.Message {
+				t.Errorf("recorded %+v, want one REJECTED transaction", gates.saved)
+			}
+		})
+	}
+}
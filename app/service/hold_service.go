@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type HoldService interface {
+	SetHold(ctx context.Context, request *web.HoldRequest) (*web.HoldResponse, *response.CustomError)
+	ReleaseHold(ctx context.Context, holdID int) (*web.HoldResponse, *response.CustomError)
+	FindHolds(ctx context.Context, query *web.HoldQuery) ([]web.HoldResponse, *response.CustomError)
+}
+
+type HoldServiceImpl struct {
+	YardRepository              repository.YardRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	ContainerHoldRepository     repository.ContainerHoldRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewHoldService(
+	yardRepo repository.YardRepository,
+	containerRepo repository.ContainerPositionRepository,
+	holdRepo repository.ContainerHoldRepository,
+	outboxRepo repository.OutboxEventRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) HoldService {
+	return &HoldServiceImpl{
+		YardRepository:              yardRepo,
+		ContainerPositionRepository: containerRepo,
+		ContainerHoldRepository:     holdRepo,
+		OutboxEventRepository:       outboxRepo,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *HoldServiceImpl) SetHold(ctx context.Context, request *web.HoldRequest) (*web.HoldResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	hold := model.ContainerHold{
+		ContainerNumber: request.ContainerNumber,
+		HoldType:        request.HoldType,
+		Reason:          request.Reason,
+		CreatedAt:       time.Now(),
+	}
+
+	holdResponse := web.HoldResponse{}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		// A pickup checks the holds with the blocks of the yard locked, so it
+		// either sees this hold or completes before it is set
+		var detail model.ContainerPositionDetail
+		if err := s.ContainerPositionRepository.FindDetailByContainerNumber(tx, &detail, hold.ContainerNumber); err == nil {
+			if err := s.YardRepository.LockYardBlocks(tx, detail.YardID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.ContainerHoldRepository.Save(tx, &hold); err != nil {
+			return err
+		}
+		holdResponse = toHoldResponse(&hold)
+		return publishEvent(tx, s.OutboxEventRepository, model.EventHoldSet, hold.ContainerNumber, nil, nil, holdResponse)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to set hold: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityHold, hold.ID, nil, hold)
+
+	return &holdResponse, nil
+}
+
+func (s *HoldServiceImpl) ReleaseHold(ctx context.Context, holdID int) (*web.HoldResponse, *response.CustomError) {
+	var hold model.ContainerHold
+	if err := s.ContainerHoldRepository.FindByID(s.DB, &hold, holdID); err != nil {
+		return nil, response.NotFoundError("Hold not found.")
+	}
+
+	if hold.ReleasedAt != nil {
+		return nil, response.BadRequestError("Hold is already released.")
+	}
+
+	before := hold
+	now := time.Now()
+	hold.ReleasedAt = &now
+
+	if err := s.ContainerHoldRepository.Release(s.DB, &hold); err != nil {
+		return nil, response.RepositoryError("Failed to release hold: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityHold, hold.ID, before, hold)
+
+	holdResponse := toHoldResponse(&hold)
+	return &holdResponse, nil
+}
+
+func (s *HoldServiceImpl) FindHolds(ctx context.Context, query *web.HoldQuery) ([]web.HoldResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var holds []model.ContainerHold
+	if err := s.ContainerHoldRepository.FindByContainerNumber(s.DB, &holds, query.ContainerNumber, query.ActiveOnly); err != nil {
+		return nil, response.RepositoryError("Failed to fetch holds: " + err.Error())
+	}
+
+	return toHoldResponses(holds), nil
+}
+
+// checkNoHolds refuses a pickup of the container while it has active holds,
+// which are listed in the error.
+func checkNoHolds(db *gorm.DB, holdRepo repository.ContainerHoldRepository, containerNumber string) *response.CustomError {
+	var holds []model.ContainerHold
+	if err := holdRepo.FindByContainerNumber(db, &holds, containerNumber, true); err != nil {
+		return response.RepositoryError("Failed to check holds: " + err.Error())
+	}
+
+	if len(holds) > 0 {
+		customErr := response.ConflictError("Container " + containerNumber + " is on hold.")
+		customErr.AdditionalInfo = map[string]any{"holds": toHoldResponses(holds)}
+		return customErr
+	}
+	return nil
+}
+
+func toHoldResponse(hold *model.ContainerHold) web.HoldResponse {
+	return web.HoldResponse{
+		ID:              hold.ID,
+		ContainerNumber: hold.ContainerNumber,
+		HoldType:        hold.HoldType,
+		Reason:          hold.Reason,
+		CreatedAt:       hold.CreatedAt,
+		ReleasedAt:      hold.ReleasedAt,
+	}
+}
+
+func toHoldResponses(holds []model.ContainerHold) []web.HoldResponse {
+	holdResponses := make([]web.HoldResponse, 0, len(holds))
+	for i := range holds {
+		holdResponses = append(holdResponses, toHoldResponse(&holds[i]))
+	}
+	return holdResponses
+}
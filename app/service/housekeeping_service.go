@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	HousekeepingReasonOverflow    = "OVERFLOW"
+	HousekeepingReasonPlanChanged = "PLAN_CHANGED"
+	HousekeepingReasonMixedVessel = "MIXED_VESSEL"
+	HousekeepingReasonBlocked     = "BLOCKED"
+	HousekeepingReasonNoTarget    = "NO_TARGET"
+)
+
+type HousekeepingService interface {
+	// PlanHousekeeping only computes the move list, nothing is written.
+	PlanHousekeeping(ctx context.Context, request *web.HousekeepingRequest) (*web.HousekeepingPlanResponse, *response.CustomError)
+
+	// ExecuteHousekeeping recomputes the move list, applies the relinks and
+	// queues the moves as work instructions. Containers only move when their
+	// instruction is confirmed.
+	ExecuteHousekeeping(ctx context.Context, request *web.HousekeepingRequest) (*web.HousekeepingPlanResponse, *response.CustomError)
+}
+
+type HousekeepingServiceImpl struct {
+	YardRepository              repository.YardRepository
+	YardPlanRepository          repository.YardPlanRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	WorkInstructionService      WorkInstructionService
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewHousekeepingService(
+	yardRepo repository.YardRepository,
+	planRepo repository.YardPlanRepository,
+	containerRepo repository.ContainerPositionRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	workInstructionService WorkInstructionService,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) HousekeepingService {
+	return &HousekeepingServiceImpl{
+		YardRepository:              yardRepo,
+		YardPlanRepository:          planRepo,
+		ContainerPositionRepository: containerRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		WorkInstructionService:      workInstructionService,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *HousekeepingServiceImpl) PlanHousekeeping(ctx context.Context, request *web.HousekeepingRequest) (*web.HousekeepingPlanResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	plan, customErr := s.buildPlan(s.DB, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return plan.response(true), nil
+}
+
+func (s *HousekeepingServiceImpl) ExecuteHousekeeping(ctx context.Context, request *web.HousekeepingRequest) (*web.HousekeepingPlanResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var planResponse *web.HousekeepingPlanResponse
+	var customErr *response.CustomError
+	var relinked []containerChange
+	after := &AfterCommit{}
+
+	// The relinks and the queued moves commit together, a move that cannot
+	// be queued leaves every container as it was.
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardRepository.LockYardBlocks(tx, yard.ID); err != nil {
+			return err
+		}
+
+		// Rebuild the plan inside the transaction so it matches what is
+		// actually stored, not what a previous dry run saw.
+		plan, planErr := s.buildPlan(tx, request)
+		if planErr != nil {
+			customErr = planErr
+			return errors.New(customErr.Message)
+		}
+
+		for _, relink := range plan.relinks {
+			before := relink.container
+			position := relink.container
+			position.YardPlanID = &relink.planID
+			position.UpdatedAt = time.Now()
+
+			if err := s.ContainerPositionRepository.UpdatePosition(tx, &position); err != nil {
+				if errors.Is(err, repository.ErrStaleRevision) {
+					customErr = changedContainerError(position.ContainerNumber)
+				}
+				return err
+			}
+			relinked = append(relinked, containerChange{&before, &position})
+		}
+
+		planResponse = plan.response(false)
+
+		planResponse.WorkInstructions, customErr = s.WorkInstructionService.QueueHousekeepingTx(ctx, tx, planResponse.Moves, after)
+		if customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to execute housekeeping plan: " + txErr.Error())
+	}
+	for _, change := range relinked {
+		auditlog.Record(ctx, model.AuditEntityContainer, change.after.ContainerNumber, change.before, change.after)
+	}
+	after.Run()
+
+	return planResponse, nil
+}
+
+// buildPlan compares every container in scope with the active yard plans of
+// its block and works out the smallest set of moves that puts misplaced boxes
+// back into a matching plan. Containers that already sit in a matching plan
+// are only relinked, and stacks are worked top-down so no move needs a
+// rehandle of a box that stays where it is. Cells reserved for gate-ins,
+// pre-advices and pending work instructions are never used as a target.
+func (s *HousekeepingServiceImpl) buildPlan(db *gorm.DB, request *web.HousekeepingRequest) (*housekeepingPlan, *response.CustomError) {
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(db, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var blocks []model.Block
+	if err := s.YardRepository.FindBlocksByYardID(db, &blocks, yard.ID); err != nil {
+		return nil, response.GeneralError("Failed to fetch blocks for the yard: " + err.Error())
+	}
+
+	if len(blocks) == 0 {
+		return nil, response.GeneralError("No blocks found in the yard.")
+	}
+
+	scopeBlockID := 0
+	if request.BlockName != "" {
+		var block model.Block
+		if err := s.YardRepository.FindBlockByNameAndYardID(db, &block, request.BlockName, yard.ID); err != nil {
+			return nil, response.NotFoundError("Block not found in this Yard.")
+		}
+		scopeBlockID = block.ID
+	}
+
+	held, err := loadCellHolders(db, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+	if err != nil {
+		return nil, response.GeneralError("Failed to fetch reserved cells: " + err.Error())
+	}
+
+	plan := &housekeepingPlan{
+		blocks:   make(map[int]*housekeepingBlock),
+		occupied: make(map[cellKey]*model.ContainerPosition),
+		held:     held,
+	}
+
+	for _, block := range blocks {
+		hb := &housekeepingBlock{block: block}
+		if err := s.YardPlanRepository.FindActivePlansByBlock(db, &hb.plans, block.ID, time.Now()); err != nil {
+			return nil, response.GeneralError("Failed to fetch yard plans: " + err.Error())
+		}
+		plan.blocks[block.ID] = hb
+		plan.blockOrder = append(plan.blockOrder, block.ID)
+	}
+
+	var positions []model.ContainerPosition
+	if err := s.ContainerPositionRepository.FindByYardID(db, &positions, yard.ID); err != nil {
+		return nil, response.GeneralError("Failed to fetch container positions: " + err.Error())
+	}
+
+	for i := range positions {
+		plan.occupy(&positions[i])
+	}
+
+	var candidates []housekeepingMove
+	for i := range positions {
+		position := &positions[i]
+		if scopeBlockID != 0 && position.BlockID != scopeBlockID {
+			continue
+		}
+
+		hb, ok := plan.blocks[position.BlockID]
+		if !ok {
+			continue
+		}
+
+		matching := findCoveringPlan(hb.plans, position)
+		mixedVessel := plan.isMixedVessel(position)
+
+		if matching != nil && !mixedVessel {
+			if position.YardPlanID == nil || *position.YardPlanID != matching.ID {
+				plan.relinks = append(plan.relinks, housekeepingRelink{container: *position, planID: matching.ID})
+			}
+			continue
+		}
+
+		reason := HousekeepingReasonPlanChanged
+		if mixedVessel {
+			reason = HousekeepingReasonMixedVessel
+		} else if position.YardPlanID == nil {
+			reason = HousekeepingReasonOverflow
+		}
+		candidates = append(candidates, housekeepingMove{container: *position, reason: reason})
+	}
+
+	// Work from the top of the stacks down, so boxes above a misplaced one
+	// are moved (or found to stay) before it is considered.
+	sort.SliceStable(candidates, func(a, b int) bool {
+		ca, cb := candidates[a].container, candidates[b].container
+		if ca.TierNumber != cb.TierNumber {
+			return ca.TierNumber > cb.TierNumber
+		}
+		if ca.BlockID != cb.BlockID {
+			return ca.BlockID < cb.BlockID
+		}
+		if ca.SlotNumber != cb.SlotNumber {
+			return ca.SlotNumber < cb.SlotNumber
+		}
+		return ca.RowNumber < cb.RowNumber
+	})
+
+	for _, candidate := range candidates {
+		if request.MaxMoves > 0 && len(plan.moves) >= request.MaxMoves {
+			break
+		}
+
+		if plan.isBuried(&candidate.container) {
+			plan.unresolved = append(plan.unresolved, housekeepingIssue{
+				container: candidate.container,
+				reason:    HousekeepingReasonBlocked,
+				message:   candidate.reason + ": container is below boxes that stay in place.",
+			})
+			continue
+		}
+
+		target := plan.findTarget(&candidate.container)
+		if target == nil {
+			plan.unresolved = append(plan.unresolved, housekeepingIssue{
+				container: candidate.container,
+				reason:    HousekeepingReasonNoTarget,
+				message:   candidate.reason + ": no free position in a matching yard plan.",
+			})
+			continue
+		}
+
+		plan.release(&candidate.container)
+		candidate.target = *target
+		plan.occupy(&candidate.target)
+		plan.moves = append(plan.moves, candidate)
+	}
+
+	return plan, nil
+}
+
+type housekeepingBlock struct {
+	block model.Block
+	plans []model.YardPlan
+}
+
+type housekeepingMove struct {
+	container model.ContainerPosition
+	target    model.ContainerPosition
+	reason    string
+}
+
+type housekeepingRelink struct {
+	container model.ContainerPosition
+	planID    int
+}
+
+type housekeepingIssue struct {
+	container model.ContainerPosition
+	reason    string
+	message   string
+}
+
+// housekeepingPlan holds the simulated yard while moves are planned, occupied
+// maps every cell to the container currently covering it and held every
+// reserved cell to the container it is held for.
+type housekeepingPlan struct {
+	blocks     map[int]*housekeepingBlock
+	blockOrder []int
+	occupied   map[cellKey]*model.ContainerPosition
+	held       map[cellKey]string
+
+	moves      []housekeepingMove
+	relinks    []housekeepingRelink
+	unresolved []housekeepingIssue
+}
+
+// coveredSlots returns the slots a container fills, 40ft boxes take two.
+func coveredSlots(position *model.ContainerPosition) []int {
+	if position.ContainerSize == "40ft" {
+		return []int{position.SlotNumber, position.SlotNumber + 1}
+	}
+	return []int{position.SlotNumber}
+}
+
+func (p *housekeepingPlan) occupy(position *model.ContainerPosition) {
+	for _, slot := range coveredSlots(position) {
+		p.occupied[cellKey{BlockID: position.BlockID, Slot: slot, Row: position.RowNumber, Tier: position.TierNumber}] = position
+	}
+}
+
+func (p *housekeepingPlan) release(position *model.ContainerPosition) {
+	for _, slot := range coveredSlots(position) {
+		delete(p.occupied, cellKey{BlockID: position.BlockID, Slot: slot, Row: position.RowNumber, Tier: position.TierNumber})
+	}
+}
+
+// isBuried reports whether any box still sits on top of the container.
+func (p *housekeepingPlan) isBuried(position *model.ContainerPosition) bool {
+	block := p.blocks[position.BlockID].block
+	for _, slot := range coveredSlots(position) {
+		for t := position.TierNumber + 1; t <= block.Tiers; t++ {
+			if p.occupied[cellKey{BlockID: position.BlockID, Slot: slot, Row: position.RowNumber, Tier: t}] != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isMixedVessel reports whether the container belongs to another vessel than
+// most of the boxes in its stack. Ties go to the vessel of the lowest box.
+func (p *housekeepingPlan) isMixedVessel(position *model.ContainerPosition) bool {
+	if position.Vessel == "" {
+		return false
+	}
+
+	block := p.blocks[position.BlockID].block
+	for _, slot := range coveredSlots(position) {
+		counts := make(map[string]int)
+		dominant := ""
+		for t := 1; t <= block.Tiers; t++ {
+			other := p.occupied[cellKey{BlockID: position.BlockID, Slot: slot, Row: position.RowNumber, Tier: t}]
+			if other == nil || other.Vessel == "" {
+				continue
+			}
+			counts[other.Vessel]++
+			if dominant == "" || counts[other.Vessel] > counts[dominant] {
+				dominant = other.Vessel
+			}
+		}
+		if dominant != "" && dominant != position.Vessel {
+			return true
+		}
+	}
+	return false
+}
+
+// findTarget looks for the first free, supported cell in a matching plan,
+// trying the container's own block before the rest of the yard.
+func (p *housekeepingPlan) findTarget(position *model.ContainerPosition) *model.ContainerPosition {
+	blockIDs := []int{position.BlockID}
+	for _, id := range p.blockOrder {
+		if id != position.BlockID {
+			blockIDs = append(blockIDs, id)
+		}
+	}
+
+	for _, blockID := range blockIDs {
+		hb := p.blocks[blockID]
+		for _, yardPlan := range hb.plans {
+			if yardPlan.ContainerSize != position.ContainerSize || yardPlan.ContainerHeight != position.ContainerHeight || yardPlan.ContainerType != position.ContainerType {
+				continue
+			}
+
+			for t := 1; t <= hb.block.Tiers; t++ {
+				for r := yardPlan.RowStart; r <= yardPlan.RowEnd; r++ {
+					for slotNum := yardPlan.SlotStart; slotNum <= yardPlan.SlotEnd; slotNum++ {
+						if position.ContainerSize == "40ft" {
+							if (slotNum-yardPlan.SlotStart+1)%2 != 1 || (slotNum+1) > yardPlan.SlotEnd {
+								continue
+							}
+						}
+
+						target := *position
+						target.BlockID = blockID
+						target.SlotNumber = slotNum
+						target.RowNumber = r
+						target.TierNumber = t
+						planID := yardPlan.ID
+						target.YardPlanID = &planID
+
+						if p.canStack(&target, hb.block) {
+							return &target
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// canStack checks that every cell of target is inside the block, free, not
+// held for another container, standing on another box (or the ground) and
+// not mixed with another vessel.
+func (p *housekeepingPlan) canStack(target *model.ContainerPosition, block model.Block) bool {
+	for _, slot := range coveredSlots(target) {
+		if slot > block.Slots {
+			return false
+		}
+		cell := cellKey{BlockID: target.BlockID, Slot: slot, Row: target.RowNumber, Tier: target.TierNumber}
+		if p.occupied[cell] != nil {
+			return false
+		}
+		if holder, ok := p.held[cell]; ok && holder != target.ContainerNumber {
+			return false
+		}
+
+		for t := 1; t < target.TierNumber; t++ {
+			below := p.occupied[cellKey{BlockID: target.BlockID, Slot: slot, Row: target.RowNumber, Tier: t}]
+			if below == nil {
+				return false
+			}
+			if target.Vessel != "" && below.Vessel != "" && below.Vessel != target.Vessel {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findCoveringPlan returns the plan whose area and specification match the
+// container where it currently stands.
+func findCoveringPlan(plans []model.YardPlan, position *model.ContainerPosition) *model.YardPlan {
+	for i := range plans {
+		yardPlan := &plans[i]
+		if yardPlan.ContainerSize != position.ContainerSize || yardPlan.ContainerHeight != position.ContainerHeight || yardPlan.ContainerType != position.ContainerType {
+			continue
+		}
+		if position.RowNumber < yardPlan.RowStart || position.RowNumber > yardPlan.RowEnd {
+			continue
+		}
+
+		lastSlot := position.SlotNumber
+		if position.ContainerSize == "40ft" {
+			lastSlot++
+		}
+		if position.SlotNumber < yardPlan.SlotStart || lastSlot > yardPlan.SlotEnd {
+			continue
+		}
+		return yardPlan
+	}
+	return nil
+}
+
+func (p *housekeepingPlan) positionResponse(position model.ContainerPosition) web.PositionResponse {
+	blockName := ""
+	if hb, ok := p.blocks[position.BlockID]; ok {
+		blockName = hb.block.Name
+	}
+	return web.PositionResponse{
+		Block:      blockName,
+		Slot:       position.SlotNumber,
+		Row:        position.RowNumber,
+		Tier:       position.TierNumber,
+		BlockID:    position.BlockID,
+		YardPlanID: position.YardPlanID,
+	}
+}
+
+func (p *housekeepingPlan) response(dryRun bool) *web.HousekeepingPlanResponse {
+	planResponse := &web.HousekeepingPlanResponse{
+		DryRun:     dryRun,
+		Moves:      []web.HousekeepingMove{},
+		Relinks:    []web.HousekeepingRelink{},
+		Unresolved: []web.HousekeepingIssue{},
+	}
+
+	for i, move := range p.moves {
+		planResponse.Moves = append(planResponse.Moves, web.HousekeepingMove{
+			Sequence:        i + 1,
+			ContainerNumber: move.container.ContainerNumber,
+			Reason:          move.reason,
+			From:            p.positionResponse(move.container),
+			To:              p.positionResponse(move.target),
+			YardPlanID:      *move.target.YardPlanID,
+		})
+	}
+
+	for _, relink := range p.relinks {
+		planResponse.Relinks = append(planResponse.Relinks, web.HousekeepingRelink{
+			ContainerNumber: relink.container.ContainerNumber,
+			Position:        p.positionResponse(relink.container),
+			FromYardPlanID:  relink.container.YardPlanID,
+			ToYardPlanID:    relink.planID,
+		})
+	}
+
+	for _, issue := range p.unresolved {
+		planResponse.Unresolved = append(planResponse.Unresolved, web.HousekeepingIssue{
+			ContainerNumber: issue.container.ContainerNumber,
+			Reason:          issue.reason,
+			Position:        p.positionResponse(issue.container),
+			Message:         issue.message,
+		})
+	}
+
+	return planResponse
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+
+	"gorm.io/gorm"
+)
+
+// housekeepingYard is yard YRD-UTAMA with block A01 of 6 slots, 1 row and 3
+// tiers.
+type housekeepingYard struct {
+	repository.YardRepository
+}
+
+var housekeepingBlock01 = model.Block{ID: 1, YardID: 1, Name: "A01", Slots: 6, Rows: 1, Tiers: 3}
+
+func (housekeepingYard) FindYardByName(db *gorm.DB, yard *model.Yard, name string) error {
+	*yard = model.Yard{ID: 1, Name: name}
+	return nil
+}
+
+func (housekeepingYard) FindBlocksByYardID(db *gorm.DB, blocks *[]model.Block, yardID int) error {
+	*blocks = []model.Block{housekeepingBlock01}
+	return nil
+}
+
+// housekeepingPlans gives every block one plan, for 20ft boxes in slots 1-2.
+type housekeepingPlans struct {
+	repository.YardPlanRepository
+}
+
+func (housekeepingPlans) FindActivePlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int, at time.Time) error {
+	*plans = []model.YardPlan{{ID: 10, BlockID: blockID, SlotStart: 1, SlotEnd: 2, RowStart: 1, RowEnd: 1,
+		ContainerSize: "20ft", ContainerHeight: "8.6ft", ContainerType: "DRY"}}
+	return nil
+}
+
+type housekeepingPositions struct {
+	repository.ContainerPositionRepository
+	positions []model.ContainerPosition
+}
+
+func (r housekeepingPositions) FindByYardID(db *gorm.DB, positions *[]model.ContainerPosition, yardID int) error {
+	*positions = slices.Clone(r.positions)
+	return nil
+}
+
+// housekeepingReservations, housekeepingPreAdvices and housekeepingWork serve
+// the cells held by gate-ins, pre-advices and work instructions.
+type housekeepingReservations struct {
+	repository.GateTransactionRepository
+	gateIns []model.GateTransaction
+}
+
+func (r housekeepingReservations) FindPendingPlacements(db *gorm.DB, transactions *[]model.GateTransaction) error {
+	*transactions = r.gateIns
+	return nil
+}
+
+type housekeepingPreAdvices struct {
+	repository.PreAdviceRepository
+}
+
+func (housekeepingPreAdvices) FindProvisionalCells(db *gorm.DB, preAdvices *[]model.PreAdvice) error {
+	return nil
+}
+
+type housekeepingWork struct {
+	repository.WorkInstructionRepository
+	instructions []model.WorkInstruction
+}
+
+func (r housekeepingWork) FindPendingDestinations(db *gorm.DB, instructions *[]model.WorkInstruction) error {
+	*instructions = r.instructions
+	return nil
+}
+
+func housekeepingBox(containerNumber string, slot, tier int) model.ContainerPosition {
+	return model.ContainerPosition{ContainerNumber: containerNumber, BlockID: 1, SlotNumber: slot, RowNumber: 1, TierNumber: tier,
+		ContainerSize: "20ft", ContainerHeight: "8.6ft", ContainerType: "DRY"}
+}
+
+func TestBuildPlan(t *testing.T) {
+	planID := 10
+	inPlan := housekeepingBox("MSKU0000010", 1, 1)
+	inPlan.YardPlanID = &planID
+
+	gateIn := func(slot int) model.GateTransaction {
+		transaction := model.GateTransaction{ContainerNumber: "GATE0000001", ContainerSize: "20ft"}
+		transaction.SetPosition(1, slot, 1, 1)
+		return transaction
+	}
+	pendingMove := func(slot int) model.WorkInstruction {
+		instruction := model.WorkInstruction{ContainerNumber: "MSKU0000099", ContainerSize: "20ft"}
+		instruction.SetTo(1, slot, 1, 1)
+		return instruction
+	}
+
+	// Moves read container@slot-row-tier, unresolved container:reason
+	tests := []struct {
+		name           string
+		positions      []model.ContainerPosition
+		gateIns        []model.GateTransaction
+		instructions   []model.WorkInstruction
+		wantMoves      []string
+		wantRelinks    int
+		wantUnresolved []string
+	}{
+		{
+			name:      "overflow box moves into the plan",
+			positions: []model.ContainerPosition{housekeepingBox("MSKU0000001", 5, 1)},
+			wantMoves: []string{"MSKU0000001@1-1-1"},
+		},
+		{
+			name:      "cell of a gate-in is skipped",
+			positions: []model.ContainerPosition{housekeepingBox("MSKU0000001", 5, 1)},
+			gateIns:   []model.GateTransaction{gateIn(1)},
+			wantMoves: []string{"MSKU0000001@2-1-1"},
+		},
+		{
+			name:         "destination of a pending move is skipped",
+			positions:    []model.ContainerPosition{housekeepingBox("MSKU0000001", 5, 1)},
+			instructions: []model.WorkInstruction{pendingMove(1)},
+			wantMoves:    []string{"MSKU0000001@2-1-1"},
+		},
+		{
+			name:           "reserved ground gives nothing to stack on",
+			positions:      []model.ContainerPosition{housekeepingBox("MSKU0000001", 5, 1)},
+			gateIns:        []model.GateTransaction{gateIn(1)},
+			instructions:   []model.WorkInstruction{pendingMove(2)},
+			wantUnresolved: []string{"MSKU0000001:" + HousekeepingReasonNoTarget},
+		},
+		{
+			name:      "stack is worked from the top",
+			positions: []model.ContainerPosition{housekeepingBox("MSKU0000001", 5, 1), housekeepingBox("MSKU0000002", 5, 2)},
+			wantMoves: []string{"MSKU0000002@1-1-1", "MSKU0000001@2-1-1"},
+		},
+		{
+			name:        "full ground is stacked on",
+			positions:   []model.ContainerPosition{inPlan, housekeepingBox("MSKU0000001", 2, 1), housekeepingBox("MSKU0000002", 5, 1)},
+			wantMoves:   []string{"MSKU0000002@1-1-2"},
+			wantRelinks: 1,
+		},
+		{
+			name:        "box in a matching plan is only relinked",
+			positions:   []model.ContainerPosition{housekeepingBox("MSKU0000001", 2, 1)},
+			wantRelinks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &HousekeepingServiceImpl{
+				YardRepository:              housekeepingYard{},
+				YardPlanRepository:          housekeepingPlans{},
+				ContainerPositionRepository: housekeepingPositions{positions: tt.positions},
+				GateTransactionRepository:   housekeepingReservations{gateIns: tt.gateIns},
+				PreAdviceRepository:         housekeepingPreAdvices{},
+				WorkInstructionRepository:   housekeepingWork{instructions: tt.instructions},
+			}
+
+			plan, customErr := s.buildPlan(nil, &web.HousekeepingRequest{YardName: "YRD-UTAMA"})
+			if customErr != nil {
+				t.Fatal(customErr.Message)
+			}
+
+			var moves, unresolved []string
+			for _, move := range plan.moves {
+				moves = append(moves, fmt.Sprintf("%s@%d-%d-%d", move.container.ContainerNumber, move.target.SlotNumber, move.target.RowNumber, move.target.TierNumber))
+			}
+			for _, issue := range plan.unresolved {
+				unresolved = append(unresolved, issue.container.ContainerNumber+":"+issue.reason)
+			}
+
+			if !slices.Equal(moves, tt.wantMoves) {
+				t.Errorf("moves = %v, want %v", moves, tt.wantMoves)
+			}
+			if len(plan.relinks) != tt.wantRelinks {
+				t.Errorf("relinked %d containers, want %d", len(plan.relinks), tt.wantRelinks)
+			}
+			if !slices.Equal(unresolved, tt.wantUnresolved) {
+				t.Errorf("unresolved = %v, want %v", unresolved, tt.wantUnresolved)
+			}
+		})
+	}
+}
+
+func TestCanStack(t *testing.T) {
+	block := model.Block{ID: 1, Slots: 4, Rows: 1, Tiers: 3}
+	box := func(containerNumber, vessel, size string, slot, tier int) *model.ContainerPosition {
+		position := housekeepingBox(containerNumber, slot, tier)
+		position.Vessel, position.ContainerSize = vessel, size
+		return &position
+	}
+
+	tests := []struct {
+		name     string
+		occupied []*model.ContainerPosition
+		held     map[cellKey]string
+		target   *model.ContainerPosition
+		want     bool
+	}{
+		{"free ground", nil, nil, box("MSKU0000001", "", "20ft", 1, 1), true},
+		{"occupied cell", []*model.ContainerPosition{box("MSKU0000002", "", "20ft", 1, 1)}, nil, box("MSKU0000001", "", "20ft", 1, 1), false},
+		{"nothing below", nil, nil, box("MSKU0000001", "", "20ft", 1, 2), false},
+		{"on a box of the same vessel", []*model.ContainerPosition{box("MSKU0000002", "MV SINAR", "20ft", 1, 1)}, nil, box("MSKU0000001", "MV SINAR", "20ft", 1, 2), true},
+		{"on a box of another vessel", []*model.ContainerPosition{box("MSKU0000002", "MV LAUT", "20ft", 1, 1)}, nil, box("MSKU0000001", "MV SINAR", "20ft", 1, 2), false},
+		{"held for another container", nil, map[cellKey]string{{BlockID: 1, Slot: 1, Row: 1, Tier: 1}: "GATE0000001"}, box("MSKU0000001", "", "20ft", 1, 1), false},
+		{"held for the container itself", nil, map[cellKey]string{{BlockID: 1, Slot: 1, Row: 1, Tier: 1}: "MSKU0000001"}, box("MSKU0000001", "", "20ft", 1, 1), true},
+		{"40ft over a held second slot", nil, map[cellKey]string{{BlockID: 1, Slot: 2, Row: 1, Tier: 1}: "GATE0000001"}, box("MSKU0000001", "", "40ft", 1, 1), false},
+		{"40ft past the last slot", nil, nil, box("MSKU0000001", "", "40ft", 4, 1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &housekeepingPlan{occupied: make(map[cellKey]*model.ContainerPosition), held: tt.held}
+			for _, position := range tt.occupied {
+				plan.occupy(position)
+			}
+
+			if got := plan.canStack(tt.target, block); got != tt.want {
+				t.Errorf("canStack = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// IdempotencyKeyMaxLength is the longest Idempotency-Key accepted.
+	IdempotencyKeyMaxLength = 255
+	// A retry takes over a key whose request has not finished after this
+	// long, the first attempt is assumed to have died.
+	idempotencyLockTimeout = 5 * time.Minute
+)
+
+type IdempotencyService interface {
+	// Begin claims the key of the request. It returns the ID of the claimed
+	// key, or the stored response when a previous request with the key
+	// already finished. A key used for another request, or for one still in
+	// progress, is rejected.
+	Begin(ctx context.Context, request *web.IdempotencyRequest) (int, *web.StoredResponse, *response.CustomError)
+	// Complete stores the response of the request that claimed the key.
+	// Server errors are not stored, the key is freed and a retry runs the
+	// request again.
+	Complete(ctx context.Context, keyID int, stored *web.StoredResponse) *response.CustomError
+	DeleteExpired(ctx context.Context) (int64, *response.CustomError)
+}
+
+type IdempotencyServiceImpl struct {
+	IdempotencyKeyRepository repository.IdempotencyKeyRepository
+	TTL                      time.Duration
+	DB                       *gorm.DB
+}
+
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyKeyRepository, ttl time.Duration, DB *gorm.DB) IdempotencyService {
+	return &IdempotencyServiceImpl{
+		IdempotencyKeyRepository: idempotencyRepo,
+		TTL:                      ttl,
+		DB:                       DB,
+	}
+}
+
+func (s *IdempotencyServiceImpl) Begin(ctx context.Context, request *web.IdempotencyRequest) (int, *web.StoredResponse, *response.CustomError) {
+	if len(request.Key) > IdempotencyKeyMaxLength {
+		return 0, nil, response.BadRequestError("Idempotency-Key must not be longer than 255 characters.")
+	}
+	// Keys are scoped to the actor. Requests without a token share one
+	// scope, their keys must be UUIDs so clients do not pick the same ones.
+	if request.Actor == "" && !isUUID(request.Key) {
+		return 0, nil, response.BadRequestError("Idempotency-Key must be a UUID when the request has no bearer token.")
+	}
+
+	now := time.Now()
+	key := model.IdempotencyKey{
+		Actor:       request.Actor,
+		Key:         request.Key,
+		Method:      request.Method,
+		Path:        request.Path,
+		RequestHash: requestHash(request),
+		Status:      model.IdempotencyStatusInProgress,
+		LockedAt:    now,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.TTL),
+	}
+
+	claimed, err := s.IdempotencyKeyRepository.Claim(s.DB, &key, now.Add(-idempotencyLockTimeout))
+	if err != nil {
+		return 0, nil, response.RepositoryError("Failed to claim idempotency key: " + err.Error())
+	}
+	if claimed {
+		return key.ID, nil, nil
+	}
+
+	var existing model.IdempotencyKey
+	if err := s.IdempotencyKeyRepository.FindByKey(s.DB, &existing, request.Actor, request.Key); err != nil {
+		// Freed by the request that held it since the claim, the client may
+		// retry right away.
+		return 0, nil, response.ConflictError("A request with this Idempotency-Key has just finished, retry the request.")
+	}
+
+	if existing.RequestHash != key.RequestHash {
+		customErr := response.BadRequestError("Idempotency-Key was already used for a different request.")
+		customErr.AdditionalInfo = map[string]any{
+			"method": existing.Method,
+			"path":   existing.Path,
+		}
+		return 0, nil, customErr
+	}
+	if existing.Status == model.IdempotencyStatusInProgress {
+		return 0, nil, response.ConflictError("A request with this Idempotency-Key is still in progress.")
+	}
+
+	return 0, &web.StoredResponse{
+		StatusCode:  existing.StatusCode,
+		ContentType: existing.ContentType,
+		Body:        []byte(existing.ResponseBody),
+	}, nil
+}
+
+func (s *IdempotencyServiceImpl) Complete(ctx context.Context, keyID int, stored *web.StoredResponse) *response.CustomError {
+	if stored.StatusCode >= http.StatusInternalServerError {
+		if err := s.IdempotencyKeyRepository.Delete(s.DB, keyID); err != nil {
+			return response.RepositoryError("Failed to free idempotency key: " + err.Error())
+		}
+		return nil
+	}
+
+	key := model.IdempotencyKey{
+		ID:           keyID,
+		Status:       model.IdempotencyStatusCompleted,
+		StatusCode:   stored.StatusCode,
+		ContentType:  stored.ContentType,
+		ResponseBody: string(stored.Body),
+	}
+
+	if err := s.IdempotencyKeyRepository.Complete(s.DB, &key); err != nil {
+		return response.RepositoryError("Failed to store idempotent response: " + err.Error())
+	}
+	return nil
+}
+
+func (s *IdempotencyServiceImpl) DeleteExpired(ctx context.Context) (int64, *response.CustomError) {
+	deleted, err := s.IdempotencyKeyRepository.DeleteExpired(s.DB, time.Now())
+	if err != nil {
+		return 0, response.RepositoryError("Failed to delete expired idempotency keys: " + err.Error())
+	}
+	return deleted, nil
+}
+
+// requestHash fingerprints what a retry must repeat exactly.
+func requestHash(request *web.IdempotencyRequest) string {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.Path + "\n"))
+	hash.Write([]byte(request.BodyHash))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// isUUID reports whether key is a UUID in the canonical 8-4-4-4-12 hex form.
+func isUUID(key string) bool {
+	if len(key) != 36 {
+		return false
+	}
+	for i, c := range key {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
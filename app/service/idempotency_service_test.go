@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"yard-planning/app/web"
+)
+
+func TestRequestHash(t *testing.T) {
+	placement := "b6213c34cc4977a951caf05c0bcb5737b200d6bed984b0505017074f1e2326fa"
+	empty := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	tests := []struct {
+		name    string
+		request web.IdempotencyRequest
+		want    string
+	}{
+		{"placement", web.IdempotencyRequest{Method: http.MethodPost, Path: "/api/placement", BodyHash: placement},
+			"777272deb94291442e4943cc04453d0cada8453ca143de1126a1a55e8839e7f5"},
+		{"empty body", web.IdempotencyRequest{Method: http.MethodPost, Path: "/api/gate/out", BodyHash: empty},
+			"262d22e8639defb68d6b509d717cf559f83c243e0f788e235899081a3d4639bd"},
+		{"actor and key not hashed", web.IdempotencyRequest{Actor: "7", Key: "k", Method: http.MethodPost, Path: "/api/placement", BodyHash: placement},
+			"777272deb94291442e4943cc04453d0cada8453ca143de1126a1a55e8839e7f5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestHash(&tt.request); got != tt.want {
+				t.Errorf("requestHash = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	base := web.IdempotencyRequest{Method: http.MethodPost, Path: "/api/placement", BodyHash: placement}
+	changes := map[string]web.IdempotencyRequest{
+		"method": {Method: http.MethodPut, Path: base.Path, BodyHash: base.BodyHash},
+		"path":   {Method: base.Method, Path: "/api/pickup", BodyHash: base.BodyHash},
+		"query":  {Method: base.Method, Path: "/api/placement?dry_run=true", BodyHash: base.BodyHash},
+		"body":   {Method: base.Method, Path: base.Path, BodyHash: empty},
+	}
+	for name, changed := range changes {
+		if requestHash(&changed) == requestHash(&base) {
+			t.Errorf("hash does not depend on the %s", name)
+		}
+	}
+}
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"7d4f3c1e-9a2b-4c5d-8e6f-0a1b2c3d4e5f", true},
+		{"7D4F3C1E-9A2B-4C5D-8E6F-0A1B2C3D4E5F", true},
+		{"00000000-0000-0000-0000-000000000000", true},
+		{"7d4f3c1e-placement-ALFI000001", false},
+		{"7d4f3c1e9a2b4c5d8e6f0a1b2c3d4e5f", false},
+		{"7d4f3c1e-9a2b-4c5d-8e6f-0a1b2c3d4e5", false},
+		{"7d4f3c1e-9a2b-4c5d-8e6f_0a1b2c3d4e5f", false},
+		{"7d4f3c1e-9a2b-4c5d-8e6f-0a1b2c3d4e5g", false},
+		{"{7d4f3c1e-9a2b-4c5d-8e6f-0a1b2c3d4e5f}", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isUUID(tt.key); got != tt.want {
+			t.Errorf("isUUID(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestBeginRejectsKey(t *testing.T) {
+	s := &IdempotencyServiceImpl{}
+
+	tests := []struct {
+		name    string
+		request web.IdempotencyRequest
+		wantErr string
+	}{
+		{"too long", web.IdempotencyRequest{Actor: "7", Key: strings.Repeat("k", IdempotencyKeyMaxLength+1)}, "longer than 255"},
+		{"not a UUID without token", web.IdempotencyRequest{Key: "placement-ALFI000001"}, "must be a UUID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, customErr := s.Begin(context.Background(), &tt.request)
+			if customErr == nil || customErr.StatusCode != http.StatusBadRequest || !strings.Contains(customErr.Message, tt.wantErr) {
+				t.Errorf("Begin error = %v, want Bad Request mentioning %q", customErr, tt.wantErr)
+			}
+		})
+	}
+}
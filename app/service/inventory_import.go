@@ -0,0 +1,329 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+
+	"gorm.io/gorm"
+)
+
+// inventoryColumns are the columns of an inventory file. Import needs the
+// first nine, dwell_days and yard_plan_id are only exported and ignored on
+// import, so an export can be imported again.
+var inventoryColumns = []string{
+	"container_number", "yard", "block", "slot", "row", "tier",
+	"container_size", "container_height", "container_type",
+	"container_status", "arrival_date", "dwell_days", "yard_plan_id",
+	"vessel", "voyage", "shipping_line",
+}
+
+const inventoryRequiredColumns = 9
+
+// inventoryImportMaxErrors bounds the error list of an import report.
+const inventoryImportMaxErrors = 1000
+
+// Layouts accepted for arrival_date, read as local time.
+var inventoryDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// excelEpoch is day zero of Excel date serial numbers.
+var excelEpoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.Local)
+
+type importBlock struct {
+	block    model.Block
+	plans    []model.YardPlan
+	occupied map[cellKey]bool
+}
+
+// inventoryImport checks rows against the yard as it was when the import
+// started plus the rows accepted before them. Yards, blocks and their
+// occupancy are loaded on first use, the reserved cells up front.
+type inventoryImport struct {
+	db            *gorm.DB
+	yardRepo      repository.YardRepository
+	planRepo      repository.YardPlanRepository
+	containerRepo repository.ContainerPositionRepository
+	now           time.Time
+
+	columns map[string]int
+	yards   map[string]*model.Yard
+	blocks  map[string]*importBlock
+	numbers map[string]bool
+	// Cells held by gate-ins, pre-advices and work instructions
+	holders map[cellKey]string
+}
+
+func newInventoryImport(db *gorm.DB, yardRepo repository.YardRepository, planRepo repository.YardPlanRepository, containerRepo repository.ContainerPositionRepository, holders map[cellKey]string, now time.Time) (*inventoryImport, error) {
+	var numbers []string
+	if err := containerRepo.FindAllContainerNumbers(db, &numbers); err != nil {
+		return nil, err
+	}
+
+	inventory := &inventoryImport{
+		db:            db,
+		yardRepo:      yardRepo,
+		planRepo:      planRepo,
+		containerRepo: containerRepo,
+		now:           now,
+		yards:         make(map[string]*model.Yard),
+		blocks:        make(map[string]*importBlock),
+		numbers:       make(map[string]bool, len(numbers)),
+		holders:       holders,
+	}
+	for _, number := range numbers {
+		inventory.numbers[number] = true
+	}
+	return inventory, nil
+}
+
+// readHeader maps the column names of the header row, in any order and
+// case, and checks that the required ones are there.
+func (i *inventoryImport) readHeader(header []string) error {
+	i.columns = make(map[string]int, len(header))
+	for index, name := range header {
+		name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+		if _, ok := i.columns[name]; !ok {
+			i.columns[name] = index
+		}
+	}
+
+	var missing []string
+	for _, name := range inventoryColumns[:inventoryRequiredColumns] {
+		if _, ok := i.columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("missing columns " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (i *inventoryImport) value(row []string, column string) string {
+	index, ok := i.columns[column]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
+
+// parseRow reads a data row. Status defaults to STORAGE and the arrival to
+// the start of the import.
+func (i *inventoryImport) parseRow(row []string) (*web.InventoryImportRow, error) {
+	parsed := &web.InventoryImportRow{
+		YardName:        i.value(row, "yard"),
+		BlockName:       i.value(row, "block"),
+		ContainerNumber: strings.ToUpper(i.value(row, "container_number")),
+		Size:            i.value(row, "container_size"),
+		Height:          i.value(row, "container_height"),
+		Type:            i.value(row, "container_type"),
+		Status:          strings.ToUpper(i.value(row, "container_status")),
+		ArrivalDate:     i.now,
+		Vessel:          i.value(row, "vessel"),
+		Voyage:          i.value(row, "voyage"),
+		ShippingLine:    i.value(row, "shipping_line"),
+	}
+	if parsed.Status == "" {
+		parsed.Status = model.ContainerStatusStorage
+	}
+
+	for _, field := range []struct {
+		column string
+		target *int
+	}{
+		{"slot", &parsed.Slot},
+		{"row", &parsed.Row},
+		{"tier", &parsed.Tier},
+	} {
+		value := i.value(row, field.column)
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return parsed, errors.New(field.column + " must be a whole number, got '" + value + "'")
+		}
+		*field.target = number
+	}
+
+	if value := i.value(row, "arrival_date"); value != "" {
+		arrival, err := parseInventoryDate(value)
+		if err != nil {
+			return parsed, err
+		}
+		if arrival.After(i.now) {
+			return parsed, errors.New("arrival_date " + value + " is in the future")
+		}
+		parsed.ArrivalDate = arrival
+	}
+
+	return parsed, nil
+}
+
+// check validates a row like a placement: the block exists in the yard, the
+// cell is inside it, free and not held for another container, 40ft
+// containers start at an odd slot, and the
+// container is not in the yard or earlier in the file. An accepted row takes
+// its cells. It returns the position to store.
+func (i *inventoryImport) check(row *web.InventoryImportRow) (*model.ContainerPosition, error) {
+	if i.numbers[row.ContainerNumber] {
+		return nil, errors.New("container " + row.ContainerNumber + " is already in the yard or listed before")
+	}
+
+	block, err := i.block(row.YardName, row.BlockName)
+	if err != nil {
+		return nil, err
+	}
+
+	if row.Slot > block.block.Slots || row.Row > block.block.Rows || row.Tier > block.block.Tiers {
+		return nil, errors.New("position is outside the block dimensions")
+	}
+
+	if row.Size == "40ft" {
+		if row.Slot%2 == 0 {
+			return nil, errors.New("40ft containers must start at an odd slot number")
+		}
+		if row.Slot+1 > block.block.Slots {
+			return nil, errors.New("not enough space for 40ft container (requires slot " + strconv.Itoa(row.Slot+1) + ")")
+		}
+	}
+
+	cells := coveredCells(block.block.ID, row.Slot, row.Row, row.Tier, row.Size)
+	for _, cell := range cells {
+		if block.occupied[cell] {
+			return nil, errors.New("position already occupied by another container")
+		}
+		if holder, ok := i.holders[cell]; ok && holder != row.ContainerNumber {
+			return nil, errors.New("position is reserved for container " + holder)
+		}
+	}
+
+	position := &model.ContainerPosition{
+		ContainerNumber: row.ContainerNumber,
+		BlockID:         block.block.ID,
+		SlotNumber:      row.Slot,
+		RowNumber:       row.Row,
+		TierNumber:      row.Tier,
+		ContainerSize:   row.Size,
+		ContainerHeight: row.Height,
+		ContainerType:   row.Type,
+		ContainerStatus: row.Status,
+		ArrivalDate:     row.ArrivalDate,
+		Vessel:          row.Vessel,
+		Voyage:          row.Voyage,
+		ShippingLine:    row.ShippingLine,
+		CreatedAt:       i.now,
+		UpdatedAt:       i.now,
+	}
+	if plan := findCoveringPlan(block.plans, position); plan != nil {
+		position.YardPlanID = &plan.ID
+	}
+
+	i.numbers[row.ContainerNumber] = true
+	for _, cell := range cells {
+		block.occupied[cell] = true
+	}
+	return position, nil
+}
+
+func (i *inventoryImport) block(yardName, blockName string) (*importBlock, error) {
+	yard, ok := i.yards[yardName]
+	if !ok {
+		var found model.Yard
+		if err := i.yardRepo.FindYardByName(i.db, &found, yardName); err == nil {
+			yard = &found
+		}
+		i.yards[yardName] = yard
+	}
+	if yard == nil {
+		return nil, errors.New("yard " + yardName + " not found")
+	}
+
+	key := strconv.Itoa(yard.ID) + "/" + blockName
+	block, ok := i.blocks[key]
+	if !ok {
+		var found model.Block
+		if err := i.yardRepo.FindBlockByNameAndYardID(i.db, &found, blockName, yard.ID); err == nil {
+			var err error
+			if block, err = i.loadBlock(&found); err != nil {
+				return nil, err
+			}
+		}
+		i.blocks[key] = block
+	}
+	if block == nil {
+		return nil, errors.New("block " + blockName + " not found in yard " + yardName)
+	}
+	return block, nil
+}
+
+func (i *inventoryImport) loadBlock(block *model.Block) (*importBlock, error) {
+	loaded := &importBlock{block: *block, occupied: make(map[cellKey]bool)}
+
+	if err := i.planRepo.FindActivePlansByBlock(i.db, &loaded.plans, block.ID, i.now); err != nil {
+		return nil, err
+	}
+
+	var positions []model.ContainerPosition
+	if err := i.containerRepo.FindByBlockID(i.db, &positions, block.ID); err != nil {
+		return nil, err
+	}
+	for _, position := range positions {
+		for _, cell := range coveredCells(block.ID, position.SlotNumber, position.RowNumber, position.TierNumber, position.ContainerSize) {
+			loaded.occupied[cell] = true
+		}
+	}
+	return loaded, nil
+}
+
+// blockIDs lists the blocks rows were checked against.
+func (i *inventoryImport) blockIDs() []int {
+	ids := make([]int, 0, len(i.blocks))
+	for _, block := range i.blocks {
+		if block != nil {
+			ids = append(ids, block.block.ID)
+		}
+	}
+	return ids
+}
+
+// parseInventoryDate reads an arrival date as text or, from spreadsheets, as
+// an Excel date serial number.
+func parseInventoryDate(value string) (time.Time, error) {
+	for _, layout := range inventoryDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		seconds := math.Round(serial * 24 * 60 * 60)
+		return excelEpoch.Add(time.Duration(seconds) * time.Second), nil
+	}
+
+	return time.Time{}, errors.New("invalid arrival_date " + value + ", use YYYY-MM-DD or RFC 3339")
+}
+
+// inventoryRow is the export row of a container, in inventoryColumns order.
+func inventoryRow(detail *web.ContainerDetailResponse) []string {
+	yardPlanID := ""
+	if detail.YardPlanID != nil {
+		yardPlanID = strconv.Itoa(*detail.YardPlanID)
+	}
+
+	return []string{
+		detail.ContainerNumber, detail.Yard, detail.Block,
+		strconv.Itoa(detail.Slot), strconv.Itoa(detail.Row), strconv.Itoa(detail.Tier),
+		detail.ContainerSize, detail.ContainerHeight, detail.ContainerType,
+		detail.ContainerStatus, detail.ArrivalDate.Format(time.RFC3339), strconv.Itoa(detail.DwellDays), yardPlanID,
+		detail.Vessel, detail.Voyage, detail.ShippingLine,
+	}
+}
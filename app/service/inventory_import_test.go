@@ -0,0 +1,217 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/helper/spreadsheet"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// importYard serves yard YRD-UTAMA with block LC01 of 9 slots, 3 rows and
+// 4 tiers. importPlans covers slots 1-4 with a 20ft DRY plan and
+// importPositions holds MSKU0000001 at 1-1-1.
+type importYard struct {
+	repository.YardRepository
+}
+
+type importPlans struct {
+	repository.YardPlanRepository
+}
+
+type importPositions struct {
+	repository.ContainerPositionRepository
+}
+
+func (importYard) FindYardByName(db *gorm.DB, yard *model.Yard, name string) error {
+	if name != "YRD-UTAMA" {
+		return errors.New("record not found")
+	}
+	*yard = model.Yard{ID: 1, Name: name}
+	return nil
+}
+
+func (importYard) FindBlockByNameAndYardID(db *gorm.DB, block *model.Block, blockName string, yardID int) error {
+	if blockName != "LC01" || yardID != 1 {
+		return errors.New("record not found")
+	}
+	*block = model.Block{ID: 7, YardID: yardID, Name: blockName, Slots: 9, Rows: 3, Tiers: 4}
+	return nil
+}
+
+func (importPlans) FindActivePlansByBlock(db *gorm.DB, plans *[]model.YardPlan, blockID int, at time.Time) error {
+	*plans = []model.YardPlan{{ID: 3, BlockID: blockID, SlotStart: 1, SlotEnd: 4, RowStart: 1, RowEnd: 3, ContainerSize: "20ft", ContainerHeight: "8.6ft", ContainerType: "DRY"}}
+	return nil
+}
+
+func (importPositions) FindByBlockID(db *gorm.DB, positions *[]model.ContainerPosition, blockID int) error {
+	*positions = []model.ContainerPosition{{ContainerNumber: "MSKU0000001", BlockID: blockID, SlotNumber: 1, RowNumber: 1, TierNumber: 1, ContainerSize: "20ft"}}
+	return nil
+}
+
+func (importPositions) FindAllContainerNumbers(db *gorm.DB, numbers *[]string) error {
+	*numbers = []string{"MSKU0000001"}
+	return nil
+}
+
+func TestCheckImportRow(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.Local)
+	header := []string{"Container Number", "yard", "block", "slot", "row", "tier", "container_size", "container_height", "container_type", "container_status", "arrival_date"}
+	row := func(containerNumber, yard, block, slot, rowNumber, tier, size, status, arrival string) []string {
+		return []string{containerNumber, yard, block, slot, rowNumber, tier, size, "8.6ft", "DRY", status, arrival}
+	}
+
+	tests := []struct {
+		name    string
+		row     []string
+		wantErr string
+	}{
+		{"valid row in a plan", row("tghu0000002", "YRD-UTAMA", "LC01", "2", "1", "1", "20ft", "", "2026-10-01"), ""},
+		{"valid 40ft row", row("TGHU0000003", "YRD-UTAMA", "LC01", "5", "2", "1", "40ft", "LONG_STAY", "2026-10-01 08:30"), ""},
+		{"listed earlier in the file", row("TGHU0000002", "YRD-UTAMA", "LC01", "3", "3", "1", "20ft", "", ""), "listed before"},
+		{"already in the yard", row("MSKU0000001", "YRD-UTAMA", "LC01", "9", "1", "1", "20ft", "", ""), "already in the yard"},
+		{"occupied cell", row("TGHU0000004", "YRD-UTAMA", "LC01", "1", "1", "1", "20ft", "", ""), "already occupied"},
+		{"cell taken by an earlier row", row("TGHU0000005", "YRD-UTAMA", "LC01", "6", "2", "1", "20ft", "", ""), "already occupied"},
+		{"cell held for another container", row("TGHU0000006", "YRD-UTAMA", "LC01", "3", "1", "1", "20ft", "", ""), "reserved for container MSCU9999999"},
+		{"40ft reaching into a held cell", row("TGHU0000007", "YRD-UTAMA", "LC01", "3", "1", "1", "40ft", "", ""), "reserved for container MSCU9999999"},
+		{"cell held for the container itself", row("MSCU9999999", "YRD-UTAMA", "LC01", "4", "1", "1", "20ft", "", ""), ""},
+		{"outside the block", row("TGHU0000008", "YRD-UTAMA", "LC01", "11", "1", "1", "20ft", "", ""), "outside the block"},
+		{"40ft at an even slot", row("TGHU0000009", "YRD-UTAMA", "LC01", "2", "3", "1", "40ft", "", ""), "odd slot"},
+		{"40ft at the last slot", row("TGHU0000010", "YRD-UTAMA", "LC01", "9", "3", "1", "40ft", "", ""), "requires slot 10"},
+		{"unknown yard", row("TGHU0000011", "YRD-LAIN", "LC01", "2", "3", "1", "20ft", "", ""), "yard YRD-LAIN not found"},
+		{"unknown block", row("TGHU0000012", "YRD-UTAMA", "LC09", "2", "3", "1", "20ft", "", ""), "block LC09 not found"},
+		{"slot not a number", row("TGHU0000013", "YRD-UTAMA", "LC01", "two", "3", "1", "20ft", "", ""), "slot must be a whole number"},
+		{"tier zero", row("TGHU0000014", "YRD-UTAMA", "LC01", "2", "3", "0", "20ft", "", ""), "Tier"},
+		{"unknown size", row("TGHU0000015", "YRD-UTAMA", "LC01", "2", "3", "1", "45ft", "", ""), "Size"},
+		{"unknown status", row("TGHU0000016", "YRD-UTAMA", "LC01", "2", "3", "1", "20ft", "EMPTY", ""), "Status"},
+		{"arrival in the future", row("TGHU0000017", "YRD-UTAMA", "LC01", "2", "3", "1", "20ft", "", "2026-10-20"), "in the future"},
+		{"invalid arrival", row("TGHU0000018", "YRD-UTAMA", "LC01", "2", "3", "1", "20ft", "", "19/10/2026"), "invalid arrival_date"},
+		{"missing yard", row("TGHU0000019", "", "LC01", "2", "3", "1", "20ft", "", ""), "YardName"},
+	}
+
+	for _, format := range []string{"csv", "xlsx"} {
+		t.Run(format, func(t *testing.T) {
+			var file bytes.Buffer
+			writer, err := spreadsheet.NewWriter(format, &file, "Inventory")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Write(header); err != nil {
+				t.Fatal(err)
+			}
+			for _, tt := range tests {
+				if err := writer.Write(tt.row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := spreadsheet.NewReader(format, &file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			holders := map[cellKey]string{
+				{BlockID: 7, Slot: 3, Row: 1, Tier: 1}: "MSCU9999999",
+				{BlockID: 7, Slot: 4, Row: 1, Tier: 1}: "MSCU9999999",
+			}
+			inventory, err := newInventoryImport(nil, importYard{}, importPlans{}, importPositions{}, holders, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &InventoryServiceImpl{Validate: validator.New()}
+
+			headerRow, err := reader.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := inventory.readHeader(headerRow); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tt := range tests {
+				row, err := reader.Read()
+				if err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+
+				position, err := s.checkImportRow(inventory, row)
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Errorf("%s: unexpected error %v", tt.name, err)
+					} else if position.ContainerNumber != strings.ToUpper(tt.row[0]) || position.BlockID != 7 {
+						t.Errorf("%s: position %s in block %d", tt.name, position.ContainerNumber, position.BlockID)
+					}
+					continue
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.wantErr)
+				}
+			}
+
+			if _, err := reader.Read(); !errors.Is(err, io.EOF) {
+				t.Errorf("read past the last row = %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		wantErr bool
+	}{
+		{"import layout", inventoryColumns[:inventoryRequiredColumns], false},
+		{"export layout", inventoryColumns, false},
+		{"any order and case", []string{"TIER", "Row", "slot", "Block", "yard", "Container Number", "container_type", "container_height", "container_size"}, false},
+		{"missing tier", inventoryColumns[:5], true},
+		{"empty", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory := &inventoryImport{}
+			if err := inventory.readHeader(tt.header); (err != nil) != tt.wantErr {
+				t.Errorf("readHeader error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseInventoryDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2026-10-01", time.Date(2026, time.October, 1, 0, 0, 0, 0, time.Local), false},
+		{"2026-10-01 08:30", time.Date(2026, time.October, 1, 8, 30, 0, 0, time.Local), false},
+		{"2026-10-01T08:30:15", time.Date(2026, time.October, 1, 8, 30, 15, 0, time.Local), false},
+		{"2026-10-01T08:30:00+07:00", time.Date(2026, time.October, 1, 1, 30, 0, 0, time.UTC), false},
+		{"46296.5", time.Date(2026, time.October, 1, 12, 0, 0, 0, time.Local), false},
+		{"01/10/2026", time.Time{}, true},
+		{"-1", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseInventoryDate(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseInventoryDate(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseInventoryDate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
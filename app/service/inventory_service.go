@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/cache"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/helper/spreadsheet"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type InventoryService interface {
+	SearchContainers(ctx context.Context, query *web.ContainerSearchQuery) (*web.ContainerListResponse, *response.CustomError)
+	FindContainer(ctx context.Context, containerNumber string) (*web.ContainerDetailResponse, *response.CustomError)
+
+	// ImportContainers reads container positions from a CSV or XLSX file,
+	// one row at a time. Every row is checked against the block dimensions
+	// and occupancy; the rows are stored only when all of them are valid and
+	// the import is not a dry run.
+	ImportContainers(ctx context.Context, query *web.InventoryImportQuery, file io.Reader) (*web.InventoryImportResponse, *response.CustomError)
+	// ExportContainers prepares the current inventory as a file in the
+	// import layout, rows are read from the database while it is written.
+	ExportContainers(ctx context.Context, query *web.InventoryExportQuery) (*web.TableExport, *response.CustomError)
+}
+
+type InventoryServiceImpl struct {
+	YardRepository              repository.YardRepository
+	YardPlanRepository          repository.YardPlanRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	ContainerMoveRepository     repository.ContainerMoveRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	OccupancyCache              cache.BlockOccupancyCache
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewInventoryService(
+	yardRepo repository.YardRepository,
+	planRepo repository.YardPlanRepository,
+	containerRepo repository.ContainerPositionRepository,
+	moveRepo repository.ContainerMoveRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	outboxRepo repository.OutboxEventRepository,
+	occupancyCache cache.BlockOccupancyCache,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) InventoryService {
+	return &InventoryServiceImpl{
+		YardRepository:              yardRepo,
+		YardPlanRepository:          planRepo,
+		ContainerPositionRepository: containerRepo,
+		ContainerMoveRepository:     moveRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		OutboxEventRepository:       outboxRepo,
+		OccupancyCache:              occupancyCache,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+// containerCursor is the keyset position of the last returned row, encoded
+// as base64 JSON so clients treat it as opaque.
+type containerCursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func (s *InventoryServiceImpl) SearchContainers(ctx context.Context, query *web.ContainerSearchQuery) (*web.ContainerListResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	now := time.Now()
+	filter := &repository.ContainerSearchFilter{
+		YardName:    query.YardName,
+		BlockName:   query.BlockName,
+		Size:        query.Size,
+		Height:      query.Height,
+		Type:        query.Type,
+		Status:      query.Status,
+		YardPlanID:  query.YardPlanID,
+		ArrivalFrom: query.ArrivalFrom,
+		ArrivalTo:   query.ArrivalTo,
+		SortColumn:  query.Sort,
+		Descending:  query.Order == "desc",
+		Limit:       query.Limit,
+	}
+
+	applyDwellFilter(filter, query.MinDwellDays, query.MaxDwellDays, now)
+
+	if query.Sort == "dwell_time" {
+		filter.SortColumn = "arrival_date"
+		filter.Descending = !filter.Descending
+	}
+	if filter.SortColumn == "" {
+		filter.SortColumn = "arrival_date"
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 50
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeContainerCursor(query.Cursor)
+		if err != nil {
+			return nil, response.BadRequestError("Invalid cursor.")
+		}
+
+		filter.AfterID = cursor.ID
+		if filter.SortColumn == "arrival_date" {
+			afterTime, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return nil, response.BadRequestError("Invalid cursor.")
+			}
+			filter.AfterValue = afterTime
+		} else {
+			filter.AfterValue = cursor.Value
+		}
+	}
+
+	var total int64
+	if err := s.ContainerPositionRepository.Count(s.DB, filter, &total); err != nil {
+		return nil, response.RepositoryError("Failed to count containers: " + err.Error())
+	}
+
+	// Fetch one extra row to know whether another page follows.
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	var details []model.ContainerPositionDetail
+	if err := s.ContainerPositionRepository.Search(s.DB, filter, &details); err != nil {
+		return nil, response.RepositoryError("Failed to search containers: " + err.Error())
+	}
+
+	listResponse := &web.ContainerListResponse{
+		Items: make([]web.ContainerDetailResponse, 0, len(details)),
+		Total: total,
+	}
+
+	if len(details) > limit {
+		details = details[:limit]
+
+		last := details[len(details)-1]
+		cursor := containerCursor{Value: last.ContainerNumber, ID: last.ID}
+		if filter.SortColumn == "arrival_date" {
+			cursor.Value = last.ArrivalDate.Format(time.RFC3339Nano)
+		}
+		listResponse.NextCursor = encodeContainerCursor(cursor)
+	}
+
+	for i := range details {
+		listResponse.Items = append(listResponse.Items, toContainerDetailResponse(&details[i], now))
+	}
+
+	return listResponse, nil
+}
+
+func (s *InventoryServiceImpl) FindContainer(ctx context.Context, containerNumber string) (*web.ContainerDetailResponse, *response.CustomError) {
+	var detail model.ContainerPositionDetail
+	if err := s.ContainerPositionRepository.FindDetailByContainerNumber(s.DB, &detail, containerNumber); err != nil {
+		return nil, response.NotFoundError("Container not found at any position.")
+	}
+
+	detailResponse := toContainerDetailResponse(&detail, time.Now())
+	return &detailResponse, nil
+}
+
+// errImportRejected rolls back an import with invalid rows.
+var errImportRejected = errors.New("import rejected")
+
+func (s *InventoryServiceImpl) ImportContainers(ctx context.Context, query *web.InventoryImportQuery, file io.Reader) (*web.InventoryImportResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	reader, err := spreadsheet.NewReader(query.Format, file)
+	if err != nil {
+		return nil, response.BadRequestError("Invalid " + query.Format + " file: " + err.Error())
+	}
+	defer reader.Close()
+
+	importResponse := &web.InventoryImportResponse{DryRun: query.DryRun, Errors: []web.InventoryImportError{}}
+	var customErr *response.CustomError
+	var inventory *inventoryImport
+	var imported []*model.ContainerPosition
+
+	run := func(db *gorm.DB) error {
+		holders, err := loadCellHolders(db, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+		if err != nil {
+			customErr = response.RepositoryError("Failed to load reservations: " + err.Error())
+			return err
+		}
+		if inventory, err = newInventoryImport(db, s.YardRepository, s.YardPlanRepository, s.ContainerPositionRepository, holders, time.Now()); err != nil {
+			customErr = response.RepositoryError("Failed to load the inventory: " + err.Error())
+			return err
+		}
+
+		header, err := reader.Read()
+		if err != nil {
+			customErr = response.BadRequestError("The file has no header row.")
+			return err
+		}
+		if err := inventory.readHeader(header); err != nil {
+			customErr = response.BadRequestError("Invalid header row: " + err.Error() + ".")
+			return err
+		}
+
+		for {
+			row, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				customErr = response.BadRequestError("Invalid " + query.Format + " file after line " + strconv.Itoa(reader.Line()) + ": " + err.Error())
+				return err
+			}
+			if isBlankRow(row) {
+				continue
+			}
+			importResponse.Rows++
+
+			position, rowErr := s.checkImportRow(inventory, row)
+			if rowErr != nil {
+				importResponse.Invalid++
+				if len(importResponse.Errors) < inventoryImportMaxErrors {
+					importResponse.Errors = append(importResponse.Errors, web.InventoryImportError{
+						Line:            reader.Line(),
+						ContainerNumber: strings.ToUpper(inventory.value(row, "container_number")),
+						Error:           rowErr.Error(),
+					})
+				} else {
+					importResponse.ErrorsTruncated = true
+				}
+				continue
+			}
+			importResponse.Valid++
+
+			// Once a row failed the batch is rolled back, the rest is only checked
+			if query.DryRun || importResponse.Invalid > 0 {
+				continue
+			}
+
+			if err := s.ContainerPositionRepository.Save(db, position); err != nil {
+				customErr = response.RepositoryError("Failed to import container " + position.ContainerNumber + ": " + err.Error())
+				return err
+			}
+
+			// The placement is recorded now, billing reads the original arrival
+			move := newContainerMove(model.MoveTypePlacement, position, nil, position, model.MoveReasonImport)
+			arrival := position.ArrivalDate
+			move.ArrivedAt = &arrival
+			if err := saveContainerMove(db, s.ContainerMoveRepository, s.OutboxEventRepository, &move); err != nil {
+				customErr = response.RepositoryError("Failed to record container move: " + err.Error())
+				return err
+			}
+			imported = append(imported, position)
+		}
+
+		if importResponse.Invalid > 0 {
+			return errImportRejected
+		}
+		return nil
+	}
+
+	if query.DryRun {
+		err = run(s.DB)
+	} else {
+		err = s.DB.Transaction(run)
+	}
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if err != nil && !errors.Is(err, errImportRejected) {
+		return nil, response.RepositoryError("Failed to import containers: " + err.Error())
+	}
+
+	if !query.DryRun && err == nil {
+		importResponse.Imported = importResponse.Valid
+		for _, blockID := range inventory.blockIDs() {
+			s.OccupancyCache.Invalidate(blockID)
+		}
+		for _, position := range imported {
+			auditlog.Record(ctx, model.AuditEntityContainer, position.ContainerNumber, nil, position)
+		}
+	}
+
+	return importResponse, nil
+}
+
+func (s *InventoryServiceImpl) checkImportRow(inventory *inventoryImport, row []string) (*model.ContainerPosition, error) {
+	parsed, err := inventory.parseRow(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Validate.Struct(parsed); err != nil {
+		return nil, err
+	}
+	return inventory.check(parsed)
+}
+
+func (s *InventoryServiceImpl) ExportContainers(ctx context.Context, query *web.InventoryExportQuery) (*web.TableExport, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	scope := "all"
+	if query.YardName != "" {
+		var yard model.Yard
+		if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+			return nil, response.NotFoundError("Yard not found.")
+		}
+		scope = yard.Name
+	}
+
+	filter := &repository.ContainerSearchFilter{
+		YardName:  query.YardName,
+		BlockName: query.BlockName,
+	}
+	now := time.Now()
+
+	return &web.TableExport{
+		FileName:  "inventory_" + strings.ReplaceAll(scope, " ", "_") + "_" + now.Format(billingDateFormat) + "." + query.Format,
+		SheetName: "Inventory",
+		Header:    inventoryColumns,
+		Rows: func(write func(row []string) error) error {
+			return s.ContainerPositionRepository.EachDetail(s.DB, filter, func(detail *model.ContainerPositionDetail) error {
+				detailResponse := toContainerDetailResponse(detail, now)
+				return write(inventoryRow(&detailResponse))
+			})
+		},
+	}, nil
+}
+
+// applyDwellFilter narrows the arrival dates of the filter to the containers
+// whose dwellDays at now lies within the bounds, both inclusive. A longer
+// dwell time means an earlier arrival.
+func applyDwellFilter(filter *repository.ContainerSearchFilter, minDays, maxDays *int, now time.Time) {
+	if minDays != nil {
+		latestArrival := now.Add(-time.Duration(*minDays) * 24 * time.Hour)
+		if filter.ArrivalTo == nil || latestArrival.Before(*filter.ArrivalTo) {
+			filter.ArrivalTo = &latestArrival
+		}
+	}
+	// dwellDays rounds down, so a container reaches maxDays+1 days exactly
+	// maxDays+1 full days after its arrival and drops out from then.
+	if maxDays != nil {
+		earliestArrival := now.Add(-time.Duration(*maxDays+1) * 24 * time.Hour)
+		filter.ArrivalAfter = &earliestArrival
+	}
+}
+
+// isBlankRow tells rows without any value, as spreadsheets often end with.
+func isBlankRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func toContainerDetailResponse(detail *model.ContainerPositionDetail, now time.Time) web.ContainerDetailResponse {
+	return web.ContainerDetailResponse{
+		ContainerNumber: detail.ContainerNumber,
+
+		YardID:  detail.YardID,
+		Yard:    detail.YardName,
+		BlockID: detail.BlockID,
+		Block:   detail.BlockName,
+		Slot:    detail.SlotNumber,
+		Row:     detail.RowNumber,
+		Tier:    detail.TierNumber,
+
+		ContainerSize:   detail.ContainerSize,
+		ContainerHeight: detail.ContainerHeight,
+		ContainerType:   detail.ContainerType,
+		ContainerStatus: detail.ContainerStatus,
+
+		ArrivalDate: detail.ArrivalDate,
+		DwellDays:   dwellDays(detail.ArrivalDate, now),
+		YardPlanID:  detail.YardPlanID,
+		Vessel:      detail.Vessel,
+		Voyage:      detail.Voyage,
+
+		ShippingLine: detail.ShippingLine,
+		Revision:     detail.Revision,
+	}
+}
+
+func encodeContainerCursor(cursor containerCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeContainerCursor(encoded string) (*containerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor containerCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"yard-planning/app/repository"
+)
+
+// matchesArrival applies the arrival bounds of the filter the way the
+// search query does.
+func matchesArrival(filter *repository.ContainerSearchFilter, arrival time.Time) bool {
+	if filter.ArrivalFrom != nil && arrival.Before(*filter.ArrivalFrom) {
+		return false
+	}
+	if filter.ArrivalTo != nil && arrival.After(*filter.ArrivalTo) {
+		return false
+	}
+	if filter.ArrivalAfter != nil && !arrival.After(*filter.ArrivalAfter) {
+		return false
+	}
+	return true
+}
+
+func TestApplyDwellFilterMatchesDwellDays(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name     string
+		min, max *int
+	}{
+		{"max only", nil, intPtr(3)},
+		{"min only", intPtr(3), nil},
+		{"min and max", intPtr(2), intPtr(4)},
+		{"same day", intPtr(0), intPtr(0)},
+	}
+
+	ages := []time.Duration{
+		0, time.Hour, day - time.Nanosecond, day,
+		3*day - time.Nanosecond, 3 * day, 3*day + time.Hour, 4*day - time.Nanosecond, 4 * day,
+		5*day - time.Nanosecond, 5 * day, 10 * day,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &repository.ContainerSearchFilter{}
+			applyDwellFilter(filter, tt.min, tt.max, now)
+
+			for _, age := range ages {
+				arrival := now.Add(-age)
+				days := dwellDays(arrival, now)
+				want := (tt.min == nil || days >= *tt.min) && (tt.max == nil || days <= *tt.max)
+				if got := matchesArrival(filter, arrival); got != want {
+					t.Errorf("arrival %v ago (dwell_days %d): matched = %v, want %v", age, days, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// preAdviceGracePeriod is how long a pre-advice keeps its provisional cell
+// after its expected arrival window has ended.
+const preAdviceGracePeriod = 24 * time.Hour
+
+type PreAdviceService interface {
+	// CreatePreAdvices stores the announced containers and plans a
+	// provisional cell for each of them. A pre-advice is still stored when no
+	// cell is free, with a warning in its result.
+	CreatePreAdvices(ctx context.Context, source string, request *web.BatchPreAdviceRequest) (*web.PreAdviceBatchResponse, *response.CustomError)
+	CreatePreAdvice(ctx context.Context, request *web.PreAdviceRequest) (*web.PreAdviceResult, *response.CustomError)
+	CancelPreAdvice(ctx context.Context, preAdviceID int) (*web.PreAdviceResponse, *response.CustomError)
+	FindPreAdvice(ctx context.Context, preAdviceID int) (*web.PreAdviceResponse, *response.CustomError)
+	FindPendingPreAdvices(ctx context.Context, query *web.PreAdviceQuery) ([]web.PreAdviceResponse, *response.CustomError)
+	ExpirePreAdvices(ctx context.Context) (int64, *response.CustomError)
+}
+
+type PreAdviceServiceImpl struct {
+	ContainerService            ContainerService
+	YardRepository              repository.YardRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewPreAdviceService(
+	containerService ContainerService,
+	yardRepo repository.YardRepository,
+	containerRepo repository.ContainerPositionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	outboxRepo repository.OutboxEventRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) PreAdviceService {
+	return &PreAdviceServiceImpl{
+		ContainerService:            containerService,
+		YardRepository:              yardRepo,
+		ContainerPositionRepository: containerRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		OutboxEventRepository:       outboxRepo,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *PreAdviceServiceImpl) CreatePreAdvices(ctx context.Context, source string, request *web.BatchPreAdviceRequest) (*web.PreAdviceBatchResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	results := make([]web.PreAdviceResult, len(request.PreAdvices))
+	yards := make(map[string]*model.Yard)
+	seen := make(map[string]bool)
+
+	// Items that pass validation are planned together so they never share a
+	// provisional cell.
+	var planned []int
+	suggestRequest := &web.BatchContainerRequest{}
+
+	for i := range request.PreAdvices {
+		item := &request.PreAdvices[i]
+		results[i].ContainerNumber = item.ContainerNumber
+
+		if err := s.Validate.Struct(item); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if goods := joinDangerousGoods(item.DangerousGoods); len(goods) > maxDangerousGoodsLength {
+			results[i].Error = "Dangerous goods take " + strconv.Itoa(len(goods)) + " characters, at most " + strconv.Itoa(maxDangerousGoodsLength) + " fit."
+			continue
+		}
+
+		if seen[item.ContainerNumber] {
+			results[i].Error = "Container number " + item.ContainerNumber + " appears more than once in the batch."
+			continue
+		}
+		seen[item.ContainerNumber] = true
+
+		if _, ok := yards[item.YardName]; !ok {
+			var yard model.Yard
+			if err := s.YardRepository.FindYardByName(s.DB, &yard, item.YardName); err != nil {
+				results[i].Error = "Yard not found."
+				continue
+			}
+			yards[item.YardName] = &yard
+		}
+
+		var existing model.ContainerPosition
+		if err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &existing, item.ContainerNumber); err == nil {
+			results[i].Error = "Container " + item.ContainerNumber + " is already in the yard."
+			continue
+		}
+
+		var pending model.PreAdvice
+		if err := s.PreAdviceRepository.FindPendingByContainerNumber(s.DB, &pending, item.ContainerNumber); err == nil {
+			results[i].Error = "Container " + item.ContainerNumber + " already has a pending pre-advice."
+			continue
+		} else if !errors.Is(err, repository.ErrPreAdviceNotFound) {
+			return nil, response.RepositoryError("Failed to fetch pre-advices: " + err.Error())
+		}
+
+		planned = append(planned, i)
+		suggestRequest.Containers = append(suggestRequest.Containers, item.ContainerRequest)
+	}
+
+	// The provisional cells are planned and stored with the yards locked so
+	// no other suggestion hands them out in between.
+	yardIDs := make([]int, 0, len(yards))
+	for _, yard := range yards {
+		yardIDs = append(yardIDs, yard.ID)
+	}
+	sort.Ints(yardIDs)
+
+	now := time.Now()
+	created := make([]model.PreAdvice, 0, len(planned))
+	var customErr *response.CustomError
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if len(planned) == 0 {
+			return nil
+		}
+
+		for _, yardID := range yardIDs {
+			if err := s.YardRepository.LockYardBlocks(tx, yardID); err != nil {
+				return err
+			}
+		}
+
+		suggestions, suggestErr := s.ContainerService.SuggestPositionsTx(tx, suggestRequest)
+		if suggestErr != nil {
+			customErr = suggestErr
+			return errors.New(customErr.Message)
+		}
+
+		for j, i := range planned {
+			item := &request.PreAdvices[i]
+			suggestion := suggestions.Results[j]
+
+			preAdvice := model.PreAdvice{
+				ContainerNumber: item.ContainerNumber,
+				YardID:          yards[item.YardName].ID,
+				Status:          model.PreAdviceStatusPending,
+				Source:          source,
+				ContainerSize:   item.Size,
+				ContainerHeight: item.Height,
+				ContainerType:   item.Type,
+				Vessel:          item.Vessel,
+				Voyage:          item.Voyage,
+				ShippingLine:    item.ShippingLine,
+				GrossWeight:     item.GrossWeight,
+				PortOfDischarge: item.PortOfDischarge,
+				DangerousGoods:  joinDangerousGoods(item.DangerousGoods),
+				ExpectedFrom:    item.ExpectedFrom,
+				ExpectedTo:      item.ExpectedTo,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			}
+
+			if suggestion.Success {
+				position := suggestion.Position
+				preAdvice.SetPosition(position.BlockID, position.Slot, position.Row, position.Tier)
+			} else {
+				results[i].Warning = "No provisional cell: " + suggestion.Error
+			}
+
+			if err := s.PreAdviceRepository.Save(tx, &preAdvice); err != nil {
+				return err
+			}
+			if err := publishReservationChanged(tx, s.OutboxEventRepository, preAdviceReservation(&preAdvice), ReservationChangeReserved); err != nil {
+				return err
+			}
+			created = append(created, preAdvice)
+
+			preAdviceResponse := toPreAdviceResponse(&preAdvice, suggestion.Position)
+			results[i].Success = true
+			results[i].PreAdvice = &preAdviceResponse
+		}
+		return nil
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to store pre-advices: " + txErr.Error())
+	}
+	for _, preAdvice := range created {
+		auditlog.Record(ctx, model.AuditEntityPreAdvice, preAdvice.ID, nil, preAdvice)
+	}
+
+	batchResponse := &web.PreAdviceBatchResponse{Total: len(results), Results: results}
+	for _, result := range results {
+		if result.Success {
+			batchResponse.Succeeded++
+		} else {
+			batchResponse.Failed++
+		}
+	}
+	return batchResponse, nil
+}
+
+func (s *PreAdviceServiceImpl) CreatePreAdvice(ctx context.Context, request *web.PreAdviceRequest) (*web.PreAdviceResult, *response.CustomError) {
+	batchResponse, customErr := s.CreatePreAdvices(ctx, model.PreAdviceSourceManual, &web.BatchPreAdviceRequest{
+		PreAdvices: []web.PreAdviceRequest{*request},
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	result := batchResponse.Results[0]
+	if !result.Success {
+		return nil, response.BadRequestError(result.Error)
+	}
+	return &result, nil
+}
+
+func (s *PreAdviceServiceImpl) CancelPreAdvice(ctx context.Context, preAdviceID int) (*web.PreAdviceResponse, *response.CustomError) {
+	var preAdvice model.PreAdvice
+	if err := s.PreAdviceRepository.FindByID(s.DB, &preAdvice, preAdviceID); err != nil {
+		return nil, response.NotFoundError("Pre-advice not found.")
+	}
+
+	if preAdvice.Status != model.PreAdviceStatusPending {
+		return nil, response.BadRequestError("Only pending pre-advices can be cancelled.")
+	}
+
+	provisional := preAdviceReservation(&preAdvice)
+	before := preAdvice
+
+	preAdvice.Status = model.PreAdviceStatusCancelled
+	preAdvice.UpdatedAt = time.Now()
+	preAdvice.ClearPosition()
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.PreAdviceRepository.UpdateStatus(tx, &preAdvice); err != nil {
+			return err
+		}
+		return publishReservationChanged(tx, s.OutboxEventRepository, provisional, ReservationChangeReleased)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to cancel pre-advice: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityPreAdvice, preAdvice.ID, before, preAdvice)
+
+	preAdviceResponse := toPreAdviceResponse(&preAdvice, nil)
+	return &preAdviceResponse, nil
+}
+
+func (s *PreAdviceServiceImpl) FindPreAdvice(ctx context.Context, preAdviceID int) (*web.PreAdviceResponse, *response.CustomError) {
+	var preAdvice model.PreAdvice
+	if err := s.PreAdviceRepository.FindByID(s.DB, &preAdvice, preAdviceID); err != nil {
+		return nil, response.NotFoundError("Pre-advice not found.")
+	}
+
+	position, customErr := s.provisionalPosition(&preAdvice)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	preAdviceResponse := toPreAdviceResponse(&preAdvice, position)
+	return &preAdviceResponse, nil
+}
+
+func (s *PreAdviceServiceImpl) FindPendingPreAdvices(ctx context.Context, query *web.PreAdviceQuery) ([]web.PreAdviceResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	yardID := 0
+	if query.YardName != "" {
+		var yard model.Yard
+		if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+			return nil, response.NotFoundError("Yard not found.")
+		}
+		yardID = yard.ID
+	}
+
+	until := time.Now().AddDate(0, 0, 7)
+	if query.Until != nil {
+		until = *query.Until
+	}
+
+	var preAdvices []model.PreAdvice
+	if err := s.PreAdviceRepository.FindPending(s.DB, &preAdvices, yardID, until); err != nil {
+		return nil, response.RepositoryError("Failed to fetch pre-advices: " + err.Error())
+	}
+
+	preAdviceResponses := make([]web.PreAdviceResponse, 0, len(preAdvices))
+	for i := range preAdvices {
+		position, customErr := s.provisionalPosition(&preAdvices[i])
+		if customErr != nil {
+			return nil, customErr
+		}
+		preAdviceResponses = append(preAdviceResponses, toPreAdviceResponse(&preAdvices[i], position))
+	}
+
+	return preAdviceResponses, nil
+}
+
+func (s *PreAdviceServiceImpl) ExpirePreAdvices(ctx context.Context) (int64, *response.CustomError) {
+	var expired []model.PreAdvice
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.PreAdviceRepository.ExpireBefore(tx, &expired, time.Now().Add(-preAdviceGracePeriod)); err != nil {
+			return err
+		}
+
+		for i := range expired {
+			if err := publishReservationChanged(tx, s.OutboxEventRepository, preAdviceReservation(&expired[i]), ReservationChangeReleased); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if txErr != nil {
+		return 0, response.RepositoryError("Failed to expire pre-advices: " + txErr.Error())
+	}
+	return int64(len(expired)), nil
+}
+
+func (s *PreAdviceServiceImpl) provisionalPosition(preAdvice *model.PreAdvice) (*web.PositionResponse, *response.CustomError) {
+	if preAdvice.BlockID == nil {
+		return nil, nil
+	}
+
+	var block model.Block
+	if err := s.YardRepository.FindBlockByID(s.DB, &block, *preAdvice.BlockID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch the provisional block of pre-advice " + strconv.Itoa(preAdvice.ID) + ": " + err.Error())
+	}
+
+	return &web.PositionResponse{
+		Block:   block.Name,
+		Slot:    *preAdvice.SlotNumber,
+		Row:     *preAdvice.RowNumber,
+		Tier:    *preAdvice.TierNumber,
+		BlockID: *preAdvice.BlockID,
+	}, nil
+}
+
+func toPreAdviceResponse(preAdvice *model.PreAdvice, position *web.PositionResponse) web.PreAdviceResponse {
+	preAdviceResponse := web.PreAdviceResponse{
+		ID:              preAdvice.ID,
+		ContainerNumber: preAdvice.ContainerNumber,
+		YardID:          preAdvice.YardID,
+		Status:          preAdvice.Status,
+		Source:          preAdvice.Source,
+
+		ContainerSize:   preAdvice.ContainerSize,
+		ContainerHeight: preAdvice.ContainerHeight,
+		ContainerType:   preAdvice.ContainerType,
+		Vessel:          preAdvice.Vessel,
+		Voyage:          preAdvice.Voyage,
+		ShippingLine:    preAdvice.ShippingLine,
+
+		GrossWeight:     preAdvice.GrossWeight,
+		PortOfDischarge: preAdvice.PortOfDischarge,
+		DangerousGoods:  splitDangerousGoods(preAdvice.DangerousGoods),
+
+		ExpectedFrom: preAdvice.ExpectedFrom,
+		ExpectedTo:   preAdvice.ExpectedTo,
+
+		ProvisionalPosition: position,
+		ArrivedAt:           preAdvice.ArrivedAt,
+	}
+
+	if preAdvice.Mismatches != "" {
+		preAdviceResponse.Mismatches = strings.Split(preAdvice.Mismatches, ",")
+	}
+	return preAdviceResponse
+}
+
+// maxDangerousGoodsLength is the size of the pre_advices.dangerous_goods
+// column.
+const maxDangerousGoodsLength = 255
+
+// joinDangerousGoods stores the dangerous goods of a pre-advice as comma
+// separated class/UN number pairs, e.g. "3/1203,8/1789".
+func joinDangerousGoods(goods []web.DangerousGoodRequest) string {
+	pairs := make([]string, 0, len(goods))
+	for _, good := range goods {
+		pairs = append(pairs, good.IMDGClass+"/"+good.UNNumber)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func splitDangerousGoods(value string) []web.DangerousGoodRequest {
+	if value == "" {
+		return nil
+	}
+
+	var goods []web.DangerousGoodRequest
+	for _, pair := range strings.Split(value, ",") {
+		class, unNumber, _ := strings.Cut(pair, "/")
+		goods = append(goods, web.DangerousGoodRequest{IMDGClass: class, UNNumber: unNumber})
+	}
+	return goods
+}
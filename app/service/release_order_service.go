@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type ReleaseOrderService interface {
+	CreateReleaseOrder(ctx context.Context, request *web.ReleaseOrderRequest) (*web.ReleaseOrderResponse, *response.CustomError)
+	FindReleaseOrder(ctx context.Context, releaseOrderID int) (*web.ReleaseOrderResponse, *response.CustomError)
+	CancelReleaseOrder(ctx context.Context, releaseOrderID int) (*web.ReleaseOrderResponse, *response.CustomError)
+}
+
+type ReleaseOrderServiceImpl struct {
+	ReleaseOrderRepository repository.ReleaseOrderRepository
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
+func NewReleaseOrderService(releaseRepo repository.ReleaseOrderRepository, DB *gorm.DB, validate *validator.Validate) ReleaseOrderService {
+	return &ReleaseOrderServiceImpl{
+		ReleaseOrderRepository: releaseRepo,
+		DB:                     DB,
+		Validate:               validate,
+	}
+}
+
+func (s *ReleaseOrderServiceImpl) CreateReleaseOrder(ctx context.Context, request *web.ReleaseOrderRequest) (*web.ReleaseOrderResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	now := time.Now()
+	if !request.ValidUntil.After(now) {
+		return nil, response.BadRequestError("valid_until must be in the future.")
+	}
+
+	var existing model.ReleaseOrder
+	if err := s.ReleaseOrderRepository.FindByReleaseNumber(s.DB, &existing, request.ReleaseNumber); err == nil {
+		return nil, response.BadRequestError("Release number " + request.ReleaseNumber + " already exists.")
+	}
+
+	pinHash, err := helper.HashPassword(request.PIN)
+	if err != nil {
+		return nil, response.GeneralError("Failed to hash PIN: " + err.Error())
+	}
+
+	releaseOrder := model.ReleaseOrder{
+		ReleaseNumber:   request.ReleaseNumber,
+		PinHash:         pinHash,
+		TruckingCompany: request.TruckingCompany,
+		ValidUntil:      request.ValidUntil,
+		Status:          model.ReleaseStatusActive,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	seen := make(map[string]bool, len(request.ContainerNumbers))
+	for _, containerNumber := range request.ContainerNumbers {
+		if seen[containerNumber] {
+			return nil, response.BadRequestError("Container number " + containerNumber + " appears more than once.")
+		}
+		seen[containerNumber] = true
+		releaseOrder.Containers = append(releaseOrder.Containers, model.ReleaseOrderContainer{ContainerNumber: containerNumber})
+	}
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.ReleaseOrderRepository.Save(tx, &releaseOrder)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to create release order: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityReleaseOrder, releaseOrder.ID, nil, releaseOrder)
+
+	releaseResponse := toReleaseOrderResponse(&releaseOrder)
+	return &releaseResponse, nil
+}
+
+func (s *ReleaseOrderServiceImpl) FindReleaseOrder(ctx context.Context, releaseOrderID int) (*web.ReleaseOrderResponse, *response.CustomError) {
+	var releaseOrder model.ReleaseOrder
+	if err := s.ReleaseOrderRepository.FindByID(s.DB, &releaseOrder, releaseOrderID); err != nil {
+		return nil, response.NotFoundError("Release order not found.")
+	}
+
+	releaseResponse := toReleaseOrderResponse(&releaseOrder)
+	return &releaseResponse, nil
+}
+
+func (s *ReleaseOrderServiceImpl) CancelReleaseOrder(ctx context.Context, releaseOrderID int) (*web.ReleaseOrderResponse, *response.CustomError) {
+	var releaseOrder model.ReleaseOrder
+	if err := s.ReleaseOrderRepository.FindByID(s.DB, &releaseOrder, releaseOrderID); err != nil {
+		return nil, response.NotFoundError("Release order not found.")
+	}
+
+	if releaseOrder.Status != model.ReleaseStatusActive {
+		return nil, response.BadRequestError("Only active release orders can be cancelled.")
+	}
+
+	before := releaseOrder
+	releaseOrder.Status = model.ReleaseStatusCancelled
+	releaseOrder.UpdatedAt = time.Now()
+
+	if err := s.ReleaseOrderRepository.UpdateStatus(s.DB, &releaseOrder); err != nil {
+		return nil, response.RepositoryError("Failed to cancel release order: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityReleaseOrder, releaseOrder.ID, before, releaseOrder)
+
+	releaseResponse := toReleaseOrderResponse(&releaseOrder)
+	return &releaseResponse, nil
+}
+
+func toReleaseOrderResponse(releaseOrder *model.ReleaseOrder) web.ReleaseOrderResponse {
+	releaseResponse := web.ReleaseOrderResponse{
+		ID:              releaseOrder.ID,
+		ReleaseNumber:   releaseOrder.ReleaseNumber,
+		TruckingCompany: releaseOrder.TruckingCompany,
+		ValidUntil:      releaseOrder.ValidUntil,
+		Status:          releaseOrder.Status,
+		Containers:      make([]web.ReleaseOrderContainerResponse, 0, len(releaseOrder.Containers)),
+		CreatedAt:       releaseOrder.CreatedAt,
+	}
+
+	for _, container := range releaseOrder.Containers {
+		if container.PickedUpAt == nil {
+			releaseResponse.Remaining++
+		}
+		releaseResponse.Containers = append(releaseResponse.Containers, web.ReleaseOrderContainerResponse{
+			ContainerNumber: container.ContainerNumber,
+			PickedUpAt:      container.PickedUpAt,
+		})
+	}
+	return releaseResponse
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReservationSourceGateIn    = "GATE_IN"
+	ReservationSourcePreAdvice = "PRE_ADVICE"
+	ReservationSourceWork      = "WORK_INSTRUCTION"
+)
+
+// reservation is a cell promised to a container that is not in the yard yet.
+type reservation struct {
+	ContainerNumber string
+	ContainerSize   string
+	ContainerHeight string
+	ContainerType   string
+	Source          string
+	BlockID         int
+	Slot            int
+	Row             int
+	Tier            int
+}
+
+// loadReservations collects the cells held by gate-ins waiting for their
+// placement, by pending pre-advices and by the destinations of pending work
+// instructions.
+func loadReservations(db *gorm.DB, gateRepo repository.GateTransactionRepository, preAdviceRepo repository.PreAdviceRepository, workRepo repository.WorkInstructionRepository) ([]reservation, error) {
+	var gateIns []model.GateTransaction
+	if err := gateRepo.FindPendingPlacements(db, &gateIns); err != nil {
+		return nil, err
+	}
+
+	var instructions []model.WorkInstruction
+	if err := workRepo.FindPendingDestinations(db, &instructions); err != nil {
+		return nil, err
+	}
+
+	var preAdvices []model.PreAdvice
+	if err := preAdviceRepo.FindProvisionalCells(db, &preAdvices); err != nil {
+		return nil, err
+	}
+
+	reservations := make([]reservation, 0, len(gateIns)+len(preAdvices)+len(instructions))
+	gated := make(map[string]bool, len(gateIns)+len(instructions))
+
+	for i := range instructions {
+		gated[instructions[i].ContainerNumber] = true
+		reservations = append(reservations, *workReservation(&instructions[i]))
+	}
+
+	for i := range gateIns {
+		gated[gateIns[i].ContainerNumber] = true
+		reservations = append(reservations, *gateInReservation(&gateIns[i]))
+	}
+
+	// A pre-advised container that already passed the gate or has a pending
+	// placement holds that cell, its provisional cell is not counted twice.
+	for i := range preAdvices {
+		if gated[preAdvices[i].ContainerNumber] {
+			continue
+		}
+		reservations = append(reservations, *preAdviceReservation(&preAdvices[i]))
+	}
+
+	return reservations, nil
+}
+
+// loadCellHolders maps every reserved cell to the container it is held for.
+func loadCellHolders(db *gorm.DB, gateRepo repository.GateTransactionRepository, preAdviceRepo repository.PreAdviceRepository, workRepo repository.WorkInstructionRepository) (map[cellKey]string, error) {
+	reservations, err := loadReservations(db, gateRepo, preAdviceRepo, workRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	holders := make(map[cellKey]string, len(reservations))
+	for _, cell := range reservations {
+		for _, key := range coveredCells(cell.BlockID, cell.Slot, cell.Row, cell.Tier, cell.ContainerSize) {
+			holders[key] = cell.ContainerNumber
+		}
+	}
+	return holders, nil
+}
+
+// workReservation returns the destination a work instruction holds, nil for
+// pickups.
+func workReservation(instruction *model.WorkInstruction) *reservation {
+	if instruction.ToBlockID == nil {
+		return nil
+	}
+
+	return &reservation{
+		ContainerNumber: instruction.ContainerNumber,
+		ContainerSize:   instruction.ContainerSize,
+		ContainerHeight: instruction.ContainerHeight,
+		ContainerType:   instruction.ContainerType,
+		Source:          ReservationSourceWork,
+		BlockID:         *instruction.ToBlockID,
+		Slot:            *instruction.ToSlot,
+		Row:             *instruction.ToRow,
+		Tier:            *instruction.ToTier,
+	}
+}
+
+// gateInReservation returns the planned cell of a gate-in, nil when it has
+// none.
+func gateInReservation(gateIn *model.GateTransaction) *reservation {
+	if gateIn.BlockID == nil {
+		return nil
+	}
+
+	return &reservation{
+		ContainerNumber: gateIn.ContainerNumber,
+		ContainerSize:   gateIn.ContainerSize,
+		ContainerHeight: gateIn.ContainerHeight,
+		ContainerType:   gateIn.ContainerType,
+		Source:          ReservationSourceGateIn,
+		BlockID:         *gateIn.BlockID,
+		Slot:            *gateIn.SlotNumber,
+		Row:             *gateIn.RowNumber,
+		Tier:            *gateIn.TierNumber,
+	}
+}
+
+// preAdviceReservation returns the provisional cell of a pre-advice, nil
+// when it has none.
+func preAdviceReservation(preAdvice *model.PreAdvice) *reservation {
+	if preAdvice.BlockID == nil {
+		return nil
+	}
+
+	return &reservation{
+		ContainerNumber: preAdvice.ContainerNumber,
+		ContainerSize:   preAdvice.ContainerSize,
+		ContainerHeight: preAdvice.ContainerHeight,
+		ContainerType:   preAdvice.ContainerType,
+		Source:          ReservationSourcePreAdvice,
+		BlockID:         *preAdvice.BlockID,
+		Slot:            *preAdvice.SlotNumber,
+		Row:             *preAdvice.RowNumber,
+		Tier:            *preAdvice.TierNumber,
+	}
+}
+
+// position returns the reserved cell as a container position so it can be
+// matched against yard plans like a placed container.
+func (r *reservation) position() *model.ContainerPosition {
+	return &model.ContainerPosition{
+		ContainerNumber: r.ContainerNumber,
+		BlockID:         r.BlockID,
+		SlotNumber:      r.Slot,
+		RowNumber:       r.Row,
+		TierNumber:      r.Tier,
+		ContainerSize:   r.ContainerSize,
+		ContainerHeight: r.ContainerHeight,
+		ContainerType:   r.ContainerType,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"yard-planning/app/repository"
 	"yard-planning/app/web"
 	"yard-planning/helper"
+	"yard-planning/helper/auditlog"
 	"yard-planning/helper/token"
 	"yard-planning/response"
 
@@ -62,6 +63,7 @@ func (s *UserServiceImpl) Register(ctx context.Context, request *web.Register) (
 		Name:  user.Name,
 		Email: user.Email,
 	}
+	auditlog.Record(ctx, model.AuditEntityUser, user.Id, nil, userResponse)
 
 	return &userResponse, nil
 }
@@ -0,0 +1,503 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Webhook delivery settings. A failed attempt is retried after
+// webhookRetryDelay, doubling per attempt up to webhookMaxRetryDelay.
+const (
+	webhookTimeout       = 10 * time.Second
+	webhookRetryDelay    = 30 * time.Second
+	webhookMaxRetryDelay = time.Hour
+	webhookEventBatch    = 500
+	webhookDeliveryBatch = 100
+	webhookDefaultLimit  = 100
+
+	// webhookDeliveryLease keeps claimed deliveries from other dispatchers
+	// while the batch is sent one after another, each send taking at most
+	// webhookTimeout.
+	webhookDeliveryLease = (webhookDeliveryBatch + 1) * webhookTimeout
+
+	// WebhookSignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256>" of
+	// "<unix time>.<body>" keyed with the webhook secret.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+type WebhookService interface {
+	FindWebhooks(ctx context.Context) ([]web.WebhookResponse, *response.CustomError)
+	CreateWebhook(ctx context.Context, request *web.WebhookRequest) (*web.WebhookResponse, *response.CustomError)
+	UpdateWebhook(ctx context.Context, webhookID int, request *web.WebhookRequest) (*web.WebhookResponse, *response.CustomError)
+	DeleteWebhook(ctx context.Context, webhookID int) *response.CustomError
+
+	// Dispatch turns new outbox events into deliveries for the subscribed
+	// webhooks and sends the deliveries that are due. It is run periodically
+	// by the scheduler.
+	Dispatch(ctx context.Context) (*web.WebhookDispatchResponse, *response.CustomError)
+	// FindDeadLetters lists the deliveries that ran out of attempts.
+	FindDeadLetters(ctx context.Context, query *web.WebhookDeliveryQuery) ([]web.WebhookDeliveryResponse, *response.CustomError)
+	// RetryDelivery queues a dead delivery again with fresh attempts.
+	RetryDelivery(ctx context.Context, deliveryID int) (*web.WebhookDeliveryResponse, *response.CustomError)
+}
+
+type WebhookServiceImpl struct {
+	WebhookRepository     repository.WebhookRepository
+	OutboxEventRepository repository.OutboxEventRepository
+	// Attempts before a delivery becomes a dead letter
+	MaxAttempts int
+	Client      *http.Client
+	DB          *gorm.DB
+	Validate    *validator.Validate
+}
+
+func NewWebhookService(
+	webhookRepo repository.WebhookRepository,
+	outboxRepo repository.OutboxEventRepository,
+	maxAttempts int,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) WebhookService {
+	return &WebhookServiceImpl{
+		WebhookRepository:     webhookRepo,
+		OutboxEventRepository: outboxRepo,
+		MaxAttempts:           maxAttempts,
+		Client:                newWebhookClient(),
+		DB:                    DB,
+		Validate:              validate,
+	}
+}
+
+func (s *WebhookServiceImpl) FindWebhooks(ctx context.Context) ([]web.WebhookResponse, *response.CustomError) {
+	var webhooks []model.Webhook
+	if err := s.WebhookRepository.FindAll(s.DB, &webhooks, false); err != nil {
+		return nil, response.RepositoryError("Failed to fetch webhooks: " + err.Error())
+	}
+
+	webhookResponses := make([]web.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		webhookResponses = append(webhookResponses, toWebhookResponse(&webhooks[i]))
+	}
+	return webhookResponses, nil
+}
+
+func (s *WebhookServiceImpl) CreateWebhook(ctx context.Context, request *web.WebhookRequest) (*web.WebhookResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	if request.Secret == "" {
+		return nil, response.BadRequestError("A secret of at least 16 characters is required.")
+	}
+	if err := checkWebhookURL(ctx, request.URL); err != nil {
+		return nil, response.BadRequestError("Invalid webhook URL: " + err.Error() + ".")
+	}
+
+	now := time.Now()
+	webhook := model.Webhook{
+		URL:        request.URL,
+		Secret:     request.Secret,
+		EventTypes: strings.Join(request.EventTypes, ","),
+		Active:     request.Active == nil || *request.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.WebhookRepository.Save(s.DB, &webhook); err != nil {
+		return nil, response.RepositoryError("Failed to create webhook: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWebhook, webhook.ID, nil, webhook)
+
+	webhookResponse := toWebhookResponse(&webhook)
+	return &webhookResponse, nil
+}
+
+func (s *WebhookServiceImpl) UpdateWebhook(ctx context.Context, webhookID int, request *web.WebhookRequest) (*web.WebhookResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	if err := checkWebhookURL(ctx, request.URL); err != nil {
+		return nil, response.BadRequestError("Invalid webhook URL: " + err.Error() + ".")
+	}
+
+	var webhook model.Webhook
+	if err := s.WebhookRepository.FindByID(s.DB, &webhook, webhookID); err != nil {
+		return nil, response.NotFoundError("Webhook not found.")
+	}
+	before := webhook
+
+	webhook.URL = request.URL
+	webhook.EventTypes = strings.Join(request.EventTypes, ",")
+	if request.Secret != "" {
+		webhook.Secret = request.Secret
+	}
+	if request.Active != nil {
+		webhook.Active = *request.Active
+	}
+	webhook.UpdatedAt = time.Now()
+
+	if err := s.WebhookRepository.Update(s.DB, &webhook); err != nil {
+		return nil, response.RepositoryError("Failed to update webhook: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWebhook, webhook.ID, before, webhook)
+
+	webhookResponse := toWebhookResponse(&webhook)
+	return &webhookResponse, nil
+}
+
+func (s *WebhookServiceImpl) DeleteWebhook(ctx context.Context, webhookID int) *response.CustomError {
+	var webhook model.Webhook
+	if err := s.WebhookRepository.FindByID(s.DB, &webhook, webhookID); err != nil {
+		return response.NotFoundError("Webhook not found.")
+	}
+
+	if err := s.WebhookRepository.Delete(s.DB, webhookID); err != nil {
+		return response.NotFoundError("Webhook not found.")
+	}
+	auditlog.Record(ctx, model.AuditEntityWebhook, webhookID, webhook, nil)
+	return nil
+}
+
+func (s *WebhookServiceImpl) Dispatch(ctx context.Context) (*web.WebhookDispatchResponse, *response.CustomError) {
+	dispatchResponse := &web.WebhookDispatchResponse{}
+
+	if err := s.fanOut(dispatchResponse); err != nil {
+		return nil, response.RepositoryError("Failed to queue webhook deliveries: " + err.Error())
+	}
+
+	now := time.Now()
+	var deliveries []model.WebhookDelivery
+	// Claimed deliveries are not picked up again until the whole batch could
+	// have timed out.
+	if err := s.WebhookRepository.ClaimDueDeliveries(s.DB, &deliveries, now, now.Add(webhookDeliveryLease), webhookDeliveryBatch); err != nil {
+		return nil, response.RepositoryError("Failed to fetch webhook deliveries: " + err.Error())
+	}
+
+	webhooks := make(map[int]*model.Webhook)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook = new(model.Webhook)
+			if err := s.WebhookRepository.FindByID(s.DB, webhook, delivery.WebhookID); err != nil {
+				return nil, response.RepositoryError("Failed to fetch webhook: " + err.Error())
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		var event model.OutboxEvent
+		if err := s.OutboxEventRepository.FindByID(s.DB, &event, delivery.EventID); err != nil {
+			return nil, response.RepositoryError("Failed to fetch outbox event: " + err.Error())
+		}
+
+		statusCode, sendErr := s.send(ctx, webhook, delivery, &event)
+
+		delivery.Attempts++
+		delivery.LastStatusCode = statusCode
+		delivery.UpdatedAt = time.Now()
+
+		switch {
+		case sendErr == nil:
+			delivery.Status = model.DeliveryStatusDelivered
+			delivery.LastError = ""
+			delivery.DeliveredAt = &delivery.UpdatedAt
+			dispatchResponse.Delivered++
+		case delivery.Attempts >= s.MaxAttempts:
+			delivery.Status = model.DeliveryStatusDead
+			delivery.LastError = sendErr.Error()
+			dispatchResponse.DeadLetters++
+			log.Printf("webhook: delivery %d of event %d to %s is dead after %d attempts: %v", delivery.ID, event.ID, webhook.URL, delivery.Attempts, sendErr)
+		default:
+			delivery.LastError = sendErr.Error()
+			delivery.NextAttemptAt = delivery.UpdatedAt.Add(retryDelay(delivery.Attempts))
+			dispatchResponse.Retried++
+		}
+
+		if err := s.WebhookRepository.UpdateDelivery(s.DB, delivery); err != nil {
+			return nil, response.RepositoryError("Failed to update webhook delivery: " + err.Error())
+		}
+	}
+
+	return dispatchResponse, nil
+}
+
+// fanOut queues the new outbox events for every active webhook subscribed to
+// them and marks them dispatched, in one transaction.
+func (s *WebhookServiceImpl) fanOut(dispatchResponse *web.WebhookDispatchResponse) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var events []model.OutboxEvent
+		if err := s.OutboxEventRepository.FindUndispatched(tx, &events, webhookEventBatch); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		var webhooks []model.Webhook
+		if err := s.WebhookRepository.FindAll(tx, &webhooks, true); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		eventIDs := make([]int, 0, len(events))
+		for _, event := range events {
+			for i := range webhooks {
+				if !webhooks[i].Subscribes(event.EventType) {
+					continue
+				}
+
+				delivery := &model.WebhookDelivery{
+					WebhookID:     webhooks[i].ID,
+					EventID:       event.ID,
+					Status:        model.DeliveryStatusPending,
+					NextAttemptAt: now,
+					CreatedAt:     now,
+					UpdatedAt:     now,
+				}
+				if err := s.WebhookRepository.SaveDelivery(tx, delivery); err != nil {
+					return err
+				}
+				dispatchResponse.Deliveries++
+			}
+			eventIDs = append(eventIDs, event.ID)
+		}
+
+		dispatchResponse.Events = len(eventIDs)
+		return s.OutboxEventRepository.MarkDispatched(tx, eventIDs, now)
+	})
+}
+
+// send posts the event to the webhook. Any response outside 2xx is an error.
+func (s *WebhookServiceImpl) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery, event *model.OutboxEvent) (int, error) {
+	body, err := json.Marshal(web.WebhookEvent{
+		ID:         event.ID,
+		Type:       event.EventType,
+		OccurredAt: event.CreatedAt,
+		Data:       json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Event", event.EventType)
+	request.Header.Set("X-Webhook-Event-ID", strconv.Itoa(event.ID))
+	request.Header.Set("X-Webhook-Delivery", strconv.Itoa(delivery.ID))
+	request.Header.Set(WebhookSignatureHeader, "t="+timestamp+",v1="+webhookSignature(webhook.Secret, timestamp, body))
+
+	result, err := s.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer result.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(result.Body, 64<<10))
+
+	if result.StatusCode < 200 || result.StatusCode > 299 {
+		return result.StatusCode, errors.New("webhook responded " + result.Status)
+	}
+	return result.StatusCode, nil
+}
+
+func (s *WebhookServiceImpl) FindDeadLetters(ctx context.Context, query *web.WebhookDeliveryQuery) ([]web.WebhookDeliveryResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = webhookDefaultLimit
+	}
+
+	var details []model.WebhookDeliveryDetail
+	if err := s.WebhookRepository.FindDeliveryDetails(s.DB, &details, model.DeliveryStatusDead, query.WebhookID, limit); err != nil {
+		return nil, response.RepositoryError("Failed to fetch dead letters: " + err.Error())
+	}
+
+	deliveryResponses := make([]web.WebhookDeliveryResponse, 0, len(details))
+	for i := range details {
+		deliveryResponse := toWebhookDeliveryResponse(&details[i].WebhookDelivery)
+		deliveryResponse.URL = details[i].URL
+		deliveryResponse.EventType = details[i].EventType
+		deliveryResponses = append(deliveryResponses, deliveryResponse)
+	}
+	return deliveryResponses, nil
+}
+
+func (s *WebhookServiceImpl) RetryDelivery(ctx context.Context, deliveryID int) (*web.WebhookDeliveryResponse, *response.CustomError) {
+	var delivery model.WebhookDelivery
+	if err := s.WebhookRepository.FindDeliveryByID(s.DB, &delivery, deliveryID); err != nil {
+		return nil, response.NotFoundError("Webhook delivery not found.")
+	}
+
+	if delivery.Status != model.DeliveryStatusDead {
+		return nil, response.BadRequestError("Only dead deliveries can be retried.")
+	}
+
+	before := delivery
+	now := time.Now()
+	delivery.Status = model.DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	if err := s.WebhookRepository.UpdateDelivery(s.DB, &delivery); err != nil {
+		return nil, response.RepositoryError("Failed to retry webhook delivery: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWebhookDelivery, delivery.ID, before, delivery)
+
+	deliveryResponse := toWebhookDeliveryResponse(&delivery)
+	return &deliveryResponse, nil
+}
+
+// newWebhookClient returns the client deliveries are sent with. It refuses
+// to connect to addresses that are not public, checked on every dial so a
+// host name resolving to an internal address later, or a redirect, can not
+// reach the internal network either.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+				return errors.New("webhook target " + host + " is not a public address")
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: webhookTimeout, Transport: transport}
+}
+
+// checkWebhookURL accepts http and https URLs whose host resolves to public
+// addresses only.
+func checkWebhookURL(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+
+	host := target.Hostname()
+	if host == "" {
+		return errors.New("host is missing")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicAddress(ip) {
+			return errors.New(host + " is not a public address")
+		}
+		return nil
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.New("host " + host + " can not be resolved")
+	}
+	for _, address := range addresses {
+		if !publicAddress(address.IP) {
+			return errors.New(host + " resolves to " + address.IP.String() + ", which is not a public address")
+		}
+	}
+	return nil
+}
+
+// publicAddress reports whether ip is a public unicast address, not a
+// loopback, private, link-local, shared (CGNAT) or unspecified one.
+func publicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// 0.0.0.0/8, 100.64.0.0/10 and the broadcast address
+		if ip4[0] == 0 || (ip4[0] == 100 && ip4[1]&0xc0 == 64) || ip4.Equal(net.IPv4bcast) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookSignature is the hex HMAC-SHA256 of "<timestamp>.<body>", the
+// timestamp lets receivers reject replayed requests.
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay is the wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := webhookRetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookMaxRetryDelay)
+}
+
+func toWebhookResponse(webhook *model.Webhook) web.WebhookResponse {
+	eventTypes := []string{}
+	if webhook.EventTypes != "" {
+		eventTypes = strings.Split(webhook.EventTypes, ",")
+	}
+
+	return web.WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: eventTypes,
+		Active:     webhook.Active,
+		CreatedAt:  webhook.CreatedAt,
+		UpdatedAt:  webhook.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *model.WebhookDelivery) web.WebhookDeliveryResponse {
+	return web.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
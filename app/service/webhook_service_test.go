@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{50, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	tests := []struct {
+		name              string
+		secret, timestamp string
+		body              string
+		want              string
+	}{
+		{"event", "s3cr3t-key-0123456789", "1792396800", `{"id":1,"type":"ContainerPlaced"}`, "8ff822716646521093158365b34d76ca8a75f42ae3b64460cd985e0fbf01e205"},
+		{"empty body", "s3cr3t-key-0123456789", "1792396800", "", "b5127a1cdd9f6c523f24e8227633ec39c67019eec3ea274524c045013085f504"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookSignature(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("webhookSignature = %s, want %s", got, tt.want)
+			}
+			if webhookSignature("another-secret-0123", tt.timestamp, []byte(tt.body)) == tt.want {
+				t.Error("signature does not depend on the secret")
+			}
+			if webhookSignature(tt.secret, "1792396801", []byte(tt.body)) == tt.want {
+				t.Error("signature does not depend on the timestamp")
+			}
+		})
+	}
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"203.0.113.10", true},
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.10", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"255.255.255.255", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := publicAddress(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("publicAddress(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://203.0.113.10/hooks/yard", false},
+		{"http://[2001:db8::1]:8080/hook", false},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"https://10.0.0.5/hook", true},
+		{"http://[::1]/hook", true},
+		{"ftp://203.0.113.10/hook", true},
+		{"https:///hook", true},
+		{"://bad", true},
+	}
+
+	for _, tt := range tests {
+		if err := checkWebhookURL(context.Background(), tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("checkWebhookURL(%q) error = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWebhookClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if _, err := newWebhookClient().Post(server.URL, "application/json", nil); err == nil {
+		t.Errorf("request to %s succeeded, want it refused", server.URL)
+	}
+}
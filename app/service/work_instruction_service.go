@@ -0,0 +1,904 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type WorkInstructionService interface {
+	// QueuePlacement checks the placement like CheckPlacement and queues it
+	// for the equipment of the block, the container is stored when the job is
+	// confirmed.
+	QueuePlacement(ctx context.Context, request *web.PlacementRequest) (*web.WorkInstructionResponse, *response.CustomError)
+	QueuePlacements(ctx context.Context, request *web.BatchPlacementRequest) (*web.BatchResponse, *response.CustomError)
+	QueuePickup(ctx context.Context, request *web.PickupRequest) (*web.WorkInstructionResponse, *response.CustomError)
+	QueueMove(ctx context.Context, request *web.MoveRequest) (*web.WorkInstructionResponse, *response.CustomError)
+
+	// QueueHousekeepingTx queues the moves of a housekeeping plan in plan
+	// order in the transaction tx of the caller. The moves are not checked
+	// one by one, a later move may depend on a cell an earlier one frees,
+	// only cells reserved for other containers are refused. Every move is
+	// chained to the one before it, so it is dispatched and confirmed only
+	// after it. The audit records and the dispatch are added to after, which
+	// also fills in the equipment of the returned instructions.
+	QueueHousekeepingTx(ctx context.Context, tx *gorm.DB, moves []web.HousekeepingMove, after *AfterCommit) ([]web.WorkInstructionResponse, *response.CustomError)
+
+	FindInstructions(ctx context.Context, query *web.WorkInstructionQuery) ([]web.WorkInstructionResponse, *response.CustomError)
+	FindInstruction(ctx context.Context, instructionID int) (*web.WorkInstructionResponse, *response.CustomError)
+	// EquipmentQueue lists the pending jobs of the equipment followed by the
+	// unassigned pending jobs of its blocks.
+	EquipmentQueue(ctx context.Context, equipmentID int) ([]web.WorkInstructionResponse, *response.CustomError)
+
+	ConfirmInstruction(ctx context.Context, instructionID int, request *web.ConfirmWorkInstructionRequest) (*web.WorkInstructionResponse, *response.CustomError)
+	RejectInstruction(ctx context.Context, instructionID int, request *web.RejectWorkInstructionRequest) (*web.WorkInstructionResponse, *response.CustomError)
+}
+
+type WorkInstructionServiceImpl struct {
+	ContainerService            ContainerService
+	YardRepository              repository.YardRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	EquipmentRepository         repository.EquipmentRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	DispatchService             DispatchService
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewWorkInstructionService(
+	containerService ContainerService,
+	yardRepo repository.YardRepository,
+	containerRepo repository.ContainerPositionRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	equipmentRepo repository.EquipmentRepository,
+	outboxRepo repository.OutboxEventRepository,
+	dispatchService DispatchService,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) WorkInstructionService {
+	return &WorkInstructionServiceImpl{
+		ContainerService:            containerService,
+		YardRepository:              yardRepo,
+		ContainerPositionRepository: containerRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		EquipmentRepository:         equipmentRepo,
+		OutboxEventRepository:       outboxRepo,
+		DispatchService:             dispatchService,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *WorkInstructionServiceImpl) QueuePlacement(ctx context.Context, request *web.PlacementRequest) (*web.WorkInstructionResponse, *response.CustomError) {
+	position, customErr := s.ContainerService.CheckPlacement(ctx, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var instruction *model.WorkInstruction
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockYards(tx, yard.ID); err != nil {
+			return err
+		}
+
+		holders, err := s.cellHolders(tx)
+		if err != nil {
+			return err
+		}
+
+		instruction, customErr = s.newPlacementInstruction(tx, yard.ID, request, position, holders)
+		if customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return s.save(tx, instruction)
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to queue placement: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, nil, instruction)
+	s.redispatch(ctx, instruction.YardID, instruction)
+
+	instructionResponse := s.toWorkInstructionResponse(instruction)
+	return &instructionResponse, nil
+}
+
+func (s *WorkInstructionServiceImpl) QueuePlacements(ctx context.Context, request *web.BatchPlacementRequest) (*web.BatchResponse, *response.CustomError) {
+	batchResponse, customErr := s.ContainerService.CheckPlacements(ctx, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	results := batchResponse.Results
+	yards := make(map[string]int)
+	for i := range results {
+		if !results[i].Success {
+			continue
+		}
+		yardName := request.Containers[i].YardName
+		if _, ok := yards[yardName]; ok {
+			continue
+		}
+		var yard model.Yard
+		if err := s.YardRepository.FindYardByName(s.DB, &yard, yardName); err != nil {
+			return nil, response.NotFoundError("Yard " + yardName + " not found.")
+		}
+		yards[yardName] = yard.ID
+	}
+	yardIDs := make([]int, 0, len(yards))
+	for _, yardID := range yards {
+		yardIDs = append(yardIDs, yardID)
+	}
+
+	instructions := make([]*model.WorkInstruction, len(results))
+	failed := 0
+	mode := batchResponse.Mode
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockYards(tx, yardIDs...); err != nil {
+			return err
+		}
+
+		holders, err := s.cellHolders(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := range results {
+			if !results[i].Success {
+				failed++
+				continue
+			}
+
+			item := &request.Containers[i]
+			instruction, customErr := s.newPlacementInstruction(tx, yards[item.YardName], item, results[i].Position, holders)
+			if customErr != nil {
+				results[i].Success = false
+				results[i].Position = nil
+				results[i].Error = customErr.Message
+				failed++
+				continue
+			}
+
+			// Later items of the batch must not get the same cell
+			for _, key := range coveredCells(*instruction.ToBlockID, *instruction.ToSlot, *instruction.ToRow, *instruction.ToTier, instruction.ContainerSize) {
+				holders[key] = instruction.ContainerNumber
+			}
+			instructions[i] = instruction
+		}
+
+		if mode == web.BatchModeAllOrNothing && failed > 0 {
+			return errBatchRolledBack
+		}
+
+		for i, instruction := range instructions {
+			if instruction == nil {
+				continue
+			}
+			if err := s.save(tx, instruction); err != nil {
+				return err
+			}
+			results[i].WorkInstructionID = &instruction.ID
+		}
+		return nil
+	})
+
+	if errors.Is(txErr, errBatchRolledBack) {
+		for i := range results {
+			if results[i].Success {
+				results[i].Success = false
+				results[i].Position = nil
+				results[i].Error = "Rolled back because another container in the batch failed."
+			}
+		}
+
+		customErr := response.BadRequestError("Batch placement rolled back: " + strconv.Itoa(failed) + " container(s) could not be placed.")
+		customErr.AdditionalInfo = newBatchResponse(mode, results)
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to queue placements: " + txErr.Error())
+	}
+
+	dispatched := make(map[int]bool)
+	for _, instruction := range instructions {
+		if instruction != nil {
+			auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, nil, instruction)
+		}
+		if instruction != nil && !dispatched[instruction.YardID] {
+			dispatched[instruction.YardID] = true
+			s.redispatch(ctx, instruction.YardID)
+		}
+	}
+
+	return newBatchResponse(mode, results), nil
+}
+
+func (s *WorkInstructionServiceImpl) QueuePickup(ctx context.Context, request *web.PickupRequest) (*web.WorkInstructionResponse, *response.CustomError) {
+	releaseOrderID, customErr := s.ContainerService.AuthorizePickup(ctx, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var instruction model.WorkInstruction
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockYards(tx, yard.ID); err != nil {
+			return err
+		}
+
+		var container model.ContainerPosition
+		if err := s.ContainerPositionRepository.FindByContainerNumber(tx, &container, request.ContainerNumber); err != nil {
+			customErr = response.NotFoundError("Container not found at any position or already picked up.")
+			return errors.New(customErr.Message)
+		}
+
+		if customErr = s.checkNoPendingInstruction(tx, request.ContainerNumber); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		instruction = newWorkInstruction(model.WorkTypePickup, yard.ID, container.BlockID, &container)
+		instruction.SetFrom(container.BlockID, container.SlotNumber, container.RowNumber, container.TierNumber)
+		instruction.ReleaseOrderID = &releaseOrderID
+
+		return s.WorkInstructionRepository.Save(tx, &instruction)
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to queue pickup: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, nil, instruction)
+	s.redispatch(ctx, instruction.YardID, &instruction)
+
+	instructionResponse := s.toWorkInstructionResponse(&instruction)
+	return &instructionResponse, nil
+}
+
+func (s *WorkInstructionServiceImpl) QueueMove(ctx context.Context, request *web.MoveRequest) (*web.WorkInstructionResponse, *response.CustomError) {
+	position, customErr := s.ContainerService.CheckMove(ctx, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	var detail model.ContainerPositionDetail
+	if err := s.ContainerPositionRepository.FindDetailByContainerNumber(s.DB, &detail, request.ContainerNumber); err != nil {
+		return nil, response.NotFoundError("Container not found at any position.")
+	}
+	container := detail.ContainerPosition
+	if request.Revision != 0 && container.Revision != request.Revision {
+		return nil, staleContainerError(&container)
+	}
+
+	var toBlock model.Block
+	if err := s.YardRepository.FindBlockByID(s.DB, &toBlock, position.BlockID); err != nil {
+		return nil, response.NotFoundError("Block not found.")
+	}
+
+	instruction := newWorkInstruction(model.WorkTypeMove, detail.YardID, container.BlockID, &container)
+	instruction.SetFrom(container.BlockID, container.SlotNumber, container.RowNumber, container.TierNumber)
+	instruction.SetTo(position.BlockID, position.Slot, position.Row, position.Tier)
+	instruction.ContainerRevision = &container.Revision
+	instruction.Reason = request.Reason
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.lockYards(tx, detail.YardID, toBlock.YardID); err != nil {
+			return err
+		}
+
+		if customErr = s.checkNoPendingInstruction(tx, request.ContainerNumber); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		holders, err := s.cellHolders(tx)
+		if err != nil {
+			return err
+		}
+		if customErr = checkCellHolder(holders, request.ContainerNumber, position, container.ContainerSize); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		return s.save(tx, &instruction)
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to queue move: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, nil, instruction)
+	s.redispatch(ctx, instruction.YardID, &instruction)
+
+	instructionResponse := s.toWorkInstructionResponse(&instruction)
+	return &instructionResponse, nil
+}
+
+func (s *WorkInstructionServiceImpl) QueueHousekeepingTx(ctx context.Context, tx *gorm.DB, moves []web.HousekeepingMove, after *AfterCommit) ([]web.WorkInstructionResponse, *response.CustomError) {
+	instructions := make([]model.WorkInstruction, 0, len(moves))
+	yardIDs := make([]int, 0, 1)
+
+	for _, move := range moves {
+		var detail model.ContainerPositionDetail
+		if err := s.ContainerPositionRepository.FindDetailByContainerNumber(tx, &detail, move.ContainerNumber); err != nil {
+			return nil, response.NotFoundError("Container " + move.ContainerNumber + " not found at any position.")
+		}
+
+		instruction := newWorkInstruction(model.WorkTypeMove, detail.YardID, move.From.BlockID, &detail.ContainerPosition)
+		instruction.SetFrom(move.From.BlockID, move.From.Slot, move.From.Row, move.From.Tier)
+		instruction.SetTo(move.To.BlockID, move.To.Slot, move.To.Row, move.To.Tier)
+		instruction.ContainerRevision = &detail.Revision
+		instruction.Reason = "HOUSEKEEPING: " + move.Reason
+		instructions = append(instructions, instruction)
+		yardIDs = append(yardIDs, detail.YardID)
+	}
+
+	if err := s.lockYards(tx, yardIDs...); err != nil {
+		return nil, response.RepositoryError("Failed to lock yard: " + err.Error())
+	}
+
+	holders, err := s.cellHolders(tx)
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch reserved cells: " + err.Error())
+	}
+
+	for i := range instructions {
+		instruction := &instructions[i]
+		if customErr := s.checkNoPendingInstruction(tx, instruction.ContainerNumber); customErr != nil {
+			return nil, customErr
+		}
+
+		to := &moves[i].To
+		if customErr := checkCellHolder(holders, instruction.ContainerNumber, to, instruction.ContainerSize); customErr != nil {
+			return nil, customErr
+		}
+		for _, key := range coveredCells(to.BlockID, to.Slot, to.Row, to.Tier, instruction.ContainerSize) {
+			holders[key] = instruction.ContainerNumber
+		}
+
+		if i > 0 {
+			instruction.PredecessorID = &instructions[i-1].ID
+		}
+
+		if err := s.save(tx, instruction); err != nil {
+			return nil, response.RepositoryError("Failed to queue housekeeping moves: " + err.Error())
+		}
+	}
+
+	instructionResponses := s.toWorkInstructionResponses(instructions)
+	after.add(func() {
+		for _, instruction := range instructions {
+			auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, nil, instruction)
+		}
+
+		if len(instructions) > 0 {
+			queued := make([]*model.WorkInstruction, 0, len(instructions))
+			for i := range instructions {
+				queued = append(queued, &instructions[i])
+			}
+			s.redispatch(ctx, instructions[0].YardID, queued...)
+
+			// The returned responses share their backing array with the
+			// caller, refresh them with the assigned equipment.
+			for i := range instructions {
+				instructionResponses[i] = s.toWorkInstructionResponse(&instructions[i])
+			}
+		}
+	})
+
+	return instructionResponses, nil
+}
+
+func (s *WorkInstructionServiceImpl) FindInstructions(ctx context.Context, query *web.WorkInstructionQuery) ([]web.WorkInstructionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	filter := repository.WorkInstructionFilter{
+		EquipmentID: query.EquipmentID,
+		Status:      query.Status,
+	}
+
+	if query.YardName != "" {
+		var yard model.Yard
+		if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+			return nil, response.NotFoundError("Yard not found.")
+		}
+		filter.YardID = yard.ID
+	}
+
+	var instructions []model.WorkInstruction
+	if err := s.WorkInstructionRepository.Find(s.DB, &filter, &instructions); err != nil {
+		return nil, response.RepositoryError("Failed to fetch work instructions: " + err.Error())
+	}
+
+	return s.toWorkInstructionResponses(instructions), nil
+}
+
+func (s *WorkInstructionServiceImpl) FindInstruction(ctx context.Context, instructionID int) (*web.WorkInstructionResponse, *response.CustomError) {
+	var instruction model.WorkInstruction
+	if err := s.WorkInstructionRepository.FindByID(s.DB, &instruction, instructionID); err != nil {
+		return nil, response.NotFoundError("Work instruction not found.")
+	}
+
+	instructionResponse := s.toWorkInstructionResponse(&instruction)
+	return &instructionResponse, nil
+}
+
+func (s *WorkInstructionServiceImpl) EquipmentQueue(ctx context.Context, equipmentID int) ([]web.WorkInstructionResponse, *response.CustomError) {
+	var equipment model.Equipment
+	if err := s.EquipmentRepository.FindByID(s.DB, &equipment, equipmentID); err != nil {
+		return nil, response.NotFoundError("Equipment not found.")
+	}
+
+	var instructions []model.WorkInstruction
+	assigned := repository.WorkInstructionFilter{EquipmentID: equipment.ID, Status: model.WorkStatusPending, SortBySequence: true}
+	if err := s.WorkInstructionRepository.Find(s.DB, &assigned, &instructions); err != nil {
+		return nil, response.RepositoryError("Failed to fetch work instructions: " + err.Error())
+	}
+
+	for _, blockID := range equipment.BlockIDs {
+		var unassigned []model.WorkInstruction
+		filter := repository.WorkInstructionFilter{BlockID: blockID, Unassigned: true, Status: model.WorkStatusPending}
+		if err := s.WorkInstructionRepository.Find(s.DB, &filter, &unassigned); err != nil {
+			return nil, response.RepositoryError("Failed to fetch work instructions: " + err.Error())
+		}
+		instructions = append(instructions, unassigned...)
+	}
+
+	return s.toWorkInstructionResponses(instructions), nil
+}
+
+func (s *WorkInstructionServiceImpl) ConfirmInstruction(ctx context.Context, instructionID int, request *web.ConfirmWorkInstructionRequest) (*web.WorkInstructionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var instruction *model.WorkInstruction
+	var before model.WorkInstruction
+	var customErr *response.CustomError
+	after := &AfterCommit{}
+
+	// The container change and the confirmation commit together, with the
+	// instruction row locked so a concurrent confirm or reject waits.
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		instruction, customErr = s.findPending(tx, instructionID)
+		if customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		before = *instruction
+
+		yardIDs, err := s.instructionYardIDs(tx, instruction)
+		if err != nil {
+			return err
+		}
+		if err := s.lockYards(tx, yardIDs...); err != nil {
+			return err
+		}
+
+		if customErr = s.checkPredecessor(tx, instruction); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		if request.EquipmentID != 0 {
+			var equipment model.Equipment
+			if err := s.EquipmentRepository.FindByID(tx, &equipment, request.EquipmentID); err != nil || equipment.YardID != instruction.YardID {
+				customErr = response.NotFoundError("Equipment not found in the yard of the work instruction.")
+				return errors.New(customErr.Message)
+			}
+			instruction.EquipmentID = &equipment.ID
+		}
+
+		if customErr = s.execute(ctx, tx, instruction, after); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		now := time.Now()
+		instruction.Status = model.WorkStatusConfirmed
+		instruction.Operator = request.Operator
+		instruction.CompletedAt = &now
+		instruction.UpdatedAt = now
+
+		return s.complete(tx, instruction)
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to confirm work instruction: " + txErr.Error())
+	}
+	after.Run()
+	auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, before, instruction)
+
+	// The equipment is now where it put the container down
+	if instruction.EquipmentID != nil {
+		blockID, slot := instruction.End()
+		if err := s.EquipmentRepository.UpdateLocation(s.DB, *instruction.EquipmentID, blockID, slot); err != nil {
+			log.Printf("dispatch: failed to update location of equipment %d: %v", *instruction.EquipmentID, err)
+		}
+	}
+	s.redispatch(ctx, instruction.YardID)
+
+	instructionResponse := s.toWorkInstructionResponse(instruction)
+	return &instructionResponse, nil
+}
+
+func (s *WorkInstructionServiceImpl) RejectInstruction(ctx context.Context, instructionID int, request *web.RejectWorkInstructionRequest) (*web.WorkInstructionResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var instruction *model.WorkInstruction
+	var before model.WorkInstruction
+	var customErr *response.CustomError
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		instruction, customErr = s.findPending(tx, instructionID)
+		if customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		before = *instruction
+		now := time.Now()
+		instruction.Status = model.WorkStatusRejected
+		instruction.Operator = request.Operator
+		instruction.RejectReason = request.Reason
+		instruction.CompletedAt = &now
+		instruction.UpdatedAt = now
+
+		return s.complete(tx, instruction)
+	})
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to reject work instruction: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityWorkInstruction, instruction.ID, before, instruction)
+	s.redispatch(ctx, instruction.YardID)
+
+	instructionResponse := s.toWorkInstructionResponse(instruction)
+	return &instructionResponse, nil
+}
+
+// execute performs the container change the instruction describes in tx. A
+// failed check leaves the instruction pending, the operator can retry or
+// reject it.
+func (s *WorkInstructionServiceImpl) execute(ctx context.Context, tx *gorm.DB, instruction *model.WorkInstruction, after *AfterCommit) *response.CustomError {
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByID(tx, &yard, instruction.YardID); err != nil {
+		return response.NotFoundError("Yard not found.")
+	}
+
+	switch instruction.WorkType {
+	case model.WorkTypePlacement:
+		var block model.Block
+		if err := s.YardRepository.FindBlockByID(tx, &block, *instruction.ToBlockID); err != nil {
+			return response.NotFoundError("Block not found.")
+		}
+
+		_, customErr := s.ContainerService.PlaceContainerTx(ctx, tx, &web.PlacementRequest{
+			YardName:        yard.Name,
+			ContainerNumber: instruction.ContainerNumber,
+			BlockName:       block.Name,
+			Slot:            *instruction.ToSlot,
+			Row:             *instruction.ToRow,
+			Tier:            *instruction.ToTier,
+			Size:            instruction.ContainerSize,
+			Height:          instruction.ContainerHeight,
+			Type:            instruction.ContainerType,
+			Vessel:          instruction.Vessel,
+			Voyage:          instruction.Voyage,
+			ShippingLine:    instruction.ShippingLine,
+		}, after)
+		return customErr
+
+	case model.WorkTypePickup:
+		return s.ContainerService.CompletePickupTx(ctx, tx, instruction.ContainerNumber, *instruction.ReleaseOrderID, after)
+
+	case model.WorkTypeMove:
+		var container model.ContainerPosition
+		if err := s.ContainerPositionRepository.FindByContainerNumber(tx, &container, instruction.ContainerNumber); err != nil {
+			return response.NotFoundError("Container not found at any position.")
+		}
+		if container.BlockID != *instruction.FromBlockID || container.SlotNumber != *instruction.FromSlot ||
+			container.RowNumber != *instruction.FromRow || container.TierNumber != *instruction.FromTier {
+			return response.GeneralError("Container " + instruction.ContainerNumber + " is no longer at the source position of the instruction.")
+		}
+		if instruction.ContainerRevision != nil && container.Revision != *instruction.ContainerRevision {
+			return staleContainerError(&container)
+		}
+
+		var block model.Block
+		if err := s.YardRepository.FindBlockByID(tx, &block, *instruction.ToBlockID); err != nil {
+			return response.NotFoundError("Block not found.")
+		}
+
+		// A transfer is queued in the yard the container leaves
+		toYardName := ""
+		if block.YardID != yard.ID {
+			var toYard model.Yard
+			if err := s.YardRepository.FindYardByID(tx, &toYard, block.YardID); err != nil {
+				return response.NotFoundError("Yard not found.")
+			}
+			toYardName = toYard.Name
+		}
+
+		_, customErr := s.ContainerService.MoveContainerTx(ctx, tx, &web.MoveRequest{
+			YardName:        yard.Name,
+			ToYardName:      toYardName,
+			ContainerNumber: instruction.ContainerNumber,
+			BlockName:       block.Name,
+			Slot:            *instruction.ToSlot,
+			Row:             *instruction.ToRow,
+			Tier:            *instruction.ToTier,
+			Reason:          instruction.Reason,
+			Revision:        container.Revision,
+		}, after)
+		return customErr
+	}
+
+	return response.GeneralError("Unknown work type " + instruction.WorkType + ".")
+}
+
+// save stores a new instruction and publishes the reservation of its
+// destination.
+func (s *WorkInstructionServiceImpl) save(db *gorm.DB, instruction *model.WorkInstruction) error {
+	if err := s.WorkInstructionRepository.Save(db, instruction); err != nil {
+		return err
+	}
+	return publishReservationChanged(db, s.OutboxEventRepository, workReservation(instruction), ReservationChangeReserved)
+}
+
+// complete stores a confirmed or rejected instruction and publishes that its
+// destination is no longer reserved.
+func (s *WorkInstructionServiceImpl) complete(db *gorm.DB, instruction *model.WorkInstruction) error {
+	if err := s.WorkInstructionRepository.Complete(db, instruction); err != nil {
+		return err
+	}
+	return publishReservationChanged(db, s.OutboxEventRepository, workReservation(instruction), ReservationChangeReleased)
+}
+
+// findPending locks the pending instruction until the transaction db ends.
+func (s *WorkInstructionServiceImpl) findPending(db *gorm.DB, instructionID int) (*model.WorkInstruction, *response.CustomError) {
+	var instruction model.WorkInstruction
+	if err := s.WorkInstructionRepository.FindByIDForUpdate(db, &instruction, instructionID); err != nil {
+		return nil, response.NotFoundError("Work instruction not found.")
+	}
+
+	if instruction.Status != model.WorkStatusPending {
+		return nil, response.BadRequestError("Work instruction is already " + instruction.Status + ".")
+	}
+	return &instruction, nil
+}
+
+// checkPredecessor refuses to confirm an instruction while the instruction
+// chained before it is still pending. A rejected predecessor does not block,
+// the checks of the instruction itself then decide.
+func (s *WorkInstructionServiceImpl) checkPredecessor(db *gorm.DB, instruction *model.WorkInstruction) *response.CustomError {
+	if instruction.PredecessorID == nil {
+		return nil
+	}
+
+	var predecessor model.WorkInstruction
+	if err := s.WorkInstructionRepository.FindByID(db, &predecessor, *instruction.PredecessorID); err != nil {
+		return nil
+	}
+	if predecessor.Status == model.WorkStatusPending {
+		return response.ConflictError("Work instruction " + strconv.Itoa(predecessor.ID) + " for container " + predecessor.ContainerNumber + " has to be confirmed first.")
+	}
+	return nil
+}
+
+// instructionYardIDs returns the yard of the instruction and, for a transfer,
+// the yard of its destination.
+func (s *WorkInstructionServiceImpl) instructionYardIDs(db *gorm.DB, instruction *model.WorkInstruction) ([]int, error) {
+	yardIDs := []int{instruction.YardID}
+	if instruction.ToBlockID != nil {
+		var block model.Block
+		if err := s.YardRepository.FindBlockByID(db, &block, *instruction.ToBlockID); err != nil {
+			return nil, err
+		}
+		yardIDs = append(yardIDs, block.YardID)
+	}
+	return yardIDs, nil
+}
+
+// lockYards locks the blocks of the yards in ID order until the transaction
+// db ends, so reservations read afterwards stay valid until the instruction
+// is stored.
+func (s *WorkInstructionServiceImpl) lockYards(db *gorm.DB, yardIDs ...int) error {
+	sorted := slices.Clone(yardIDs)
+	slices.Sort(sorted)
+	for _, yardID := range slices.Compact(sorted) {
+		if err := s.YardRepository.LockYardBlocks(db, yardID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WorkInstructionServiceImpl) newPlacementInstruction(db *gorm.DB, yardID int, request *web.PlacementRequest, position *web.PositionResponse, holders map[cellKey]string) (*model.WorkInstruction, *response.CustomError) {
+	if customErr := s.checkNoPendingInstruction(db, request.ContainerNumber); customErr != nil {
+		return nil, customErr
+	}
+	if customErr := checkCellHolder(holders, request.ContainerNumber, position, request.Size); customErr != nil {
+		return nil, customErr
+	}
+
+	instruction := newWorkInstruction(model.WorkTypePlacement, yardID, position.BlockID, &model.ContainerPosition{
+		ContainerNumber: request.ContainerNumber,
+		ContainerSize:   request.Size,
+		ContainerHeight: request.Height,
+		ContainerType:   request.Type,
+		Vessel:          request.Vessel,
+		Voyage:          request.Voyage,
+		ShippingLine:    request.ShippingLine,
+	})
+	instruction.SetTo(position.BlockID, position.Slot, position.Row, position.Tier)
+	return &instruction, nil
+}
+
+func (s *WorkInstructionServiceImpl) checkNoPendingInstruction(db *gorm.DB, containerNumber string) *response.CustomError {
+	var pending model.WorkInstruction
+	if err := s.WorkInstructionRepository.FindPendingByContainerNumber(db, &pending, containerNumber); err == nil {
+		return response.BadRequestError("Container " + containerNumber + " already has pending work instruction #" + strconv.Itoa(pending.ID) + ".")
+	}
+	return nil
+}
+
+// cellHolders maps every reserved cell to the container it is held for.
+func (s *WorkInstructionServiceImpl) cellHolders(db *gorm.DB) (map[cellKey]string, error) {
+	return loadCellHolders(db, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+}
+
+// checkCellHolder rejects a destination held for another container. Cells
+// held for the container itself, such as its pre-advice slot, are allowed.
+func checkCellHolder(holders map[cellKey]string, containerNumber string, position *web.PositionResponse, size string) *response.CustomError {
+	for _, key := range coveredCells(position.BlockID, position.Slot, position.Row, position.Tier, size) {
+		if holder, ok := holders[key]; ok && holder != containerNumber {
+			return response.GeneralError("Position is reserved for container " + holder + ".")
+		}
+	}
+	return nil
+}
+
+func coveredCells(blockID, slot, row, tier int, size string) []cellKey {
+	cells := []cellKey{{BlockID: blockID, Slot: slot, Row: row, Tier: tier}}
+	if size == "40ft" {
+		cells = append(cells, cellKey{BlockID: blockID, Slot: slot + 1, Row: row, Tier: tier})
+	}
+	return cells
+}
+
+// redispatch recomputes the equipment queues of the yard and copies the new
+// assignment onto the given instructions. The jobs are already stored, so a
+// failure is only logged and the previous queues stay until the next change.
+func (s *WorkInstructionServiceImpl) redispatch(ctx context.Context, yardID int, instructions ...*model.WorkInstruction) {
+	planResponse, customErr := s.DispatchService.Dispatch(ctx, yardID)
+	if customErr != nil {
+		log.Printf("dispatch: yard %d: %s", yardID, customErr.Message)
+		return
+	}
+
+	for _, equipmentPlan := range planResponse.Equipment {
+		for _, job := range equipmentPlan.Jobs {
+			for _, instruction := range instructions {
+				if instruction.ID == job.WorkInstructionID {
+					equipmentID, sequence := equipmentPlan.EquipmentID, job.Sequence
+					instruction.EquipmentID, instruction.Sequence = &equipmentID, &sequence
+				}
+			}
+		}
+	}
+}
+
+func (s *WorkInstructionServiceImpl) toWorkInstructionResponses(instructions []model.WorkInstruction) []web.WorkInstructionResponse {
+	instructionResponses := make([]web.WorkInstructionResponse, 0, len(instructions))
+	for i := range instructions {
+		instructionResponses = append(instructionResponses, s.toWorkInstructionResponse(&instructions[i]))
+	}
+	return instructionResponses
+}
+
+func (s *WorkInstructionServiceImpl) toWorkInstructionResponse(instruction *model.WorkInstruction) web.WorkInstructionResponse {
+	instructionResponse := web.WorkInstructionResponse{
+		ID:              instruction.ID,
+		WorkType:        instruction.WorkType,
+		Status:          instruction.Status,
+		ContainerNumber: instruction.ContainerNumber,
+		YardID:          instruction.YardID,
+		Block:           s.blockName(instruction.BlockID),
+		EquipmentID:     instruction.EquipmentID,
+		Priority:        instruction.Priority,
+		Sequence:        instruction.Sequence,
+		PredecessorID:   instruction.PredecessorID,
+
+		ContainerSize:   instruction.ContainerSize,
+		ContainerHeight: instruction.ContainerHeight,
+		ContainerType:   instruction.ContainerType,
+
+		ReleaseOrderID: instruction.ReleaseOrderID,
+		Reason:         instruction.Reason,
+
+		Operator:     instruction.Operator,
+		RejectReason: instruction.RejectReason,
+		CreatedAt:    instruction.CreatedAt,
+		CompletedAt:  instruction.CompletedAt,
+	}
+
+	if instruction.FromBlockID != nil {
+		instructionResponse.From = &web.PositionResponse{
+			Block:   s.blockName(*instruction.FromBlockID),
+			Slot:    *instruction.FromSlot,
+			Row:     *instruction.FromRow,
+			Tier:    *instruction.FromTier,
+			BlockID: *instruction.FromBlockID,
+		}
+	}
+	if instruction.ToBlockID != nil {
+		instructionResponse.To = &web.PositionResponse{
+			Block:   s.blockName(*instruction.ToBlockID),
+			Slot:    *instruction.ToSlot,
+			Row:     *instruction.ToRow,
+			Tier:    *instruction.ToTier,
+			BlockID: *instruction.ToBlockID,
+		}
+	}
+	return instructionResponse
+}
+
+func (s *WorkInstructionServiceImpl) blockName(blockID int) string {
+	var block model.Block
+	s.YardRepository.FindBlockByID(s.DB, &block, blockID)
+	return block.Name
+}
+
+func newWorkInstruction(workType string, yardID, blockID int, container *model.ContainerPosition) model.WorkInstruction {
+	now := time.Now()
+	return model.WorkInstruction{
+		WorkType:        workType,
+		Status:          model.WorkStatusPending,
+		ContainerNumber: container.ContainerNumber,
+		YardID:          yardID,
+		BlockID:         blockID,
+		Priority:        model.WorkPriority(workType),
+		ContainerSize:   container.ContainerSize,
+		ContainerHeight: container.ContainerHeight,
+		ContainerType:   container.ContainerType,
+		Vessel:          container.Vessel,
+		Voyage:          container.Voyage,
+		ShippingLine:    container.ShippingLine,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
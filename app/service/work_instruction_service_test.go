@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/response"
+
+	"gorm.io/gorm"
+)
+
+// lockRecorder records the order in which yards are locked.
+type lockRecorder struct {
+	repository.YardRepository
+	locked []int
+}
+
+func (r *lockRecorder) LockYardBlocks(db *gorm.DB, yardID int) error {
+	r.locked = append(r.locked, yardID)
+	return nil
+}
+
+func TestLockYardsInIDOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		yardIDs []int
+		want    []int
+	}{
+		{"single yard", []int{3}, []int{3}},
+		{"transfer to a lower yard", []int{10, 1}, []int{1, 10}},
+		{"same yard twice", []int{2, 2, 1, 2}, []int{1, 2}},
+		{"none", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yards := &lockRecorder{}
+			s := &WorkInstructionServiceImpl{YardRepository: yards}
+
+			if err := s.lockYards(nil, tt.yardIDs...); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(yards.locked, tt.want) {
+				t.Errorf("locked %v, want %v", yards.locked, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCellHolder(t *testing.T) {
+	holders := map[cellKey]string{
+		{BlockID: 1, Slot: 2, Row: 1, Tier: 1}: "MSKU1234565",
+	}
+
+	tests := []struct {
+		name     string
+		owner    string
+		position web.PositionResponse
+		size     string
+		wantErr  bool
+	}{
+		{"free cell", "TGHU7654321", web.PositionResponse{BlockID: 1, Slot: 4, Row: 1, Tier: 1}, "20ft", false},
+		{"held for another container", "TGHU7654321", web.PositionResponse{BlockID: 1, Slot: 2, Row: 1, Tier: 1}, "20ft", true},
+		{"held for the container itself", "MSKU1234565", web.PositionResponse{BlockID: 1, Slot: 2, Row: 1, Tier: 1}, "20ft", false},
+		{"40ft reaching into a held cell", "TGHU7654321", web.PositionResponse{BlockID: 1, Slot: 1, Row: 1, Tier: 1}, "40ft", true},
+		{"20ft next to a held cell", "TGHU7654321", web.PositionResponse{BlockID: 1, Slot: 1, Row: 1, Tier: 1}, "20ft", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customErr := checkCellHolder(holders, tt.owner, &tt.position, tt.size)
+			if (customErr != nil) != tt.wantErr {
+				t.Errorf("checkCellHolder = %v, want error %v", customErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+// moveYard serves yard 1 with block 5 and MSKU0000001 at 5-3-2-1 at
+// revision 4.
+type moveYard struct {
+	repository.YardRepository
+}
+
+type movePositions struct {
+	repository.ContainerPositionRepository
+}
+
+func (moveYard) FindYardByID(db *gorm.DB, yard *model.Yard, yardID int) error {
+	*yard = model.Yard{ID: yardID, Name: "YRD-UTAMA"}
+	return nil
+}
+
+func (moveYard) FindBlockByID(db *gorm.DB, block *model.Block, blockID int) error {
+	*block = model.Block{ID: blockID, YardID: 1, Name: "LC05"}
+	return nil
+}
+
+func (movePositions) FindByContainerNumber(db *gorm.DB, position *model.ContainerPosition, containerNumber string) error {
+	*position = model.ContainerPosition{ID: 9, ContainerNumber: containerNumber, BlockID: 5, SlotNumber: 3, RowNumber: 2, TierNumber: 1, Revision: 4}
+	return nil
+}
+
+// moveRecorder keeps the moves it is asked to make.
+type moveRecorder struct {
+	ContainerService
+	moves []*web.MoveRequest
+}
+
+func (r *moveRecorder) MoveContainerTx(ctx context.Context, tx *gorm.DB, request *web.MoveRequest, after *AfterCommit) (*web.PositionResponse, *response.CustomError) {
+	r.moves = append(r.moves, request)
+	return &web.PositionResponse{}, nil
+}
+
+func TestExecuteMoveChecksRevision(t *testing.T) {
+	revision := func(revision int) *int { return &revision }
+
+	tests := []struct {
+		name       string
+		revision   *int
+		wantStatus int
+	}{
+		{"container unchanged", revision(4), 0},
+		{"container changed since queued", revision(3), http.StatusPreconditionFailed},
+		{"queued without revision", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves := &moveRecorder{}
+			s := &WorkInstructionServiceImpl{
+				YardRepository:              moveYard{},
+				ContainerPositionRepository: movePositions{},
+				ContainerService:            moves,
+			}
+
+			instruction := model.WorkInstruction{WorkType: model.WorkTypeMove, ContainerNumber: "MSKU0000001", YardID: 1, ContainerRevision: tt.revision}
+			instruction.SetFrom(5, 3, 2, 1)
+			instruction.SetTo(5, 7, 1, 1)
+
+			customErr := s.execute(context.Background(), nil, &instruction, &AfterCommit{})
+			if tt.wantStatus != 0 {
+				if customErr == nil || customErr.StatusCode != tt.wantStatus {
+					t.Fatalf("execute error = %v, want status %d", customErr, tt.wantStatus)
+				}
+				if len(moves.moves) != 0 {
+					t.Errorf("container moved %d times, want none", len(moves.moves))
+				}
+				return
+			}
+
+			if customErr != nil {
+				t.Fatalf("execute error = %s", customErr.Message)
+			}
+			if len(moves.moves) != 1 {
+				t.Fatalf("container moved %d times, want once", len(moves.moves))
+			}
+			if got := moves.moves[0]; got.Revision != 4 || got.Slot != 7 || got.BlockName != "LC05" {
+				t.Errorf("move = %+v, want revision 4 to LC05 slot 7", got)
+			}
+		})
+	}
+}
+
+// chainedInstructions serves instruction 10 in the given status.
+type chainedInstructions struct {
+	repository.WorkInstructionRepository
+	status string
+}
+
+func (r chainedInstructions) FindByID(db *gorm.DB, instruction *model.WorkInstruction, instructionID int) error {
+	*instruction = model.WorkInstruction{ID: instructionID, ContainerNumber: "MSKU0000002", Status: r.status}
+	return nil
+}
+
+func TestCheckPredecessor(t *testing.T) {
+	predecessorID := 10
+
+	tests := []struct {
+		name          string
+		predecessorID *int
+		status        string
+		wantStatus    int
+	}{
+		{"not chained", nil, model.WorkStatusPending, 0},
+		{"predecessor pending", &predecessorID, model.WorkStatusPending, http.StatusConflict},
+		{"predecessor confirmed", &predecessorID, model.WorkStatusConfirmed, 0},
+		{"predecessor rejected", &predecessorID, model.WorkStatusRejected, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &WorkInstructionServiceImpl{WorkInstructionRepository: chainedInstructions{status: tt.status}}
+			instruction := &model.WorkInstruction{ID: 11, ContainerNumber: "MSKU0000001", PredecessorID: tt.predecessorID}
+
+			status := 0
+			if customErr := s.checkPredecessor(nil, instruction); customErr != nil {
+				status = customErr.StatusCode
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,835 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/cache"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// auditMoveReason is the reason of the history entries an audit writes.
+const auditMoveReason = "AUDIT"
+
+type YardAuditService interface {
+	CreateAudit(ctx context.Context, request *web.YardAuditRequest) (*web.YardAuditResponse, *response.CustomError)
+	FindAudits(ctx context.Context, query *web.YardAuditQuery) ([]web.YardAuditResponse, *response.CustomError)
+	// FindAudit returns the audit with the findings of its last
+	// reconciliation.
+	FindAudit(ctx context.Context, auditID int) (*web.YardAuditResponse, *response.CustomError)
+
+	// RecordScans stores what checkers found in the cells of an open audit.
+	// Scanning a cell again, or the same container somewhere else, replaces
+	// the earlier scan.
+	RecordScans(ctx context.Context, auditID int, request *web.YardAuditScanRequest) (*web.YardAuditResponse, *response.CustomError)
+	// Reconcile compares the scans with the recorded positions of the
+	// audited blocks and replaces the findings of an earlier run. It can be
+	// run again after more scans.
+	Reconcile(ctx context.Context, auditID int) (*web.YardAuditReconciliationResponse, *response.CustomError)
+	// ApplyCorrections applies the approved findings as container moves,
+	// rejects the other pending ones and closes the audit, all or nothing.
+	ApplyCorrections(ctx context.Context, auditID int, request *web.YardAuditApplyRequest) (*web.YardAuditApplyResponse, *response.CustomError)
+}
+
+type YardAuditServiceImpl struct {
+	YardRepository              repository.YardRepository
+	YardPlanRepository          repository.YardPlanRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	ContainerMoveRepository     repository.ContainerMoveRepository
+	GateTransactionRepository   repository.GateTransactionRepository
+	PreAdviceRepository         repository.PreAdviceRepository
+	WorkInstructionRepository   repository.WorkInstructionRepository
+	ReleaseOrderRepository      repository.ReleaseOrderRepository
+	YardAuditRepository         repository.YardAuditRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	OccupancyCache              cache.BlockOccupancyCache
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewYardAuditService(
+	yardRepo repository.YardRepository,
+	planRepo repository.YardPlanRepository,
+	containerRepo repository.ContainerPositionRepository,
+	moveRepo repository.ContainerMoveRepository,
+	gateRepo repository.GateTransactionRepository,
+	preAdviceRepo repository.PreAdviceRepository,
+	workRepo repository.WorkInstructionRepository,
+	releaseRepo repository.ReleaseOrderRepository,
+	auditRepo repository.YardAuditRepository,
+	outboxRepo repository.OutboxEventRepository,
+	occupancyCache cache.BlockOccupancyCache,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) YardAuditService {
+	return &YardAuditServiceImpl{
+		YardRepository:              yardRepo,
+		YardPlanRepository:          planRepo,
+		ContainerPositionRepository: containerRepo,
+		ContainerMoveRepository:     moveRepo,
+		GateTransactionRepository:   gateRepo,
+		PreAdviceRepository:         preAdviceRepo,
+		WorkInstructionRepository:   workRepo,
+		ReleaseOrderRepository:      releaseRepo,
+		YardAuditRepository:         auditRepo,
+		OutboxEventRepository:       outboxRepo,
+		OccupancyCache:              occupancyCache,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *YardAuditServiceImpl) CreateAudit(ctx context.Context, request *web.YardAuditRequest) (*web.YardAuditResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, request.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	now := time.Now()
+	audit := &model.YardAudit{
+		YardID:    yard.ID,
+		Status:    model.AuditStatusOpen,
+		CreatedBy: request.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if request.BlockName != "" {
+		var block model.Block
+		if err := s.YardRepository.FindBlockByNameAndYardID(s.DB, &block, request.BlockName, yard.ID); err != nil {
+			return nil, response.NotFoundError("Block not found in the specified Yard.")
+		}
+		audit.BlockID = &block.ID
+	}
+
+	if err := s.YardAuditRepository.Save(s.DB, audit); err != nil {
+		return nil, response.RepositoryError("Failed to create yard audit: " + err.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityYardAudit, audit.ID, nil, audit)
+
+	auditResponse := s.toYardAuditResponse(audit, 0, nil)
+	return &auditResponse, nil
+}
+
+func (s *YardAuditServiceImpl) FindAudits(ctx context.Context, query *web.YardAuditQuery) ([]web.YardAuditResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var audits []model.YardAudit
+	if err := s.YardAuditRepository.Find(s.DB, &audits, yard.ID, query.Status); err != nil {
+		return nil, response.RepositoryError("Failed to fetch yard audits: " + err.Error())
+	}
+
+	auditResponses := make([]web.YardAuditResponse, 0, len(audits))
+	for i := range audits {
+		var scans []model.YardAuditScan
+		if err := s.YardAuditRepository.FindScans(s.DB, &scans, audits[i].ID); err != nil {
+			return nil, response.RepositoryError("Failed to fetch audit scans: " + err.Error())
+		}
+		auditResponses = append(auditResponses, s.toYardAuditResponse(&audits[i], len(scans), nil))
+	}
+	return auditResponses, nil
+}
+
+func (s *YardAuditServiceImpl) FindAudit(ctx context.Context, auditID int) (*web.YardAuditResponse, *response.CustomError) {
+	var audit model.YardAudit
+	if err := s.YardAuditRepository.FindByID(s.DB, &audit, auditID); err != nil {
+		return nil, response.NotFoundError("Yard audit not found.")
+	}
+
+	var scans []model.YardAuditScan
+	if err := s.YardAuditRepository.FindScans(s.DB, &scans, audit.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit scans: " + err.Error())
+	}
+
+	var findings []model.YardAuditFinding
+	if err := s.YardAuditRepository.FindFindings(s.DB, &findings, audit.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit findings: " + err.Error())
+	}
+
+	auditResponse := s.toYardAuditResponse(&audit, len(scans), findings)
+	return &auditResponse, nil
+}
+
+func (s *YardAuditServiceImpl) RecordScans(ctx context.Context, auditID int, request *web.YardAuditScanRequest) (*web.YardAuditResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	audit, customErr := s.findOpenAudit(s.DB, auditID)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	blocks := make(map[string]*model.Block)
+	now := time.Now()
+	saved := make([]*model.YardAuditScan, 0, len(request.Scans))
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range request.Scans {
+			item := &request.Scans[i]
+
+			block, ok := blocks[item.BlockName]
+			if !ok {
+				block = new(model.Block)
+				if err := s.YardRepository.FindBlockByNameAndYardID(tx, block, item.BlockName, audit.YardID); err != nil {
+					customErr = response.NotFoundError("Block " + item.BlockName + " not found in the audited Yard.")
+					return err
+				}
+				blocks[item.BlockName] = block
+			}
+
+			if audit.BlockID != nil && *audit.BlockID != block.ID {
+				customErr = response.BadRequestError("Block " + item.BlockName + " is not part of this audit.")
+				return errors.New("block outside audit")
+			}
+			if item.Slot > block.Slots || item.Row > block.Rows || item.Tier > block.Tiers {
+				customErr = response.BadRequestError("Scan " + strconv.Itoa(i+1) + " is outside the Block dimensions.")
+				return errors.New("scan outside block")
+			}
+
+			scan := &model.YardAuditScan{
+				AuditID:         audit.ID,
+				BlockID:         block.ID,
+				SlotNumber:      item.Slot,
+				RowNumber:       item.Row,
+				TierNumber:      item.Tier,
+				ContainerNumber: strings.ToUpper(strings.TrimSpace(item.ContainerNumber)),
+				ContainerSize:   item.Size,
+				ContainerHeight: item.Height,
+				ContainerType:   item.Type,
+				ScannedBy:       request.ScannedBy,
+				ScannedAt:       now,
+			}
+			if err := s.YardAuditRepository.SaveScan(tx, scan); err != nil {
+				customErr = response.RepositoryError("Failed to record scan: " + err.Error())
+				return err
+			}
+			saved = append(saved, scan)
+		}
+		return nil
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to record scans: " + txErr.Error())
+	}
+	for _, scan := range saved {
+		auditlog.Record(ctx, model.AuditEntityYardAuditScan, scan.ID, nil, scan)
+	}
+
+	var scans []model.YardAuditScan
+	if err := s.YardAuditRepository.FindScans(s.DB, &scans, audit.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit scans: " + err.Error())
+	}
+
+	auditResponse := s.toYardAuditResponse(audit, len(scans), nil)
+	return &auditResponse, nil
+}
+
+func (s *YardAuditServiceImpl) Reconcile(ctx context.Context, auditID int) (*web.YardAuditReconciliationResponse, *response.CustomError) {
+	audit, customErr := s.findOpenAudit(s.DB, auditID)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	var scans []model.YardAuditScan
+	if err := s.YardAuditRepository.FindScans(s.DB, &scans, audit.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit scans: " + err.Error())
+	}
+	if len(scans) == 0 {
+		return nil, response.BadRequestError("The audit has no scans to reconcile.")
+	}
+
+	blockIDs := []int{}
+	if audit.BlockID != nil {
+		blockIDs = append(blockIDs, *audit.BlockID)
+	} else {
+		var blocks []model.Block
+		if err := s.YardRepository.FindBlocksByYardID(s.DB, &blocks, audit.YardID); err != nil {
+			return nil, response.RepositoryError("Failed to fetch blocks: " + err.Error())
+		}
+		for _, block := range blocks {
+			blockIDs = append(blockIDs, block.ID)
+		}
+	}
+
+	// The recorded positions of the audited blocks, in block order
+	var recorded []model.ContainerPosition
+	for _, blockID := range blockIDs {
+		var positions []model.ContainerPosition
+		if err := s.ContainerPositionRepository.FindByBlockID(s.DB, &positions, blockID); err != nil {
+			return nil, response.RepositoryError("Failed to fetch container positions: " + err.Error())
+		}
+		recorded = append(recorded, positions...)
+	}
+
+	recordedByNumber := make(map[string]*model.ContainerPosition, len(recorded))
+	for i := range recorded {
+		recordedByNumber[recorded[i].ContainerNumber] = &recorded[i]
+	}
+
+	scannedCells := make(map[cellKey]bool, len(scans))
+	scannedNumbers := make(map[string]bool, len(scans))
+	for _, scan := range scans {
+		scannedCells[cellKey{scan.BlockID, scan.SlotNumber, scan.RowNumber, scan.TierNumber}] = true
+		if scan.ContainerNumber != "" {
+			scannedNumbers[scan.ContainerNumber] = true
+		}
+	}
+
+	now := time.Now()
+	reconciliation := &web.YardAuditReconciliationResponse{ScannedCells: len(scans)}
+	var findings []model.YardAuditFinding
+
+	for _, scan := range scans {
+		if scan.ContainerNumber == "" {
+			continue
+		}
+
+		position, ok := recordedByNumber[scan.ContainerNumber]
+		if !ok {
+			// Recorded outside the audited blocks or not at all
+			var elsewhere model.ContainerPosition
+			if err := s.ContainerPositionRepository.FindByContainerNumber(s.DB, &elsewhere, scan.ContainerNumber); err == nil {
+				position = &elsewhere
+			}
+		}
+
+		if position == nil {
+			finding := newAuditFinding(model.FindingTypeUnexpected, model.FindingActionAdd, scan.ContainerNumber, now)
+			finding.SetFound(scan.BlockID, scan.SlotNumber, scan.RowNumber, scan.TierNumber)
+			finding.ContainerSize, finding.ContainerHeight, finding.ContainerType = scan.ContainerSize, scan.ContainerHeight, scan.ContainerType
+			findings = append(findings, finding)
+			reconciliation.Unexpected++
+			continue
+		}
+
+		if coversCell(position, cellKey{scan.BlockID, scan.SlotNumber, scan.RowNumber, scan.TierNumber}) {
+			reconciliation.Matched++
+			continue
+		}
+
+		finding := newAuditFinding(model.FindingTypeMisplaced, model.FindingActionMove, scan.ContainerNumber, now)
+		finding.SetRecorded(position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber)
+		finding.SetFound(scan.BlockID, scan.SlotNumber, scan.RowNumber, scan.TierNumber)
+		finding.ContainerSize, finding.ContainerHeight, finding.ContainerType = position.ContainerSize, position.ContainerHeight, position.ContainerType
+		findings = append(findings, finding)
+		reconciliation.Misplaced++
+	}
+
+	// A recorded container found nowhere is only missing when its cell was
+	// looked at, cells nobody scanned are left unchecked.
+	for i := range recorded {
+		position := &recorded[i]
+		if scannedNumbers[position.ContainerNumber] {
+			continue
+		}
+
+		checked := false
+		for _, cell := range coveredCells(position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber, position.ContainerSize) {
+			checked = checked || scannedCells[cell]
+		}
+		if !checked {
+			reconciliation.Unchecked++
+			continue
+		}
+
+		finding := newAuditFinding(model.FindingTypeMissing, model.FindingActionRemove, position.ContainerNumber, now)
+		finding.SetRecorded(position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber)
+		finding.ContainerSize, finding.ContainerHeight, finding.ContainerType = position.ContainerSize, position.ContainerHeight, position.ContainerType
+		findings = append(findings, finding)
+		reconciliation.Missing++
+	}
+
+	// A new reconciliation replaces the findings of the last one
+	var replaced []model.YardAuditFinding
+	if err := s.YardAuditRepository.FindFindings(s.DB, &replaced, audit.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit findings: " + err.Error())
+	}
+
+	before := *audit
+	audit.ReconciledAt = &now
+	audit.UpdatedAt = now
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.YardAuditRepository.ReplaceFindings(tx, audit, findings)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to store audit findings: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityYardAudit, audit.ID, before, audit)
+	for _, finding := range replaced {
+		auditlog.Record(ctx, model.AuditEntityYardAuditFinding, finding.ID, finding, nil)
+	}
+	for _, finding := range findings {
+		auditlog.Record(ctx, model.AuditEntityYardAuditFinding, finding.ID, nil, finding)
+	}
+
+	reconciliation.Audit = s.toYardAuditResponse(audit, len(scans), findings)
+	return reconciliation, nil
+}
+
+// auditCorrection is an approved finding with the current position of its
+// container, nil for containers to add. A container to remove leaves against
+// releaseOrder like a pickup.
+type auditCorrection struct {
+	finding      *model.YardAuditFinding
+	position     *model.ContainerPosition
+	releaseOrder *model.ReleaseOrder
+}
+
+func (s *YardAuditServiceImpl) ApplyCorrections(ctx context.Context, auditID int, request *web.YardAuditApplyRequest) (*web.YardAuditApplyResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var audit *model.YardAudit
+	var before model.YardAudit
+	var findings, pending []model.YardAuditFinding
+	var changes []containerChange
+	var customErr *response.CustomError
+	applyResponse := &web.YardAuditApplyResponse{}
+	touched := make(map[int]bool)
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if audit, customErr = s.findOpenAudit(tx, auditID); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		if audit.ReconciledAt == nil {
+			customErr = response.BadRequestError("The audit has not been reconciled yet.")
+			return errors.New("audit not reconciled")
+		}
+
+		if err := s.YardAuditRepository.FindFindings(tx, &findings, audit.ID); err != nil {
+			customErr = response.RepositoryError("Failed to fetch audit findings: " + err.Error())
+			return err
+		}
+		before = *audit
+		pending = append([]model.YardAuditFinding(nil), findings...)
+
+		// Reservations read after the lock hold until the corrections are stored
+		if err := s.YardRepository.LockYardBlocks(tx, audit.YardID); err != nil {
+			customErr = response.RepositoryError("Failed to lock yard: " + err.Error())
+			return err
+		}
+		holders, err := loadCellHolders(tx, s.GateTransactionRepository, s.PreAdviceRepository, s.WorkInstructionRepository)
+		if err != nil {
+			customErr = response.RepositoryError("Failed to load reservations: " + err.Error())
+			return err
+		}
+
+		corrections, checkErr := s.checkCorrections(tx, findings, request.FindingIDs, holders, time.Now())
+		if checkErr != nil {
+			customErr = checkErr
+			return errors.New(checkErr.Message)
+		}
+
+		if changes, customErr = s.applyCorrections(tx, corrections, touched); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		var rejected []int
+		for i := range findings {
+			finding := &findings[i]
+			if finding.Status != model.FindingStatusPending {
+				continue
+			}
+			finding.Status = model.FindingStatusRejected
+			for _, correction := range corrections {
+				if correction.finding.ID == finding.ID {
+					finding.Status = model.FindingStatusApplied
+				}
+			}
+			if finding.Status == model.FindingStatusRejected {
+				rejected = append(rejected, finding.ID)
+			}
+		}
+
+		if err := s.YardAuditRepository.ResolveFindings(tx, audit.ID, request.FindingIDs, model.FindingStatusApplied); err != nil {
+			customErr = response.RepositoryError("Failed to update audit findings: " + err.Error())
+			return err
+		}
+		if err := s.YardAuditRepository.ResolveFindings(tx, audit.ID, rejected, model.FindingStatusRejected); err != nil {
+			customErr = response.RepositoryError("Failed to update audit findings: " + err.Error())
+			return err
+		}
+		applyResponse.Applied = len(corrections)
+		applyResponse.Rejected = len(rejected)
+
+		now := time.Now()
+		audit.Status = model.AuditStatusClosed
+		audit.ClosedBy = request.ApprovedBy
+		audit.ClosedAt = &now
+		audit.UpdatedAt = now
+		if err := s.YardAuditRepository.Close(tx, audit); err != nil {
+			customErr = response.GeneralError("Conflict: " + err.Error() + ".")
+			return err
+		}
+		return nil
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to apply audit corrections: " + txErr.Error())
+	}
+
+	for blockID := range touched {
+		s.OccupancyCache.Invalidate(blockID)
+	}
+
+	for _, change := range changes {
+		container := change.before
+		if container == nil {
+			container = change.after
+		}
+		auditlog.Record(ctx, model.AuditEntityContainer, container.ContainerNumber, change.before, change.after)
+	}
+	for i := range findings {
+		if findings[i].Status != pending[i].Status {
+			auditlog.Record(ctx, model.AuditEntityYardAuditFinding, findings[i].ID, pending[i], findings[i])
+		}
+	}
+	auditlog.Record(ctx, model.AuditEntityYardAudit, audit.ID, before, audit)
+
+	var scans []model.YardAuditScan
+	if err := s.YardAuditRepository.FindScans(s.DB, &scans, audit.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch audit scans: " + err.Error())
+	}
+
+	applyResponse.Audit = s.toYardAuditResponse(audit, len(scans), findings)
+	return applyResponse, nil
+}
+
+// checkCorrections checks the approved findings against the inventory as it
+// is now. Containers must still be where the reconciliation saw them, a
+// container to remove needs an open release order like a pickup, and every
+// destination must fit its block, be free once the other approved
+// corrections are applied and not be held for another container.
+func (s *YardAuditServiceImpl) checkCorrections(db *gorm.DB, findings []model.YardAuditFinding, approvedIDs []int, holders map[cellKey]string, now time.Time) ([]auditCorrection, *response.CustomError) {
+	byID := make(map[int]*model.YardAuditFinding, len(findings))
+	for i := range findings {
+		byID[findings[i].ID] = &findings[i]
+	}
+
+	var corrections []auditCorrection
+	leaving := make(map[string]bool)
+	approved := make(map[int]bool, len(approvedIDs))
+
+	for _, findingID := range approvedIDs {
+		finding, ok := byID[findingID]
+		if !ok || finding.Status != model.FindingStatusPending {
+			return nil, response.BadRequestError("Finding " + strconv.Itoa(findingID) + " is not a pending finding of this audit.")
+		}
+		if approved[findingID] {
+			continue
+		}
+		approved[findingID] = true
+
+		var current model.ContainerPosition
+		found := s.ContainerPositionRepository.FindByContainerNumber(db, &current, finding.ContainerNumber) == nil
+
+		correction := auditCorrection{finding: finding}
+		if finding.Action == model.FindingActionAdd {
+			if found {
+				return nil, response.GeneralError("Conflict: Container " + finding.ContainerNumber + " was recorded since the reconciliation, reconcile the audit again.")
+			}
+			if finding.ContainerSize == "" || finding.ContainerHeight == "" || finding.ContainerType == "" {
+				return nil, response.BadRequestError("Container " + finding.ContainerNumber + " can not be added without size, height and type, scan it again with them.")
+			}
+		} else {
+			if !found || current.BlockID != *finding.RecordedBlockID || current.SlotNumber != *finding.RecordedSlot ||
+				current.RowNumber != *finding.RecordedRow || current.TierNumber != *finding.RecordedTier {
+				return nil, response.GeneralError("Conflict: Container " + finding.ContainerNumber + " moved since the reconciliation, reconcile the audit again.")
+			}
+			correction.position = &current
+			leaving[current.ContainerNumber] = true
+		}
+
+		if finding.Action == model.FindingActionRemove {
+			var releaseOrder model.ReleaseOrder
+			if err := s.ReleaseOrderRepository.FindOpenByContainerNumber(db, &releaseOrder, finding.ContainerNumber, now); err != nil {
+				return nil, response.GeneralError("Conflict: Container " + finding.ContainerNumber + " has no active release order, it can only be removed once released like a pickup.")
+			}
+			correction.releaseOrder = &releaseOrder
+		}
+		corrections = append(corrections, correction)
+	}
+
+	blocks := make(map[int]*model.Block)
+	occupied := make(map[int]map[cellKey]string)
+
+	for _, correction := range corrections {
+		finding := correction.finding
+		if finding.Action == model.FindingActionRemove {
+			continue
+		}
+
+		blockID := *finding.FoundBlockID
+		block, ok := blocks[blockID]
+		if !ok {
+			block = new(model.Block)
+			if err := s.YardRepository.FindBlockByID(db, block, blockID); err != nil {
+				return nil, response.NotFoundError("Block of container " + finding.ContainerNumber + " not found.")
+			}
+			blocks[blockID] = block
+
+			var positions []model.ContainerPosition
+			if err := s.ContainerPositionRepository.FindByBlockID(db, &positions, blockID); err != nil {
+				return nil, response.RepositoryError("Failed to fetch container positions: " + err.Error())
+			}
+			occupied[blockID] = make(map[cellKey]string)
+			for _, position := range positions {
+				if leaving[position.ContainerNumber] {
+					continue
+				}
+				for _, cell := range coveredCells(position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber, position.ContainerSize) {
+					occupied[blockID][cell] = position.ContainerNumber
+				}
+			}
+		}
+
+		slot, row, tier := *finding.FoundSlot, *finding.FoundRow, *finding.FoundTier
+		if slot+containerSlots(finding.ContainerSize)-1 > block.Slots || row > block.Rows || tier > block.Tiers {
+			return nil, response.BadRequestError("Container " + finding.ContainerNumber + " does not fit block " + block.Name + " at the scanned cell.")
+		}
+		if finding.ContainerSize == "40ft" && slot%2 == 0 {
+			return nil, response.BadRequestError("Container " + finding.ContainerNumber + " is 40ft and must start at an odd slot, scan it at its first slot.")
+		}
+
+		for _, cell := range coveredCells(blockID, slot, row, tier, finding.ContainerSize) {
+			if holder, taken := occupied[blockID][cell]; taken {
+				return nil, response.GeneralError("Conflict: The scanned cell of container " + finding.ContainerNumber + " is still recorded for " + holder + ", approve its correction as well.")
+			}
+			if holder, held := holders[cell]; held && holder != finding.ContainerNumber {
+				return nil, response.GeneralError("Conflict: The scanned cell of container " + finding.ContainerNumber + " is reserved for container " + holder + ".")
+			}
+			occupied[blockID][cell] = finding.ContainerNumber
+		}
+	}
+
+	return corrections, nil
+}
+
+// applyCorrections removes, moves and adds the containers of the checked
+// corrections and records each as a move. Moved containers are first parked
+// on a negative tier, so boxes that swapped cells do not collide on the
+// unique cell index. The blocks changed are added to touched, the changed
+// containers are returned.
+func (s *YardAuditServiceImpl) applyCorrections(db *gorm.DB, corrections []auditCorrection, touched map[int]bool) ([]containerChange, *response.CustomError) {
+	now := time.Now()
+	changes := make([]containerChange, 0, len(corrections))
+	// Revision of each parked container, its next update must match it
+	parkedRevisions := make(map[int]int)
+
+	for _, correction := range corrections {
+		if correction.position == nil {
+			continue
+		}
+		touched[correction.position.BlockID] = true
+
+		if correction.finding.Action == model.FindingActionRemove {
+			if err := s.ContainerPositionRepository.Delete(db, correction.position.ID); err != nil {
+				return nil, response.RepositoryError("Failed to remove container " + correction.position.ContainerNumber + ": " + err.Error())
+			}
+			if err := useRelease(db, s.ReleaseOrderRepository, correction.releaseOrder, correction.position.ContainerNumber, now); err != nil {
+				return nil, response.RepositoryError("Failed to use release order of container " + correction.position.ContainerNumber + ": " + err.Error())
+			}
+			history := newContainerMove(model.MoveTypePickup, correction.position, correction.position, nil, auditMoveReason)
+			if err := saveContainerMove(db, s.ContainerMoveRepository, s.OutboxEventRepository, &history); err != nil {
+				return nil, response.RepositoryError("Failed to record container move: " + err.Error())
+			}
+			changes = append(changes, containerChange{before: correction.position})
+			continue
+		}
+
+		parked := *correction.position
+		parked.TierNumber = -parked.ID
+		parked.UpdatedAt = now
+		if err := s.ContainerPositionRepository.UpdatePosition(db, &parked); err != nil {
+			if errors.Is(err, repository.ErrStaleRevision) {
+				return nil, changedContainerError(parked.ContainerNumber)
+			}
+			return nil, response.RepositoryError("Failed to move container " + parked.ContainerNumber + ": " + err.Error())
+		}
+		parkedRevisions[parked.ID] = parked.Revision
+	}
+
+	for _, correction := range corrections {
+		finding := correction.finding
+		if finding.Action == model.FindingActionRemove {
+			continue
+		}
+		touched[*finding.FoundBlockID] = true
+
+		to := model.ContainerPosition{
+			ContainerNumber: finding.ContainerNumber,
+			ContainerSize:   finding.ContainerSize,
+			ContainerHeight: finding.ContainerHeight,
+			ContainerType:   finding.ContainerType,
+			ContainerStatus: model.ContainerStatusStorage,
+			ArrivalDate:     now,
+			CreatedAt:       now,
+		}
+		if correction.position != nil {
+			to = *correction.position
+			to.Revision = parkedRevisions[to.ID]
+		}
+		to.BlockID, to.SlotNumber, to.RowNumber, to.TierNumber = *finding.FoundBlockID, *finding.FoundSlot, *finding.FoundRow, *finding.FoundTier
+		to.YardPlanID = nil
+		to.UpdatedAt = now
+
+		yardPlan, err := s.YardPlanRepository.FindApplicablePlan(db, to.BlockID, to.SlotNumber, to.RowNumber, to.ContainerSize, to.ContainerHeight, to.ContainerType, now)
+		if err == nil && yardPlan != nil {
+			to.YardPlanID = &yardPlan.ID
+		}
+
+		var history model.ContainerMove
+		if correction.position != nil {
+			if err := s.ContainerPositionRepository.UpdatePosition(db, &to); err != nil {
+				if errors.Is(err, repository.ErrStaleRevision) {
+					return nil, changedContainerError(to.ContainerNumber)
+				}
+				return nil, response.RepositoryError("Failed to move container " + to.ContainerNumber + ": " + err.Error())
+			}
+			history = newContainerMove(model.MoveTypeMove, &to, correction.position, &to, auditMoveReason)
+		} else {
+			if err := s.ContainerPositionRepository.Save(db, &to); err != nil {
+				return nil, response.RepositoryError("Failed to add container " + to.ContainerNumber + ": " + err.Error())
+			}
+			history = newContainerMove(model.MoveTypePlacement, &to, nil, &to, auditMoveReason)
+		}
+
+		if err := saveContainerMove(db, s.ContainerMoveRepository, s.OutboxEventRepository, &history); err != nil {
+			return nil, response.RepositoryError("Failed to record container move: " + err.Error())
+		}
+		changes = append(changes, containerChange{before: correction.position, after: &to})
+	}
+	return changes, nil
+}
+
+func (s *YardAuditServiceImpl) findOpenAudit(db *gorm.DB, auditID int) (*model.YardAudit, *response.CustomError) {
+	var audit model.YardAudit
+	if err := s.YardAuditRepository.FindByID(db, &audit, auditID); err != nil {
+		return nil, response.NotFoundError("Yard audit not found.")
+	}
+	if audit.Status != model.AuditStatusOpen {
+		return nil, response.BadRequestError("Yard audit is already closed.")
+	}
+	return &audit, nil
+}
+
+func (s *YardAuditServiceImpl) toYardAuditResponse(audit *model.YardAudit, scans int, findings []model.YardAuditFinding) web.YardAuditResponse {
+	names := make(map[int]string)
+	blockName := func(blockID int) string {
+		name, ok := names[blockID]
+		if !ok {
+			var block model.Block
+			if err := s.YardRepository.FindBlockByID(s.DB, &block, blockID); err == nil {
+				name = block.Name
+			}
+			names[blockID] = name
+		}
+		return name
+	}
+
+	auditResponse := web.YardAuditResponse{
+		ID:           audit.ID,
+		YardID:       audit.YardID,
+		Status:       audit.Status,
+		CreatedBy:    audit.CreatedBy,
+		ClosedBy:     audit.ClosedBy,
+		Scans:        scans,
+		CreatedAt:    audit.CreatedAt,
+		ReconciledAt: audit.ReconciledAt,
+		ClosedAt:     audit.ClosedAt,
+	}
+	if audit.BlockID != nil {
+		auditResponse.Block = blockName(*audit.BlockID)
+	}
+
+	for _, finding := range findings {
+		findingResponse := web.YardAuditFindingResponse{
+			ID:              finding.ID,
+			FindingType:     finding.FindingType,
+			ContainerNumber: finding.ContainerNumber,
+			Action:          finding.Action,
+			Status:          finding.Status,
+			ContainerSize:   finding.ContainerSize,
+			ContainerHeight: finding.ContainerHeight,
+			ContainerType:   finding.ContainerType,
+		}
+		if finding.RecordedBlockID != nil {
+			findingResponse.Recorded = &web.PositionResponse{
+				Block:   blockName(*finding.RecordedBlockID),
+				Slot:    *finding.RecordedSlot,
+				Row:     *finding.RecordedRow,
+				Tier:    *finding.RecordedTier,
+				BlockID: *finding.RecordedBlockID,
+			}
+		}
+		if finding.FoundBlockID != nil {
+			findingResponse.Found = &web.PositionResponse{
+				Block:   blockName(*finding.FoundBlockID),
+				Slot:    *finding.FoundSlot,
+				Row:     *finding.FoundRow,
+				Tier:    *finding.FoundTier,
+				BlockID: *finding.FoundBlockID,
+			}
+		}
+		auditResponse.Findings = append(auditResponse.Findings, findingResponse)
+	}
+	return auditResponse
+}
+
+func newAuditFinding(findingType, action, containerNumber string, now time.Time) model.YardAuditFinding {
+	return model.YardAuditFinding{
+		FindingType:     findingType,
+		ContainerNumber: containerNumber,
+		Action:          action,
+		Status:          model.FindingStatusPending,
+		CreatedAt:       now,
+	}
+}
+
+// coversCell reports whether the container, both slots of a 40ft one,
+// stands in cell.
+func coversCell(position *model.ContainerPosition, cell cellKey) bool {
+	for _, covered := range coveredCells(position.BlockID, position.SlotNumber, position.RowNumber, position.TierNumber, position.ContainerSize) {
+		if covered == cell {
+			return true
+		}
+	}
+	return false
+}
+
+// containerSlots is the number of slots a container of the size takes.
+func containerSlots(size string) int {
+	if size == "40ft" {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+
+	"gorm.io/gorm"
+)
+
+// auditInventory records containers in block 5 of 10 slots, 3 rows and 4
+// tiers.
+type auditInventory struct {
+	repository.YardRepository
+	repository.ContainerPositionRepository
+	positions []model.ContainerPosition
+}
+
+func (a *auditInventory) FindBlockByID(db *gorm.DB, block *model.Block, blockID int) error {
+	if blockID != 5 {
+		return errors.New("block not found")
+	}
+	*block = model.Block{ID: 5, Name: "LC05", Slots: 10, Rows: 3, Tiers: 4}
+	return nil
+}
+
+func (a *auditInventory) FindByContainerNumber(db *gorm.DB, position *model.ContainerPosition, containerNumber string) error {
+	for _, recorded := range a.positions {
+		if recorded.ContainerNumber == containerNumber {
+			*position = recorded
+			return nil
+		}
+	}
+	return errors.New("container not found")
+}
+
+func (a *auditInventory) FindByBlockID(db *gorm.DB, positions *[]model.ContainerPosition, blockID int) error {
+	*positions = a.positions
+	return nil
+}
+
+// auditReleases has an open release order for the containers listed.
+type auditReleases struct {
+	repository.ReleaseOrderRepository
+	released map[string]int
+}
+
+func (a auditReleases) FindOpenByContainerNumber(db *gorm.DB, releaseOrder *model.ReleaseOrder, containerNumber string, now time.Time) error {
+	id, ok := a.released[containerNumber]
+	if !ok {
+		return errors.New("release order not found")
+	}
+	*releaseOrder = model.ReleaseOrder{ID: id, Status: model.ReleaseStatusActive}
+	return nil
+}
+
+func TestCheckCorrections(t *testing.T) {
+	recorded := func(id int, containerNumber string, slot, row int) model.ContainerPosition {
+		return model.ContainerPosition{ID: id, ContainerNumber: containerNumber, BlockID: 5, SlotNumber: slot, RowNumber: row, TierNumber: 1, ContainerSize: "20ft"}
+	}
+	inventory := &auditInventory{positions: []model.ContainerPosition{
+		recorded(1, "AAAU0000001", 1, 1),
+		recorded(2, "BBBU0000002", 2, 1),
+		recorded(3, "CCCU0000003", 3, 1),
+		recorded(8, "HHHU0000008", 9, 2),
+	}}
+	s := &YardAuditServiceImpl{
+		YardRepository:              inventory,
+		ContainerPositionRepository: inventory,
+		ReleaseOrderRepository:      auditReleases{released: map[string]int{"AAAU0000001": 40}},
+	}
+
+	finding := func(id int, action, containerNumber string) model.YardAuditFinding {
+		return model.YardAuditFinding{ID: id, Action: action, ContainerNumber: containerNumber, Status: model.FindingStatusPending,
+			ContainerSize: "20ft", ContainerHeight: "8.6ft", ContainerType: "DRY"}
+	}
+	remove := func(id int, containerNumber string, slot, row int) model.YardAuditFinding {
+		f := finding(id, model.FindingActionRemove, containerNumber)
+		f.SetRecorded(5, slot, row, 1)
+		return f
+	}
+	move := func(id int, containerNumber string, fromSlot, fromRow, toSlot, toRow int) model.YardAuditFinding {
+		f := finding(id, model.FindingActionMove, containerNumber)
+		f.SetRecorded(5, fromSlot, fromRow, 1)
+		f.SetFound(5, toSlot, toRow, 1)
+		return f
+	}
+	add := func(id int, containerNumber string, slot, row int) model.YardAuditFinding {
+		f := finding(id, model.FindingActionAdd, containerNumber)
+		f.SetFound(5, slot, row, 1)
+		return f
+	}
+
+	unscanned := add(9, "IIIU0000009", 10, 3)
+	unscanned.ContainerSize = ""
+	applied := add(10, "JJJU0000010", 10, 3)
+	applied.Status = model.FindingStatusApplied
+	evenForty := add(11, "KKKU0000011", 6, 3)
+	evenForty.ContainerSize = "40ft"
+	heldForty := add(13, "LLLU0000013", 7, 1)
+	heldForty.ContainerSize = "40ft"
+
+	findings := []model.YardAuditFinding{
+		remove(1, "AAAU0000001", 1, 1),
+		remove(2, "BBBU0000002", 2, 1),
+		move(3, "CCCU0000003", 3, 1, 6, 1),
+		add(4, "EEEU0000005", 7, 1),
+		add(5, "FFFU0000006", 8, 1),
+		add(6, "GGGU0000007", 1, 1),
+		move(7, "HHHU0000008", 9, 1, 9, 3),
+		add(8, "MSCU9999999", 8, 1),
+		unscanned,
+		applied,
+		evenForty,
+		add(12, "CCCU0000003", 10, 3),
+		heldForty,
+	}
+	holders := map[cellKey]string{
+		{BlockID: 5, Slot: 8, Row: 1, Tier: 1}: "MSCU9999999",
+	}
+
+	tests := []struct {
+		name        string
+		approved    []int
+		wantErr     string
+		wantRelease map[string]int
+	}{
+		{"remove against its release order", []int{1}, "", map[string]int{"AAAU0000001": 40}},
+		{"remove without a release order", []int{2}, "has no active release order", nil},
+		{"move to a free cell", []int{3}, "", nil},
+		{"add to a free cell", []int{4}, "", nil},
+		{"add to a cell held for another container", []int{5}, "reserved for container MSCU9999999", nil},
+		{"add to a cell held for the container itself", []int{8}, "", nil},
+		{"add to a cell still recorded for another container", []int{6}, "still recorded for AAAU0000001", nil},
+		{"add after removing the recorded container", []int{1, 6}, "", map[string]int{"AAAU0000001": 40}},
+		{"container moved since the reconciliation", []int{7}, "moved since the reconciliation", nil},
+		{"add without size", []int{9}, "without size, height and type", nil},
+		{"finding not pending", []int{10}, "is not a pending finding", nil},
+		{"unknown finding", []int{99}, "is not a pending finding", nil},
+		{"40ft at an even slot", []int{11}, "must start at an odd slot", nil},
+		{"add of a recorded container", []int{12}, "was recorded since the reconciliation", nil},
+		{"40ft reaching into a held cell", []int{13}, "reserved for container MSCU9999999", nil},
+		{"approved twice", []int{4, 4}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrections, customErr := s.checkCorrections(nil, findings, tt.approved, holders, time.Now())
+			if tt.wantErr != "" {
+				if customErr == nil || !strings.Contains(customErr.Message, tt.wantErr) {
+					t.Fatalf("checkCorrections error = %v, want it to mention %q", customErr, tt.wantErr)
+				}
+				return
+			}
+			if customErr != nil {
+				t.Fatalf("checkCorrections error = %s", customErr.Message)
+			}
+
+			approved := make(map[int]bool)
+			for _, id := range tt.approved {
+				approved[id] = true
+			}
+			if len(corrections) != len(approved) {
+				t.Fatalf("got %d corrections, want %d", len(corrections), len(approved))
+			}
+			for _, correction := range corrections {
+				wantRelease, released := tt.wantRelease[correction.finding.ContainerNumber]
+				switch {
+				case released && (correction.releaseOrder == nil || correction.releaseOrder.ID != wantRelease):
+					t.Errorf("%s leaves against %+v, want release order %d", correction.finding.ContainerNumber, correction.releaseOrder, wantRelease)
+				case !released && correction.releaseOrder != nil:
+					t.Errorf("%s has release order %d, want none", correction.finding.ContainerNumber, correction.releaseOrder.ID)
+				}
+				if (correction.position == nil) != (correction.finding.Action == model.FindingActionAdd) {
+					t.Errorf("%s %s has position %+v", correction.finding.Action, correction.finding.ContainerNumber, correction.position)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,661 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"yard-planning/app/model"
+	"yard-planning/app/repository"
+	"yard-planning/app/web"
+	"yard-planning/helper/auditlog"
+	"yard-planning/response"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type YardPlanService interface {
+	CreatePlan(ctx context.Context, request *web.YardPlanRequest) (*web.YardPlanResponse, *response.CustomError)
+	CreateVersion(ctx context.Context, planID int, request *web.YardPlanRequest) (*web.YardPlanResponse, *response.CustomError)
+	PublishPlan(ctx context.Context, planID int, request *web.PublishYardPlanRequest) (*web.YardPlanResponse, *response.CustomError)
+
+	// AnalyzeImpact reports what a proposed change would do without saving it.
+	AnalyzeImpact(ctx context.Context, planID int, request *web.YardPlanRequest) (*web.YardPlanImpactResponse, *response.CustomError)
+	// UpdatePlan saves the change only when the request is confirmed, and only
+	// to a draft plan. Published plans change through a new version.
+	UpdatePlan(ctx context.Context, planID int, request *web.UpdateYardPlanRequest) (*web.YardPlanImpactResponse, *response.CustomError)
+
+	FindPlanByID(ctx context.Context, planID int) (*web.YardPlanResponse, *response.CustomError)
+	FindPlans(ctx context.Context, query *web.YardPlanQuery) ([]web.YardPlanResponse, *response.CustomError)
+	// ExportPlans prepares every plan version of the blocks of a yard as a
+	// CSV or XLSX file.
+	ExportPlans(ctx context.Context, query *web.YardPlanExportQuery) (*web.TableExport, *response.CustomError)
+
+	// SyncActivePlans flips is_active on plans whose validity window started
+	// or ended, it is run periodically by the scheduler.
+	SyncActivePlans(ctx context.Context) (int64, *response.CustomError)
+}
+
+const (
+	ImpactReasonOutsideArea = "OUTSIDE_AREA"
+	ImpactReasonSpecChanged = "SPEC_CHANGED"
+)
+
+type YardPlanServiceImpl struct {
+	YardRepository              repository.YardRepository
+	YardPlanRepository          repository.YardPlanRepository
+	ContainerPositionRepository repository.ContainerPositionRepository
+	OutboxEventRepository       repository.OutboxEventRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewYardPlanService(
+	yardRepo repository.YardRepository,
+	planRepo repository.YardPlanRepository,
+	containerRepo repository.ContainerPositionRepository,
+	outboxRepo repository.OutboxEventRepository,
+	DB *gorm.DB,
+	validate *validator.Validate,
+) YardPlanService {
+	return &YardPlanServiceImpl{
+		YardRepository:              yardRepo,
+		YardPlanRepository:          planRepo,
+		ContainerPositionRepository: containerRepo,
+		OutboxEventRepository:       outboxRepo,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *YardPlanServiceImpl) CreatePlan(ctx context.Context, request *web.YardPlanRequest) (*web.YardPlanResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	if customErr := s.checkPlanFitsBlock(s.DB, request); customErr != nil {
+		return nil, customErr
+	}
+
+	plan := newDraftPlan(request)
+	plan.Version = 1
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardPlanRepository.Save(tx, &plan); err != nil {
+			return err
+		}
+		return publishPlanChanged(tx, s.OutboxEventRepository, &plan, PlanChangeCreated)
+	})
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to create yard plan: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityYardPlan, plan.ID, nil, plan)
+
+	return toYardPlanResponse(&plan), nil
+}
+
+func (s *YardPlanServiceImpl) CreateVersion(ctx context.Context, planID int, request *web.YardPlanRequest) (*web.YardPlanResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var previous model.YardPlan
+	if err := s.YardPlanRepository.FindByID(s.DB, &previous, planID); err != nil {
+		return nil, response.NotFoundError("Yard plan not found.")
+	}
+
+	if request.BlockID != previous.BlockID {
+		return nil, response.BadRequestError("A new plan version must stay in the same block.")
+	}
+
+	var draft model.YardPlan
+	if err := s.YardPlanRepository.FindDraftVersion(s.DB, &draft, previous.ID); err == nil {
+		return nil, draftExistsError(&draft)
+	}
+
+	if customErr := s.checkPlanFitsBlock(s.DB, request); customErr != nil {
+		return nil, customErr
+	}
+
+	plan := newDraftPlan(request)
+	plan.Version = previous.Version + 1
+	plan.PreviousVersionID = &previous.ID
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardPlanRepository.Save(tx, &plan); err != nil {
+			return err
+		}
+		return publishPlanChanged(tx, s.OutboxEventRepository, &plan, PlanChangeVersionCreated)
+	})
+	// A concurrent request created the draft first, the unique index on
+	// the drafts of a plan rejected this one.
+	if txErr != nil && s.YardPlanRepository.FindDraftVersion(s.DB, &draft, previous.ID) == nil {
+		return nil, draftExistsError(&draft)
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to create yard plan version: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityYardPlan, plan.ID, nil, plan)
+
+	return toYardPlanResponse(&plan), nil
+}
+
+func (s *YardPlanServiceImpl) PublishPlan(ctx context.Context, planID int, request *web.PublishYardPlanRequest) (*web.YardPlanResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	now := time.Now()
+	validFrom := now
+	if request.ValidFrom != nil {
+		validFrom = *request.ValidFrom
+	}
+
+	if request.ValidTo != nil && !request.ValidTo.After(validFrom) {
+		return nil, response.BadRequestError("valid_to must be after valid_from.")
+	}
+
+	var plan, before model.YardPlan
+	var customErr *response.CustomError
+
+	// Set when the previous version is superseded
+	var previousBefore, previousAfter *model.YardPlan
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardPlanRepository.FindByID(tx, &plan, planID); err != nil {
+			customErr = response.NotFoundError("Yard plan not found.")
+			return err
+		}
+		before = plan
+
+		if request.Revision != 0 && plan.Revision != request.Revision {
+			customErr = stalePlanError(&plan)
+			return errors.New(customErr.Message)
+		}
+
+		if plan.Status != model.YardPlanStatusDraft {
+			customErr = response.BadRequestError("Only draft yard plans can be published.")
+			return errors.New(customErr.Message)
+		}
+
+		// The previous version stays in force until this one takes over.
+		if plan.PreviousVersionID != nil {
+			var previous model.YardPlan
+			err := s.YardPlanRepository.FindByID(tx, &previous, *plan.PreviousVersionID)
+			if err == nil && previous.Status == model.YardPlanStatusPublished {
+				if previous.ValidFrom != nil && !validFrom.After(*previous.ValidFrom) {
+					customErr = response.BadRequestError("Version " + strconv.Itoa(plan.Version) + " must start after version " + strconv.Itoa(previous.Version) + ".")
+					return errors.New(customErr.Message)
+				}
+
+				if previous.ValidTo == nil || previous.ValidTo.After(validFrom) {
+					superseded := previous
+					previousBefore = &superseded
+					previousAfter = &previous
+
+					previous.ValidTo = &validFrom
+					previous.IsActive = previous.IsValidAt(now)
+					previous.UpdatedAt = now
+
+					if err := s.YardPlanRepository.UpdateStatus(tx, &previous); err != nil {
+						return err
+					}
+					if err := publishPlanChanged(tx, s.OutboxEventRepository, &previous, PlanChangeSuperseded); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		plan.Status = model.YardPlanStatusPublished
+		plan.ValidFrom = &validFrom
+		plan.ValidTo = request.ValidTo
+		plan.PublishedAt = &now
+		plan.IsActive = plan.IsValidAt(now)
+		plan.UpdatedAt = now
+
+		var overlapping []model.YardPlan
+		if err := s.YardPlanRepository.FindOverlappingPlans(tx, &overlapping, &plan); err != nil {
+			return err
+		}
+
+		if len(overlapping) > 0 {
+			customErr = response.BadRequestError("Yard plan overlaps other published plans with a different container specification.")
+			customErr.AdditionalInfo = toYardPlanResponses(overlapping)
+			return errors.New(customErr.Message)
+		}
+
+		if err := s.YardPlanRepository.UpdateStatus(tx, &plan); err != nil {
+			return err
+		}
+		return publishPlanChanged(tx, s.OutboxEventRepository, &plan, PlanChangePublished)
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if errors.Is(txErr, repository.ErrStaleRevision) {
+		return nil, response.PreconditionFailedError("Yard plan was changed by another request, reload it and retry.")
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to publish yard plan: " + txErr.Error())
+	}
+
+	if previousAfter != nil {
+		auditlog.Record(ctx, model.AuditEntityYardPlan, previousAfter.ID, previousBefore, previousAfter)
+	}
+	auditlog.Record(ctx, model.AuditEntityYardPlan, plan.ID, before, plan)
+
+	return toYardPlanResponse(&plan), nil
+}
+
+func (s *YardPlanServiceImpl) AnalyzeImpact(ctx context.Context, planID int, request *web.YardPlanRequest) (*web.YardPlanImpactResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	_, impact, customErr := s.analyzeImpact(s.DB, planID, request)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	return impact, nil
+}
+
+func (s *YardPlanServiceImpl) UpdatePlan(ctx context.Context, planID int, request *web.UpdateYardPlanRequest) (*web.YardPlanImpactResponse, *response.CustomError) {
+	if err := s.Validate.Struct(request); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	if !request.Confirm {
+		_, impact, customErr := s.analyzeImpact(s.DB, planID, &request.YardPlanRequest)
+		if customErr != nil {
+			return nil, customErr
+		}
+		return impact, nil
+	}
+
+	var impact *web.YardPlanImpactResponse
+	var customErr *response.CustomError
+	var before model.YardPlan
+	var proposed *model.YardPlan
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardPlanRepository.FindByID(tx, &before, planID); err != nil {
+			customErr = response.NotFoundError("Yard plan not found.")
+			return err
+		}
+
+		if request.Revision != 0 && before.Revision != request.Revision {
+			customErr = stalePlanError(&before)
+			return errors.New(customErr.Message)
+		}
+
+		// A published plan is changed through a new version so the change
+		// goes through publishing like any other.
+		if before.Status != model.YardPlanStatusDraft {
+			customErr = response.BadRequestError("Only draft yard plans can be updated, create a new version to change a " + strings.ToLower(before.Status) + " plan.")
+			return errors.New(customErr.Message)
+		}
+
+		proposed, impact, customErr = s.analyzeImpact(tx, planID, &request.YardPlanRequest)
+		if customErr != nil {
+			return errors.New(customErr.Message)
+		}
+
+		if len(impact.Overlaps) > 0 {
+			customErr = response.BadRequestError("Yard plan overlaps other published plans with a different container specification.")
+			customErr.AdditionalInfo = impact
+			return errors.New(customErr.Message)
+		}
+
+		proposed.UpdatedAt = time.Now()
+		if err := s.YardPlanRepository.Update(tx, proposed); err != nil {
+			return err
+		}
+		if err := publishPlanChanged(tx, s.OutboxEventRepository, proposed, PlanChangeUpdated); err != nil {
+			return err
+		}
+
+		impact.Saved = true
+		impact.Revision = proposed.Revision
+		return nil
+	})
+
+	if customErr != nil {
+		return nil, customErr
+	}
+	if errors.Is(txErr, repository.ErrStaleRevision) {
+		return nil, response.PreconditionFailedError("Yard plan was changed by another request, reload it and retry.")
+	}
+	if txErr != nil {
+		return nil, response.RepositoryError("Failed to update yard plan: " + txErr.Error())
+	}
+	auditlog.Record(ctx, model.AuditEntityYardPlan, proposed.ID, before, proposed)
+
+	return impact, nil
+}
+
+// analyzeImpact applies the request to a copy of the plan and compares it with
+// the containers already linked to the plan, the other published plans and
+// the capacity of the current layout.
+func (s *YardPlanServiceImpl) analyzeImpact(db *gorm.DB, planID int, request *web.YardPlanRequest) (*model.YardPlan, *web.YardPlanImpactResponse, *response.CustomError) {
+	var current model.YardPlan
+	if err := s.YardPlanRepository.FindByID(db, &current, planID); err != nil {
+		return nil, nil, response.NotFoundError("Yard plan not found.")
+	}
+
+	if request.BlockID != current.BlockID {
+		return nil, nil, response.BadRequestError("A yard plan cannot be moved to another block.")
+	}
+
+	if customErr := s.checkPlanFitsBlock(db, request); customErr != nil {
+		return nil, nil, customErr
+	}
+
+	var block model.Block
+	if err := s.YardRepository.FindBlockByID(db, &block, current.BlockID); err != nil {
+		return nil, nil, response.NotFoundError("Block not found.")
+	}
+
+	proposed := current
+	proposed.PlanName = request.PlanName
+	proposed.SlotStart, proposed.SlotEnd = request.SlotStart, request.SlotEnd
+	proposed.RowStart, proposed.RowEnd = request.RowStart, request.RowEnd
+	proposed.ContainerSize = request.ContainerSize
+	proposed.ContainerHeight = request.ContainerHeight
+	proposed.ContainerType = request.ContainerType
+	proposed.PriorityStackingDirection = request.PriorityStackingDirection
+
+	var positions []model.ContainerPosition
+	if err := s.ContainerPositionRepository.FindByYardPlanID(db, &positions, current.ID); err != nil {
+		return nil, nil, response.RepositoryError("Failed to fetch containers of the yard plan: " + err.Error())
+	}
+
+	var activePlans []model.YardPlan
+	if err := s.YardPlanRepository.FindActivePlansByBlock(db, &activePlans, current.BlockID, time.Now()); err != nil {
+		return nil, nil, response.RepositoryError("Failed to fetch yard plans: " + err.Error())
+	}
+
+	otherPlans := make([]model.YardPlan, 0, len(activePlans))
+	for _, plan := range activePlans {
+		if plan.ID != current.ID {
+			otherPlans = append(otherPlans, plan)
+		}
+	}
+
+	impact := &web.YardPlanImpactResponse{
+		PlanID:             current.ID,
+		Revision:           current.Revision,
+		AffectedContainers: []web.ImpactedContainer{},
+		CapacityBefore:     planCapacity(&current, &block),
+		CapacityAfter:      planCapacity(&proposed, &block),
+	}
+
+	for i := range positions {
+		position := &positions[i]
+		if findCoveringPlan([]model.YardPlan{proposed}, position) != nil {
+			continue
+		}
+
+		reason := ImpactReasonOutsideArea
+		if position.ContainerSize != proposed.ContainerSize || position.ContainerHeight != proposed.ContainerHeight || position.ContainerType != proposed.ContainerType {
+			reason = ImpactReasonSpecChanged
+		}
+
+		affected := web.ImpactedContainer{
+			ContainerNumber: position.ContainerNumber,
+			Position: web.PositionResponse{
+				Block:   block.Name,
+				Slot:    position.SlotNumber,
+				Row:     position.RowNumber,
+				Tier:    position.TierNumber,
+				BlockID: block.ID,
+			},
+			Reason: reason,
+		}
+
+		if other := findCoveringPlan(otherPlans, position); other != nil {
+			otherID := other.ID
+			affected.NewYardPlanID = &otherID
+		} else {
+			impact.StrandedCount++
+		}
+
+		impact.AffectedContainers = append(impact.AffectedContainers, affected)
+	}
+
+	var overlapping []model.YardPlan
+	if err := s.YardPlanRepository.FindOverlappingPlans(db, &overlapping, &proposed); err != nil {
+		return nil, nil, response.RepositoryError("Failed to check overlapping yard plans: " + err.Error())
+	}
+	impact.Overlaps = toYardPlanResponses(overlapping)
+
+	return &proposed, impact, nil
+}
+
+func (s *YardPlanServiceImpl) FindPlanByID(ctx context.Context, planID int) (*web.YardPlanResponse, *response.CustomError) {
+	var plan model.YardPlan
+	if err := s.YardPlanRepository.FindByID(s.DB, &plan, planID); err != nil {
+		return nil, response.NotFoundError("Yard plan not found.")
+	}
+
+	return toYardPlanResponse(&plan), nil
+}
+
+func (s *YardPlanServiceImpl) FindPlans(ctx context.Context, query *web.YardPlanQuery) ([]web.YardPlanResponse, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var plans []model.YardPlan
+	var err error
+
+	if query.At != nil {
+		err = s.YardPlanRepository.FindActivePlansByBlock(s.DB, &plans, query.BlockID, *query.At)
+	} else {
+		err = s.YardPlanRepository.FindPlansByBlock(s.DB, &plans, query.BlockID)
+	}
+
+	if err != nil {
+		return nil, response.RepositoryError("Failed to fetch yard plans: " + err.Error())
+	}
+
+	return toYardPlanResponses(plans), nil
+}
+
+// yardPlanColumns are the columns of a yard plan export.
+var yardPlanColumns = []string{
+	"id", "yard", "block", "plan_name", "version", "status", "is_active",
+	"slot_start", "slot_end", "row_start", "row_end",
+	"container_size", "container_height", "container_type", "priority_stacking_direction",
+	"valid_from", "valid_to", "capacity_teu",
+}
+
+func (s *YardPlanServiceImpl) ExportPlans(ctx context.Context, query *web.YardPlanExportQuery) (*web.TableExport, *response.CustomError) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var yard model.Yard
+	if err := s.YardRepository.FindYardByName(s.DB, &yard, query.YardName); err != nil {
+		return nil, response.NotFoundError("Yard not found.")
+	}
+
+	var blocks []model.Block
+	if err := s.YardRepository.FindBlocksByYardID(s.DB, &blocks, yard.ID); err != nil {
+		return nil, response.RepositoryError("Failed to fetch blocks: " + err.Error())
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	return &web.TableExport{
+		FileName:  "yard_plans_" + strings.ReplaceAll(yard.Name, " ", "_") + "_" + time.Now().Format("2006-01-02") + "." + query.Format,
+		SheetName: "Yard plans",
+		Header:    yardPlanColumns,
+		Rows: func(write func(row []string) error) error {
+			for i := range blocks {
+				block := &blocks[i]
+
+				var plans []model.YardPlan
+				if err := s.YardPlanRepository.FindPlansByBlock(s.DB, &plans, block.ID); err != nil {
+					return err
+				}
+
+				for j := range plans {
+					plan := &plans[j]
+					row := []string{
+						strconv.Itoa(plan.ID), yard.Name, block.Name, plan.PlanName,
+						strconv.Itoa(plan.Version), plan.Status, strconv.FormatBool(plan.IsActive),
+						strconv.Itoa(plan.SlotStart), strconv.Itoa(plan.SlotEnd), strconv.Itoa(plan.RowStart), strconv.Itoa(plan.RowEnd),
+						plan.ContainerSize, plan.ContainerHeight, plan.ContainerType, plan.PriorityStackingDirection,
+						formatTime(plan.ValidFrom), formatTime(plan.ValidTo), strconv.Itoa(planCapacity(plan, block).TEU),
+					}
+					if err := write(row); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+func (s *YardPlanServiceImpl) SyncActivePlans(ctx context.Context) (int64, *response.CustomError) {
+	var changed []model.YardPlan
+
+	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.YardPlanRepository.SyncActiveFlags(tx, &changed, time.Now()); err != nil {
+			return err
+		}
+
+		for i := range changed {
+			change := PlanChangeDeactivated
+			if changed[i].IsActive {
+				change = PlanChangeActivated
+			}
+			if err := publishPlanChanged(tx, s.OutboxEventRepository, &changed[i], change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if txErr != nil {
+		return 0, response.RepositoryError("Failed to sync active yard plans: " + txErr.Error())
+	}
+	return int64(len(changed)), nil
+}
+
+func (s *YardPlanServiceImpl) checkPlanFitsBlock(db *gorm.DB, request *web.YardPlanRequest) *response.CustomError {
+	var block model.Block
+	if err := s.YardRepository.FindBlockByID(db, &block, request.BlockID); err != nil {
+		return response.NotFoundError("Block not found.")
+	}
+
+	if request.SlotEnd > block.Slots || request.RowEnd > block.Rows {
+		return response.BadRequestError("Yard plan area is outside the Block dimensions.")
+	}
+	return nil
+}
+
+// planCapacity counts the positions a plan offers over the full stack height.
+// A 40ft plan can only use slot pairs, so a trailing odd slot is not counted.
+func planCapacity(plan *model.YardPlan, block *model.Block) web.PlanCapacity {
+	slots := plan.SlotEnd - plan.SlotStart + 1
+	rows := plan.RowEnd - plan.RowStart + 1
+	teuPerPosition := 1
+
+	if plan.ContainerSize == "40ft" {
+		slots /= 2
+		teuPerPosition = 2
+	}
+
+	positions := slots * rows * block.Tiers
+	return web.PlanCapacity{
+		Positions: positions,
+		TEU:       positions * teuPerPosition,
+	}
+}
+
+func newDraftPlan(request *web.YardPlanRequest) model.YardPlan {
+	return model.YardPlan{
+		BlockID:   request.BlockID,
+		PlanName:  request.PlanName,
+		SlotStart: request.SlotStart,
+		SlotEnd:   request.SlotEnd,
+		RowStart:  request.RowStart,
+		RowEnd:    request.RowEnd,
+
+		ContainerSize:   request.ContainerSize,
+		ContainerHeight: request.ContainerHeight,
+		ContainerType:   request.ContainerType,
+
+		PriorityStackingDirection: request.PriorityStackingDirection,
+		IsActive:                  false,
+		Status:                    model.YardPlanStatusDraft,
+
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// draftExistsError rejects a second draft version of a plan, the pending one
+// has to be published or edited instead.
+func draftExistsError(draft *model.YardPlan) *response.CustomError {
+	customErr := response.ConflictError("Version " + strconv.Itoa(draft.Version) + " of this yard plan is still a draft, publish or edit it first.")
+	customErr.AdditionalInfo = toYardPlanResponse(draft)
+	return customErr
+}
+
+// stalePlanError rejects a change sent with an If-Match other than the
+// current revision of the plan.
+func stalePlanError(plan *model.YardPlan) *response.CustomError {
+	customErr := response.PreconditionFailedError("Yard plan was changed since it was read, reload it and retry.")
+	customErr.AdditionalInfo = map[string]any{
+		"revision": plan.Revision,
+	}
+	return customErr
+}
+
+func toYardPlanResponse(plan *model.YardPlan) *web.YardPlanResponse {
+	return &web.YardPlanResponse{
+		ID:       plan.ID,
+		BlockID:  plan.BlockID,
+		PlanName: plan.PlanName,
+
+		SlotStart: plan.SlotStart,
+		SlotEnd:   plan.SlotEnd,
+		RowStart:  plan.RowStart,
+		RowEnd:    plan.RowEnd,
+
+		ContainerSize:   plan.ContainerSize,
+		ContainerHeight: plan.ContainerHeight,
+		ContainerType:   plan.ContainerType,
+
+		PriorityStackingDirection: plan.PriorityStackingDirection,
+
+		Version:           plan.Version,
+		PreviousVersionID: plan.PreviousVersionID,
+		Status:            plan.Status,
+		IsActive:          plan.IsActive,
+		ValidFrom:         plan.ValidFrom,
+		ValidTo:           plan.ValidTo,
+		PublishedAt:       plan.PublishedAt,
+		Revision:          plan.Revision,
+	}
+}
+
+func toYardPlanResponses(plans []model.YardPlan) []web.YardPlanResponse {
+	responses := make([]web.YardPlanResponse, 0, len(plans))
+	for i := range plans {
+		responses = append(responses, *toYardPlanResponse(&plans[i]))
+	}
+	return responses
+}
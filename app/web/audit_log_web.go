@@ -0,0 +1,56 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+	"yard-planning/helper/auditlog"
+)
+
+type AuditLogQuery struct {
+	EntityType string `form:"entity_type" validate:"required_with=EntityID"`
+	EntityID   string `form:"entity_id"`
+	Actor      string `form:"actor"`
+
+	From *time.Time `form:"from"`
+	To   *time.Time `form:"to"`
+
+	Limit int `form:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type AuditLogResponse struct {
+	ID             int             `json:"id"`
+	Actor          string          `json:"actor,omitempty"`
+	Method         string          `json:"method"`
+	Endpoint       string          `json:"endpoint"`
+	Path           string          `json:"path"`
+	RequestPayload json.RawMessage `json:"request_payload,omitempty"`
+	ClientIP       string          `json:"client_ip"`
+	StatusCode     int             `json:"status_code"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	Changes []AuditLogChangeResponse `json:"changes"`
+}
+
+type AuditLogChangeResponse struct {
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditLogRequest is what the audit middleware captured of a request.
+type AuditLogRequest struct {
+	Actor      string
+	Method     string
+	Endpoint   string
+	Path       string
+	ClientIP   string
+	StatusCode int
+
+	ContentType string
+	// Body up to the capture limit, BodySize is the full size
+	Body     []byte
+	BodySize int64
+
+	Changes []auditlog.Change
+}
@@ -0,0 +1,96 @@
+package web
+
+import "time"
+
+type TariffTierRequest struct {
+	FromDay   int   `json:"from_day" validate:"required,min=1"`
+	DailyRate int64 `json:"daily_rate" validate:"min=0"`
+}
+
+type TariffRequest struct {
+	Name string `json:"name" validate:"required"`
+
+	// Optional, empty values match any yard, size or type
+	YardName      string `json:"yard"`
+	ContainerSize string `json:"container_size" validate:"omitempty,oneof=20ft 40ft"`
+	ContainerType string `json:"container_type"`
+
+	FreeDays        int                 `json:"free_days" validate:"min=0"`
+	ReeferDailyRate int64               `json:"reefer_daily_rate" validate:"min=0"`
+	Tiers           []TariffTierRequest `json:"tiers" validate:"required,min=1,dive"`
+}
+
+type TariffTierResponse struct {
+	FromDay   int   `json:"from_day"`
+	DailyRate int64 `json:"daily_rate"`
+}
+
+type TariffResponse struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	YardID        *int   `json:"yard_id,omitempty"`
+	Yard          string `json:"yard,omitempty"`
+	ContainerSize string `json:"container_size,omitempty"`
+	ContainerType string `json:"container_type,omitempty"`
+
+	FreeDays        int                  `json:"free_days"`
+	ReeferDailyRate int64                `json:"reefer_daily_rate"`
+	Tiers           []TariffTierResponse `json:"tiers"`
+}
+
+type InvoicePreviewQuery struct {
+	// One of container_number or shipping_line is required
+	ContainerNumber string `form:"container_number"`
+	ShippingLine    string `form:"shipping_line"`
+
+	// Billing period, both days included
+	From time.Time `form:"from" time_format:"2006-01-02" validate:"required"`
+	To   time.Time `form:"to" time_format:"2006-01-02" validate:"required,gtefield=From"`
+}
+
+type StorageCharge struct {
+	FromDay   int   `json:"from_day"`
+	ToDay     int   `json:"to_day"`
+	Days      int   `json:"days"`
+	DailyRate int64 `json:"daily_rate"`
+	Amount    int64 `json:"amount"`
+}
+
+type InvoiceLine struct {
+	ContainerNumber string     `json:"container_number"`
+	ShippingLine    string     `json:"shipping_line,omitempty"`
+	ContainerSize   string     `json:"container_size"`
+	ContainerType   string     `json:"container_type"`
+	Yard            string     `json:"yard"`
+	ArrivalDate     time.Time  `json:"arrival_date"`
+	DepartureDate   *time.Time `json:"departure_date,omitempty"`
+
+	TariffID   *int   `json:"tariff_id,omitempty"`
+	TariffName string `json:"tariff_name,omitempty"`
+
+	// Billed days of the visit, counted from the arrival day as day 1
+	BilledFrom   string `json:"billed_from"`
+	BilledTo     string `json:"billed_to"`
+	FromDay      int    `json:"from_day"`
+	ToDay        int    `json:"to_day"`
+	Days         int    `json:"days"`
+	FreeDaysUsed int    `json:"free_days_used"`
+
+	StorageCharges []StorageCharge `json:"storage_charges"`
+	StorageAmount  int64           `json:"storage_amount"`
+	ReeferDays     int             `json:"reefer_days,omitempty"`
+	ReeferAmount   int64           `json:"reefer_amount,omitempty"`
+	Amount         int64           `json:"amount"`
+
+	Note string `json:"note,omitempty"`
+}
+
+type InvoicePreviewResponse struct {
+	ContainerNumber string        `json:"container_number,omitempty"`
+	ShippingLine    string        `json:"shipping_line,omitempty"`
+	From            string        `json:"from"`
+	To              string        `json:"to"`
+	Currency        string        `json:"currency"`
+	Lines           []InvoiceLine `json:"lines"`
+	Total           int64         `json:"total"`
+}
@@ -0,0 +1,74 @@
+package web
+
+type BlockViewQuery struct {
+	Format string `form:"format" validate:"omitempty,oneof=json ascii svg"`
+	View   string `form:"view" validate:"omitempty,oneof=all bay top"`
+
+	// Optional, only render the bay of this slot
+	Slot int `form:"slot" validate:"omitempty,min=1"`
+}
+
+type BlockViewPlan struct {
+	Code            string `json:"code"`
+	ID              int    `json:"id"`
+	PlanName        string `json:"plan_name"`
+	SlotStart       int    `json:"slot_start"`
+	SlotEnd         int    `json:"slot_end"`
+	RowStart        int    `json:"row_start"`
+	RowEnd          int    `json:"row_end"`
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+}
+
+type BlockViewCell struct {
+	Slot int `json:"slot"`
+	Row  int `json:"row"`
+	Tier int `json:"tier"`
+
+	ContainerNumber string `json:"container_number,omitempty"`
+	ContainerSize   string `json:"container_size,omitempty"`
+	ContainerHeight string `json:"container_height,omitempty"`
+	ContainerType   string `json:"container_type,omitempty"`
+
+	// IsTail marks the second slot covered by a 40ft container
+	IsTail bool `json:"is_tail,omitempty"`
+
+	// Set on empty cells held for a container that has not been placed yet
+	ReservedFor       string `json:"reserved_for,omitempty"`
+	ReservationSource string `json:"reservation_source,omitempty"`
+
+	PlanCode string `json:"plan_code,omitempty"`
+}
+
+// BayView is the row x tier cross-section of one slot, Tiers[0] is the ground tier.
+type BayView struct {
+	Slot  int               `json:"slot"`
+	Tiers [][]BlockViewCell `json:"tiers"`
+}
+
+type TopViewCell struct {
+	Slot        int    `json:"slot"`
+	Row         int    `json:"row"`
+	StackHeight int    `json:"stack_height"`
+	TopNumber   string `json:"top_container_number,omitempty"`
+	PlanCode    string `json:"plan_code,omitempty"`
+}
+
+type BlockViewResponse struct {
+	BlockID int    `json:"block_id"`
+	Block   string `json:"block"`
+	Slots   int    `json:"slots"`
+	Rows    int    `json:"rows"`
+	Tiers   int    `json:"tiers"`
+
+	Plans []BlockViewPlan `json:"plans"`
+	Bays  []BayView       `json:"bays,omitempty"`
+
+	// Top is the slot x row view, Top[0] is row 1.
+	Top [][]TopViewCell `json:"top,omitempty"`
+
+	// Containers recorded at a cell outside the block dimensions, such as
+	// a box parked by a yard audit. They are not drawn.
+	OutOfBlock []string `json:"out_of_block,omitempty"`
+}
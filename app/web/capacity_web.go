@@ -0,0 +1,81 @@
+package web
+
+import "time"
+
+type CapacityReportQuery struct {
+	// Optional, all yards when empty
+	YardName string `form:"yard"`
+}
+
+type CapacityHistoryQuery struct {
+	YardName   string `form:"yard" validate:"required"`
+	BlockID    int    `form:"block_id" validate:"omitempty,min=1"`
+	YardPlanID int    `form:"yard_plan_id" validate:"omitempty,min=1"`
+
+	// Optional, defaults to the last 30 days
+	From *time.Time `form:"from"`
+	To   *time.Time `form:"to"`
+}
+
+// CapacityFigures are expressed in TEU, a 40ft container counts as two.
+// Overflow is occupied space outside every active plan and is not part of
+// the total.
+type CapacityFigures struct {
+	TotalTEU    int     `json:"total_teu"`
+	OccupiedTEU int     `json:"occupied_teu"`
+	ReservedTEU int     `json:"reserved_teu"`
+	FreeTEU     int     `json:"free_teu"`
+	OverflowTEU int     `json:"overflow_teu"`
+	Utilization float64 `json:"utilization"`
+}
+
+type CapacitySpecReport struct {
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+	CapacityFigures
+}
+
+type PlanCapacityReport struct {
+	YardPlanID      int    `json:"yard_plan_id"`
+	PlanName        string `json:"plan_name"`
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+	CapacityFigures
+}
+
+type BlockCapacityReport struct {
+	BlockID int    `json:"block_id"`
+	Block   string `json:"block"`
+	CapacityFigures
+	BySpec []CapacitySpecReport `json:"by_spec"`
+	Plans  []PlanCapacityReport `json:"plans"`
+}
+
+type YardCapacityReport struct {
+	YardID int    `json:"yard_id"`
+	Yard   string `json:"yard"`
+	CapacityFigures
+	BySpec []CapacitySpecReport  `json:"by_spec"`
+	Blocks []BlockCapacityReport `json:"blocks"`
+}
+
+type CapacityReportResponse struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Yards       []YardCapacityReport `json:"yards"`
+}
+
+type CapacitySnapshotResponse struct {
+	SnapshotDate string `json:"snapshot_date"`
+	Level        string `json:"level"`
+	YardID       int    `json:"yard_id"`
+	BlockID      *int   `json:"block_id,omitempty"`
+	YardPlanID   *int   `json:"yard_plan_id,omitempty"`
+	CapacityFigures
+}
+
+type CapacitySnapshotResult struct {
+	SnapshotDate string `json:"snapshot_date"`
+	Snapshots    int    `json:"snapshots"`
+}
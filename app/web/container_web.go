@@ -28,11 +28,43 @@ type PlacementRequest struct {
 	Size   string `json:"container_size" validate:"required,oneof=20ft 40ft"`
 	Height string `json:"container_height" validate:"required,oneof=8.6ft 9.6ft"`
 	Type   string `json:"container_type" validate:"required"`
+
+	// Optional, used to keep one vessel per stack
+	Vessel string `json:"vessel"`
+	Voyage string `json:"voyage"`
+
+	// Optional, the line billed for storage
+	ShippingLine string `json:"shipping_line"`
+}
+
+type MoveRequest struct {
+	YardName        string `json:"yard" validate:"required"`
+	ContainerNumber string `json:"container_number" validate:"required"`
+
+	BlockName string `json:"block" validate:"required"`
+	Slot      int    `json:"slot" validate:"required,min=1"`
+	Row       int    `json:"row" validate:"required,min=1"`
+	Tier      int    `json:"tier" validate:"required,min=1"`
+
+	// Optional, the yard of the target block for a transfer to another
+	// yard. Empty keeps the container in its yard.
+	ToYardName string `json:"to_yard"`
+
+	Reason string `json:"reason"`
+
+	// Revision of the container the client last read, from the If-Match
+	// header. Zero skips the check.
+	Revision int `json:"-"`
 }
 
 type PickupRequest struct {
 	YardName        string `json:"yard" validate:"required"`
 	ContainerNumber string `json:"container_number" validate:"required"`
+
+	// The release order authorizing the pickup
+	ReleaseNumber   string `json:"release_number" validate:"required"`
+	PIN             string `json:"pin" validate:"required"`
+	TruckingCompany string `json:"trucking_company" validate:"required"`
 }
 
 type PositionRequest struct {
@@ -51,12 +83,50 @@ type PositionResponse struct {
 
 	BlockID    int  `json:"-"`
 	YardPlanID *int `json:"-"`
+
+	// Set by placement when the container was pre-advised
+	PreAdviceID *int     `json:"pre_advice_id,omitempty"`
+	Mismatches  []string `json:"spec_mismatches,omitempty"`
 }
 
 type SuggestedPositionResponse struct {
 	SuggestedPosition PositionResponse `json:"suggested_position"`
 }
 
+const (
+	BatchModeAllOrNothing = "ALL_OR_NOTHING"
+	BatchModeBestEffort   = "BEST_EFFORT"
+)
+
+type BatchContainerRequest struct {
+	Containers []ContainerRequest `json:"containers" validate:"required,min=1,max=500"`
+}
+
+type BatchPlacementRequest struct {
+	// ALL_OR_NOTHING (default) rolls back the whole batch when one container fails,
+	// BEST_EFFORT keeps every container that could be placed.
+	Mode       string             `json:"mode" validate:"omitempty,oneof=ALL_OR_NOTHING BEST_EFFORT"`
+	Containers []PlacementRequest `json:"containers" validate:"required,min=1,max=500"`
+}
+
+type BatchItemResult struct {
+	ContainerNumber string            `json:"container_number"`
+	Success         bool              `json:"success"`
+	Position        *PositionResponse `json:"position,omitempty"`
+	Error           string            `json:"error,omitempty"`
+
+	// Set when the placement was queued as a work instruction
+	WorkInstructionID *int `json:"work_instruction_id,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode      string            `json:"mode,omitempty"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
 type GeneralResponse struct {
 	Message string `json:"message"`
 }
@@ -0,0 +1,31 @@
+package web
+
+type DispatchQuery struct {
+	YardName string `form:"yard" validate:"required"`
+}
+
+type DispatchJobResponse struct {
+	WorkInstructionID int     `json:"work_instruction_id"`
+	ContainerNumber   string  `json:"container_number"`
+	WorkType          string  `json:"work_type"`
+	Priority          int     `json:"priority"`
+	Sequence          int     `json:"sequence"`
+	TravelDistance    float64 `json:"travel_distance_m"`
+	CarryDistance     float64 `json:"carry_distance_m"`
+}
+
+type DispatchEquipmentResponse struct {
+	EquipmentID   int                   `json:"equipment_id"`
+	Code          string                `json:"code"`
+	TotalDistance float64               `json:"total_distance_m"`
+	Jobs          []DispatchJobResponse `json:"jobs"`
+}
+
+type DispatchPlanResponse struct {
+	Yard          string                      `json:"yard"`
+	Strategy      string                      `json:"strategy"`
+	Simulated     bool                        `json:"simulated"`
+	TotalDistance float64                     `json:"total_distance_m"`
+	Equipment     []DispatchEquipmentResponse `json:"equipment"`
+	Unassigned    []int                       `json:"unassigned_work_instructions"`
+}
@@ -0,0 +1,66 @@
+package web
+
+import "time"
+
+type DwellThresholdRequest struct {
+	// Optional, applies to every yard when empty
+	YardName string `json:"yard"`
+	// Optional, applies to every container type when empty
+	ContainerType string `json:"container_type"`
+
+	FreeDays int    `json:"free_days" validate:"min=0"`
+	Action   string `json:"action" validate:"required,oneof=REPORT NOTIFY MARK_LONG_STAY"`
+}
+
+type DwellThresholdResponse struct {
+	ID            int    `json:"id"`
+	YardID        *int   `json:"yard_id,omitempty"`
+	Yard          string `json:"yard,omitempty"`
+	ContainerType string `json:"container_type,omitempty"`
+	FreeDays      int    `json:"free_days"`
+	Action        string `json:"action"`
+}
+
+type LongStayQuery struct {
+	YardName      string `form:"yard"`
+	ContainerType string `form:"container_type"`
+}
+
+type LongStayContainer struct {
+	ContainerDetailResponse
+
+	FreeDays    int    `json:"free_days"`
+	OverdueDays int    `json:"overdue_days"`
+	ThresholdID *int   `json:"threshold_id,omitempty"` // empty when the default free time applies
+	Action      string `json:"action"`
+}
+
+type LongStayReportResponse struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Total       int                 `json:"total"`
+	Items       []LongStayContainer `json:"items"`
+}
+
+type DwellEnforcementResponse struct {
+	Overdue        int `json:"overdue"`
+	Alerted        int `json:"alerted"`
+	MarkedLongStay int `json:"marked_long_stay"`
+	// Long stay containers queued for a move to the long term yard
+	QueuedTransfers int `json:"queued_transfers"`
+}
+
+type DwellAlertQuery struct {
+	// Optional, defaults to the last 7 days
+	Since *time.Time `form:"since"`
+}
+
+type DwellAlertResponse struct {
+	ID              int       `json:"id"`
+	ContainerNumber string    `json:"container_number"`
+	ArrivalDate     time.Time `json:"arrival_date"`
+	YardID          int       `json:"yard_id"`
+	DwellDays       int       `json:"dwell_days"`
+	FreeDays        int       `json:"free_days"`
+	Action          string    `json:"action"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,103 @@
+package web
+
+import "time"
+
+type EDIPartnerRequest struct {
+	ShippingLine string `json:"shipping_line" validate:"required,max=50"`
+	SenderID     string `json:"sender_id" validate:"required,max=35"`
+	RecipientID  string `json:"recipient_id" validate:"required,max=35"`
+	// IANA time zone, Asia/Jakarta when empty
+	Timezone string `json:"timezone" validate:"omitempty,timezone,max=64"`
+}
+
+type EDIPartnerResponse struct {
+	ID           int       `json:"id"`
+	ShippingLine string    `json:"shipping_line"`
+	SenderID     string    `json:"sender_id"`
+	RecipientID  string    `json:"recipient_id"`
+	Timezone     string    `json:"timezone"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CODECOQuery struct {
+	ShippingLine string `form:"shipping_line" validate:"required"`
+
+	// Report period, both days included
+	From time.Time `form:"from" time_format:"2006-01-02" validate:"required"`
+	To   time.Time `form:"to" time_format:"2006-01-02" validate:"required,gtefield=From"`
+}
+
+type COARRIQuery struct {
+	ShippingLine string `form:"shipping_line" validate:"required"`
+	Vessel       string `form:"vessel" validate:"required"`
+	Voyage       string `form:"voyage" validate:"required"`
+}
+
+type COARRIImportQuery struct {
+	YardName string `form:"yard" validate:"required"`
+
+	// Optional, the arrival window of the containers. Defaults to the vessel
+	// ETA (DTM+132) of the message and the 48 hours after it.
+	ExpectedFrom *time.Time `form:"expected_from"`
+	ExpectedTo   *time.Time `form:"expected_to"`
+}
+
+type COARRIImportResponse struct {
+	Messages   int                     `json:"messages"`
+	PreAdvices *PreAdviceBatchResponse `json:"pre_advices"`
+}
+
+type EDIDocument struct {
+	FileName   string
+	Content    string
+	Messages   int
+	Containers int
+}
+
+type BAPLIEImportQuery struct {
+	YardName string `form:"yard" validate:"required"`
+	// UN/LOCODE of this terminal, only containers discharged here are imported
+	Port string `form:"port" validate:"required,len=5"`
+
+	// Optional, only computes the capacity forecast without pre-advising
+	ForecastOnly bool `form:"forecast_only"`
+
+	// Optional, the arrival window of the containers. Defaults to the vessel
+	// ETA of the message and the 48 hours after it.
+	ExpectedFrom *time.Time `form:"expected_from"`
+	ExpectedTo   *time.Time `form:"expected_to"`
+}
+
+// BAPLIEForecast compares the free space of one yard plan with the
+// containers the vessels bring for it. Containers no plan has room for are
+// listed per container specification without a plan, their remaining TEU is
+// negative.
+type BAPLIEForecast struct {
+	YardPlanID      int    `json:"yard_plan_id,omitempty"`
+	PlanName        string `json:"plan_name,omitempty"`
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+	Containers      int    `json:"incoming_containers"`
+	IncomingTEU     int    `json:"incoming_teu"`
+	FreeTEU         int    `json:"free_teu"`
+	RemainingTEU    int    `json:"remaining_teu"`
+	HasRoom         bool   `json:"has_room"`
+}
+
+type BAPLIEForecastResponse struct {
+	Yard        string           `json:"yard"`
+	IncomingTEU int              `json:"incoming_teu"`
+	FreeTEU     int              `json:"free_teu"`
+	HasRoom     bool             `json:"has_room"`
+	ByPlan      []BAPLIEForecast `json:"by_plan"`
+}
+
+type BAPLIEImportResponse struct {
+	Messages int `json:"messages"`
+	// Containers on board and the part discharged in this port
+	Containers  int                     `json:"containers"`
+	Discharging int                     `json:"discharging"`
+	Forecast    *BAPLIEForecastResponse `json:"forecast"`
+	PreAdvices  *PreAdviceBatchResponse `json:"pre_advices,omitempty"`
+}
@@ -0,0 +1,29 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventStreamQuery struct {
+	YardName string `form:"yard"`
+	// Optional, requires the yard
+	BlockName string `form:"block"`
+
+	// Last event the client received, the events after it are sent first.
+	// Taken from the Last-Event-ID header when not set
+	LastEventID int `form:"last_event_id" validate:"omitempty,min=1"`
+}
+
+// StreamEvent is the data of one Server-Sent Event, its ID is the SSE
+// event ID.
+type StreamEvent struct {
+	ID          int             `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	YardID      *int            `json:"yard_id,omitempty"`
+	BlockID     *int            `json:"block_id,omitempty"`
+	ToBlockID   *int            `json:"to_block_id,omitempty"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
@@ -0,0 +1,82 @@
+package web
+
+import "time"
+
+type TruckRequest struct {
+	TruckPlate    string   `json:"truck_plate" validate:"required"`
+	DriverName    string   `json:"driver_name" validate:"required"`
+	DriverLicense string   `json:"driver_license"`
+	SealNumbers   []string `json:"seal_numbers" validate:"max=10,dive,required"`
+	DamageRemarks string   `json:"damage_remarks"`
+}
+
+type GateInRequest struct {
+	ContainerRequest
+	TruckRequest
+
+	Vessel           string `json:"vessel"`
+	Voyage           string `json:"voyage"`
+	ShippingLine     string `json:"shipping_line"`
+	BookingReference string `json:"booking_reference"`
+}
+
+type GateOutRequest struct {
+	PickupRequest
+	TruckRequest
+}
+
+type ConfirmGatePlacementRequest struct {
+	// Optional, the planned cell is used when block is empty
+	BlockName string `json:"block"`
+	Slot      int    `json:"slot" validate:"omitempty,min=1"`
+	Row       int    `json:"row" validate:"omitempty,min=1"`
+	Tier      int    `json:"tier" validate:"omitempty,min=1"`
+}
+
+type GateTransactionResponse struct {
+	ID              int    `json:"id"`
+	Direction       string `json:"direction"`
+	Status          string `json:"status"`
+	ContainerNumber string `json:"container_number"`
+	Yard            string `json:"yard"`
+
+	ContainerSize   string `json:"container_size,omitempty"`
+	ContainerHeight string `json:"container_height,omitempty"`
+	ContainerType   string `json:"container_type,omitempty"`
+
+	TruckPlate    string   `json:"truck_plate"`
+	DriverName    string   `json:"driver_name"`
+	DriverLicense string   `json:"driver_license,omitempty"`
+	SealNumbers   []string `json:"seal_numbers"`
+	DamageRemarks string   `json:"damage_remarks,omitempty"`
+
+	BookingReference string `json:"booking_reference,omitempty"`
+	ReleaseReference string `json:"release_reference,omitempty"`
+
+	// Planned or actual cell for a gate-in, origin cell for a gate-out
+	Position *PositionResponse `json:"position,omitempty"`
+
+	RejectReason string     `json:"reject_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+type HoldRequest struct {
+	ContainerNumber string `json:"container_number" validate:"required"`
+	HoldType        string `json:"hold_type" validate:"required,oneof=CUSTOMS LINE TERMINAL DAMAGE"`
+	Reason          string `json:"reason"`
+}
+
+type HoldQuery struct {
+	ContainerNumber string `form:"container_number" validate:"required"`
+	ActiveOnly      bool   `form:"active"`
+}
+
+type HoldResponse struct {
+	ID              int        `json:"id"`
+	ContainerNumber string     `json:"container_number"`
+	HoldType        string     `json:"hold_type"`
+	Reason          string     `json:"reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ReleasedAt      *time.Time `json:"released_at,omitempty"`
+}
@@ -0,0 +1,44 @@
+package web
+
+type HousekeepingRequest struct {
+	YardName  string `json:"yard" validate:"required"`
+	BlockName string `json:"block"`
+
+	// Optional, limits the number of physical moves in one plan
+	MaxMoves int `json:"max_moves" validate:"omitempty,min=1"`
+}
+
+type HousekeepingMove struct {
+	Sequence        int              `json:"sequence"`
+	ContainerNumber string           `json:"container_number"`
+	Reason          string           `json:"reason"`
+	From            PositionResponse `json:"from"`
+	To              PositionResponse `json:"to"`
+	YardPlanID      int              `json:"yard_plan_id"`
+}
+
+// HousekeepingRelink is a container that already sits inside a matching plan
+// but is linked to the wrong one, it only needs its yard_plan_id corrected.
+type HousekeepingRelink struct {
+	ContainerNumber string           `json:"container_number"`
+	Position        PositionResponse `json:"position"`
+	FromYardPlanID  *int             `json:"from_yard_plan_id"`
+	ToYardPlanID    int              `json:"to_yard_plan_id"`
+}
+
+type HousekeepingIssue struct {
+	ContainerNumber string           `json:"container_number"`
+	Reason          string           `json:"reason"`
+	Position        PositionResponse `json:"position"`
+	Message         string           `json:"message"`
+}
+
+type HousekeepingPlanResponse struct {
+	DryRun     bool                 `json:"dry_run"`
+	Moves      []HousekeepingMove   `json:"moves"`
+	Relinks    []HousekeepingRelink `json:"relinks"`
+	Unresolved []HousekeepingIssue  `json:"unresolved"`
+
+	// Set by execution, one instruction per move in sequence order
+	WorkInstructions []WorkInstructionResponse `json:"work_instructions,omitempty"`
+}
@@ -0,0 +1,21 @@
+package web
+
+// IdempotencyRequest is a request sent with an Idempotency-Key header.
+type IdempotencyRequest struct {
+	Actor  string
+	Key    string
+	Method string
+	// Path with the query string, the same key on another resource is a
+	// different request
+	Path string
+	// Hex SHA-256 of the request body
+	BodyHash string
+}
+
+// StoredResponse is the response of the first request with a key, replayed
+// to its retries.
+type StoredResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,119 @@
+package web
+
+import "time"
+
+type ContainerSearchQuery struct {
+	YardName   string `form:"yard"`
+	BlockName  string `form:"block"`
+	Size       string `form:"container_size" validate:"omitempty,oneof=20ft 40ft"`
+	Height     string `form:"container_height" validate:"omitempty,oneof=8.6ft 9.6ft"`
+	Type       string `form:"container_type"`
+	Status     string `form:"container_status"`
+	YardPlanID int    `form:"yard_plan_id" validate:"omitempty,min=1"`
+
+	ArrivalFrom  *time.Time `form:"arrival_from"`
+	ArrivalTo    *time.Time `form:"arrival_to"`
+	MinDwellDays *int       `form:"min_dwell_days" validate:"omitempty,min=0"`
+	MaxDwellDays *int       `form:"max_dwell_days" validate:"omitempty,min=0"`
+
+	// dwell_time sorts by time in the yard, which is arrival_date reversed
+	Sort   string `form:"sort" validate:"omitempty,oneof=arrival_date container_number dwell_time"`
+	Order  string `form:"order" validate:"omitempty,oneof=asc desc"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=500"`
+	Cursor string `form:"cursor"`
+}
+
+type ContainerDetailResponse struct {
+	ContainerNumber string `json:"container_number"`
+
+	YardID  int    `json:"yard_id"`
+	Yard    string `json:"yard"`
+	BlockID int    `json:"block_id"`
+	Block   string `json:"block"`
+	Slot    int    `json:"slot"`
+	Row     int    `json:"row"`
+	Tier    int    `json:"tier"`
+
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+	ContainerStatus string `json:"container_status"`
+
+	ArrivalDate time.Time `json:"arrival_date"`
+	DwellDays   int       `json:"dwell_days"`
+	YardPlanID  *int      `json:"yard_plan_id,omitempty"`
+	Vessel      string    `json:"vessel,omitempty"`
+	Voyage      string    `json:"voyage,omitempty"`
+
+	ShippingLine string `json:"shipping_line,omitempty"`
+	Revision     int    `json:"revision"`
+}
+
+type ContainerListResponse struct {
+	Items      []ContainerDetailResponse `json:"items"`
+	Total      int64                     `json:"total"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
+
+type InventoryImportQuery struct {
+	Format string `form:"format" validate:"required,oneof=csv xlsx"`
+	// Optional, validates the file and reports what would be imported
+	DryRun bool `form:"dry_run"`
+}
+
+// InventoryImportRow is one data row of an inventory file, checked like a
+// placement request.
+type InventoryImportRow struct {
+	YardName        string `validate:"required"`
+	BlockName       string `validate:"required"`
+	ContainerNumber string `validate:"required,max=20"`
+
+	Slot int `validate:"required,min=1"`
+	Row  int `validate:"required,min=1"`
+	Tier int `validate:"required,min=1"`
+
+	Size   string `validate:"required,oneof=20ft 40ft"`
+	Height string `validate:"required,oneof=8.6ft 9.6ft"`
+	Type   string `validate:"required,max=50"`
+	Status string `validate:"required,oneof=STORAGE LONG_STAY"`
+
+	ArrivalDate  time.Time
+	Vessel       string `validate:"max=100"`
+	Voyage       string `validate:"max=50"`
+	ShippingLine string `validate:"max=50"`
+}
+
+type InventoryImportError struct {
+	Line            int    `json:"line"`
+	ContainerNumber string `json:"container_number,omitempty"`
+	Error           string `json:"error"`
+}
+
+// InventoryImportResponse reports an import. A committed import is all or
+// nothing, Imported stays zero when any row is invalid. Only the first
+// errors are listed, ErrorsTruncated tells that there were more.
+type InventoryImportResponse struct {
+	DryRun          bool                   `json:"dry_run"`
+	Rows            int                    `json:"rows"`
+	Valid           int                    `json:"valid"`
+	Invalid         int                    `json:"invalid"`
+	Imported        int                    `json:"imported"`
+	Errors          []InventoryImportError `json:"errors"`
+	ErrorsTruncated bool                   `json:"errors_truncated,omitempty"`
+}
+
+type InventoryExportQuery struct {
+	Format string `form:"format" validate:"required,oneof=csv xlsx"`
+	// Optional filters
+	YardName  string `form:"yard"`
+	BlockName string `form:"block"`
+}
+
+// TableExport is a file built row by row while it is sent. Rows calls write
+// for every data row, the header excluded.
+type TableExport struct {
+	FileName  string
+	SheetName string
+	Header    []string
+	Rows      func(write func(row []string) error) error
+}
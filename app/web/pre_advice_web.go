@@ -0,0 +1,77 @@
+package web
+
+import "time"
+
+type PreAdviceRequest struct {
+	ContainerRequest
+
+	Vessel       string `json:"vessel"`
+	Voyage       string `json:"voyage"`
+	ShippingLine string `json:"shipping_line"`
+
+	// Optional cargo details, usually taken from the vessel stowage plan
+	GrossWeight     int                    `json:"gross_weight_kg" validate:"omitempty,min=0"`
+	PortOfDischarge string                 `json:"port_of_discharge" validate:"omitempty,len=5"`
+	DangerousGoods  []DangerousGoodRequest `json:"dangerous_goods" validate:"omitempty,dive"`
+
+	ExpectedFrom time.Time `json:"expected_from" validate:"required"`
+	ExpectedTo   time.Time `json:"expected_to" validate:"required,gtefield=ExpectedFrom"`
+}
+
+type DangerousGoodRequest struct {
+	IMDGClass string `json:"imdg_class" validate:"required,max=5"`
+	UNNumber  string `json:"un_number" validate:"omitempty,len=4,numeric"`
+}
+
+type BatchPreAdviceRequest struct {
+	PreAdvices []PreAdviceRequest `json:"pre_advices" validate:"required,min=1,max=500"`
+}
+
+type PreAdviceQuery struct {
+	YardName string `form:"yard"`
+	// Optional, lists pre-advices expected before this time, defaults to a week ahead
+	Until *time.Time `form:"until"`
+}
+
+type PreAdviceResponse struct {
+	ID              int    `json:"id"`
+	ContainerNumber string `json:"container_number"`
+	YardID          int    `json:"yard_id"`
+	Status          string `json:"status"`
+	Source          string `json:"source"`
+
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+	Vessel          string `json:"vessel,omitempty"`
+	Voyage          string `json:"voyage,omitempty"`
+	ShippingLine    string `json:"shipping_line,omitempty"`
+
+	GrossWeight     int                    `json:"gross_weight_kg,omitempty"`
+	PortOfDischarge string                 `json:"port_of_discharge,omitempty"`
+	DangerousGoods  []DangerousGoodRequest `json:"dangerous_goods,omitempty"`
+
+	ExpectedFrom time.Time `json:"expected_from"`
+	ExpectedTo   time.Time `json:"expected_to"`
+
+	ProvisionalPosition *PositionResponse `json:"provisional_position,omitempty"`
+
+	ArrivedAt  *time.Time `json:"arrived_at,omitempty"`
+	Mismatches []string   `json:"spec_mismatches,omitempty"`
+}
+
+type PreAdviceResult struct {
+	ContainerNumber string             `json:"container_number"`
+	Success         bool               `json:"success"`
+	PreAdvice       *PreAdviceResponse `json:"pre_advice,omitempty"`
+	// Set when the pre-advice was stored without a provisional cell
+	Warning string `json:"warning,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type PreAdviceBatchResponse struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []PreAdviceResult `json:"results"`
+}
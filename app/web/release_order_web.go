@@ -0,0 +1,30 @@
+package web
+
+import "time"
+
+type ReleaseOrderRequest struct {
+	ReleaseNumber   string    `json:"release_number" validate:"required,max=50"`
+	PIN             string    `json:"pin" validate:"required,numeric,min=4,max=12"`
+	TruckingCompany string    `json:"trucking_company" validate:"required,max=100"`
+	ValidUntil      time.Time `json:"valid_until" validate:"required"`
+
+	ContainerNumbers []string `json:"container_numbers" validate:"required,min=1,max=500,dive,required"`
+}
+
+type ReleaseOrderContainerResponse struct {
+	ContainerNumber string     `json:"container_number"`
+	PickedUpAt      *time.Time `json:"picked_up_at,omitempty"`
+}
+
+type ReleaseOrderResponse struct {
+	ID              int       `json:"id"`
+	ReleaseNumber   string    `json:"release_number"`
+	TruckingCompany string    `json:"trucking_company"`
+	ValidUntil      time.Time `json:"valid_until"`
+	Status          string    `json:"status"`
+
+	Containers []ReleaseOrderContainerResponse `json:"containers"`
+	Remaining  int                             `json:"remaining"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookRequest struct {
+	URL string `json:"url" validate:"required,url,max=500"`
+	// Shared secret of the HMAC signature, required on create and kept on
+	// update when empty
+	Secret string `json:"secret" validate:"omitempty,min=16,max=255"`
+
+	// Optional, empty subscribes to every event type
+	EventTypes []string `json:"event_types" validate:"dive,oneof=ContainerPlaced ContainerPickedUp ContainerMoved ReservationChanged PlanChanged HoldSet DwellExceeded"`
+	// Optional, defaults to true
+	Active *bool `json:"active"`
+}
+
+type WebhookResponse struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryQuery struct {
+	WebhookID int `form:"webhook_id" validate:"omitempty,min=1"`
+	Limit     int `form:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID        int    `json:"id"`
+	WebhookID int    `json:"webhook_id"`
+	URL       string `json:"url,omitempty"`
+	EventID   int    `json:"event_id"`
+	EventType string `json:"event_type,omitempty"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type WebhookDispatchResponse struct {
+	// Outbox events turned into deliveries
+	Events     int `json:"events"`
+	Deliveries int `json:"deliveries"`
+
+	Delivered   int `json:"delivered"`
+	Retried     int `json:"retried"`
+	DeadLetters int `json:"dead_letters"`
+}
+
+// WebhookEvent is the body posted to a webhook.
+type WebhookEvent struct {
+	ID         int             `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
@@ -0,0 +1,80 @@
+package web
+
+import "time"
+
+type EquipmentRequest struct {
+	YardName      string   `json:"yard" validate:"required"`
+	Code          string   `json:"code" validate:"required,max=20"`
+	EquipmentType string   `json:"equipment_type" validate:"required,oneof=RTG REACH_STACKER"`
+	Blocks        []string `json:"blocks" validate:"required,min=1,dive,required"`
+
+	// Optional, defaults to true
+	Active *bool `json:"active"`
+
+	// Optional, where the equipment currently stands. Kept on update when empty.
+	CurrentBlock string `json:"current_block" validate:"required_with=CurrentSlot"`
+	CurrentSlot  int    `json:"current_slot" validate:"required_with=CurrentBlock,omitempty,min=1"`
+}
+
+type EquipmentQuery struct {
+	YardName string `form:"yard" validate:"required"`
+}
+
+type EquipmentResponse struct {
+	ID            int      `json:"id"`
+	Code          string   `json:"code"`
+	EquipmentType string   `json:"equipment_type"`
+	YardID        int      `json:"yard_id"`
+	Active        bool     `json:"active"`
+	Blocks        []string `json:"blocks"`
+	PendingJobs   int64    `json:"pending_jobs"`
+
+	CurrentBlock string `json:"current_block,omitempty"`
+	CurrentSlot  int    `json:"current_slot,omitempty"`
+}
+
+type WorkInstructionQuery struct {
+	YardName    string `form:"yard"`
+	EquipmentID int    `form:"equipment_id" validate:"omitempty,min=1"`
+	Status      string `form:"status" validate:"omitempty,oneof=PENDING CONFIRMED REJECTED"`
+}
+
+type ConfirmWorkInstructionRequest struct {
+	Operator string `json:"operator" validate:"required"`
+
+	// Optional, the equipment that did the job when it differs from the assigned one
+	EquipmentID int `json:"equipment_id" validate:"omitempty,min=1"`
+}
+
+type RejectWorkInstructionRequest struct {
+	Operator string `json:"operator" validate:"required"`
+	Reason   string `json:"reason" validate:"required,max=255"`
+}
+
+type WorkInstructionResponse struct {
+	ID              int    `json:"id"`
+	WorkType        string `json:"work_type"`
+	Status          string `json:"status"`
+	ContainerNumber string `json:"container_number"`
+	YardID          int    `json:"yard_id"`
+	Block           string `json:"block"`
+	EquipmentID     *int   `json:"equipment_id,omitempty"`
+	Priority        int    `json:"priority"`
+	Sequence        *int   `json:"sequence,omitempty"`
+	PredecessorID   *int   `json:"predecessor_id,omitempty"`
+
+	From *PositionResponse `json:"from,omitempty"`
+	To   *PositionResponse `json:"to,omitempty"`
+
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+
+	ReleaseOrderID *int   `json:"release_order_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+
+	Operator     string     `json:"operator,omitempty"`
+	RejectReason string     `json:"reject_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,94 @@
+package web
+
+import "time"
+
+type YardAuditRequest struct {
+	YardName  string `json:"yard" validate:"required"`
+	CreatedBy string `json:"created_by" validate:"required,max=100"`
+
+	// Optional, audits a single block instead of the whole yard
+	BlockName string `json:"block"`
+}
+
+type YardAuditQuery struct {
+	YardName string `form:"yard" validate:"required"`
+	Status   string `form:"status" validate:"omitempty,oneof=OPEN CLOSED"`
+}
+
+type YardAuditScanRequest struct {
+	ScannedBy string              `json:"scanned_by" validate:"required,max=100"`
+	Scans     []YardAuditScanItem `json:"scans" validate:"required,min=1,dive"`
+}
+
+// YardAuditScanItem is what was found in one cell. Leave the container
+// number empty for a cell that was seen empty.
+type YardAuditScanItem struct {
+	BlockName       string `json:"block" validate:"required"`
+	Slot            int    `json:"slot" validate:"required,min=1"`
+	Row             int    `json:"row" validate:"required,min=1"`
+	Tier            int    `json:"tier" validate:"required,min=1"`
+	ContainerNumber string `json:"container_number" validate:"max=20"`
+
+	// Optional, needed to add a container the inventory does not know
+	Size   string `json:"container_size" validate:"omitempty,oneof=20ft 40ft"`
+	Height string `json:"container_height" validate:"omitempty,oneof=8.6ft 9.6ft"`
+	Type   string `json:"container_type"`
+}
+
+type YardAuditApplyRequest struct {
+	ApprovedBy string `json:"approved_by" validate:"required,max=100"`
+
+	// Findings to apply, the other pending findings are rejected
+	FindingIDs []int `json:"finding_ids" validate:"dive,min=1"`
+}
+
+type YardAuditResponse struct {
+	ID     int    `json:"id"`
+	YardID int    `json:"yard_id"`
+	Block  string `json:"block,omitempty"`
+	Status string `json:"status"`
+
+	CreatedBy    string     `json:"created_by"`
+	ClosedBy     string     `json:"closed_by,omitempty"`
+	Scans        int        `json:"scans"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReconciledAt *time.Time `json:"reconciled_at,omitempty"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+
+	Findings []YardAuditFindingResponse `json:"findings,omitempty"`
+}
+
+type YardAuditFindingResponse struct {
+	ID              int    `json:"id"`
+	FindingType     string `json:"finding_type"`
+	ContainerNumber string `json:"container_number"`
+	Action          string `json:"action"`
+	Status          string `json:"status"`
+
+	Recorded *PositionResponse `json:"recorded,omitempty"`
+	Found    *PositionResponse `json:"found,omitempty"`
+
+	ContainerSize   string `json:"container_size,omitempty"`
+	ContainerHeight string `json:"container_height,omitempty"`
+	ContainerType   string `json:"container_type,omitempty"`
+}
+
+// YardAuditReconciliationResponse counts the recorded containers of the
+// audited blocks by outcome. Containers whose cell nobody scanned are
+// unchecked, they are not reported missing.
+type YardAuditReconciliationResponse struct {
+	Audit YardAuditResponse `json:"audit"`
+
+	ScannedCells int `json:"scanned_cells"`
+	Matched      int `json:"matched"`
+	Missing      int `json:"missing"`
+	Unexpected   int `json:"unexpected"`
+	Misplaced    int `json:"misplaced"`
+	Unchecked    int `json:"unchecked"`
+}
+
+type YardAuditApplyResponse struct {
+	Audit    YardAuditResponse `json:"audit"`
+	Applied  int               `json:"applied"`
+	Rejected int               `json:"rejected"`
+}
@@ -0,0 +1,108 @@
+package web
+
+import "time"
+
+type YardPlanRequest struct {
+	BlockID  int    `json:"block_id" validate:"required,min=1"`
+	PlanName string `json:"plan_name" validate:"required"`
+
+	SlotStart int `json:"slot_start" validate:"required,min=1"`
+	SlotEnd   int `json:"slot_end" validate:"required,min=1,gtefield=SlotStart"`
+	RowStart  int `json:"row_start" validate:"required,min=1"`
+	RowEnd    int `json:"row_end" validate:"required,min=1,gtefield=RowStart"`
+
+	ContainerSize   string `json:"container_size" validate:"required,oneof=20ft 40ft"`
+	ContainerHeight string `json:"container_height" validate:"required,oneof=8.6ft 9.6ft"`
+	ContainerType   string `json:"container_type" validate:"required"`
+
+	PriorityStackingDirection string `json:"priority_stacking_direction"`
+}
+
+type UpdateYardPlanRequest struct {
+	YardPlanRequest
+
+	// The change is only saved when confirm is true, otherwise only the
+	// impact report is returned.
+	Confirm bool `json:"confirm"`
+
+	// Revision the client last read, from the If-Match header. Required
+	// when confirm is true, zero (If-Match: *) skips the check.
+	Revision int `json:"-"`
+}
+
+type PublishYardPlanRequest struct {
+	// Optional, defaults to now
+	ValidFrom *time.Time `json:"valid_from"`
+	// Optional, open-ended when empty
+	ValidTo *time.Time `json:"valid_to"`
+
+	// Revision the client last read, from the If-Match header. Zero
+	// (If-Match: *) skips the check
+	Revision int `json:"-"`
+}
+
+type YardPlanQuery struct {
+	BlockID int `form:"block_id" validate:"required,min=1"`
+	// Optional, lists the plans that apply at this time instead of every version
+	At *time.Time `form:"at"`
+}
+
+type YardPlanResponse struct {
+	ID       int    `json:"id"`
+	BlockID  int    `json:"block_id"`
+	PlanName string `json:"plan_name"`
+
+	SlotStart int `json:"slot_start"`
+	SlotEnd   int `json:"slot_end"`
+	RowStart  int `json:"row_start"`
+	RowEnd    int `json:"row_end"`
+
+	ContainerSize   string `json:"container_size"`
+	ContainerHeight string `json:"container_height"`
+	ContainerType   string `json:"container_type"`
+
+	PriorityStackingDirection string `json:"priority_stacking_direction"`
+
+	Version           int        `json:"version"`
+	PreviousVersionID *int       `json:"previous_version_id,omitempty"`
+	Status            string     `json:"status"`
+	IsActive          bool       `json:"is_active"`
+	ValidFrom         *time.Time `json:"valid_from,omitempty"`
+	ValidTo           *time.Time `json:"valid_to,omitempty"`
+	PublishedAt       *time.Time `json:"published_at,omitempty"`
+	Revision          int        `json:"revision"`
+}
+
+type PlanCapacity struct {
+	Positions int `json:"positions"`
+	TEU       int `json:"teu"`
+}
+
+type ImpactedContainer struct {
+	ContainerNumber string           `json:"container_number"`
+	Position        PositionResponse `json:"position"`
+	Reason          string           `json:"reason"`
+
+	// Another active plan that would still cover the container, empty when
+	// the container would fall outside every plan.
+	NewYardPlanID *int `json:"new_yard_plan_id,omitempty"`
+}
+
+type YardPlanImpactResponse struct {
+	PlanID int  `json:"plan_id"`
+	Saved  bool `json:"saved"`
+	// Revision of the stored plan, the new one when the change was saved
+	Revision int `json:"revision"`
+
+	AffectedContainers []ImpactedContainer `json:"affected_containers"`
+	StrandedCount      int                 `json:"stranded_count"`
+	Overlaps           []YardPlanResponse  `json:"overlaps"`
+
+	CapacityBefore PlanCapacity `json:"capacity_before"`
+	CapacityAfter  PlanCapacity `json:"capacity_after"`
+}
+
+type YardPlanExportQuery struct {
+	Format   string `form:"format" validate:"required,oneof=csv xlsx"`
+	YardName string `form:"yard" validate:"required"`
+}
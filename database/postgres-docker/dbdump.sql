@@ -3,6 +3,30 @@ DROP TABLE IF EXISTS yards CASCADE;
 DROP TABLE IF EXISTS blocks CASCADE;
 DROP TABLE IF EXISTS yard_plans CASCADE;
 DROP TABLE IF EXISTS container_positions CASCADE;
+DROP TABLE IF EXISTS container_moves CASCADE;
+DROP TABLE IF EXISTS capacity_snapshots CASCADE;
+DROP TABLE IF EXISTS dwell_thresholds CASCADE;
+DROP TABLE IF EXISTS dwell_alerts CASCADE;
+DROP TABLE IF EXISTS tariffs CASCADE;
+DROP TABLE IF EXISTS tariff_tiers CASCADE;
+DROP TABLE IF EXISTS gate_transactions CASCADE;
+DROP TABLE IF EXISTS container_holds CASCADE;
+DROP TABLE IF EXISTS pre_advices CASCADE;
+DROP TABLE IF EXISTS release_orders CASCADE;
+DROP TABLE IF EXISTS release_order_containers CASCADE;
+DROP TABLE IF EXISTS equipments CASCADE;
+DROP TABLE IF EXISTS equipment_blocks CASCADE;
+DROP TABLE IF EXISTS work_instructions CASCADE;
+DROP TABLE IF EXISTS edi_partners CASCADE;
+DROP TABLE IF EXISTS yard_audits CASCADE;
+DROP TABLE IF EXISTS yard_audit_scans CASCADE;
+DROP TABLE IF EXISTS yard_audit_findings CASCADE;
+DROP TABLE IF EXISTS outbox_events CASCADE;
+DROP TABLE IF EXISTS webhooks CASCADE;
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS audit_logs CASCADE;
+DROP TABLE IF EXISTS audit_log_changes CASCADE;
+DROP TABLE IF EXISTS idempotency_keys CASCADE;
 
 --users
 CREATE TABLE users (
@@ -54,6 +78,7 @@ CREATE TABLE yards (
     id SERIAL PRIMARY KEY,
     name VARCHAR(100) NOT NULL UNIQUE,
     location VARCHAR(255),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta',
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -65,6 +90,8 @@ CREATE TABLE blocks (
     slots INTEGER NOT NULL CHECK (slots > 0),
     rows INTEGER NOT NULL CHECK (rows > 0),
     tiers INTEGER NOT NULL CHECK (tiers > 0),
+    pos_x INTEGER NOT NULL DEFAULT 0,
+    pos_y INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     UNIQUE (yard_id, name)
@@ -83,11 +110,21 @@ CREATE TABLE yard_plans (
     container_type VARCHAR(50) NOT NULL,
     priority_stacking_direction VARCHAR(50),
     is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    version INTEGER NOT NULL DEFAULT 1,
+    previous_version_id INTEGER REFERENCES yard_plans(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PUBLISHED' CHECK (status IN ('DRAFT', 'PUBLISHED', 'RETIRED')),
+    valid_from TIMESTAMP WITH TIME ZONE,
+    valid_to TIMESTAMP WITH TIME ZONE,
+    published_at TIMESTAMP WITH TIME ZONE,
+    revision INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     CHECK (slot_start <= slot_end),
-    CHECK (row_start <= row_end)
+    CHECK (row_start <= row_end),
+    CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from < valid_to)
 );
+-- One pending draft per plan
+CREATE UNIQUE INDEX idx_yard_plans_draft_version ON yard_plans (previous_version_id) WHERE status = 'DRAFT';
 -- container_positions
 CREATE TABLE container_positions (
     id SERIAL PRIMARY KEY,
@@ -103,10 +140,408 @@ CREATE TABLE container_positions (
     arrival_date TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     yard_plan_id INTEGER REFERENCES yard_plans(id) ON DELETE
     SET NULL,
+        vessel VARCHAR(100) NOT NULL DEFAULT '',
+        voyage VARCHAR(50) NOT NULL DEFAULT '',
+        shipping_line VARCHAR(50) NOT NULL DEFAULT '',
+        revision INTEGER NOT NULL DEFAULT 1,
         created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
         UNIQUE (block_id, slot_number, row_number, tier_number)
 );
+-- container_moves
+CREATE TABLE container_moves (
+    id SERIAL PRIMARY KEY,
+    container_number VARCHAR(20) NOT NULL,
+    move_type VARCHAR(20) NOT NULL CHECK (move_type IN ('PLACEMENT', 'PICKUP', 'MOVE')),
+    from_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    from_slot INTEGER,
+    from_row INTEGER,
+    from_tier INTEGER,
+    to_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    to_slot INTEGER,
+    to_row INTEGER,
+    to_tier INTEGER,
+    container_size VARCHAR(5) NOT NULL,
+    container_height VARCHAR(5) NOT NULL,
+    container_type VARCHAR(50) NOT NULL,
+    vessel VARCHAR(100) NOT NULL DEFAULT '',
+    voyage VARCHAR(50) NOT NULL DEFAULT '',
+    shipping_line VARCHAR(50) NOT NULL DEFAULT '',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    arrived_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_container_moves_container_number ON container_moves (container_number);
+
+CREATE TABLE capacity_snapshots (
+    id SERIAL PRIMARY KEY,
+    snapshot_date DATE NOT NULL,
+    level VARCHAR(10) NOT NULL CHECK (level IN ('YARD', 'BLOCK', 'PLAN')),
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE CASCADE,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE CASCADE,
+    yard_plan_id INTEGER REFERENCES yard_plans(id) ON DELETE CASCADE,
+    total_teu INTEGER NOT NULL,
+    occupied_teu INTEGER NOT NULL,
+    reserved_teu INTEGER NOT NULL,
+    free_teu INTEGER NOT NULL,
+    overflow_teu INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_capacity_snapshots_date ON capacity_snapshots (snapshot_date, level, yard_id);
+
+CREATE TABLE dwell_thresholds (
+    id SERIAL PRIMARY KEY,
+    yard_id INTEGER REFERENCES yards(id) ON DELETE CASCADE,
+    container_type VARCHAR(50) NOT NULL DEFAULT '',
+    free_days INTEGER NOT NULL CHECK (free_days >= 0),
+    action VARCHAR(20) NOT NULL CHECK (action IN ('REPORT', 'NOTIFY', 'MARK_LONG_STAY')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE dwell_alerts (
+    id SERIAL PRIMARY KEY,
+    container_number VARCHAR(20) NOT NULL,
+    arrival_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE CASCADE,
+    dwell_days INTEGER NOT NULL,
+    free_days INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (container_number, arrival_date)
+);
+
+CREATE TABLE tariffs (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    yard_id INTEGER REFERENCES yards(id) ON DELETE CASCADE,
+    container_size VARCHAR(5) NOT NULL DEFAULT '',
+    container_type VARCHAR(50) NOT NULL DEFAULT '',
+    free_days INTEGER NOT NULL CHECK (free_days >= 0),
+    reefer_daily_rate BIGINT NOT NULL DEFAULT 0 CHECK (reefer_daily_rate >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE tariff_tiers (
+    id SERIAL PRIMARY KEY,
+    tariff_id INTEGER NOT NULL REFERENCES tariffs(id) ON DELETE CASCADE,
+    from_day INTEGER NOT NULL CHECK (from_day >= 1),
+    daily_rate BIGINT NOT NULL CHECK (daily_rate >= 0),
+    UNIQUE (tariff_id, from_day)
+);
+CREATE INDEX idx_container_moves_shipping_line ON container_moves (shipping_line, created_at);
+
+CREATE TABLE gate_transactions (
+    id SERIAL PRIMARY KEY,
+    direction VARCHAR(5) NOT NULL CHECK (direction IN ('IN', 'OUT')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING_PLACEMENT', 'COMPLETED', 'CANCELLED', 'REJECTED')),
+    container_number VARCHAR(20) NOT NULL,
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE RESTRICT,
+    container_size VARCHAR(5) NOT NULL DEFAULT '',
+    container_height VARCHAR(5) NOT NULL DEFAULT '',
+    container_type VARCHAR(50) NOT NULL DEFAULT '',
+    vessel VARCHAR(100) NOT NULL DEFAULT '',
+    voyage VARCHAR(50) NOT NULL DEFAULT '',
+    shipping_line VARCHAR(50) NOT NULL DEFAULT '',
+    truck_plate VARCHAR(20) NOT NULL,
+    driver_name VARCHAR(100) NOT NULL,
+    driver_license VARCHAR(50) NOT NULL DEFAULT '',
+    seal_numbers VARCHAR(255) NOT NULL DEFAULT '',
+    damage_remarks TEXT NOT NULL DEFAULT '',
+    booking_reference VARCHAR(50) NOT NULL DEFAULT '',
+    release_reference VARCHAR(50) NOT NULL DEFAULT '',
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    slot_number INTEGER,
+    row_number INTEGER,
+    tier_number INTEGER,
+    reject_reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_gate_transactions_pending ON gate_transactions (container_number) WHERE status = 'PENDING_PLACEMENT';
+
+CREATE TABLE container_holds (
+    id SERIAL PRIMARY KEY,
+    container_number VARCHAR(20) NOT NULL,
+    hold_type VARCHAR(20) NOT NULL CHECK (hold_type IN ('CUSTOMS', 'LINE', 'TERMINAL', 'DAMAGE')),
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    released_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX idx_container_holds_container_number ON container_holds (container_number);
+
+CREATE TABLE pre_advices (
+    id SERIAL PRIMARY KEY,
+    container_number VARCHAR(20) NOT NULL,
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'ARRIVED', 'CANCELLED', 'EXPIRED')),
+    source VARCHAR(20) NOT NULL DEFAULT 'MANUAL',
+    container_size VARCHAR(5) NOT NULL,
+    container_height VARCHAR(5) NOT NULL,
+    container_type VARCHAR(50) NOT NULL,
+    vessel VARCHAR(100) NOT NULL DEFAULT '',
+    voyage VARCHAR(50) NOT NULL DEFAULT '',
+    shipping_line VARCHAR(50) NOT NULL DEFAULT '',
+    gross_weight_kg INTEGER NOT NULL DEFAULT 0 CHECK (gross_weight_kg >= 0),
+    port_of_discharge VARCHAR(5) NOT NULL DEFAULT '',
+    dangerous_goods VARCHAR(255) NOT NULL DEFAULT '',
+    expected_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    expected_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    slot_number INTEGER,
+    row_number INTEGER,
+    tier_number INTEGER,
+    arrived_at TIMESTAMP WITH TIME ZONE,
+    mismatches VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (expected_to >= expected_from)
+);
+CREATE UNIQUE INDEX idx_pre_advices_pending ON pre_advices (container_number) WHERE status = 'PENDING';
+
+CREATE TABLE release_orders (
+    id SERIAL PRIMARY KEY,
+    release_number VARCHAR(50) UNIQUE NOT NULL,
+    pin_hash VARCHAR(255) NOT NULL,
+    trucking_company VARCHAR(100) NOT NULL,
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('ACTIVE', 'COMPLETED', 'CANCELLED')),
+    failed_pin_attempts INTEGER NOT NULL DEFAULT 0,
+    pin_locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE release_order_containers (
+    id SERIAL PRIMARY KEY,
+    release_order_id INTEGER NOT NULL REFERENCES release_orders(id) ON DELETE CASCADE,
+    container_number VARCHAR(20) NOT NULL,
+    picked_up_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (release_order_id, container_number)
+);
+CREATE INDEX idx_release_order_containers_container_number ON release_order_containers (container_number);
+
+CREATE TABLE equipments (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    equipment_type VARCHAR(20) NOT NULL CHECK (equipment_type IN ('RTG', 'REACH_STACKER')),
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE RESTRICT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    current_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    current_slot INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE equipment_blocks (
+    equipment_id INTEGER NOT NULL REFERENCES equipments(id) ON DELETE CASCADE,
+    block_id INTEGER NOT NULL REFERENCES blocks(id) ON DELETE CASCADE,
+    PRIMARY KEY (equipment_id, block_id)
+);
+CREATE INDEX idx_equipment_blocks_block_id ON equipment_blocks (block_id);
+
+CREATE TABLE work_instructions (
+    id SERIAL PRIMARY KEY,
+    work_type VARCHAR(20) NOT NULL CHECK (work_type IN ('PLACEMENT', 'PICKUP', 'MOVE')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'CONFIRMED', 'REJECTED')),
+    container_number VARCHAR(20) NOT NULL,
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE RESTRICT,
+    block_id INTEGER NOT NULL REFERENCES blocks(id) ON DELETE RESTRICT,
+    equipment_id INTEGER REFERENCES equipments(id) ON DELETE SET NULL,
+    priority INTEGER NOT NULL DEFAULT 1,
+    sequence INTEGER,
+    predecessor_id INTEGER REFERENCES work_instructions(id) ON DELETE SET NULL,
+    from_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    from_slot INTEGER,
+    from_row INTEGER,
+    from_tier INTEGER,
+    container_revision INTEGER,
+    to_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    to_slot INTEGER,
+    to_row INTEGER,
+    to_tier INTEGER,
+    container_size VARCHAR(5) NOT NULL,
+    container_height VARCHAR(5) NOT NULL,
+    container_type VARCHAR(50) NOT NULL,
+    vessel VARCHAR(100) NOT NULL DEFAULT '',
+    voyage VARCHAR(50) NOT NULL DEFAULT '',
+    shipping_line VARCHAR(50) NOT NULL DEFAULT '',
+    release_order_id INTEGER REFERENCES release_orders(id) ON DELETE SET NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    operator VARCHAR(100) NOT NULL DEFAULT '',
+    reject_reason VARCHAR(255) NOT NULL DEFAULT '',
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_work_instructions_pending ON work_instructions (container_number) WHERE status = 'PENDING';
+CREATE INDEX idx_work_instructions_block_pending ON work_instructions (block_id) WHERE status = 'PENDING';
+
+CREATE TABLE edi_partners (
+    id SERIAL PRIMARY KEY,
+    shipping_line VARCHAR(50) UNIQUE NOT NULL,
+    sender_id VARCHAR(35) NOT NULL,
+    recipient_id VARCHAR(35) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE yard_audits (
+    id SERIAL PRIMARY KEY,
+    yard_id INTEGER NOT NULL REFERENCES yards(id) ON DELETE CASCADE,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('OPEN', 'CLOSED')),
+    created_by VARCHAR(100) NOT NULL,
+    closed_by VARCHAR(100) NOT NULL DEFAULT '',
+    reconciled_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_yard_audits_yard ON yard_audits (yard_id, status);
+
+CREATE TABLE yard_audit_scans (
+    id SERIAL PRIMARY KEY,
+    audit_id INTEGER NOT NULL REFERENCES yard_audits(id) ON DELETE CASCADE,
+    block_id INTEGER NOT NULL REFERENCES blocks(id) ON DELETE CASCADE,
+    slot_number INTEGER NOT NULL,
+    row_number INTEGER NOT NULL,
+    tier_number INTEGER NOT NULL,
+    container_number VARCHAR(20) NOT NULL DEFAULT '',
+    container_size VARCHAR(5) NOT NULL DEFAULT '',
+    container_height VARCHAR(5) NOT NULL DEFAULT '',
+    container_type VARCHAR(50) NOT NULL DEFAULT '',
+    scanned_by VARCHAR(100) NOT NULL,
+    scanned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (audit_id, block_id, slot_number, row_number, tier_number)
+);
+CREATE UNIQUE INDEX idx_yard_audit_scans_container ON yard_audit_scans (audit_id, container_number) WHERE container_number <> '';
+
+CREATE TABLE yard_audit_findings (
+    id SERIAL PRIMARY KEY,
+    audit_id INTEGER NOT NULL REFERENCES yard_audits(id) ON DELETE CASCADE,
+    finding_type VARCHAR(20) NOT NULL CHECK (finding_type IN ('MISSING', 'UNEXPECTED', 'MISPLACED')),
+    container_number VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('REMOVE', 'ADD', 'MOVE')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'APPLIED', 'REJECTED')),
+    recorded_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    recorded_slot INTEGER,
+    recorded_row INTEGER,
+    recorded_tier INTEGER,
+    found_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    found_slot INTEGER,
+    found_row INTEGER,
+    found_tier INTEGER,
+    container_size VARCHAR(5) NOT NULL DEFAULT '',
+    container_height VARCHAR(5) NOT NULL DEFAULT '',
+    container_type VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_yard_audit_findings_audit ON yard_audit_findings (audit_id);
+
+CREATE TABLE outbox_events (
+    id SERIAL PRIMARY KEY,
+    stream_id INTEGER UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    yard_id INTEGER REFERENCES yards(id) ON DELETE SET NULL,
+    block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    to_block_id INTEGER REFERENCES blocks(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX idx_outbox_events_undispatched ON outbox_events (id) WHERE dispatched_at IS NULL;
+CREATE INDEX idx_outbox_events_unstreamed ON outbox_events (id) WHERE stream_id IS NULL;
+CREATE SEQUENCE outbox_events_stream_id_seq OWNED BY outbox_events.stream_id;
+
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries (
+    id SERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id INTEGER NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'DELIVERED', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (webhook_id, event_id)
+);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+CREATE TABLE audit_logs (
+    id SERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    request_payload TEXT NOT NULL DEFAULT '',
+    client_ip VARCHAR(45) NOT NULL DEFAULT '',
+    status_code INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX idx_audit_logs_actor ON audit_logs (actor, created_at);
+
+CREATE TABLE audit_log_changes (
+    id SERIAL PRIMARY KEY,
+    audit_log_id INTEGER NOT NULL REFERENCES audit_logs(id),
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    before_state TEXT,
+    after_state TEXT
+);
+CREATE INDEX idx_audit_log_changes_log ON audit_log_changes (audit_log_id);
+CREATE INDEX idx_audit_log_changes_entity ON audit_log_changes (entity_type, entity_id);
+
+-- The audit log is append-only
+CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only, % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
+CREATE TRIGGER audit_log_changes_append_only BEFORE UPDATE OR DELETE ON audit_log_changes
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+CREATE TRIGGER audit_log_changes_no_truncate BEFORE TRUNCATE ON audit_log_changes
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TABLE idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('IN_PROGRESS', 'COMPLETED')),
+    status_code INTEGER NOT NULL DEFAULT 0,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    response_body TEXT NOT NULL DEFAULT '',
+    locked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (actor, idempotency_key)
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
 
 INSERT INTO yards (id, name, location) VALUES
 (1, 'YRD-UTAMA', 'Terminal Kontainer Utama'),
@@ -121,17 +556,18 @@ INSERT INTO yards (id, name, location) VALUES
 (10, 'YRD-LONGTERM', 'Penyimpanan Jangka Panjang')
 ON CONFLICT (id) DO NOTHING;
 
-INSERT INTO blocks (id, yard_id, name, slots, rows, tiers) VALUES
-(1, 1, 'LC01', 10, 5, 5),    -- Yard 1, Dry General
-(2, 1, 'LC02', 10, 5, 5),    -- Yard 1, Dry General
-(3, 2, 'C01', 8, 4, 4),      -- Yard 2, Cadangan
-(4, 3, 'RF01', 6, 3, 4),     -- Yard 3, Reefer (Cold)
-(5, 3, 'RF02', 6, 3, 4),     -- Yard 3, Reefer (Warm)
-(6, 4, 'IM01', 12, 6, 3),    -- Yard 4, Import Area
-(7, 5, 'EX01', 12, 6, 3),    -- Yard 5, Export Area
-(8, 6, 'E01', 20, 10, 3),    -- Yard 6, Empty Area
-(9, 7, 'HAZ1', 4, 2, 2),     -- Yard 7, Hazmat (Small Capacity)
-(10, 8, 'OOG1', 4, 4, 1)     -- Yard 8, OOG (Low Tier)
+INSERT INTO blocks (id, yard_id, name, slots, rows, tiers, pos_x, pos_y) VALUES
+(1, 1, 'LC01', 10, 5, 5, 0, 0),      -- Yard 1, Dry General
+(2, 1, 'LC02', 10, 5, 5, 0, 40),     -- Yard 1, Dry General
+(3, 2, 'C01', 8, 4, 4, 0, 0),        -- Yard 2, Cadangan
+(4, 3, 'RF01', 6, 3, 4, 0, 0),       -- Yard 3, Reefer (Cold)
+(5, 3, 'RF02', 6, 3, 4, 50, 0),      -- Yard 3, Reefer (Warm)
+(6, 4, 'IM01', 12, 6, 3, 0, 0),      -- Yard 4, Import Area
+(7, 5, 'EX01', 12, 6, 3, 0, 0),      -- Yard 5, Export Area
+(8, 6, 'E01', 20, 10, 3, 0, 0),      -- Yard 6, Empty Area
+(9, 7, 'HAZ1', 4, 2, 2, 0, 0),       -- Yard 7, Hazmat (Small Capacity)
+(10, 8, 'OOG1', 4, 4, 1, 0, 0),      -- Yard 8, OOG (Low Tier)
+(11, 10, 'LT01', 20, 6, 4, 0, 0)     -- Yard 10, Long Stay
 ON CONFLICT (id) DO NOTHING;
 
 
@@ -145,8 +581,13 @@ INSERT INTO yard_plans (id, block_id, plan_name, slot_start, slot_end, row_start
 (7, 3, '20ft C01 Cadangan', 1, 8, 1, 4, '20ft', '8.6ft', 'DRY', 'BOTTOM_UP', TRUE),
 (8, 6, '40ft IM01 Import', 1, 12, 1, 6, '40ft', '9.6ft', 'DRY', 'LEFT_RIGHT', TRUE),
 (9, 8, '20ft E01 Empty', 1, 20, 1, 10, '20ft', '8.6ft', 'EMPTY', 'BOTTOM_UP', TRUE),
-(10, 9, '20ft HAZ1 Hazmat', 1, 4, 1, 2, '20ft', '8.6ft', 'HAZMAT', 'BOTTOM_UP', TRUE)
+(10, 9, '20ft HAZ1 Hazmat', 1, 4, 1, 2, '20ft', '8.6ft', 'HAZMAT', 'BOTTOM_UP', TRUE),
+(11, 11, '20ft DRY LT01 Long Stay', 1, 10, 1, 6, '20ft', '8.6ft', 'DRY', 'BOTTOM_UP', TRUE),
+(12, 11, '40ft DRY LT01 Long Stay', 11, 20, 1, 6, '40ft', '9.6ft', 'DRY', 'BOTTOM_UP', TRUE)
 ON CONFLICT (id) DO NOTHING;
+-- Plans switched off by hand before plans had versions default to PUBLISHED,
+-- retire them so the plan scheduler does not activate them again.
+UPDATE yard_plans SET status = 'RETIRED' WHERE is_active = FALSE AND status = 'PUBLISHED' AND published_at IS NULL;
 
 INSERT INTO container_positions (id, container_number, block_id, slot_number, row_number, tier_number, container_size, container_height, container_type, container_status, yard_plan_id) VALUES
 (1, 'ALFI000001', 1, 1, 1, 1, '20ft', '8.6ft', 'DRY', 'STORAGE', 1),
@@ -161,4 +602,33 @@ INSERT INTO container_positions (id, container_number, block_id, slot_number, ro
 (10, 'ALFI000010', 1, 1, 1, 3, '20ft', '8.6ft', 'DRY', 'STORAGE', 1)
 ON CONFLICT (id) DO NOTHING;
 
-SELECT setval('container_positions_id_seq', (SELECT MAX(id) FROM container_positions) + 1, false);
\ No newline at end of file
+SELECT setval('container_positions_id_seq', (SELECT MAX(id) FROM container_positions) + 1, false);
+-- PIN 1234
+INSERT INTO release_orders (id, release_number, pin_hash, trucking_company, valid_until, status) VALUES
+(1, 'DO-2026-0001', '$2a$08$YmH6QE/6q/YXwyWfLzU9I.yPgxqa5VndXDcDXOBCjKu836M3IwzRK', 'PT Angkut Jaya', '2030-12-31 23:59:59+07', 'ACTIVE')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO release_order_containers (release_order_id, container_number) VALUES
+(1, 'ALFI000002'),
+(1, 'ALFI000005'),
+(1, 'ALFI000010'),
+(1, 'GATE000001')
+ON CONFLICT (release_order_id, container_number) DO NOTHING;
+
+SELECT setval('release_orders_id_seq', (SELECT MAX(id) FROM release_orders) + 1, false);
+
+INSERT INTO equipments (id, code, equipment_type, yard_id, active, current_block_id, current_slot) VALUES
+(1, 'RTG-01', 'RTG', 1, TRUE, 1, 1),
+(2, 'RTG-02', 'RTG', 1, TRUE, 2, 10),
+(3, 'RS-01', 'REACH_STACKER', 3, TRUE, NULL, NULL)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO equipment_blocks (equipment_id, block_id) VALUES
+(1, 1),
+(2, 1),
+(2, 2),
+(3, 4),
+(3, 5)
+ON CONFLICT (equipment_id, block_id) DO NOTHING;
+
+SELECT setval('equipments_id_seq', (SELECT MAX(id) FROM equipments) + 1, false);
@@ -0,0 +1,73 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Change is the state of one entity before and after a request changed it.
+// Before is empty for created entities, After for deleted ones.
+type Change struct {
+	EntityType string
+	EntityID   string
+	Before     json.RawMessage
+	After      json.RawMessage
+}
+
+// Trail collects the changes of one request.
+type Trail struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+type trailKey struct{}
+
+// NewContext returns a context that carries a new trail.
+func NewContext(ctx context.Context) (context.Context, *Trail) {
+	trail := &Trail{}
+	return context.WithValue(ctx, trailKey{}, trail), trail
+}
+
+// Record adds a change to the trail of ctx. The states are encoded right
+// away, later edits of before or after do not show up in the log. Without a
+// trail, in scheduled jobs for example, nothing is recorded. Pass nil before
+// for created and nil after for deleted entities.
+func Record(ctx context.Context, entityType string, entityID any, before, after any) {
+	trail, ok := ctx.Value(trailKey{}).(*Trail)
+	if !ok {
+		return
+	}
+
+	change := Change{
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		Before:     encode(before),
+		After:      encode(after),
+	}
+
+	trail.mu.Lock()
+	trail.changes = append(trail.changes, change)
+	trail.mu.Unlock()
+}
+
+// Changes returns the recorded changes in order.
+func (t *Trail) Changes() []Change {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Change(nil), t.changes...)
+}
+
+func encode(state any) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
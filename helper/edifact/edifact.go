@@ -0,0 +1,129 @@
+package edifact
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service characters of syntax level A, the UNA segment is not written.
+const (
+	componentSeparator = ':'
+	elementSeparator   = '+'
+	releaseCharacter   = '?'
+	segmentTerminator  = '\''
+)
+
+// Element is one data element, its components are joined with ':'.
+type Element []string
+
+// E builds an element from its components.
+func E(components ...string) Element {
+	return components
+}
+
+// Writer builds a UNOA interchange. Values are upper-cased and escaped, empty
+// trailing components and elements are dropped. Segments end with a newline
+// after the terminator to keep files readable.
+type Writer struct {
+	builder strings.Builder
+
+	reference        string
+	messages         int
+	messageReference string
+	messageSegments  int
+}
+
+// BeginInterchange writes the UNB header.
+func (w *Writer) BeginInterchange(sender, recipient, reference string, prepared time.Time) {
+	w.reference = reference
+	w.Segment("UNB",
+		E("UNOA", "2"),
+		E(sender),
+		E(recipient),
+		E(prepared.Format("060102"), prepared.Format("1504")),
+		E(reference),
+	)
+}
+
+// BeginMessage writes the UNH header, messageType holds the message type,
+// version, release, agency and association code.
+func (w *Writer) BeginMessage(reference string, messageType Element) {
+	w.messages++
+	w.messageReference = reference
+	w.messageSegments = 0
+	w.Segment("UNH", E(reference), messageType)
+}
+
+// EndMessage writes the UNT trailer with the segment count of the message,
+// UNH and UNT included.
+func (w *Writer) EndMessage() {
+	w.Segment("UNT", E(strconv.Itoa(w.messageSegments+1)), E(w.messageReference))
+}
+
+// EndInterchange writes the UNZ trailer with the message count.
+func (w *Writer) EndInterchange() {
+	w.Segment("UNZ", E(strconv.Itoa(w.messages)), E(w.reference))
+}
+
+// Segment writes one segment.
+func (w *Writer) Segment(tag string, elements ...Element) {
+	last := len(elements)
+	for last > 0 && isEmpty(elements[last-1]) {
+		last--
+	}
+
+	w.builder.WriteString(tag)
+	for _, element := range elements[:last] {
+		w.builder.WriteByte(elementSeparator)
+
+		components := len(element)
+		for components > 0 && element[components-1] == "" {
+			components--
+		}
+		for i, component := range element[:components] {
+			if i > 0 {
+				w.builder.WriteByte(componentSeparator)
+			}
+			w.builder.WriteString(escape(component))
+		}
+	}
+	w.builder.WriteByte(segmentTerminator)
+	w.builder.WriteByte('\n')
+
+	w.messageSegments++
+}
+
+func (w *Writer) String() string {
+	return w.builder.String()
+}
+
+// DateTime formats t as CCYYMMDDHHMM, qualifier 203 in DTM segments.
+func DateTime(t time.Time) string {
+	return t.Format("200601021504")
+}
+
+func isEmpty(element Element) bool {
+	for _, component := range element {
+		if component != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func escape(value string) string {
+	value = strings.ToUpper(value)
+
+	var builder strings.Builder
+	for _, r := range value {
+		switch r {
+		case componentSeparator, elementSeparator, releaseCharacter, segmentTerminator:
+			builder.WriteByte(releaseCharacter)
+		case '\n', '\r':
+			r = ' '
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,92 @@
+package edifact
+
+import (
+	"errors"
+	"strings"
+)
+
+// Segment is one parsed segment, Elements holds the components of each data
+// element with release characters removed.
+type Segment struct {
+	Tag      string
+	Elements []Element
+}
+
+// Value returns component j of element i, empty when it is absent.
+func (s *Segment) Value(i, j int) string {
+	if i >= len(s.Elements) || j >= len(s.Elements[i]) {
+		return ""
+	}
+	return s.Elements[i][j]
+}
+
+// Parse splits an interchange into segments. A leading UNA segment overrides
+// the default service characters. Line breaks between segments are ignored.
+func Parse(data string) ([]Segment, error) {
+	var component, element, release, terminator byte = componentSeparator, elementSeparator, releaseCharacter, segmentTerminator
+
+	if strings.HasPrefix(data, "UNA") {
+		if len(data) < 9 {
+			return nil, errors.New("incomplete UNA service string advice")
+		}
+		component, element, release, terminator = data[3], data[4], data[6], data[8]
+		data = data[9:]
+	}
+
+	var segments []Segment
+	var current Segment
+	var elementValue Element
+	var value strings.Builder
+	started := false
+
+	flushComponent := func() {
+		elementValue = append(elementValue, value.String())
+		value.Reset()
+	}
+	flushElement := func() {
+		flushComponent()
+		if current.Tag == "" {
+			current.Tag = strings.TrimSpace(elementValue[0])
+		} else {
+			current.Elements = append(current.Elements, elementValue)
+		}
+		elementValue = nil
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if !started && (c == '\n' || c == '\r' || c == ' ' || c == '\t') {
+			continue
+		}
+		started = true
+
+		switch c {
+		case release:
+			i++
+			if i >= len(data) {
+				return nil, errors.New("release character at the end of the data")
+			}
+			value.WriteByte(data[i])
+		case component:
+			flushComponent()
+		case element:
+			flushElement()
+		case terminator:
+			flushElement()
+			segments = append(segments, current)
+			current = Segment{}
+			started = false
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if started {
+		return nil, errors.New("segment " + current.Tag + " is not terminated")
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("no segments found")
+	}
+	return segments, nil
+}
@@ -0,0 +1,92 @@
+package edifact
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Segment
+		wantErr bool
+	}{
+		{
+			name: "default service characters",
+			data: "UNB+UNOA:2+SENDER+RECIPIENT'\nEQD+CN+MSCU1234565+22G1:102:5'",
+			want: []Segment{
+				{Tag: "UNB", Elements: []Element{{"UNOA", "2"}, {"SENDER"}, {"RECIPIENT"}}},
+				{Tag: "EQD", Elements: []Element{{"CN"}, {"MSCU1234565"}, {"22G1", "102", "5"}}},
+			},
+		},
+		{
+			name: "release character keeps separators",
+			data: "RFF+BN:BK?+2026?'01?:A'",
+			want: []Segment{
+				{Tag: "RFF", Elements: []Element{{"BN", "BK+2026'01:A"}}},
+			},
+		},
+		{
+			name: "UNA overrides the service characters",
+			data: "UNA|*.# !\r\nLOC*9*IDJKT|139|6!",
+			want: []Segment{
+				{Tag: "LOC", Elements: []Element{{"9"}, {"IDJKT", "139", "6"}}},
+			},
+		},
+		{
+			name: "empty elements are kept",
+			data: "EQD+CN+MSCU1234565+++5'",
+			want: []Segment{
+				{Tag: "EQD", Elements: []Element{{"CN"}, {"MSCU1234565"}, {""}, {""}, {"5"}}},
+			},
+		},
+		{
+			name: "line breaks and spaces between segments",
+			data: "  UNZ+1+REF'\r\n\r\n",
+			want: []Segment{
+				{Tag: "UNZ", Elements: []Element{{"1"}, {"REF"}}},
+			},
+		},
+		{name: "segment without terminator", data: "UNB+UNOA:2'\nUNZ+1", wantErr: true},
+		{name: "release character at the end", data: "FTX+AAA+?", wantErr: true},
+		{name: "incomplete UNA", data: "UNA:+", wantErr: true},
+		{name: "no segments", data: "\r\n ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, err := Parse(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(segments, tt.want) {
+				t.Errorf("Parse = %+v, want %+v", segments, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentValue(t *testing.T) {
+	segment := Segment{Tag: "EQD", Elements: []Element{{"CN"}, {"MSCU1234565"}, {"22G1", "102", "5"}}}
+
+	tests := []struct {
+		i, j int
+		want string
+	}{
+		{0, 0, "CN"},
+		{2, 0, "22G1"},
+		{2, 2, "5"},
+		{2, 3, ""},
+		{5, 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := segment.Value(tt.i, tt.j); got != tt.want {
+			t.Errorf("Value(%d, %d) = %q, want %q", tt.i, tt.j, got, tt.want)
+		}
+	}
+}
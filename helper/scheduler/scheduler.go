@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs job in its own goroutine right away and then once per interval.
+// Errors are logged and do not stop the schedule.
+func Every(interval time.Duration, name string, job func() error) {
+	go func() {
+		run := func() {
+			if err := job(); err != nil {
+				log.Printf("scheduler: %s failed: %v", name, err)
+			}
+		}
+
+		run()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// Daily runs job in its own goroutine every day at hour:minute local time.
+// Unlike Every it does not run right away.
+func Daily(hour, minute int, name string, job func() error) {
+	go func() {
+		for {
+			time.Sleep(time.Until(nextDaily(time.Now(), hour, minute)))
+
+			if err := job(); err != nil {
+				log.Printf("scheduler: %s failed: %v", name, err)
+			}
+		}
+	}()
+}
+
+// nextDaily is the first hour:minute strictly after now, in the location of
+// now.
+func nextDaily(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, now.Location())
+	}
+	return next
+}
@@ -0,0 +1,29 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextDaily(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"later today", time.Date(2026, 10, 19, 0, 1, 0, 0, jakarta), time.Date(2026, 10, 19, 0, 5, 0, 0, jakarta)},
+		{"exactly at the time", time.Date(2026, 10, 19, 0, 5, 0, 0, jakarta), time.Date(2026, 10, 20, 0, 5, 0, 0, jakarta)},
+		{"already passed", time.Date(2026, 10, 19, 13, 0, 0, 0, jakarta), time.Date(2026, 10, 20, 0, 5, 0, 0, jakarta)},
+		{"end of month", time.Date(2026, 10, 31, 23, 59, 0, 0, jakarta), time.Date(2026, 11, 1, 0, 5, 0, 0, jakarta)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDaily(tt.now, 0, 5); !got.Equal(tt.want) {
+				t.Errorf("nextDaily(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
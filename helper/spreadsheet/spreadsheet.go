@@ -0,0 +1,120 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Supported file formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Reader returns the rows of a sheet one at a time, so large files are never
+// held in memory.
+type Reader interface {
+	// Read returns the next row, io.EOF after the last one. Blank rows may be
+	// skipped.
+	Read() ([]string, error)
+	// Line is the row number in the file of the last row read, from 1.
+	Line() int
+	Close() error
+}
+
+// Writer writes the rows of a sheet as they come.
+type Writer interface {
+	Write(row []string) error
+	// Close finishes the file, the underlying writer is left open.
+	Close() error
+}
+
+// NewReader opens a reader of the given format on r. An XLSX file is a zip
+// archive that can not be read front to back, it is spooled to a temporary
+// file first.
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r), nil
+	case FormatXLSX:
+		return spoolXLSX(r)
+	}
+	return nil, errors.New("unsupported format " + format)
+}
+
+// NewWriter opens a writer of the given format on w. sheetName is only used
+// by XLSX.
+func NewWriter(format string, w io.Writer, sheetName string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w, sheetName)
+	}
+	return nil, errors.New("unsupported format " + format)
+}
+
+// ContentType is the MIME type of a format.
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	first  bool
+	line   int
+}
+
+// NewCSVReader reads comma separated rows of any width. A UTF-8 byte order
+// mark, as written by Excel, is dropped.
+func NewCSVReader(r io.Reader) Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &csvReader{reader: reader, first: true}
+}
+
+func (c *csvReader) Read() ([]string, error) {
+	row, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	c.line, _ = c.reader.FieldPos(0)
+	if c.first {
+		c.first = false
+		if len(row) > 0 {
+			row[0] = strings.TrimPrefix(row[0], "\ufeff")
+		}
+	}
+	return row, nil
+}
+
+func (c *csvReader) Line() int {
+	return c.line
+}
+
+func (c *csvReader) Close() error {
+	return nil
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(row []string) error {
+	return c.writer.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,314 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const defaultSheetPath = "xl/worksheets/sheet1.xml"
+
+// xlsxReader streams the rows of the first worksheet of a workbook. Only the
+// shared string table is loaded up front, cells are decoded as the sheet XML
+// is read.
+type xlsxReader struct {
+	archive *zip.Reader
+	sheet   io.ReadCloser
+	decoder *xml.Decoder
+	strings []string
+	line    int
+
+	// spool is the temporary copy of the upload, removed on Close
+	spool *os.File
+}
+
+func spoolXLSX(r io.Reader) (Reader, error) {
+	spool, err := os.CreateTemp("", "upload-*.xlsx")
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := io.Copy(spool, r)
+	if err == nil {
+		var reader *xlsxReader
+		if reader, err = openXLSX(spool, size); err == nil {
+			reader.spool = spool
+			return reader, nil
+		}
+	}
+
+	spool.Close()
+	os.Remove(spool.Name())
+	return nil, err
+}
+
+// NewXLSXReader reads the first worksheet of the workbook in r.
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	return openXLSX(r, size)
+}
+
+func openXLSX(r io.ReaderAt, size int64) (*xlsxReader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("not an xlsx file: " + err.Error())
+	}
+
+	reader := &xlsxReader{archive: archive}
+	if reader.strings, err = readSharedStrings(archive); err != nil {
+		return nil, err
+	}
+
+	sheet, err := archive.Open(firstSheetPath(archive))
+	if err != nil {
+		return nil, errors.New("workbook has no worksheet")
+	}
+	reader.sheet = sheet
+	reader.decoder = xml.NewDecoder(sheet)
+	return reader, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		x.line++
+		if number, err := strconv.Atoi(attribute(start, "r")); err == nil {
+			x.line = number
+		}
+		return x.readRow()
+	}
+}
+
+// readRow collects the cells of a row up to its end tag. Cells may leave
+// columns out, their position comes from the cell reference.
+func (x *xlsxReader) readRow() ([]string, error) {
+	var row []string
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local != "c" {
+				continue
+			}
+			value, err := x.readCell(element)
+			if err != nil {
+				return nil, err
+			}
+
+			column := len(row)
+			if index, ok := columnIndex(attribute(element, "r")); ok {
+				column = index
+			}
+			for len(row) <= column {
+				row = append(row, "")
+			}
+			row[column] = value
+		case xml.EndElement:
+			if element.Name.Local == "row" {
+				return row, nil
+			}
+		}
+	}
+}
+
+func (x *xlsxReader) readCell(cell xml.StartElement) (string, error) {
+	cellType := attribute(cell, "t")
+
+	var value strings.Builder
+	inValue := false
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			// Inline strings keep their text in is/t, rich text in is/r/t
+			if element.Name.Local == "v" || element.Name.Local == "t" {
+				inValue = true
+			} else if element.Name.Local == "rPh" {
+				if err := x.decoder.Skip(); err != nil {
+					return "", err
+				}
+			}
+		case xml.EndElement:
+			if element.Name.Local == "v" || element.Name.Local == "t" {
+				inValue = false
+			} else if element.Name.Local == "c" {
+				return x.cellValue(cellType, value.String())
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(element)
+			}
+		}
+	}
+}
+
+func (x *xlsxReader) cellValue(cellType, value string) (string, error) {
+	switch cellType {
+	case "s":
+		index, err := strconv.Atoi(value)
+		if err != nil || index < 0 || index >= len(x.strings) {
+			return "", errors.New("invalid shared string reference " + value)
+		}
+		return x.strings[index], nil
+	case "b":
+		if value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	return value, nil
+}
+
+func (x *xlsxReader) Line() int {
+	return x.line
+}
+
+func (x *xlsxReader) Close() error {
+	err := x.sheet.Close()
+	if x.spool != nil {
+		x.spool.Close()
+		os.Remove(x.spool.Name())
+	}
+	return err
+}
+
+// readSharedStrings loads the shared string table, phonetic hints left out.
+func readSharedStrings(archive *zip.Reader) ([]string, error) {
+	file, err := archive.Open("xl/sharedStrings.xml")
+	if err != nil {
+		return nil, nil
+	}
+	defer file.Close()
+
+	var table []string
+	var value strings.Builder
+	inText, inItem := false, false
+
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, errors.New("invalid shared strings: " + err.Error())
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "si":
+				inItem = true
+				value.Reset()
+			case "t":
+				inText = inItem
+			case "rPh":
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "si":
+				inItem = false
+				table = append(table, value.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				value.Write(element)
+			}
+		}
+	}
+}
+
+// firstSheetPath follows the workbook relationships to the first sheet,
+// falling back to the usual part name.
+func firstSheetPath(archive *zip.Reader) string {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var relationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if decodePart(archive, "xl/workbook.xml", &workbook) != nil || len(workbook.Sheets) == 0 {
+		return defaultSheetPath
+	}
+	if decodePart(archive, "xl/_rels/workbook.xml.rels", &relationships) != nil {
+		return defaultSheetPath
+	}
+
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/")
+		}
+		return path.Join("xl", relationship.Target)
+	}
+	return defaultSheetPath
+}
+
+func decodePart(archive *zip.Reader, name string, v any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return xml.NewDecoder(file).Decode(v)
+}
+
+func attribute(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// columnIndex turns the letters of a cell reference such as "AB12" into a
+// column index from 0.
+func columnIndex(reference string) (int, bool) {
+	index, letters := 0, 0
+	for _, r := range reference {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return 0, false
+	}
+	return index - 1, true
+}
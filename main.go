@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"os"
+	"strconv"
 	"strings"
+	"time"
+	// Yard time zones must load on hosts without a zoneinfo database
+	_ "time/tzdata"
+	"yard-planning/app/cache"
 	"yard-planning/app/controller"
 	"yard-planning/app/repository"
 	"yard-planning/app/service"
 	"yard-planning/database"
+	"yard-planning/helper/scheduler"
 	"yard-planning/helper/token"
 	"yard-planning/response"
 
@@ -28,26 +37,290 @@ func main() {
 	yardRepository := repository.NewYardRepository()
 	yardPlanRepository := repository.NewYardPlanRepository()
 	containerPositionRepository := repository.NewContainerPositionRepository()
+	containerMoveRepository := repository.NewContainerMoveRepository()
+	capacitySnapshotRepository := repository.NewCapacitySnapshotRepository()
+	dwellThresholdRepository := repository.NewDwellThresholdRepository()
+	dwellAlertRepository := repository.NewDwellAlertRepository()
+	tariffRepository := repository.NewTariffRepository()
+	gateTransactionRepository := repository.NewGateTransactionRepository()
+	containerHoldRepository := repository.NewContainerHoldRepository()
+	preAdviceRepository := repository.NewPreAdviceRepository()
+	releaseOrderRepository := repository.NewReleaseOrderRepository()
+	equipmentRepository := repository.NewEquipmentRepository()
+	workInstructionRepository := repository.NewWorkInstructionRepository()
+	ediPartnerRepository := repository.NewEDIPartnerRepository()
+	yardAuditRepository := repository.NewYardAuditRepository()
+	outboxEventRepository := repository.NewOutboxEventRepository()
+	webhookRepository := repository.NewWebhookRepository()
+	auditLogRepository := repository.NewAuditLogRepository()
+	idempotencyKeyRepository := repository.NewIdempotencyKeyRepository()
+
+	// Initialize caches
+	occupancyCacheTTL, err := time.ParseDuration(os.Getenv("OCCUPANCY_CACHE_TTL"))
+	if err != nil {
+		occupancyCacheTTL = 5 * time.Minute
+	}
+	occupancyCache := cache.NewBlockOccupancyCache(containerPositionRepository, occupancyCacheTTL)
+
+	defaultFreeDays, err := strconv.Atoi(os.Getenv("DWELL_FREE_DAYS"))
+	if err != nil {
+		defaultFreeDays = 7
+	}
+
+	// Yard long stay containers are moved to, "-" disables the moves
+	dwellLongTermYard := os.Getenv("DWELL_LONG_TERM_YARD")
+	if dwellLongTermYard == "" {
+		dwellLongTermYard = "YRD-LONGTERM"
+	} else if dwellLongTermYard == "-" {
+		dwellLongTermYard = ""
+	}
+
+	billingCurrency := os.Getenv("BILLING_CURRENCY")
+	if billingCurrency == "" {
+		billingCurrency = "IDR"
+	}
+
+	webhookMaxAttempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil || webhookMaxAttempts < 1 {
+		webhookMaxAttempts = 8
+	}
+
+	webhookDispatchInterval, err := time.ParseDuration(os.Getenv("WEBHOOK_DISPATCH_INTERVAL"))
+	if err != nil {
+		webhookDispatchInterval = 15 * time.Second
+	}
+
+	eventStreamInterval, err := time.ParseDuration(os.Getenv("EVENT_STREAM_INTERVAL"))
+	if err != nil {
+		eventStreamInterval = time.Second
+	}
+
+	idempotencyKeyTTL, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_KEY_TTL"))
+	if err != nil {
+		idempotencyKeyTTL = 24 * time.Hour
+	}
+
+	// Local time of the daily capacity snapshot, HH:MM
+	capacitySnapshotAt, err := time.Parse("15:04", os.Getenv("CAPACITY_SNAPSHOT_AT"))
+	if err != nil {
+		capacitySnapshotAt = time.Date(0, 1, 1, 0, 5, 0, 0, time.UTC)
+	}
 
 	// Initialize services
 	userService := service.NewUserService(userRepository, db, validate)
-	containerService := service.NewContainerService(yardRepository, yardPlanRepository, containerPositionRepository, db, validate)
+	containerService := service.NewContainerService(yardRepository, yardPlanRepository, containerPositionRepository, containerMoveRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, releaseOrderRepository, containerHoldRepository, outboxEventRepository, occupancyCache, db, validate)
+	yardPlanService := service.NewYardPlanService(yardRepository, yardPlanRepository, containerPositionRepository, outboxEventRepository, db, validate)
+	blockViewService := service.NewBlockViewService(yardRepository, yardPlanRepository, containerPositionRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, db, validate)
+	inventoryService := service.NewInventoryService(yardRepository, yardPlanRepository, containerPositionRepository, containerMoveRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, outboxEventRepository, occupancyCache, db, validate)
+	dispatchService := service.NewDispatchService(yardRepository, equipmentRepository, workInstructionRepository, service.NewGreedyDispatchStrategy(), db, validate)
+	workInstructionService := service.NewWorkInstructionService(containerService, yardRepository, containerPositionRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, equipmentRepository, outboxEventRepository, dispatchService, db, validate)
+	housekeepingService := service.NewHousekeepingService(yardRepository, yardPlanRepository, containerPositionRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, workInstructionService, db, validate)
+	capacityReportService := service.NewCapacityReportService(yardRepository, yardPlanRepository, containerPositionRepository, capacitySnapshotRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, db, validate)
+	dwellService := service.NewDwellService(containerService, workInstructionService, yardRepository, dwellThresholdRepository, dwellAlertRepository, containerPositionRepository, workInstructionRepository, outboxEventRepository, defaultFreeDays, dwellLongTermYard, db, validate)
+	billingService := service.NewBillingService(yardRepository, tariffRepository, containerPositionRepository, containerMoveRepository, billingCurrency, db, validate)
+	holdService := service.NewHoldService(yardRepository, containerPositionRepository, containerHoldRepository, outboxEventRepository, db, validate)
+	gateService := service.NewGateService(containerService, yardRepository, containerPositionRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, outboxEventRepository, db, validate)
+	releaseOrderService := service.NewReleaseOrderService(releaseOrderRepository, db, validate)
+	preAdviceService := service.NewPreAdviceService(containerService, yardRepository, containerPositionRepository, preAdviceRepository, outboxEventRepository, db, validate)
+	equipmentService := service.NewEquipmentService(yardRepository, equipmentRepository, workInstructionRepository, dispatchService, db, validate)
+	yardAuditService := service.NewYardAuditService(yardRepository, yardPlanRepository, containerPositionRepository, containerMoveRepository, gateTransactionRepository, preAdviceRepository, workInstructionRepository, releaseOrderRepository, yardAuditRepository, outboxEventRepository, occupancyCache, db, validate)
+	ediService := service.NewEDIService(yardRepository, ediPartnerRepository, containerMoveRepository, preAdviceService, capacityReportService, db, validate)
+	webhookService := service.NewWebhookService(webhookRepository, outboxEventRepository, webhookMaxAttempts, db, validate)
+	eventStreamService := service.NewEventStreamService(yardRepository, outboxEventRepository, db, validate)
+	auditLogService := service.NewAuditLogService(auditLogRepository, db, validate)
+	idempotencyService := service.NewIdempotencyService(idempotencyKeyRepository, idempotencyKeyTTL, db)
 
 	// Initialize controllers
 	userController := controller.NewUserController(userService)
-	containerController := controller.NewContainerController(containerService)
+	containerController := controller.NewContainerController(containerService, workInstructionService)
+	housekeepingController := controller.NewHousekeepingController(housekeepingService)
+	yardPlanController := controller.NewYardPlanController(yardPlanService)
+	blockController := controller.NewBlockController(blockViewService)
+	inventoryController := controller.NewInventoryController(inventoryService)
+	reportController := controller.NewReportController(capacityReportService)
+	dwellController := controller.NewDwellController(dwellService)
+	billingController := controller.NewBillingController(billingService)
+	holdController := controller.NewHoldController(holdService)
+	gateController := controller.NewGateController(gateService)
+	preAdviceController := controller.NewPreAdviceController(preAdviceService)
+	releaseOrderController := controller.NewReleaseOrderController(releaseOrderService)
+	workInstructionController := controller.NewWorkInstructionController(workInstructionService)
+	equipmentController := controller.NewEquipmentController(equipmentService)
+	dispatchController := controller.NewDispatchController(dispatchService)
+	ediController := controller.NewEDIController(ediService)
+	yardAuditController := controller.NewYardAuditController(yardAuditService)
+	webhookController := controller.NewWebhookController(webhookService)
+	eventStreamController := controller.NewEventStreamController(eventStreamService)
+	auditLogController := controller.NewAuditLogController(auditLogService)
+	idempotencyController := controller.NewIdempotencyController(idempotencyService)
+
+	// Scheduled jobs
+	scheduler.Every(time.Minute, "yard plan activation", func() error {
+		if _, customErr := yardPlanService.SyncActivePlans(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+	scheduler.Every(time.Hour, "dwell threshold enforcement", func() error {
+		if _, customErr := dwellService.EnforceThresholds(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+	scheduler.Every(time.Hour, "pre-advice expiry", func() error {
+		if _, customErr := preAdviceService.ExpirePreAdvices(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+	scheduler.Every(webhookDispatchInterval, "webhook delivery", func() error {
+		if _, customErr := webhookService.Dispatch(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+	scheduler.Every(eventStreamInterval, "event stream", func() error {
+		if _, customErr := eventStreamService.Broadcast(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+	scheduler.Every(time.Hour, "idempotency key expiry", func() error {
+		if _, customErr := idempotencyService.DeleteExpired(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
+	scheduler.Daily(capacitySnapshotAt.Hour(), capacitySnapshotAt.Minute(), "capacity snapshot", func() error {
+		if _, customErr := capacityReportService.TakeSnapshot(context.Background()); customErr != nil {
+			return errors.New(customErr.Message)
+		}
+		return nil
+	})
 
 	router := gin.Default()
 
 	api := router.Group("/api")
+	api.Use(auditLogController.Record)
 	{
 
 		api.POST("/register", userController.Register)
 		api.POST("/login", userController.Login)
 
 		api.POST("/suggestion", containerController.SuggestPosition)
-		api.POST("/placement", containerController.PlaceContainer)
-		api.POST("/pickup", containerController.PickupContainer)
+		api.POST("/placement", idempotencyController.Guard, containerController.PlaceContainer)
+		api.POST("/pickup", idempotencyController.Guard, containerController.PickupContainer)
+		api.POST("/suggestion/batch", containerController.SuggestPositions)
+		api.POST("/placement/batch", idempotencyController.Guard, containerController.PlaceContainers)
+
+		api.POST("/housekeeping/plan", housekeepingController.PlanHousekeeping)
+		api.POST("/housekeeping/execute", idempotencyController.Guard, housekeepingController.ExecuteHousekeeping)
+
+		api.GET("/yard-plans", yardPlanController.FindPlans)
+		api.GET("/yard-plans/export", yardPlanController.ExportPlans)
+		api.POST("/yard-plans", yardPlanController.CreatePlan)
+		api.GET("/yard-plans/:id", yardPlanController.FindPlanByID)
+		api.PUT("/yard-plans/:id", yardPlanController.UpdatePlan)
+		api.POST("/yard-plans/:id/impact", yardPlanController.AnalyzeImpact)
+		api.POST("/yard-plans/:id/versions", yardPlanController.CreateVersion)
+		api.POST("/yard-plans/:id/publish", yardPlanController.PublishPlan)
+
+		api.GET("/blocks/:id/view", blockController.ViewBlock)
+
+		api.GET("/containers", inventoryController.SearchContainers)
+		api.GET("/containers/export", inventoryController.ExportContainers)
+		api.POST("/containers/import", idempotencyController.Guard, inventoryController.ImportContainers)
+		api.GET("/containers/:number", inventoryController.FindContainer)
+
+		api.GET("/reports/capacity", reportController.CapacityReport)
+		api.POST("/reports/capacity/snapshots", reportController.TakeCapacitySnapshot)
+		api.GET("/reports/capacity/history", reportController.CapacityHistory)
+
+		api.GET("/dwell/thresholds", dwellController.FindThresholds)
+		api.POST("/dwell/thresholds", dwellController.CreateThreshold)
+		api.PUT("/dwell/thresholds/:id", dwellController.UpdateThreshold)
+		api.DELETE("/dwell/thresholds/:id", dwellController.DeleteThreshold)
+		api.GET("/dwell/long-stay", dwellController.LongStayReport)
+		api.POST("/dwell/enforce", dwellController.EnforceThresholds)
+		api.GET("/dwell/alerts", dwellController.FindAlerts)
+
+		api.GET("/tariffs", billingController.FindTariffs)
+		api.POST("/tariffs", billingController.CreateTariff)
+		api.PUT("/tariffs/:id", billingController.UpdateTariff)
+		api.DELETE("/tariffs/:id", billingController.DeleteTariff)
+		api.GET("/billing/preview", billingController.PreviewInvoice)
+
+		api.POST("/gate/in", idempotencyController.Guard, gateController.GateIn)
+		api.POST("/gate/out", idempotencyController.Guard, gateController.GateOut)
+		api.GET("/gate/:id", gateController.FindTransaction)
+		api.POST("/gate/:id/placement", idempotencyController.Guard, gateController.ConfirmPlacement)
+		api.POST("/gate/:id/cancel", idempotencyController.Guard, gateController.CancelGateIn)
+
+		api.GET("/holds", holdController.FindHolds)
+		api.POST("/holds", holdController.SetHold)
+		api.POST("/holds/:id/release", holdController.ReleaseHold)
+
+		api.GET("/pre-advices", preAdviceController.FindPreAdvices)
+		api.POST("/pre-advices", preAdviceController.CreatePreAdvice)
+		api.POST("/pre-advices/batch", preAdviceController.CreatePreAdvices)
+		api.GET("/pre-advices/:id", preAdviceController.FindPreAdvice)
+		api.POST("/pre-advices/:id/cancel", preAdviceController.CancelPreAdvice)
+
+		api.POST("/release-orders", releaseOrderController.CreateReleaseOrder)
+		api.GET("/release-orders/:id", releaseOrderController.FindReleaseOrder)
+		api.POST("/release-orders/:id/cancel", releaseOrderController.CancelReleaseOrder)
+
+		api.POST("/moves", idempotencyController.Guard, workInstructionController.QueueMove)
+		api.GET("/work-instructions", workInstructionController.FindInstructions)
+		api.GET("/work-instructions/:id", workInstructionController.FindInstruction)
+		api.POST("/work-instructions/:id/confirm", idempotencyController.Guard, workInstructionController.ConfirmInstruction)
+		api.POST("/work-instructions/:id/reject", idempotencyController.Guard, workInstructionController.RejectInstruction)
+
+		api.GET("/equipment", equipmentController.FindEquipment)
+		api.POST("/equipment", equipmentController.CreateEquipment)
+		api.PUT("/equipment/:id", equipmentController.UpdateEquipment)
+		api.GET("/equipment/:id/queue", workInstructionController.EquipmentQueue)
+
+		api.POST("/dispatch", dispatchController.DispatchYard)
+		api.GET("/dispatch/simulate", dispatchController.SimulateDispatch)
+
+		api.GET("/edi/partners", ediController.FindPartners)
+		api.POST("/edi/partners", ediController.CreatePartner)
+		api.PUT("/edi/partners/:id", ediController.UpdatePartner)
+		api.DELETE("/edi/partners/:id", ediController.DeletePartner)
+		api.GET("/edi/codeco", ediController.ExportCODECO)
+		api.GET("/edi/coarri", ediController.ExportCOARRI)
+		api.POST("/edi/coarri", ediController.ImportCOARRI)
+		api.POST("/edi/baplie", ediController.ImportBAPLIE)
+
+		api.GET("/audits", yardAuditController.FindAudits)
+		api.POST("/audits", yardAuditController.CreateAudit)
+		api.GET("/audits/:id", yardAuditController.FindAudit)
+		api.POST("/audits/:id/scans", yardAuditController.RecordScans)
+		api.POST("/audits/:id/reconcile", yardAuditController.Reconcile)
+		api.POST("/audits/:id/apply", idempotencyController.Guard, yardAuditController.ApplyCorrections)
+
+		// Webhooks make the server call out, only signed-in users manage them
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(CheckAuth())
+		{
+			webhooks.GET("", webhookController.FindWebhooks)
+			webhooks.POST("", webhookController.CreateWebhook)
+			webhooks.PUT("/:id", webhookController.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+			webhooks.POST("/dispatch", webhookController.Dispatch)
+			webhooks.GET("/dead-letters", webhookController.FindDeadLetters)
+			webhooks.POST("/deliveries/:id/retry", webhookController.RetryDelivery)
+		}
+
+		api.GET("/events/stream", eventStreamController.Stream)
+
+		// The log holds request bodies, only signed-in users read it
+		auditLogs := api.Group("/audit-logs")
+		auditLogs.Use(CheckAuth())
+		{
+			auditLogs.GET("", auditLogController.FindLogs)
+		}
 
 		auth := api.Group("/auth")
 		auth.Use(CheckAuth())
@@ -43,6 +43,24 @@ var (
 		Status:     false,
 		Message:    "BAD REQUEST ERROR",
 	}
+	conflictError = CustomError{
+		Code:       "ERR0006",
+		StatusCode: http.StatusConflict,
+		Status:     false,
+		Message:    "CONFLICT",
+	}
+	preconditionFailedError = CustomError{
+		Code:       "ERR0007",
+		StatusCode: http.StatusPreconditionFailed,
+		Status:     false,
+		Message:    "PRECONDITION FAILED",
+	}
+	tooManyRequestsError = CustomError{
+		Code:       "ERR0008",
+		StatusCode: http.StatusTooManyRequests,
+		Status:     false,
+		Message:    "TOO MANY REQUESTS",
+	}
 )
 
 func GeneralError(message ...string) *CustomError {
@@ -84,3 +102,27 @@ func BadRequestError(message ...string) *CustomError {
 	}
 	return &err
 }
+
+func ConflictError(message ...string) *CustomError {
+	err := conflictError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func PreconditionFailedError(message ...string) *CustomError {
+	err := preconditionFailedError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func TooManyRequestsError(message ...string) *CustomError {
+	err := tooManyRequestsError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}